@@ -0,0 +1,311 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"site-availability/authentication/session"
+	"site-availability/logging"
+)
+
+const (
+	// Prefix starts every token, so leaked tokens are easy to recognize
+	Prefix = "sat_"
+	// AdminRole grants a token every permission, like an admin session
+	AdminRole = "admin"
+	// AuthMethod is the auth method of sessions authenticated with a token
+	AuthMethod = "token"
+	// lastUsedInterval is the resolution of the last-used time, so the token file
+	// is not rewritten on every request
+	lastUsedInterval = time.Minute
+)
+
+var (
+	// ErrNotFound is returned for unknown token IDs
+	ErrNotFound = errors.New("API token not found")
+	// ErrInvalid is returned for malformed, unknown or revoked tokens
+	ErrInvalid = errors.New("invalid API token")
+	// ErrExpired is returned for tokens past their expiration
+	ErrExpired = errors.New("API token expired")
+)
+
+// Token is an API token. The secret is only returned when the token is created; the store keeps its hash.
+type Token struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"` // User the token acts for
+	Roles      []string   `json:"roles"` // Roles from server_settings.roles, or "admin"
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // Tokens without an expiration never expire
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// IsAdmin reports whether the token was granted the admin role
+func (t Token) IsAdmin() bool {
+	return slices.Contains(t.Roles, AdminRole)
+}
+
+// Expired reports whether the token expired before now
+func (t Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// Session returns the session requests authenticated with the token act as, so RBAC applies to
+// tokens exactly as to users with the same roles
+func (t Token) Session() *session.Session {
+	sess := &session.Session{
+		ID:         "token:" + t.ID,
+		Username:   t.Owner,
+		IsAdmin:    t.IsAdmin(),
+		Roles:      slices.Clone(t.Roles),
+		AuthMethod: AuthMethod,
+		CreatedAt:  t.CreatedAt,
+	}
+	if t.ExpiresAt != nil {
+		sess.ExpiresAt = *t.ExpiresAt
+	}
+	return sess
+}
+
+// clone returns a copy of the token that shares no memory with it
+func (t Token) clone() Token {
+	t.Roles = slices.Clone(t.Roles)
+	if t.ExpiresAt != nil {
+		expiresAt := *t.ExpiresAt
+		t.ExpiresAt = &expiresAt
+	}
+	if t.LastUsedAt != nil {
+		lastUsedAt := *t.LastUsedAt
+		t.LastUsedAt = &lastUsedAt
+	}
+	return t
+}
+
+// record is a stored token
+type record struct {
+	Token
+	Hash string `json:"hash"` // SHA-256 of the token
+}
+
+// tokenFile is the content of the token file
+type tokenFile struct {
+	Tokens []record `json:"tokens"`
+}
+
+// Store keeps API tokens, in memory and in a JSON file when a path is set
+type Store struct {
+	path    string
+	records map[string]*record // [ID]record
+	mutex   sync.Mutex
+}
+
+// NewStore loads the tokens from the file at path. With an empty path tokens are only kept in memory
+// and lost on restart.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		records: make(map[string]*record),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read API token file %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		var file tokenFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse API token file %s: %w", path, err)
+		}
+		for i := range file.Tokens {
+			s.records[file.Tokens[i].ID] = &file.Tokens[i]
+		}
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"path":   path,
+		"tokens": len(s.records),
+	}).Info("API token store initialized")
+
+	return s, nil
+}
+
+// Create stores a new token with the name, owner, roles, creator and expiration of spec, and returns
+// it with its secret value
+func (s *Store) Create(spec Token, now time.Time) (Token, string, error) {
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return Token{}, "", err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return Token{}, "", err
+	}
+	value := Prefix + id + "_" + secret
+
+	token := spec.clone()
+	token.ID = id
+	token.CreatedAt = now.UTC()
+	token.LastUsedAt = nil
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[id] = &record{Token: token, Hash: hashToken(value)}
+	if err := s.save(); err != nil {
+		delete(s.records, id)
+		return Token{}, "", err
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"id":         token.ID,
+		"name":       token.Name,
+		"owner":      token.Owner,
+		"roles":      token.Roles,
+		"created_by": token.CreatedBy,
+	}).Info("Created API token")
+	return token.clone(), value, nil
+}
+
+// Authenticate returns the token with the secret value and records that it was used
+func (s *Store) Authenticate(value string, now time.Time) (Token, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(value, Prefix), "_")
+	if !strings.HasPrefix(value, Prefix) || !ok {
+		return Token{}, ErrInvalid
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, exists := s.records[id]
+	if !exists || subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(hashToken(value))) != 1 {
+		return Token{}, ErrInvalid
+	}
+	if stored.Expired(now) {
+		return Token{}, ErrExpired
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedInterval {
+		lastUsedAt := now.UTC()
+		stored.LastUsedAt = &lastUsedAt
+		if err := s.save(); err != nil {
+			// Tracking must not lock scripts out
+			logging.Logger.WithError(err).WithField("id", id).Warn("Failed to record API token use")
+		}
+	}
+	return stored.clone(), nil
+}
+
+// List returns all tokens, oldest first
+func (s *Store) List() []Token {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tokens := make([]Token, 0, len(s.records))
+	for _, stored := range s.records {
+		tokens = append(tokens, stored.clone())
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+		}
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens
+}
+
+// Get returns a token by ID
+func (s *Store) Get(id string) (Token, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, exists := s.records[id]
+	if !exists {
+		return Token{}, false
+	}
+	return stored.clone(), true
+}
+
+// Revoke deletes a token, so it is rejected from now on
+func (s *Store) Revoke(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, exists := s.records[id]
+	if !exists {
+		return ErrNotFound
+	}
+	delete(s.records, id)
+	if err := s.save(); err != nil {
+		s.records[id] = stored
+		return err
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"id":    id,
+		"name":  stored.Name,
+		"owner": stored.Owner,
+	}).Info("Revoked API token")
+	return nil
+}
+
+// save atomically rewrites the token file. Caller must hold the lock.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := tokenFile{Tokens: make([]record, 0, len(s.records))}
+	for _, stored := range s.records {
+		file.Tokens = append(file.Tokens, *stored)
+	}
+	sort.Slice(file.Tokens, func(i, j int) bool { return file.Tokens[i].ID < file.Tokens[j].ID })
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API tokens: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create API token directory %s: %w", dir, err)
+		}
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write temporary API token file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace API token file: %w", err)
+	}
+	return nil
+}
+
+// hashToken returns the hex SHA-256 of a token. Tokens are random, so a fast hash is enough.
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns size random bytes encoded with encode
+func randomString(size int, encode func([]byte) string) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return encode(data), nil
+}
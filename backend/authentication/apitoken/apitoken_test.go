@@ -0,0 +1,177 @@
+package apitoken
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	if err := logging.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore("")
+	require.NoError(t, err)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	roles := []string{"viewer"}
+	token, value, err := store.Create(Token{Name: "ci", Owner: "alice", Roles: roles, CreatedBy: "alice"}, now)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, Prefix+token.ID+"_"))
+	assert.Len(t, token.ID, 16)
+	assert.Equal(t, now, token.CreatedAt)
+	assert.Nil(t, token.LastUsedAt)
+	roles[0] = "changed"
+	assert.Equal(t, []string{"viewer"}, token.Roles, "the store keeps its own copy of the roles")
+
+	authenticated, err := store.Authenticate(value, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, authenticated.ID)
+	assert.Equal(t, "alice", authenticated.Owner)
+
+	listed, found := store.Get(token.ID)
+	require.True(t, found)
+	assert.Equal(t, now.Add(time.Second), *listed.LastUsedAt)
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		for _, invalid := range []string{"", "garbage", Prefix, Prefix + token.ID, value + "x", strings.Replace(value, Prefix, "xyz_", 1), Prefix + "unknown_" + strings.Repeat("a", 43)} {
+			_, err := store.Authenticate(invalid, now)
+			assert.ErrorIs(t, err, ErrInvalid, invalid)
+		}
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		other, otherValue, err := store.Create(Token{Name: "other", Owner: "bob", Roles: []string{"viewer"}}, now)
+		require.NoError(t, err)
+		assert.Len(t, store.List(), 2)
+
+		require.NoError(t, store.Revoke(other.ID))
+		_, err = store.Authenticate(otherValue, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+		assert.ErrorIs(t, store.Revoke(other.ID), ErrNotFound)
+		assert.Len(t, store.List(), 1)
+	})
+}
+
+func TestStoreExpiration(t *testing.T) {
+	store, err := NewStore("")
+	require.NoError(t, err)
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+
+	token, value, err := store.Create(Token{Name: "ci", Owner: "alice", Roles: []string{"viewer"}, ExpiresAt: &expiresAt}, now)
+	require.NoError(t, err)
+	assert.False(t, token.Expired(now))
+	assert.True(t, token.Expired(now.Add(2*time.Hour)))
+
+	_, err = store.Authenticate(value, now.Add(59*time.Minute))
+	assert.NoError(t, err)
+	_, err = store.Authenticate(value, now.Add(61*time.Minute))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	_, value, err = store.Create(Token{Name: "forever", Owner: "alice", Roles: []string{"viewer"}}, now)
+	require.NoError(t, err)
+	_, err = store.Authenticate(value, now.Add(10*365*24*time.Hour))
+	assert.NoError(t, err, "tokens without an expiration never expire")
+}
+
+func TestStoreLastUsed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-tokens.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	token, value, err := store.Create(Token{Name: "ci", Owner: "alice", Roles: []string{"viewer"}}, now)
+	require.NoError(t, err)
+
+	lastUsed := func() time.Time {
+		t.Helper()
+		stored, found := store.Get(token.ID)
+		require.True(t, found)
+		require.NotNil(t, stored.LastUsedAt)
+		return *stored.LastUsedAt
+	}
+
+	_, err = store.Authenticate(value, now)
+	require.NoError(t, err)
+	assert.Equal(t, now, lastUsed())
+
+	_, err = store.Authenticate(value, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, now, lastUsed(), "the last-used time is recorded at most once per minute")
+
+	_, err = store.Authenticate(value, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Minute), lastUsed())
+
+	reloaded, err := NewStore(path)
+	require.NoError(t, err)
+	stored, found := reloaded.Get(token.ID)
+	require.True(t, found)
+	assert.Equal(t, now.Add(time.Minute), *stored.LastUsedAt)
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens", "api-tokens.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	expiresAt := now.Add(time.Hour).Truncate(time.Second)
+
+	token, value, err := store.Create(Token{Name: "ci", Owner: "alice", Roles: []string{"viewer", "sre"}, CreatedBy: "admin", ExpiresAt: &expiresAt}, now)
+	require.NoError(t, err)
+	revoked, _, err := store.Create(Token{Name: "old", Owner: "alice", Roles: []string{"viewer"}}, now)
+	require.NoError(t, err)
+	require.NoError(t, store.Revoke(revoked.ID))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), value, "tokens are hashed at rest")
+	assert.Contains(t, string(data), hashToken(value))
+
+	reloaded, err := NewStore(path)
+	require.NoError(t, err)
+	tokens := reloaded.List()
+	require.Len(t, tokens, 1)
+	assert.Equal(t, token.ID, tokens[0].ID)
+	assert.Equal(t, []string{"viewer", "sre"}, tokens[0].Roles)
+	assert.Equal(t, "admin", tokens[0].CreatedBy)
+	assert.True(t, expiresAt.Equal(*tokens[0].ExpiresAt))
+
+	_, err = reloaded.Authenticate(value, now)
+	assert.NoError(t, err, "tokens stay valid after a restart")
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = NewStore(path)
+	assert.Error(t, err)
+}
+
+func TestTokenSession(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	token := Token{ID: "abc", Owner: "alice", Roles: []string{"viewer"}, ExpiresAt: &expiresAt}
+
+	sess := token.Session()
+	assert.Equal(t, "alice", sess.Username)
+	assert.Equal(t, []string{"viewer"}, sess.Roles)
+	assert.Equal(t, AuthMethod, sess.AuthMethod)
+	assert.Equal(t, expiresAt, sess.ExpiresAt)
+	assert.False(t, sess.IsAdmin)
+
+	token.Roles = []string{AdminRole}
+	assert.True(t, token.IsAdmin())
+	assert.True(t, token.Session().IsAdmin)
+}
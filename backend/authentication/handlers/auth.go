@@ -1,12 +1,20 @@
 package authhandlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"site-availability/authentication/apitoken"
+	"site-availability/authentication/ldap"
 	"site-availability/authentication/local"
+	"site-availability/authentication/lockout"
 	"site-availability/authentication/middleware"
 	"site-availability/authentication/oidc"
 	"site-availability/authentication/session"
@@ -20,20 +28,35 @@ type AuthHandlers struct {
 	sessionManager *session.Manager
 	localAuth      *local.LocalAuthenticator
 	oidcAuth       *oidc.OIDCAuthenticator
+	ldapAuth       *ldap.LDAPAuthenticator
+	tokens         *apitoken.Store
+	loginLockout   *lockout.Tracker
 }
 
-// NewAuthHandlers creates a new authentication handlers instance
-func NewAuthHandlers(cfg *config.Config, sessionManager *session.Manager) (*AuthHandlers, error) {
+// NewAuthHandlers creates a new authentication handlers instance. API tokens can be managed
+// when a token store is given. The lockout tracker is shared between reloads, so failed logins
+// are not forgotten; without one the handlers track failed logins themselves.
+func NewAuthHandlers(cfg *config.Config, sessionManager *session.Manager, tokens *apitoken.Store, loginLockout *lockout.Tracker) (*AuthHandlers, error) {
 	oidcAuth, err := oidc.NewOIDCAuthenticator(cfg)
 	if err != nil {
 		return nil, err
 	}
+	ldapAuth, err := ldap.NewLDAPAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if loginLockout == nil {
+		loginLockout = lockout.NewTracker()
+	}
 
 	return &AuthHandlers{
 		config:         cfg,
 		sessionManager: sessionManager,
 		localAuth:      local.NewLocalAuthenticator(cfg),
 		oidcAuth:       oidcAuth,
+		ldapAuth:       ldapAuth,
+		tokens:         tokens,
+		loginLockout:   loginLockout,
 	}, nil
 }
 
@@ -74,6 +97,9 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// errDirectoryUnavailable is returned when the LDAP directory could not check a login
+var errDirectoryUnavailable = errors.New("LDAP directory unavailable")
+
 // HandleLogin processes login requests
 func (ah *AuthHandlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -81,8 +107,8 @@ func (ah *AuthHandlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if authentication is enabled
-	if !ah.localAuth.IsEnabled() {
+	// Check if password authentication is enabled
+	if !ah.localAuth.IsEnabled() && !ah.ldapAuth.IsEnabled() {
 		ah.sendError(w, http.StatusForbidden, "Authentication is disabled")
 		return
 	}
@@ -95,18 +121,35 @@ func (ah *AuthHandlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Locked accounts are rejected without checking the password. The attempt is reserved before
+	// the slow password check, so parallel guesses count towards the lockout too.
+	attempt, remaining, locked := ah.loginLockout.Begin(ah.lockoutKey(loginReq.Username), time.Now(), ah.config.ServerSettings.LoginLockout)
+	if locked {
+		logging.Logger.WithField("username", loginReq.Username).Info("Login rejected, account is locked")
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(remaining.Round(time.Second).Seconds()))))
+		ah.sendError(w, http.StatusTooManyRequests, "Too many failed logins, try again later")
+		return
+	}
+	defer attempt.Cancel()
+
 	// Validate credentials
-	if err := ah.localAuth.Authenticate(loginReq.Username, loginReq.Password); err != nil {
+	userInfo, err := ah.authenticatePassword(r.Context(), loginReq.Username, loginReq.Password)
+	if errors.Is(err, errDirectoryUnavailable) {
+		// Not the user's fault, so it doesn't count towards a lockout
+		logging.Logger.WithError(err).WithField("username", loginReq.Username).Error("Login failed, LDAP directory unavailable")
+		ah.sendError(w, http.StatusServiceUnavailable, "Authentication service unavailable")
+		return
+	}
+	if err != nil {
 		logging.Logger.WithFields(map[string]interface{}{
 			"username": loginReq.Username,
 			"error":    err.Error(),
 		}).Info("Login failed")
+		attempt.Fail(time.Now())
 		ah.sendError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
-
-	// Get user info
-	userInfo := ah.localAuth.GetUserInfo(loginReq.Username)
+	attempt.Succeed()
 
 	// Create session
 	sessionInfo, err := ah.sessionManager.CreateSession(
@@ -114,7 +157,7 @@ func (ah *AuthHandlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		userInfo.IsAdmin,
 		userInfo.Roles,
 		userInfo.Groups,
-		"local", // Local admin authentication
+		userInfo.AuthMethod, // "local" or "ldap"
 	)
 	if err != nil {
 		logging.Logger.WithError(err).Error("Failed to create session")
@@ -123,14 +166,21 @@ func (ah *AuthHandlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set session cookie
+	cookieValue, err := ah.sessionManager.CookieValue(sessionInfo)
+	if err != nil {
+		logging.Logger.WithError(err).Error("Failed to create session cookie")
+		ah.sendError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
 	sessionTimeout, _ := session.ParseTimeout(ah.config.ServerSettings.SessionTimeout)
 	maxAge := int(sessionTimeout.Seconds())
-	cookie := middleware.CreateSessionCookie(sessionInfo.ID, maxAge, r, ah.config.ServerSettings.TrustProxyHeaders)
+	cookie := middleware.CreateSessionCookie(cookieValue, maxAge, r, ah.config.ServerSettings.TrustProxyHeaders)
 	http.SetCookie(w, cookie)
 
 	logging.Logger.WithFields(map[string]interface{}{
-		"username":   userInfo.Username,
-		"session_id": "****", // Mask session ID for security
+		"username":    userInfo.Username,
+		"auth_method": userInfo.AuthMethod,
+		"session_id":  "****", // Mask session ID for security
 	}).Info("User logged in successfully")
 
 	// Send success response
@@ -145,6 +195,58 @@ func (ah *AuthHandlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// authenticatePassword checks a password login against the local accounts, then LDAP. Local
+// accounts take precedence: their usernames are never checked against the directory.
+func (ah *AuthHandlers) authenticatePassword(ctx context.Context, username, password string) (UserInfo, error) {
+	if !ah.usesLDAP(username) {
+		if err := ah.localAuth.Authenticate(username, password); err != nil {
+			return UserInfo{}, err
+		}
+		localUser := ah.localAuth.GetUserInfo(username)
+		return UserInfo{
+			Username:   localUser.Username,
+			Roles:      localUser.Roles,
+			Groups:     localUser.Groups,
+			IsAdmin:    localUser.IsAdmin,
+			AuthMethod: localUser.AuthMethod,
+		}, nil
+	}
+
+	ldapUser, err := ah.ldapAuth.Authenticate(ctx, username, password)
+	if errors.Is(err, ldap.ErrInvalidCredentials) {
+		return UserInfo{}, err
+	}
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("%w: %v", errDirectoryUnavailable, err)
+	}
+	return UserInfo{
+		Username:   ldapUser.Username,
+		Roles:      ldapUser.Roles,
+		Groups:     ldapUser.Groups,
+		IsAdmin:    ldapUser.IsAdmin,
+		AuthMethod: ldapUser.AuthMethod,
+	}, nil
+}
+
+// usesLDAP reports whether a password login is checked against LDAP rather than the local accounts
+func (ah *AuthHandlers) usesLDAP(username string) bool {
+	return ah.ldapAuth.IsEnabled() && !(ah.localAuth.IsEnabled() && ah.localAuth.HasUser(username))
+}
+
+// lockoutKey returns the account failed logins are counted for. Directories match usernames
+// regardless of case, so "ALICE" and "alice" share the failures of an LDAP account.
+func (ah *AuthHandlers) lockoutKey(username string) string {
+	if ah.usesLDAP(username) {
+		return strings.ToLower(username)
+	}
+	return username
+}
+
+// isAuthEnabled reports whether any login method is enabled
+func (ah *AuthHandlers) isAuthEnabled() bool {
+	return ah.localAuth.IsEnabled() || ah.oidcAuth.IsEnabled() || ah.ldapAuth.IsEnabled()
+}
+
 // HandleUser returns current user information
 func (ah *AuthHandlers) HandleUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -152,8 +254,8 @@ func (ah *AuthHandlers) HandleUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if any authentication is enabled (local admin OR OIDC)
-	if !ah.localAuth.IsEnabled() && !ah.oidcAuth.IsEnabled() {
+	// Check if any authentication is enabled (local, OIDC or LDAP)
+	if !ah.isAuthEnabled() {
 		ah.sendError(w, http.StatusForbidden, "Authentication is disabled")
 		return
 	}
@@ -248,8 +350,12 @@ func (ah *AuthHandlers) HandleAuthConfig(w http.ResponseWriter, r *http.Request)
 		authMethods = append(authMethods, "oidc")
 	}
 
+	if ah.ldapAuth.IsEnabled() {
+		authMethods = append(authMethods, "ldap")
+	}
+
 	response := map[string]interface{}{
-		"auth_enabled": ah.localAuth.IsEnabled() || ah.oidcAuth.IsEnabled(),
+		"auth_enabled": ah.isAuthEnabled(),
 		"auth_methods": authMethods,
 	}
 
@@ -258,6 +364,11 @@ func (ah *AuthHandlers) HandleAuthConfig(w http.ResponseWriter, r *http.Request)
 		response["oidc_provider_name"] = ah.oidcAuth.GetProviderName()
 	}
 
+	// Add LDAP provider info if enabled
+	if ah.ldapAuth.IsEnabled() {
+		response["ldap_provider_name"] = ah.ldapAuth.GetProviderName()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		logging.Logger.WithError(err).Error("Failed to encode auth config response")
@@ -416,9 +527,15 @@ func (ah *AuthHandlers) HandleOIDCCallback(w http.ResponseWriter, r *http.Reques
 	}).Debug("Session created successfully for OIDC user")
 
 	// Set session cookie
+	cookieValue, err := ah.sessionManager.CookieValue(sessionInfo)
+	if err != nil {
+		logging.Logger.WithError(err).Error("Failed to create session cookie for OIDC user")
+		ah.sendError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
 	sessionTimeout, _ := session.ParseTimeout(ah.config.ServerSettings.SessionTimeout)
 	maxAge := int(sessionTimeout.Seconds())
-	cookie := middleware.CreateSessionCookie(sessionInfo.ID, maxAge, r, ah.config.ServerSettings.TrustProxyHeaders)
+	cookie := middleware.CreateSessionCookie(cookieValue, maxAge, r, ah.config.ServerSettings.TrustProxyHeaders)
 	http.SetCookie(w, cookie)
 
 	logging.Logger.WithFields(map[string]interface{}{
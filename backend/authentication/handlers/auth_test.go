@@ -1,12 +1,18 @@
 package authhandlers
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"site-availability/authentication/middleware"
 	"site-availability/authentication/session"
@@ -41,7 +47,7 @@ func TestNewAuthHandlers(t *testing.T) {
 	sessionManager := session.NewManager(sessionTimeout)
 
 	t.Run("create auth handlers successfully", func(t *testing.T) {
-		handlers, err := NewAuthHandlers(cfg, sessionManager)
+		handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
 		require.NoError(t, err)
 		assert.NotNil(t, handlers)
 		assert.NotNil(t, handlers.config)
@@ -72,7 +78,7 @@ func TestHandleLogin(t *testing.T) {
 
 	sessionTimeout, _ := session.ParseTimeout("1h")
 	sessionManager := session.NewManager(sessionTimeout)
-	handlers, err := NewAuthHandlers(cfg, sessionManager)
+	handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
 	require.NoError(t, err)
 
 	t.Run("successful login", func(t *testing.T) {
@@ -144,6 +150,303 @@ func TestHandleLogin(t *testing.T) {
 	})
 }
 
+func TestHandleLoginLocalUsers(t *testing.T) {
+	require.NoError(t, logging.Init())
+
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			SessionTimeout: "1h",
+			LocalUsers: config.LocalUsersConfig{
+				Enabled: true,
+				Users: map[string]config.LocalUserConfig{
+					// bcrypt hash of "alice-password"
+					"alice": {PasswordHash: "$2a$04$Z32EVzD2hOgi9c2o6E4TUupW0sGYMalo68/tepcKkzjgx8eaX7A9.", Roles: []string{"frontend"}},
+					"carol": {PasswordHash: "$2a$04$Z32EVzD2hOgi9c2o6E4TUupW0sGYMalo68/tepcKkzjgx8eaX7A9."},
+				},
+			},
+			LoginLockout: config.LoginLockoutConfig{MaxAttempts: 3, Duration: "10m"},
+		},
+	}
+	sessionManager := session.NewManager(time.Hour)
+	defer sessionManager.Close()
+	handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
+	require.NoError(t, err)
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(LoginRequest{Username: username, Password: password})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		handlers.HandleLogin(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+		return w
+	}
+
+	t.Run("roles of the user", func(t *testing.T) {
+		w := login("alice", "alice-password")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var sessionCookie *http.Cookie
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "session_id" {
+				sessionCookie = cookie
+			}
+		}
+		require.NotNil(t, sessionCookie)
+		sessionInfo, valid := sessionManager.ValidateSession(sessionCookie.Value)
+		require.True(t, valid)
+		assert.Equal(t, "alice", sessionInfo.Username)
+		assert.Equal(t, []string{"frontend"}, sessionInfo.Roles)
+		assert.False(t, sessionInfo.IsAdmin)
+		assert.Equal(t, "local", sessionInfo.AuthMethod)
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, login("alice", "wrong").Code)
+		assert.Equal(t, http.StatusOK, login("alice", "alice-password").Code, "a successful login resets the failures")
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("alice", "wrong").Code)
+		}
+		w := login("alice", "alice-password")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "locked accounts are rejected even with the right password")
+		assert.Equal(t, "600", w.Header().Get("Retry-After"))
+
+		for i := 0; i < 3; i++ {
+			login("mallory", "guess")
+		}
+		assert.Equal(t, http.StatusTooManyRequests, login("mallory", "guess").Code, "unknown users are locked like existing ones")
+	})
+
+	t.Run("parallel guesses", func(t *testing.T) {
+		const guesses = 20
+		codes := make(chan int, guesses)
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < guesses; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				codes <- login("carol", "wrong").Code
+			}()
+		}
+		close(start)
+		wg.Wait()
+		close(codes)
+
+		checked := 0
+		for code := range codes {
+			if code == http.StatusUnauthorized {
+				checked++
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, code)
+			}
+		}
+		assert.LessOrEqual(t, checked, 3, "no more passwords are checked than the lockout allows")
+		assert.Equal(t, http.StatusTooManyRequests, login("carol", "alice-password").Code)
+	})
+}
+
+// ber encodes a BER element with a definite length
+func ber(tag byte, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	length := []byte{byte(len(body))}
+	if len(body) >= 0x80 {
+		length = []byte{0x82, byte(len(body) >> 8), byte(len(body))}
+	}
+	return append(append([]byte{tag}, length...), body...)
+}
+
+// startScriptedDirectory starts an LDAP server that accepts the service account and alice, who
+// is in the operators group. Binds with the password "wrong" fail.
+func startScriptedDirectory(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	result := func(tag byte, code byte) []byte {
+		return ber(tag, ber(0x0a, []byte{code}), ber(0x04), ber(0x04))
+	}
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		searches := 0
+		for {
+			// LDAPMessage: SEQUENCE { messageID INTEGER, protocolOp }
+			if _, err := reader.ReadByte(); err != nil {
+				return
+			}
+			length, err := reader.ReadByte()
+			if err != nil || length >= 0x80 {
+				return
+			}
+			message := make([]byte, length)
+			if _, err := io.ReadFull(reader, message); err != nil {
+				return
+			}
+			id := ber(0x02, message[2:2+message[1]])
+			var replies [][]byte
+			switch message[2+message[1]] {
+			case 0x60: // BindRequest
+				code := byte(0)
+				if bytes.Contains(message, []byte("wrong")) {
+					code = 49
+				}
+				replies = append(replies, result(0x61, code))
+			case 0x63: // SearchRequest: the user, then the user's groups
+				searches++
+				dn, attribute, value := "uid=alice,dc=example,dc=org", "uid", "alice"
+				if searches > 1 {
+					dn, attribute, value = "cn=operators,dc=example,dc=org", "cn", "operators"
+				}
+				attributes := ber(0x30, ber(0x30, ber(0x04, []byte(attribute)), ber(0x31, ber(0x04, []byte(value)))))
+				replies = append(replies, ber(0x64, ber(0x04, []byte(dn)), attributes), result(0x65, 0))
+			default: // UnbindRequest
+				return
+			}
+			for _, reply := range replies {
+				if _, err := conn.Write(ber(0x30, id, reply)); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return "ldap://" + listener.Addr().String()
+}
+
+func TestHandleLoginLDAP(t *testing.T) {
+	require.NoError(t, logging.Init())
+
+	newHandlers := func(t *testing.T, url string) (*AuthHandlers, *session.Manager) {
+		cfg := &config.Config{
+			ServerSettings: config.ServerSettings{
+				SessionTimeout: "1h",
+				LocalUsers: config.LocalUsersConfig{
+					Enabled: true,
+					Users: map[string]config.LocalUserConfig{
+						// bcrypt hash of "alice-password"
+						"bob": {PasswordHash: "$2a$04$Z32EVzD2hOgi9c2o6E4TUupW0sGYMalo68/tepcKkzjgx8eaX7A9.", Roles: []string{"frontend"}},
+					},
+				},
+				LDAP: config.LDAPConfig{
+					Enabled:            true,
+					Name:               "Corporate AD",
+					URL:                url,
+					BindDN:             "cn=service,dc=example,dc=org",
+					BindPassword:       "service-password",
+					BaseDN:             "dc=example,dc=org",
+					UserFilter:         "(uid={username})",
+					GroupBaseDN:        "dc=example,dc=org",
+					GroupFilter:        "(member={dn})",
+					GroupNameAttribute: "cn",
+					Timeout:            "5s",
+					Permissions: config.OIDCPermissions{
+						Groups: map[string][]string{"operators": {"operator"}},
+					},
+				},
+				LoginLockout: config.LoginLockoutConfig{MaxAttempts: 2, Duration: "10m"},
+			},
+		}
+		sessionManager := session.NewManager(time.Hour)
+		t.Cleanup(func() { sessionManager.Close() })
+		handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
+		require.NoError(t, err)
+		return handlers, sessionManager
+	}
+	login := func(t *testing.T, handlers *AuthHandlers, username, password string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(LoginRequest{Username: username, Password: password})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		handlers.HandleLogin(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+		return w
+	}
+	sessionOf := func(t *testing.T, sessionManager *session.Manager, w *httptest.ResponseRecorder) *session.Session {
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "session_id" {
+				sessionInfo, valid := sessionManager.ValidateSession(cookie.Value)
+				require.True(t, valid)
+				return sessionInfo
+			}
+		}
+		require.Fail(t, "no session cookie")
+		return nil
+	}
+
+	t.Run("directory groups map to roles", func(t *testing.T) {
+		handlers, sessionManager := newHandlers(t, startScriptedDirectory(t))
+		w := login(t, handlers, "alice", "alice-password")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		sessionInfo := sessionOf(t, sessionManager, w)
+		assert.Equal(t, "alice", sessionInfo.Username)
+		assert.Equal(t, []string{"operator"}, sessionInfo.Roles)
+		assert.Equal(t, []string{"operators"}, sessionInfo.Groups)
+		assert.False(t, sessionInfo.IsAdmin)
+		assert.Equal(t, "ldap", sessionInfo.AuthMethod)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		handlers, _ := newHandlers(t, startScriptedDirectory(t))
+		assert.Equal(t, http.StatusUnauthorized, login(t, handlers, "alice", "wrong").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(t, handlers, "alice", "wrong").Code)
+		assert.Equal(t, http.StatusTooManyRequests, login(t, handlers, "alice", "alice-password").Code, "LDAP logins are locked out too")
+	})
+
+	t.Run("sessions use the directory username", func(t *testing.T) {
+		handlers, sessionManager := newHandlers(t, startScriptedDirectory(t))
+		w := login(t, handlers, "ALICE", "alice-password")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		sessionInfo := sessionOf(t, sessionManager, w)
+		assert.Equal(t, "alice", sessionInfo.Username)
+		assert.Equal(t, []string{"operator"}, sessionInfo.Roles)
+	})
+
+	t.Run("lockout ignores the case of LDAP usernames", func(t *testing.T) {
+		handlers, _ := newHandlers(t, startScriptedDirectory(t))
+		assert.Equal(t, http.StatusUnauthorized, login(t, handlers, "ALICE", "wrong").Code)
+		assert.Equal(t, http.StatusUnauthorized, login(t, handlers, "Alice", "wrong").Code)
+		assert.Equal(t, http.StatusTooManyRequests, login(t, handlers, "alice", "alice-password").Code)
+	})
+
+	t.Run("local users take precedence", func(t *testing.T) {
+		handlers, sessionManager := newHandlers(t, "ldap://127.0.0.1:1")
+		w := login(t, handlers, "bob", "alice-password")
+		require.Equal(t, http.StatusOK, w.Code, "local users don't need the directory")
+		assert.Equal(t, "local", sessionOf(t, sessionManager, w).AuthMethod)
+		assert.Equal(t, http.StatusUnauthorized, login(t, handlers, "bob", "wrong").Code)
+	})
+
+	t.Run("directory unavailable", func(t *testing.T) {
+		handlers, _ := newHandlers(t, "ldap://127.0.0.1:1")
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusServiceUnavailable, login(t, handlers, "alice", "alice-password").Code,
+				"outages don't count towards a lockout")
+		}
+	})
+
+	t.Run("auth config advertises LDAP", func(t *testing.T) {
+		handlers, _ := newHandlers(t, "ldap://127.0.0.1:1")
+		w := httptest.NewRecorder()
+		handlers.HandleAuthConfig(w, httptest.NewRequest(http.MethodGet, "/auth/config", nil))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response["auth_enabled"].(bool))
+		assert.ElementsMatch(t, []interface{}{"local", "ldap"}, response["auth_methods"])
+		assert.Equal(t, "Corporate AD", response["ldap_provider_name"])
+	})
+}
+
 func TestHandleUser(t *testing.T) {
 	// Initialize logger for tests
 	err := logging.Init()
@@ -165,7 +468,7 @@ func TestHandleUser(t *testing.T) {
 
 	sessionTimeout, _ := session.ParseTimeout("1h")
 	sessionManager := session.NewManager(sessionTimeout)
-	handlers, err := NewAuthHandlers(cfg, sessionManager)
+	handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
 	require.NoError(t, err)
 
 	t.Run("get user info with valid session", func(t *testing.T) {
@@ -222,7 +525,7 @@ func TestHandleLogout(t *testing.T) {
 
 	sessionTimeout, _ := session.ParseTimeout("1h")
 	sessionManager := session.NewManager(sessionTimeout)
-	handlers, err := NewAuthHandlers(cfg, sessionManager)
+	handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
 	require.NoError(t, err)
 
 	t.Run("successful logout with session", func(t *testing.T) {
@@ -264,6 +567,56 @@ func TestHandleLogout(t *testing.T) {
 	})
 }
 
+func TestCookieSessionLoginLogout(t *testing.T) {
+	err := logging.Init()
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			HostURL:        "http://localhost:8080",
+			SessionTimeout: "1h",
+			LocalAdmin: config.LocalAdminConfig{
+				Enabled:  true,
+				Username: "admin",
+				Password: "password",
+			},
+		},
+	}
+	sessionManager, err := session.NewCookieManager(time.Hour, []string{strings.Repeat("k", 32)}, session.NewMemoryStore())
+	require.NoError(t, err)
+	defer sessionManager.Close()
+	handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(LoginRequest{Username: "admin", Password: "password"})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	handlers.HandleLogin(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	sessionCookie := cookies[0]
+	assert.Equal(t, "session_id", sessionCookie.Name)
+	assert.NotContains(t, sessionCookie.Value, "admin", "the session is encrypted in the cookie")
+	assert.Equal(t, 0, handlers.GetSessionCount(), "no session is kept on the server")
+
+	sessionInfo, valid := sessionManager.ValidateSession(sessionCookie.Value)
+	require.True(t, valid)
+	assert.Equal(t, "admin", sessionInfo.Username)
+	assert.True(t, sessionInfo.IsAdmin)
+	assert.Equal(t, "local", sessionInfo.AuthMethod)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(sessionCookie)
+	w = httptest.NewRecorder()
+	handlers.HandleLogout(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	_, valid = sessionManager.ValidateSession(sessionCookie.Value)
+	assert.False(t, valid, "a copy of the cookie is rejected after logout")
+}
+
 func TestHandleAuthConfig(t *testing.T) {
 	// Initialize logger for tests
 	err := logging.Init()
@@ -285,7 +638,7 @@ func TestHandleAuthConfig(t *testing.T) {
 
 		sessionTimeout, _ := session.ParseTimeout("1h")
 		sessionManager := session.NewManager(sessionTimeout)
-		handlers, err := NewAuthHandlers(cfg, sessionManager)
+		handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
 		require.NoError(t, err)
 
 		req := httptest.NewRequest(http.MethodGet, "/auth/config", nil)
@@ -320,7 +673,7 @@ func TestGetSessionCount(t *testing.T) {
 
 	sessionTimeout, _ := session.ParseTimeout("1h")
 	sessionManager := session.NewManager(sessionTimeout)
-	handlers, err := NewAuthHandlers(cfg, sessionManager)
+	handlers, err := NewAuthHandlers(cfg, sessionManager, nil, nil)
 	require.NoError(t, err)
 
 	t.Run("get session count", func(t *testing.T) {
@@ -0,0 +1,211 @@
+package authhandlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"site-availability/authentication/apitoken"
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/session"
+	"site-availability/logging"
+)
+
+// maxTokenNameLength is the longest accepted API token name
+const maxTokenNameLength = 100
+
+// CreateTokenRequest represents the request to create an API token
+type CreateTokenRequest struct {
+	Name      string   `json:"name"`
+	Roles     []string `json:"roles"`
+	ExpiresIn string   `json:"expires_in,omitempty"` // Lifetime such as "720h"; defaults to api_tokens.max_ttl
+	Owner     string   `json:"owner,omitempty"`      // Admins may create tokens for other users
+}
+
+// CreateTokenResponse represents a created API token. The secret value is only returned once.
+type CreateTokenResponse struct {
+	apitoken.Token
+	Value string `json:"token"`
+}
+
+// TokenListResponse represents the API tokens visible to the user
+type TokenListResponse struct {
+	Tokens []apitoken.Token `json:"tokens"`
+}
+
+// HandleListTokens returns the API tokens of the user, or all tokens for admins
+func (ah *AuthHandlers) HandleListTokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.tokenManager(w, r)
+	if !ok {
+		return
+	}
+
+	tokens := make([]apitoken.Token, 0)
+	for _, token := range ah.tokens.List() {
+		if user.IsAdmin || token.Owner == user.Username {
+			tokens = append(tokens, token)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(TokenListResponse{Tokens: tokens}); err != nil {
+		logging.Logger.WithError(err).Error("Failed to encode API token list response")
+	}
+}
+
+// HandleCreateToken creates an API token. Users may create tokens for themselves with a subset
+// of their roles; admins may create tokens for anyone with any configured role.
+func (ah *AuthHandlers) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.tokenManager(w, r)
+	if !ok {
+		return
+	}
+
+	var request CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		ah.sendError(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+
+	spec, err := ah.tokenSpec(user, request, time.Now())
+	if err != nil {
+		ah.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, value, err := ah.tokens.Create(spec, time.Now())
+	if err != nil {
+		logging.Logger.WithError(err).Error("Failed to create API token")
+		ah.sendError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(CreateTokenResponse{Token: token, Value: value}); err != nil {
+		logging.Logger.WithError(err).Error("Failed to encode API token response")
+	}
+}
+
+// HandleRevokeToken revokes an API token of the user, or any token for admins
+func (ah *AuthHandlers) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := ah.tokenManager(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	token, exists := ah.tokens.Get(id)
+	if !exists || (!user.IsAdmin && token.Owner != user.Username) {
+		ah.sendError(w, http.StatusNotFound, "API token not found")
+		return
+	}
+
+	if err := ah.tokens.Revoke(id); err != nil {
+		if errors.Is(err, apitoken.ErrNotFound) {
+			ah.sendError(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		logging.Logger.WithError(err).Error("Failed to revoke API token")
+		ah.sendError(w, http.StatusInternalServerError, "Failed to revoke API token")
+		return
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"id":         id,
+		"revoked_by": user.Username,
+	}).Debug("API token revoked")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tokenManager returns the user managing API tokens. Requests authenticated with an API token
+// can't manage tokens, so a leaked token can't be used to create more.
+func (ah *AuthHandlers) tokenManager(w http.ResponseWriter, r *http.Request) (*session.Session, bool) {
+	if !ah.isAuthEnabled() {
+		ah.sendError(w, http.StatusForbidden, "Authentication is disabled")
+		return nil, false
+	}
+	if ah.tokens == nil {
+		ah.sendError(w, http.StatusNotFound, "API tokens are not available")
+		return nil, false
+	}
+
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		ah.sendError(w, http.StatusUnauthorized, "Authentication required")
+		return nil, false
+	}
+	if user.AuthMethod == apitoken.AuthMethod {
+		ah.sendError(w, http.StatusForbidden, "API tokens can't be managed with an API token")
+		return nil, false
+	}
+	return user, true
+}
+
+// tokenSpec validates a create request against the roles the user may grant and the maximum lifetime
+func (ah *AuthHandlers) tokenSpec(user *session.Session, request CreateTokenRequest, now time.Time) (apitoken.Token, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return apitoken.Token{}, fmt.Errorf("name is required")
+	}
+	if len(name) > maxTokenNameLength {
+		return apitoken.Token{}, fmt.Errorf("name must be at most %d characters", maxTokenNameLength)
+	}
+
+	owner := strings.TrimSpace(request.Owner)
+	if owner == "" {
+		owner = user.Username
+	}
+	if owner != user.Username && !user.IsAdmin {
+		return apitoken.Token{}, fmt.Errorf("only admins can create tokens for other users")
+	}
+
+	if len(request.Roles) == 0 {
+		return apitoken.Token{}, fmt.Errorf("at least one role is required")
+	}
+	var roles []string
+	for _, role := range request.Roles {
+		if slices.Contains(roles, role) {
+			continue
+		}
+		if _, configured := ah.config.ServerSettings.Roles[role]; !configured && role != apitoken.AdminRole {
+			return apitoken.Token{}, fmt.Errorf("unknown role %q", role)
+		}
+		if !user.IsAdmin && (role == apitoken.AdminRole || !slices.Contains(user.Roles, role)) {
+			return apitoken.Token{}, fmt.Errorf("role %q can't be granted, tokens may only have roles of their creator", role)
+		}
+		roles = append(roles, role)
+	}
+
+	var maxTTL time.Duration
+	if ah.config.ServerSettings.APITokens.MaxTTL != "" {
+		maxTTL, _ = time.ParseDuration(ah.config.ServerSettings.APITokens.MaxTTL)
+	}
+	ttl := maxTTL
+	if request.ExpiresIn != "" {
+		var err error
+		ttl, err = time.ParseDuration(request.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			return apitoken.Token{}, fmt.Errorf("expires_in must be a positive duration such as '720h'")
+		}
+		if maxTTL > 0 && ttl > maxTTL {
+			return apitoken.Token{}, fmt.Errorf("expires_in must be at most %s", ah.config.ServerSettings.APITokens.MaxTTL)
+		}
+	}
+
+	spec := apitoken.Token{
+		Name:      name,
+		Owner:     owner,
+		Roles:     roles,
+		CreatedBy: user.Username,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl).UTC()
+		spec.ExpiresAt = &expiresAt
+	}
+	return spec, nil
+}
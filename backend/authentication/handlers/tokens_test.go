@@ -0,0 +1,198 @@
+package authhandlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/authentication/apitoken"
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/session"
+	"site-availability/config"
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenHandlers returns handlers with an in-memory token store
+func newTokenHandlers(t *testing.T, maxTTL string) *AuthHandlers {
+	t.Helper()
+	require.NoError(t, logging.Init())
+
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			SessionTimeout: "1h",
+			LocalAdmin:     config.LocalAdminConfig{Enabled: true, Username: "admin", Password: "password"},
+			APITokens:      config.APITokensConfig{MaxTTL: maxTTL},
+			Roles: map[string]config.RoleConfig{
+				"viewer": {Labels: map[string]string{"env": "prod"}},
+				"sre":    {Permissions: []string{config.PermissionCheck}},
+			},
+		},
+	}
+	tokens, err := apitoken.NewStore("")
+	require.NoError(t, err)
+	sessionManager := session.NewManager(time.Hour)
+	t.Cleanup(func() { sessionManager.Close() })
+
+	handlers, err := NewAuthHandlers(cfg, sessionManager, tokens, nil)
+	require.NoError(t, err)
+	return handlers
+}
+
+// tokenRequest calls a token handler as a user
+func tokenRequest(handler http.HandlerFunc, user *session.Session, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+	}
+	w := httptest.NewRecorder()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(method+" /api/tokens/{id}", handler)
+	mux.HandleFunc(method+" /api/tokens", handler)
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleCreateToken(t *testing.T) {
+	handlers := newTokenHandlers(t, "720h")
+	alice := &session.Session{Username: "alice", Roles: []string{"viewer"}, AuthMethod: "oidc"}
+	admin := &session.Session{Username: "admin", IsAdmin: true, Roles: []string{"admin"}, AuthMethod: "local"}
+
+	t.Run("self-service", func(t *testing.T) {
+		w := tokenRequest(handlers.HandleCreateToken, alice, http.MethodPost, "/api/tokens", CreateTokenRequest{
+			Name:      "ci",
+			Roles:     []string{"viewer", "viewer"},
+			ExpiresIn: "24h",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response CreateTokenResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, "ci", response.Name)
+		assert.Equal(t, "alice", response.Owner)
+		assert.Equal(t, "alice", response.CreatedBy)
+		assert.Equal(t, []string{"viewer"}, response.Roles)
+		require.NotNil(t, response.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *response.ExpiresAt, time.Minute)
+
+		token, err := handlers.tokens.Authenticate(response.Value, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, response.ID, token.ID)
+	})
+
+	t.Run("default expiration is the maximum lifetime", func(t *testing.T) {
+		w := tokenRequest(handlers.HandleCreateToken, alice, http.MethodPost, "/api/tokens", CreateTokenRequest{Name: "ci", Roles: []string{"viewer"}})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response CreateTokenResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		require.NotNil(t, response.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(720*time.Hour), *response.ExpiresAt, time.Minute)
+	})
+
+	t.Run("admin for another user", func(t *testing.T) {
+		w := tokenRequest(handlers.HandleCreateToken, admin, http.MethodPost, "/api/tokens", CreateTokenRequest{
+			Name:  "deploy",
+			Roles: []string{"sre", "admin"},
+			Owner: "deploy-bot",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response CreateTokenResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, "deploy-bot", response.Owner)
+		assert.Equal(t, "admin", response.CreatedBy)
+		assert.True(t, response.IsAdmin())
+	})
+
+	invalid := []struct {
+		name    string
+		user    *session.Session
+		request CreateTokenRequest
+		wantErr string
+	}{
+		{name: "missing name", user: alice, request: CreateTokenRequest{Roles: []string{"viewer"}}, wantErr: "name is required"},
+		{name: "missing roles", user: alice, request: CreateTokenRequest{Name: "ci"}, wantErr: "at least one role"},
+		{name: "unknown role", user: admin, request: CreateTokenRequest{Name: "ci", Roles: []string{"nobody"}}, wantErr: `unknown role "nobody"`},
+		{name: "role the user lacks", user: alice, request: CreateTokenRequest{Name: "ci", Roles: []string{"sre"}}, wantErr: `role "sre" can't be granted`},
+		{name: "admin role", user: alice, request: CreateTokenRequest{Name: "ci", Roles: []string{"admin"}}, wantErr: `role "admin" can't be granted`},
+		{name: "other owner", user: alice, request: CreateTokenRequest{Name: "ci", Roles: []string{"viewer"}, Owner: "bob"}, wantErr: "only admins"},
+		{name: "invalid expiration", user: alice, request: CreateTokenRequest{Name: "ci", Roles: []string{"viewer"}, ExpiresIn: "1 month"}, wantErr: "expires_in must be a positive duration"},
+		{name: "expiration over the maximum", user: admin, request: CreateTokenRequest{Name: "ci", Roles: []string{"viewer"}, ExpiresIn: "8760h"}, wantErr: "expires_in must be at most 720h"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tokenRequest(handlers.HandleCreateToken, tt.user, http.MethodPost, "/api/tokens", tt.request)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var response ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Contains(t, response.Error, tt.wantErr)
+		})
+	}
+
+	t.Run("tokens can't create tokens", func(t *testing.T) {
+		tokenUser := &session.Session{Username: "alice", Roles: []string{"viewer"}, AuthMethod: apitoken.AuthMethod}
+		w := tokenRequest(handlers.HandleCreateToken, tokenUser, http.MethodPost, "/api/tokens", CreateTokenRequest{Name: "ci", Roles: []string{"viewer"}})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		w := tokenRequest(handlers.HandleCreateToken, nil, http.MethodPost, "/api/tokens", CreateTokenRequest{Name: "ci", Roles: []string{"viewer"}})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestHandleListAndRevokeTokens(t *testing.T) {
+	handlers := newTokenHandlers(t, "")
+	alice := &session.Session{Username: "alice", Roles: []string{"viewer"}, AuthMethod: "oidc"}
+	bob := &session.Session{Username: "bob", Roles: []string{"viewer"}, AuthMethod: "oidc"}
+	admin := &session.Session{Username: "admin", IsAdmin: true, Roles: []string{"admin"}, AuthMethod: "local"}
+
+	aliceToken, _, err := handlers.tokens.Create(apitoken.Token{Name: "alice-ci", Owner: "alice", Roles: []string{"viewer"}}, time.Now())
+	require.NoError(t, err)
+	bobToken, _, err := handlers.tokens.Create(apitoken.Token{Name: "bob-ci", Owner: "bob", Roles: []string{"viewer"}}, time.Now())
+	require.NoError(t, err)
+
+	list := func(user *session.Session) []string {
+		t.Helper()
+		w := tokenRequest(handlers.HandleListTokens, user, http.MethodGet, "/api/tokens", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "hash")
+
+		var response TokenListResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		names := make([]string, 0, len(response.Tokens))
+		for _, token := range response.Tokens {
+			names = append(names, token.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"alice-ci"}, list(alice), "users only see their own tokens")
+	assert.ElementsMatch(t, []string{"alice-ci", "bob-ci"}, list(admin), "admins see all tokens")
+
+	w := tokenRequest(handlers.HandleRevokeToken, alice, http.MethodDelete, "/api/tokens/"+bobToken.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "users can't revoke tokens of others")
+
+	w = tokenRequest(handlers.HandleRevokeToken, bob, http.MethodDelete, "/api/tokens/"+bobToken.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"alice-ci"}, list(admin))
+
+	w = tokenRequest(handlers.HandleRevokeToken, admin, http.MethodDelete, "/api/tokens/"+aliceToken.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code, "admins can revoke any token")
+	assert.Empty(t, list(alice))
+
+	w = tokenRequest(handlers.HandleRevokeToken, admin, http.MethodDelete, "/api/tokens/"+aliceToken.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,204 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// BER tags used by LDAP (RFC 4511). Tags have the class and constructed bits set.
+const (
+	tagBoolean     byte = 0x01
+	tagInteger     byte = 0x02
+	tagOctetString byte = 0x04
+	tagEnumerated  byte = 0x0a
+	tagSequence    byte = 0x30
+	tagSet         byte = 0x31
+
+	tagBindRequest           byte = 0x60
+	tagBindResponse          byte = 0x61
+	tagUnbindRequest         byte = 0x42
+	tagSearchRequest         byte = 0x63
+	tagSearchResultEntry     byte = 0x64
+	tagSearchResultDone      byte = 0x65
+	tagSearchResultReference byte = 0x73
+	tagExtendedRequest       byte = 0x77
+	tagExtendedResponse      byte = 0x78
+
+	// tagConstructed marks elements that contain other elements
+	tagConstructed byte = 0x20
+)
+
+// maxPacketSize bounds the size of a received element, so a broken server can't exhaust memory
+const maxPacketSize = 16 << 20
+
+// packet is a BER element: primitive elements carry data, constructed elements carry children
+type packet struct {
+	tag      byte
+	data     []byte
+	children []*packet
+}
+
+// newSequence returns a constructed element
+func newSequence(tag byte, children ...*packet) *packet {
+	return &packet{tag: tag, children: children}
+}
+
+// newString returns an OCTET STRING, or another primitive string element with the tag
+func newString(tag byte, value string) *packet {
+	return &packet{tag: tag, data: []byte(value)}
+}
+
+// newInteger returns an INTEGER or ENUMERATED element
+func newInteger(tag byte, value int64) *packet {
+	// Minimal two's complement encoding
+	data := []byte{byte(value)}
+	for value > 127 || value < -128 {
+		value >>= 8
+		data = append([]byte{byte(value)}, data...)
+	}
+	return &packet{tag: tag, data: data}
+}
+
+// newBoolean returns a BOOLEAN element
+func newBoolean(tag byte, value bool) *packet {
+	if value {
+		return &packet{tag: tag, data: []byte{0xff}}
+	}
+	return &packet{tag: tag, data: []byte{0x00}}
+}
+
+// constructed reports whether the element contains other elements
+func (p *packet) constructed() bool {
+	return p.tag&tagConstructed != 0
+}
+
+// encode returns the DER encoding of the element
+func (p *packet) encode() []byte {
+	content := p.data
+	if p.constructed() {
+		content = nil
+		for _, child := range p.children {
+			content = append(content, child.encode()...)
+		}
+	}
+	encoded := append([]byte{p.tag}, encodeLength(len(content))...)
+	return append(encoded, content...)
+}
+
+// encodeLength returns a definite length in the short or long form
+func encodeLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	var encoded []byte
+	for length > 0 {
+		encoded = append([]byte{byte(length)}, encoded...)
+		length >>= 8
+	}
+	return append([]byte{0x80 | byte(len(encoded))}, encoded...)
+}
+
+// readPacket reads an element
+func readPacket(reader *bufio.Reader) (*packet, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if tag&0x1f == 0x1f {
+		return nil, fmt.Errorf("unsupported BER tag 0x%02x", tag)
+	}
+
+	length, err := readLength(reader)
+	if err != nil {
+		return nil, err
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+	return parsePacket(tag, content)
+}
+
+// readLength reads a definite length
+func readLength(reader *bufio.Reader) (int, error) {
+	first, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if first < 0x80 {
+		return int(first), nil
+	}
+
+	count := int(first & 0x7f)
+	if count == 0 || count > 4 {
+		return 0, fmt.Errorf("unsupported BER length encoding 0x%02x", first)
+	}
+	length := 0
+	for i := 0; i < count; i++ {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length = length<<8 | int(b)
+	}
+	if length > maxPacketSize {
+		return 0, fmt.Errorf("BER element of %d bytes is too large", length)
+	}
+	return length, nil
+}
+
+// parsePacket parses the content of an element, and of its children when it is constructed
+func parsePacket(tag byte, content []byte) (*packet, error) {
+	p := &packet{tag: tag}
+	if tag&tagConstructed == 0 {
+		p.data = content
+		return p, nil
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(content))
+	for {
+		if _, err := reader.Peek(1); err == io.EOF {
+			return p, nil
+		}
+		child, err := readPacket(reader)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("invalid BER element 0x%02x: %w", tag, err)
+		}
+		p.children = append(p.children, child)
+	}
+}
+
+// int returns the value of an INTEGER or ENUMERATED element
+func (p *packet) int() int64 {
+	var value int64
+	for i, b := range p.data {
+		if i == 0 && b&0x80 != 0 {
+			value = -1 // Negative numbers are sign extended
+		}
+		value = value<<8 | int64(b)
+	}
+	return value
+}
+
+// bool returns the value of a BOOLEAN element
+func (p *packet) bool() bool {
+	return len(p.data) > 0 && p.data[0] != 0
+}
+
+// string returns the content of a primitive element
+func (p *packet) string() string {
+	return string(p.data)
+}
+
+// child returns a child element, or nil when there are not enough children
+func (p *packet) child(index int) *packet {
+	if index < 0 || index >= len(p.children) {
+		return nil
+	}
+	return p.children[index]
+}
@@ -0,0 +1,83 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, encoded []byte) *packet {
+	t.Helper()
+	p, err := readPacket(bufio.NewReader(bytes.NewReader(encoded)))
+	require.NoError(t, err)
+	return p
+}
+
+func TestIntegerEncoding(t *testing.T) {
+	tests := []struct {
+		value   int64
+		encoded []byte
+	}{
+		{0, []byte{0x02, 0x01, 0x00}},
+		{3, []byte{0x02, 0x01, 0x03}},
+		{127, []byte{0x02, 0x01, 0x7f}},
+		{128, []byte{0x02, 0x02, 0x00, 0x80}},
+		{256, []byte{0x02, 0x02, 0x01, 0x00}},
+		{-1, []byte{0x02, 0x01, 0xff}},
+		{-129, []byte{0x02, 0x02, 0xff, 0x7f}},
+	}
+	for _, tt := range tests {
+		encoded := newInteger(tagInteger, tt.value).encode()
+		assert.Equal(t, tt.encoded, encoded, "encoding %d", tt.value)
+		assert.Equal(t, tt.value, decode(t, encoded).int(), "decoding %d", tt.value)
+	}
+}
+
+func TestLongLength(t *testing.T) {
+	value := strings.Repeat("x", 300)
+	encoded := newString(tagOctetString, value).encode()
+	assert.Equal(t, []byte{0x04, 0x82, 0x01, 0x2c}, encoded[:4])
+	assert.Equal(t, value, decode(t, encoded).string())
+}
+
+func TestSequenceRoundTrip(t *testing.T) {
+	message := newSequence(tagSequence,
+		newInteger(tagInteger, 7),
+		newSequence(tagBindRequest,
+			newInteger(tagInteger, 3),
+			newString(tagOctetString, "cn=service"),
+			newString(bindSimpleAuthentication, "secret"),
+		),
+	)
+
+	decoded := decode(t, message.encode())
+	assert.Equal(t, tagSequence, decoded.tag)
+	assert.Equal(t, int64(7), decoded.child(0).int())
+	bind := decoded.child(1)
+	assert.Equal(t, tagBindRequest, bind.tag)
+	assert.Equal(t, "cn=service", bind.child(1).string())
+	assert.Equal(t, bindSimpleAuthentication, bind.child(2).tag)
+	assert.Equal(t, "secret", bind.child(2).string())
+	assert.Nil(t, bind.child(3))
+	assert.True(t, newBoolean(tagBoolean, true).bool())
+	assert.False(t, newBoolean(tagBoolean, false).bool())
+}
+
+func TestReadPacketErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated content":   {0x04, 0x05, 'a', 'b'},
+		"truncated child":     {0x30, 0x03, 0x04, 0x05, 'a'},
+		"indefinite length":   {0x30, 0x80},
+		"oversized element":   {0x04, 0x84, 0x7f, 0xff, 0xff, 0xff},
+		"multi-byte tag":      {0x1f, 0x01, 0x00},
+		"missing length byte": {0x04},
+	}
+	for name, encoded := range tests {
+		_, err := readPacket(bufio.NewReader(bytes.NewReader(encoded)))
+		assert.Error(t, err, name)
+	}
+}
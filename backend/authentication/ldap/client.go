@@ -0,0 +1,278 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// LDAP result codes (RFC 4511 appendix A)
+const (
+	resultSuccess                 = 0
+	resultSizeLimitExceeded       = 4
+	resultInvalidCredentials      = 49
+	startTLSOID                   = "1.3.6.1.4.1.1466.20037"
+	protocolVersion               = 3
+	scopeWholeSubtree             = 2
+	derefNever                    = 0
+	bindSimpleAuthentication byte = 0x80
+	extendedRequestName      byte = 0x80
+	noAttributes                  = "1.1"
+)
+
+// resultError is a response with a result code other than success
+type resultError struct {
+	code    int64
+	message string
+}
+
+func (e *resultError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("LDAP result code %d", e.code)
+	}
+	return fmt.Sprintf("LDAP result code %d: %s", e.code, e.message)
+}
+
+// isResultCode reports whether err is a response with the result code
+func isResultCode(err error, code int64) bool {
+	result, ok := err.(*resultError)
+	return ok && result.code == code
+}
+
+// entry is a search result
+type entry struct {
+	dn         string
+	attributes map[string][]string // Keyed by lowercase attribute name
+}
+
+// values returns the values of an attribute
+func (e entry) values(attribute string) []string {
+	return e.attributes[strings.ToLower(attribute)]
+}
+
+// conn is a connection to a directory server. Requests are sent one at a time.
+type conn struct {
+	netConn   net.Conn
+	reader    *bufio.Reader
+	messageID int64
+}
+
+// dial connects to an ldap:// or ldaps:// url, upgrading ldap:// with StartTLS when asked. The
+// connection fails once the context deadline passes.
+func dial(ctx context.Context, rawURL string, startTLS bool, tlsConfig *tls.Config) (*conn, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP url: %w", err)
+	}
+	address := parsed.Host
+	if parsed.Port() == "" {
+		port := "389"
+		if parsed.Scheme == "ldaps" {
+			port = "636"
+		}
+		address = net.JoinHostPort(parsed.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server %s: %w", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := netConn.SetDeadline(deadline); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+
+	c := &conn{netConn: netConn, reader: bufio.NewReader(netConn)}
+	if parsed.Scheme == "ldaps" {
+		if err := c.upgradeTLS(parsed.Hostname(), tlsConfig); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	} else if startTLS {
+		if err := c.startTLS(parsed.Hostname(), tlsConfig); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// startTLS asks the server to start TLS on the connection and upgrades it
+func (c *conn) startTLS(host string, tlsConfig *tls.Config) error {
+	op := newSequence(tagExtendedRequest, newString(extendedRequestName, startTLSOID))
+	response, err := c.request(op, tagExtendedResponse)
+	if err != nil {
+		return fmt.Errorf("LDAP StartTLS failed: %w", err)
+	}
+	if err := checkResult(response); err != nil {
+		return fmt.Errorf("LDAP StartTLS failed: %w", err)
+	}
+	return c.upgradeTLS(host, tlsConfig)
+}
+
+// upgradeTLS runs a TLS handshake over the connection
+func (c *conn) upgradeTLS(host string, tlsConfig *tls.Config) error {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if tlsConfig != nil {
+		config = tlsConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	tlsConn := tls.Client(c.netConn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("LDAP TLS handshake failed: %w", err)
+	}
+	c.netConn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// bind authenticates the connection with a DN and password
+func (c *conn) bind(dn, password string) error {
+	op := newSequence(tagBindRequest,
+		newInteger(tagInteger, protocolVersion),
+		newString(tagOctetString, dn),
+		newString(bindSimpleAuthentication, password),
+	)
+	response, err := c.request(op, tagBindResponse)
+	if err != nil {
+		return err
+	}
+	return checkResult(response)
+}
+
+// search returns the entries below baseDN that match the filter, with the requested attributes
+func (c *conn) search(baseDN string, filter *packet, attributes []string, sizeLimit int64) ([]entry, error) {
+	requested := newSequence(tagSequence)
+	for _, attribute := range attributes {
+		requested.children = append(requested.children, newString(tagOctetString, attribute))
+	}
+	op := newSequence(tagSearchRequest,
+		newString(tagOctetString, baseDN),
+		newInteger(tagEnumerated, scopeWholeSubtree),
+		newInteger(tagEnumerated, derefNever),
+		newInteger(tagInteger, sizeLimit),
+		newInteger(tagInteger, 0),
+		newBoolean(tagBoolean, false),
+		filter,
+		requested,
+	)
+	id, err := c.send(op)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for {
+		response, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch response.tag {
+		case tagSearchResultEntry:
+			entries = append(entries, parseEntry(response))
+		case tagSearchResultReference:
+			// Referrals to other servers are not followed
+		case tagSearchResultDone:
+			return entries, checkResult(response)
+		default:
+			return nil, fmt.Errorf("unexpected LDAP response 0x%02x to a search", response.tag)
+		}
+	}
+}
+
+// close sends an unbind request and closes the connection
+func (c *conn) close() {
+	_, _ = c.send(&packet{tag: tagUnbindRequest})
+	c.netConn.Close()
+}
+
+// request sends an operation and reads its single response
+func (c *conn) request(op *packet, responseTag byte) (*packet, error) {
+	id, err := c.send(op)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.receive(id)
+	if err != nil {
+		return nil, err
+	}
+	if response.tag != responseTag {
+		return nil, fmt.Errorf("unexpected LDAP response 0x%02x, expected 0x%02x", response.tag, responseTag)
+	}
+	return response, nil
+}
+
+// send writes an operation in a new message and returns the message ID
+func (c *conn) send(op *packet) (int64, error) {
+	c.messageID++
+	message := newSequence(tagSequence, newInteger(tagInteger, c.messageID), op)
+	if _, err := c.netConn.Write(message.encode()); err != nil {
+		return 0, fmt.Errorf("failed to send LDAP request: %w", err)
+	}
+	return c.messageID, nil
+}
+
+// receive reads the next message, which must answer the message ID, and returns its operation
+func (c *conn) receive(id int64) (*packet, error) {
+	message, err := readPacket(c.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LDAP response: %w", err)
+	}
+	if message.tag != tagSequence || len(message.children) < 2 {
+		return nil, fmt.Errorf("invalid LDAP message")
+	}
+	if received := message.child(0).int(); received != id {
+		// Message ID 0 is an unsolicited notification, such as the server disconnecting
+		return nil, fmt.Errorf("unexpected LDAP message %d, expected %d", received, id)
+	}
+	return message.child(1), nil
+}
+
+// checkResult returns an error unless the LDAPResult of a response reports success
+func checkResult(response *packet) error {
+	code := response.child(0)
+	if code == nil || code.tag != tagEnumerated {
+		return fmt.Errorf("invalid LDAP result")
+	}
+	if code.int() == resultSuccess {
+		return nil
+	}
+	message := ""
+	if diagnostic := response.child(2); diagnostic != nil {
+		message = diagnostic.string()
+	}
+	return &resultError{code: code.int(), message: message}
+}
+
+// parseEntry reads the DN and attributes of a SearchResultEntry
+func parseEntry(response *packet) entry {
+	result := entry{attributes: make(map[string][]string)}
+	if dn := response.child(0); dn != nil {
+		result.dn = dn.string()
+	}
+	attributes := response.child(1)
+	if attributes == nil {
+		return result
+	}
+	for _, attribute := range attributes.children {
+		name, values := attribute.child(0), attribute.child(1)
+		if name == nil || values == nil {
+			continue
+		}
+		key := strings.ToLower(name.string())
+		for _, value := range values.children {
+			result.attributes[key] = append(result.attributes[key], value.string())
+		}
+	}
+	return result
+}
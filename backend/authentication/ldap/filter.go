@@ -0,0 +1,244 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Filter choice tags (RFC 4511 section 4.5.1)
+const (
+	filterAnd             byte = 0xa0
+	filterOr              byte = 0xa1
+	filterNot             byte = 0xa2
+	filterEqualityMatch   byte = 0xa3
+	filterSubstrings      byte = 0xa4
+	filterGreaterOrEqual  byte = 0xa5
+	filterLessOrEqual     byte = 0xa6
+	filterPresent         byte = 0x87
+	filterApproxMatch     byte = 0xa8
+	filterExtensibleMatch byte = 0xa9
+
+	substringInitial byte = 0x80
+	substringAny     byte = 0x81
+	substringFinal   byte = 0x82
+
+	extensibleRule  byte = 0x81
+	extensibleType  byte = 0x82
+	extensibleValue byte = 0x83
+	extensibleDN    byte = 0x84
+)
+
+// EscapeFilter escapes a value for use in a search filter (RFC 4515), so user input can't change the filter
+func EscapeFilter(value string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '*', '(', ')', 0:
+			fmt.Fprintf(&escaped, "\\%02x", c)
+		default:
+			escaped.WriteByte(c)
+		}
+	}
+	return escaped.String()
+}
+
+// compileFilter encodes a search filter in the string representation of RFC 4515, such as
+// "(&(objectClass=person)(uid=alice))"
+func compileFilter(filter string) (*packet, error) {
+	compiled, rest, err := parseFilter(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP filter %q: %w", filter, err)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid LDAP filter %q: unexpected %q after the filter", filter, rest)
+	}
+	return compiled, nil
+}
+
+// parseFilter parses a parenthesized filter and returns the rest of the string
+func parseFilter(filter string) (*packet, string, error) {
+	if !strings.HasPrefix(filter, "(") {
+		return nil, "", fmt.Errorf("filter must start with '('")
+	}
+	filter = filter[1:]
+	if filter == "" {
+		return nil, "", fmt.Errorf("unexpected end of filter")
+	}
+
+	switch filter[0] {
+	case '&', '|':
+		tag := filterAnd
+		if filter[0] == '|' {
+			tag = filterOr
+		}
+		set := newSequence(tag)
+		rest := filter[1:]
+		for strings.HasPrefix(rest, "(") {
+			child, remaining, err := parseFilter(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			set.children = append(set.children, child)
+			rest = remaining
+		}
+		if len(set.children) == 0 {
+			return nil, "", fmt.Errorf("'%c' needs at least one filter", filter[0])
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("missing ')'")
+		}
+		return set, rest[1:], nil
+	case '!':
+		child, rest, err := parseFilter(filter[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("missing ')'")
+		}
+		return newSequence(filterNot, child), rest[1:], nil
+	default:
+		end := strings.IndexByte(filter, ')')
+		if end < 0 {
+			return nil, "", fmt.Errorf("missing ')'")
+		}
+		item, err := parseItem(filter[:end])
+		if err != nil {
+			return nil, "", err
+		}
+		return item, filter[end+1:], nil
+	}
+}
+
+// parseItem parses a simple filter such as "uid=alice", "cn=a*b", "mail=*" or "member:1.2.840.113556.1.4.1941:=dn"
+func parseItem(item string) (*packet, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq < 0 {
+		return nil, fmt.Errorf("missing '=' in %q", item)
+	}
+	attribute, rawValue := item[:eq], item[eq+1:]
+
+	var tag byte
+	switch {
+	case strings.HasSuffix(attribute, "~"):
+		tag, attribute = filterApproxMatch, strings.TrimSuffix(attribute, "~")
+	case strings.HasSuffix(attribute, ">"):
+		tag, attribute = filterGreaterOrEqual, strings.TrimSuffix(attribute, ">")
+	case strings.HasSuffix(attribute, "<"):
+		tag, attribute = filterLessOrEqual, strings.TrimSuffix(attribute, "<")
+	case strings.HasSuffix(attribute, ":"):
+		return parseExtensible(strings.TrimSuffix(attribute, ":"), rawValue)
+	}
+	if attribute == "" || strings.ContainsAny(attribute, "()*\\ ") {
+		return nil, fmt.Errorf("invalid attribute %q", attribute)
+	}
+
+	if tag == 0 {
+		if rawValue == "*" {
+			return newString(filterPresent, attribute), nil
+		}
+		if strings.Contains(rawValue, "*") {
+			return parseSubstrings(attribute, rawValue)
+		}
+		tag = filterEqualityMatch
+	}
+
+	value, err := unescapeFilterValue(rawValue)
+	if err != nil {
+		return nil, err
+	}
+	return newSequence(tag, newString(tagOctetString, attribute), newString(tagOctetString, value)), nil
+}
+
+// parseSubstrings parses a value with wildcards such as "a*b*c"
+func parseSubstrings(attribute, rawValue string) (*packet, error) {
+	parts := strings.Split(rawValue, "*")
+	components := newSequence(tagSequence)
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		value, err := unescapeFilterValue(part)
+		if err != nil {
+			return nil, err
+		}
+		tag := substringAny
+		switch i {
+		case 0:
+			tag = substringInitial
+		case len(parts) - 1:
+			tag = substringFinal
+		}
+		components.children = append(components.children, newString(tag, value))
+	}
+	return newSequence(filterSubstrings, newString(tagOctetString, attribute), components), nil
+}
+
+// parseExtensible parses the left side of an extensible match: "attr", "attr:dn", "attr:rule",
+// "attr:dn:rule" or ":rule" / ":dn:rule" without attribute
+func parseExtensible(left, rawValue string) (*packet, error) {
+	parts := strings.Split(left, ":")
+	attribute, options := parts[0], parts[1:]
+
+	dnAttributes := false
+	rule := ""
+	for _, option := range options {
+		switch {
+		case strings.EqualFold(option, "dn"):
+			if dnAttributes || rule != "" {
+				return nil, fmt.Errorf("invalid extensible match %q", left+":=")
+			}
+			dnAttributes = true
+		case option != "" && rule == "":
+			rule = option
+		default:
+			return nil, fmt.Errorf("invalid extensible match %q", left+":=")
+		}
+	}
+	if attribute == "" && rule == "" {
+		return nil, fmt.Errorf("extensible match needs an attribute or a matching rule")
+	}
+
+	value, err := unescapeFilterValue(rawValue)
+	if err != nil {
+		return nil, err
+	}
+	match := newSequence(filterExtensibleMatch)
+	if rule != "" {
+		match.children = append(match.children, newString(extensibleRule, rule))
+	}
+	if attribute != "" {
+		match.children = append(match.children, newString(extensibleType, attribute))
+	}
+	match.children = append(match.children, newString(extensibleValue, value))
+	if dnAttributes {
+		match.children = append(match.children, newBoolean(extensibleDN, true))
+	}
+	return match, nil
+}
+
+// unescapeFilterValue decodes the \XX escapes of a filter value
+func unescapeFilterValue(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			unescaped.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("incomplete escape in %q", value)
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", value)
+		}
+		unescaped.Write(decoded)
+		i += 2
+	}
+	return unescaped.String(), nil
+}
@@ -0,0 +1,131 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeFilter(t *testing.T) {
+	assert.Equal(t, "alice", EscapeFilter("alice"))
+	assert.Equal(t, `\2a`, EscapeFilter("*"))
+	assert.Equal(t, `alice\29\28uid=\2a`, EscapeFilter("alice)(uid=*"))
+	assert.Equal(t, `a\5cb\00`, EscapeFilter("a\\b\x00"))
+	assert.Equal(t, "Jörg", EscapeFilter("Jörg"), "UTF-8 is kept as is")
+}
+
+func TestCompileFilter(t *testing.T) {
+	t.Run("equality", func(t *testing.T) {
+		filter, err := compileFilter("(uid=alice)")
+		require.NoError(t, err)
+		expected := []byte{0xa3, 0x0c, 0x04, 0x03, 'u', 'i', 'd', 0x04, 0x05, 'a', 'l', 'i', 'c', 'e'}
+		assert.Equal(t, expected, filter.encode())
+	})
+
+	t.Run("and, or and not", func(t *testing.T) {
+		filter, err := compileFilter("(&(objectClass=person)(|(uid=a)(cn=b))(!(disabled=TRUE)))")
+		require.NoError(t, err)
+		assert.Equal(t, filterAnd, filter.tag)
+		require.Len(t, filter.children, 3)
+		assert.Equal(t, filterEqualityMatch, filter.child(0).tag)
+		assert.Equal(t, filterOr, filter.child(1).tag)
+		assert.Len(t, filter.child(1).children, 2)
+		assert.Equal(t, filterNot, filter.child(2).tag)
+		assert.Equal(t, filterEqualityMatch, filter.child(2).child(0).tag)
+	})
+
+	t.Run("present", func(t *testing.T) {
+		filter, err := compileFilter("(mail=*)")
+		require.NoError(t, err)
+		assert.Equal(t, filterPresent, filter.tag)
+		assert.Equal(t, "mail", filter.string())
+	})
+
+	t.Run("substrings", func(t *testing.T) {
+		filter, err := compileFilter("(cn=Jo*n*Sm*th)")
+		require.NoError(t, err)
+		assert.Equal(t, filterSubstrings, filter.tag)
+		assert.Equal(t, "cn", filter.child(0).string())
+		components := filter.child(1).children
+		require.Len(t, components, 4)
+		assert.Equal(t, substringInitial, components[0].tag)
+		assert.Equal(t, "Jo", components[0].string())
+		assert.Equal(t, substringAny, components[1].tag)
+		assert.Equal(t, substringAny, components[2].tag)
+		assert.Equal(t, substringFinal, components[3].tag)
+		assert.Equal(t, "th", components[3].string())
+
+		filter, err = compileFilter("(cn=*son)")
+		require.NoError(t, err)
+		require.Len(t, filter.child(1).children, 1)
+		assert.Equal(t, substringFinal, filter.child(1).child(0).tag)
+	})
+
+	t.Run("comparisons", func(t *testing.T) {
+		tests := []struct {
+			filter    string
+			tag       byte
+			attribute string
+			value     string
+		}{
+			{"(uidNumber>=1000)", filterGreaterOrEqual, "uidNumber", "1000"},
+			{"(uidNumber<=2000)", filterLessOrEqual, "uidNumber", "2000"},
+			{"(cn~=jon)", filterApproxMatch, "cn", "jon"},
+		}
+		for _, tt := range tests {
+			filter, err := compileFilter(tt.filter)
+			require.NoError(t, err, tt.filter)
+			assert.Equal(t, tt.tag, filter.tag, tt.filter)
+			assert.Equal(t, tt.attribute, filter.child(0).string(), tt.filter)
+			assert.Equal(t, tt.value, filter.child(1).string(), tt.filter)
+		}
+	})
+
+	t.Run("extensible match", func(t *testing.T) {
+		filter, err := compileFilter("(member:1.2.840.113556.1.4.1941:=cn=alice,dc=example,dc=org)")
+		require.NoError(t, err)
+		assert.Equal(t, filterExtensibleMatch, filter.tag)
+		require.Len(t, filter.children, 3)
+		assert.Equal(t, extensibleRule, filter.child(0).tag)
+		assert.Equal(t, "1.2.840.113556.1.4.1941", filter.child(0).string())
+		assert.Equal(t, extensibleType, filter.child(1).tag)
+		assert.Equal(t, "member", filter.child(1).string())
+		assert.Equal(t, extensibleValue, filter.child(2).tag)
+		assert.Equal(t, "cn=alice,dc=example,dc=org", filter.child(2).string())
+
+		filter, err = compileFilter("(ou:dn:=people)")
+		require.NoError(t, err)
+		require.Len(t, filter.children, 3)
+		assert.Equal(t, extensibleDN, filter.child(2).tag)
+		assert.True(t, filter.child(2).bool())
+	})
+
+	t.Run("escapes", func(t *testing.T) {
+		filter, err := compileFilter(`(cn=a\2a\28b\29\5c)`)
+		require.NoError(t, err)
+		assert.Equal(t, filterEqualityMatch, filter.tag, "an escaped star is not a wildcard")
+		assert.Equal(t, `a*(b)\`, filter.child(1).string())
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, raw := range []string{
+			"",
+			"uid=alice",
+			"(uid=alice",
+			"(uid=alice))",
+			"(uid=alice)(cn=b)",
+			"(&)",
+			"(!(uid=a)",
+			"(alice)",
+			"(=alice)",
+			`(cn=a\2)`,
+			`(cn=a\zz)`,
+			"(:=value)",
+			"(cn:dn:dn:=value)",
+		} {
+			_, err := compileFilter(raw)
+			assert.Error(t, err, raw)
+		}
+	})
+}
@@ -0,0 +1,336 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"site-availability/config"
+	"site-availability/logging"
+)
+
+// AuthMethod is the auth method of sessions created by LDAP logins
+const AuthMethod = "ldap"
+
+const (
+	// maxNestingDepth bounds how many levels of nested groups are resolved
+	maxNestingDepth = 10
+	// groupsPerSearch bounds how many group DNs are OR'd into one nested group search
+	groupsPerSearch = 50
+)
+
+// defaultUsernameAttributes hold the canonical username when username_attribute is not set, in order
+var defaultUsernameAttributes = []string{"sAMAccountName", "uid"}
+
+// ErrInvalidCredentials is returned when the user is not found or the password is wrong
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LDAPAuthenticator handles password logins against an LDAP directory or Active Directory
+type LDAPAuthenticator struct {
+	config    *config.Config
+	timeout   time.Duration
+	tlsConfig *tls.Config
+}
+
+// UserInfo represents authenticated user information from LDAP
+type UserInfo struct {
+	Username   string   `json:"username"`
+	IsAdmin    bool     `json:"is_admin"`
+	Roles      []string `json:"roles"`
+	Groups     []string `json:"groups"`
+	AuthMethod string   `json:"auth_method"`
+}
+
+// NewLDAPAuthenticator creates a new LDAP authenticator. The filters are checked here, so a broken
+// filter fails at startup instead of at the first login.
+func NewLDAPAuthenticator(cfg *config.Config) (*LDAPAuthenticator, error) {
+	la := &LDAPAuthenticator{config: cfg}
+	settings := cfg.ServerSettings.LDAP
+	if !settings.Enabled {
+		return la, nil
+	}
+
+	if _, err := compileFilter(userFilter(settings, "user")); err != nil {
+		return nil, fmt.Errorf("LDAP user_filter: %w", err)
+	}
+	if _, err := compileFilter(groupFilter(settings, "cn=group", "user")); err != nil {
+		return nil, fmt.Errorf("LDAP group_filter: %w", err)
+	}
+
+	timeout, err := time.ParseDuration(settings.Timeout)
+	if err != nil || timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	la.timeout = timeout
+
+	if cfg.ServerSettings.CustomCAPath != "" {
+		la.tlsConfig = createTLSConfigFromCA(cfg.ServerSettings.CustomCAPath)
+	}
+	return la, nil
+}
+
+// IsEnabled returns whether LDAP authentication is enabled
+func (la *LDAPAuthenticator) IsEnabled() bool {
+	return la.config.ServerSettings.LDAP.Enabled
+}
+
+// GetProviderName returns the configured provider name
+func (la *LDAPAuthenticator) GetProviderName() string {
+	if la.config.ServerSettings.LDAP.Name != "" {
+		return la.config.ServerSettings.LDAP.Name
+	}
+	return "LDAP"
+}
+
+// Authenticate finds the user with the service account, verifies the password by binding as the
+// user and reads the user's groups. It returns ErrInvalidCredentials for unknown users and wrong
+// passwords; other errors mean the directory could not be queried. The returned username is the
+// one stored in the directory, so "ALICE" logs in as "alice".
+func (la *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (*UserInfo, error) {
+	if !la.IsEnabled() {
+		return nil, fmt.Errorf("LDAP is not enabled")
+	}
+	// An empty password would be an unauthenticated bind, which servers accept for any DN
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	settings := la.config.ServerSettings.LDAP
+
+	ctx, cancel := context.WithTimeout(ctx, la.timeout)
+	defer cancel()
+
+	c, err := dial(ctx, settings.URL, settings.StartTLS, la.tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	if err := la.bindServiceAccount(c); err != nil {
+		return nil, err
+	}
+
+	userDN, canonical, err := la.findUser(c, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.bind(userDN, password); err != nil {
+		if isResultCode(err, resultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("LDAP bind as %q failed: %w", userDN, err)
+	}
+	username = canonical
+
+	// Groups are read as the service account, as users may not be allowed to search groups
+	if err := la.bindServiceAccount(c); err != nil {
+		return nil, err
+	}
+	groups, err := la.findGroups(c, username, userDN)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, isAdmin := la.getUserRoles(username, groups)
+	logging.Logger.WithFields(map[string]interface{}{
+		"username": username,
+		"dn":       userDN,
+		"groups":   groups,
+		"roles":    roles,
+	}).Debug("LDAP user authenticated")
+
+	return &UserInfo{
+		Username:   username,
+		IsAdmin:    isAdmin,
+		Roles:      roles,
+		Groups:     groups,
+		AuthMethod: AuthMethod,
+	}, nil
+}
+
+// bindServiceAccount binds as the configured service account. Without one, searches are anonymous.
+func (la *LDAPAuthenticator) bindServiceAccount(c *conn) error {
+	settings := la.config.ServerSettings.LDAP
+	if settings.BindDN == "" {
+		return nil
+	}
+	if err := c.bind(settings.BindDN, settings.BindPassword); err != nil {
+		return fmt.Errorf("LDAP bind as service account %q failed: %w", settings.BindDN, err)
+	}
+	return nil
+}
+
+// findUser returns the DN and the canonical username of the single entry matching the user filter
+func (la *LDAPAuthenticator) findUser(c *conn, username string) (string, string, error) {
+	settings := la.config.ServerSettings.LDAP
+	filter, err := compileFilter(userFilter(settings, username))
+	if err != nil {
+		return "", "", fmt.Errorf("LDAP user_filter: %w", err)
+	}
+
+	attributes := usernameAttributes(settings)
+	entries, err := c.search(settings.BaseDN, filter, attributes, 2)
+	if err != nil && !isResultCode(err, resultSizeLimitExceeded) {
+		return "", "", fmt.Errorf("LDAP user search failed: %w", err)
+	}
+	switch {
+	case len(entries) == 0:
+		logging.Logger.WithField("username", username).Debug("LDAP user not found")
+		return "", "", ErrInvalidCredentials
+	case len(entries) > 1 || err != nil:
+		logging.Logger.WithField("username", username).Warn("LDAP user_filter matches more than one entry, refusing login")
+		return "", "", ErrInvalidCredentials
+	}
+
+	for _, attribute := range attributes {
+		if values := entries[0].values(attribute); len(values) > 0 && values[0] != "" {
+			return entries[0].dn, values[0], nil
+		}
+	}
+	return "", "", fmt.Errorf("LDAP user %q has no %s attribute", entries[0].dn, strings.Join(attributes, " or "))
+}
+
+// usernameAttributes returns the attributes holding the canonical username, in order
+func usernameAttributes(settings config.LDAPConfig) []string {
+	if settings.UsernameAttribute != "" {
+		return []string{settings.UsernameAttribute}
+	}
+	return defaultUsernameAttributes
+}
+
+// findGroups returns the names of the user's groups, and of the groups containing them when
+// nested groups are enabled
+func (la *LDAPAuthenticator) findGroups(c *conn, username, userDN string) ([]string, error) {
+	settings := la.config.ServerSettings.LDAP
+	names := make(map[string]bool)
+	visited := map[string]bool{strings.ToLower(userDN): true}
+
+	members := []string{userDN}
+	for depth := 0; len(members) > 0; depth++ {
+		if depth > 0 && (!settings.NestedGroups || depth > maxNestingDepth) {
+			break
+		}
+
+		var found []string
+		for start := 0; start < len(members); start += groupsPerSearch {
+			end := start + groupsPerSearch
+			if end > len(members) {
+				end = len(members)
+			}
+			entries, err := la.searchGroups(c, username, members[start:end])
+			if err != nil {
+				return nil, err
+			}
+			for _, group := range entries {
+				for _, name := range group.values(settings.GroupNameAttribute) {
+					names[name] = true
+				}
+				key := strings.ToLower(group.dn)
+				if !visited[key] {
+					visited[key] = true
+					found = append(found, group.dn)
+				}
+			}
+		}
+		members = found
+	}
+
+	groups := make([]string, 0, len(names))
+	for name := range names {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// searchGroups returns the groups that have one of the DNs as a member
+func (la *LDAPAuthenticator) searchGroups(c *conn, username string, memberDNs []string) ([]entry, error) {
+	settings := la.config.ServerSettings.LDAP
+	filters := make([]string, 0, len(memberDNs))
+	for _, dn := range memberDNs {
+		filters = append(filters, groupFilter(settings, dn, username))
+	}
+	rawFilter := filters[0]
+	if len(filters) > 1 {
+		rawFilter = "(|" + strings.Join(filters, "") + ")"
+	}
+
+	filter, err := compileFilter(rawFilter)
+	if err != nil {
+		return nil, fmt.Errorf("LDAP group_filter: %w", err)
+	}
+	entries, err := c.search(settings.GroupBaseDN, filter, []string{settings.GroupNameAttribute}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("LDAP group search failed: %w", err)
+	}
+	return entries, nil
+}
+
+// getUserRoles maps username and groups to roles based on configuration, like OIDC permissions
+func (la *LDAPAuthenticator) getUserRoles(username string, groups []string) ([]string, bool) {
+	permissions := la.config.ServerSettings.LDAP.Permissions
+	roleSet := make(map[string]bool)
+	for _, role := range permissions.Users[username] {
+		roleSet[role] = true
+	}
+	for _, group := range groups {
+		for _, role := range permissions.Groups[group] {
+			roleSet[role] = true
+		}
+	}
+
+	roles := make([]string, 0, len(roleSet))
+	for role := range roleSet {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	if len(roles) == 0 {
+		logging.Logger.WithField("username", username).Warn("No roles assigned to user - user will have no permissions")
+	}
+	return roles, roleSet["admin"]
+}
+
+// userFilter returns the user filter for a username
+func userFilter(settings config.LDAPConfig, username string) string {
+	return strings.ReplaceAll(settings.UserFilter, "{username}", EscapeFilter(username))
+}
+
+// groupFilter returns the group filter for a member DN
+func groupFilter(settings config.LDAPConfig, memberDN, username string) string {
+	return strings.NewReplacer(
+		"{dn}", EscapeFilter(memberDN),
+		"{username}", EscapeFilter(username),
+	).Replace(settings.GroupFilter)
+}
+
+// createTLSConfigFromCA creates a TLS config with custom CA certificates
+func createTLSConfigFromCA(caPath string) *tls.Config {
+	caCertPool := x509.NewCertPool()
+	for _, path := range strings.Split(caPath, ":") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		certData, err := os.ReadFile(path)
+		if err != nil {
+			logging.Logger.WithError(err).WithField("path", path).Error("Failed to read CA certificate for LDAP")
+			continue
+		}
+		if ok := caCertPool.AppendCertsFromPEM(certData); !ok {
+			logging.Logger.WithField("path", path).Error("Failed to append CA certificate for LDAP")
+		}
+	}
+
+	return &tls.Config{
+		RootCAs:    caCertPool,
+		MinVersion: tls.VersionTLS12,
+	}
+}
@@ -0,0 +1,317 @@
+package ldap
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"site-availability/config"
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	serviceDN       = "cn=service,dc=example,dc=org"
+	servicePassword = "service-password"
+	aliceDN         = "uid=alice,ou=people,dc=example,dc=org"
+	bobDN           = "cn=Bob Smith,ou=people,dc=example,dc=org"
+)
+
+func TestMain(m *testing.M) {
+	if err := logging.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newDirectory returns a directory where alice is in operators, which is in admins, and bob is
+// in viewers through uniqueMember
+func newDirectory() *testDirectory {
+	d := newTestDirectory()
+	d.add(serviceDN, "cn", "service")
+	d.passwords[serviceDN] = servicePassword
+
+	d.add(aliceDN, "uid", "alice", "objectClass", "person")
+	d.passwords[aliceDN] = "alice-password"
+	d.add(bobDN, "sAMAccountName", "bob", "objectClass", "person")
+	d.passwords[bobDN] = "bob-password"
+	d.add("uid=twin,ou=people,dc=example,dc=org", "uid", "twin")
+	d.add("uid=twin,ou=contractors,dc=example,dc=org", "uid", "twin")
+
+	d.add("cn=operators,ou=groups,dc=example,dc=org", "cn", "operators", "member", aliceDN)
+	d.add("cn=admins,ou=groups,dc=example,dc=org", "cn", "admins", "member", "cn=operators,ou=groups,dc=example,dc=org")
+	d.add("cn=viewers,ou=groups,dc=example,dc=org", "cn", "viewers", "uniqueMember", bobDN)
+	return d
+}
+
+// newLDAPConfig returns an enabled LDAP config for a directory url, with the defaults applied
+func newLDAPConfig(url string) *config.Config {
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			LDAP: config.LDAPConfig{
+				Enabled:      true,
+				URL:          url,
+				BindDN:       serviceDN,
+				BindPassword: servicePassword,
+				BaseDN:       "dc=example,dc=org",
+				GroupBaseDN:  "ou=groups,dc=example,dc=org",
+				Permissions: config.OIDCPermissions{
+					Users:  map[string][]string{"bob": {"viewer"}},
+					Groups: map[string][]string{"admins": {"admin"}, "operators": {"operator"}},
+				},
+			},
+		},
+	}
+	cfg.ServerSettings.LDAP.UserFilter = "(|(sAMAccountName={username})(uid={username}))"
+	cfg.ServerSettings.LDAP.GroupFilter = "(|(member={dn})(uniqueMember={dn}))"
+	cfg.ServerSettings.LDAP.GroupNameAttribute = "cn"
+	cfg.ServerSettings.LDAP.Timeout = "5s"
+	return cfg
+}
+
+func newAuthenticator(t *testing.T, cfg *config.Config) *LDAPAuthenticator {
+	auth, err := NewLDAPAuthenticator(cfg)
+	require.NoError(t, err)
+	return auth
+}
+
+func TestNewLDAPAuthenticator(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		auth := newAuthenticator(t, &config.Config{})
+		assert.False(t, auth.IsEnabled())
+		assert.Equal(t, "LDAP", auth.GetProviderName())
+	})
+
+	t.Run("provider name", func(t *testing.T) {
+		cfg := newLDAPConfig("ldap://127.0.0.1")
+		cfg.ServerSettings.LDAP.Name = "Corporate AD"
+		auth := newAuthenticator(t, cfg)
+		assert.True(t, auth.IsEnabled())
+		assert.Equal(t, "Corporate AD", auth.GetProviderName())
+	})
+
+	t.Run("invalid user filter", func(t *testing.T) {
+		cfg := newLDAPConfig("ldap://127.0.0.1")
+		cfg.ServerSettings.LDAP.UserFilter = "(uid={username}"
+		_, err := NewLDAPAuthenticator(cfg)
+		assert.ErrorContains(t, err, "user_filter")
+	})
+
+	t.Run("invalid group filter", func(t *testing.T) {
+		cfg := newLDAPConfig("ldap://127.0.0.1")
+		cfg.ServerSettings.LDAP.GroupFilter = "member={dn}"
+		_, err := NewLDAPAuthenticator(cfg)
+		assert.ErrorContains(t, err, "group_filter")
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	directory := newDirectory()
+	url := directory.start(t, false)
+	ctx := context.Background()
+
+	t.Run("groups map to roles", func(t *testing.T) {
+		auth := newAuthenticator(t, newLDAPConfig(url))
+		user, err := auth.Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Username)
+		assert.Equal(t, []string{"operators"}, user.Groups, "nested groups are off by default")
+		assert.Equal(t, []string{"operator"}, user.Roles)
+		assert.False(t, user.IsAdmin)
+		assert.Equal(t, AuthMethod, user.AuthMethod)
+	})
+
+	t.Run("nested groups", func(t *testing.T) {
+		cfg := newLDAPConfig(url)
+		cfg.ServerSettings.LDAP.NestedGroups = true
+		auth := newAuthenticator(t, cfg)
+		user, err := auth.Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins", "operators"}, user.Groups)
+		assert.Equal(t, []string{"admin", "operator"}, user.Roles)
+		assert.True(t, user.IsAdmin)
+	})
+
+	t.Run("user roles and uniqueMember", func(t *testing.T) {
+		auth := newAuthenticator(t, newLDAPConfig(url))
+		user, err := auth.Authenticate(ctx, "bob", "bob-password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"viewers"}, user.Groups)
+		assert.Equal(t, []string{"viewer"}, user.Roles)
+	})
+
+	t.Run("searches run as the service account", func(t *testing.T) {
+		d := newDirectory()
+		auth := newAuthenticator(t, newLDAPConfig(d.start(t, false)))
+		_, err := auth.Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{serviceDN, aliceDN, serviceDN}, d.bindDNs())
+		for _, bound := range d.searchBinds() {
+			assert.Equal(t, serviceDN, bound)
+		}
+	})
+
+	t.Run("anonymous search", func(t *testing.T) {
+		d := newDirectory()
+		cfg := newLDAPConfig(d.start(t, false))
+		cfg.ServerSettings.LDAP.BindDN = ""
+		cfg.ServerSettings.LDAP.BindPassword = ""
+		auth := newAuthenticator(t, cfg)
+		_, err := auth.Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{aliceDN}, d.bindDNs())
+		assert.Equal(t, []string{"", aliceDN}, d.searchBinds())
+	})
+
+	t.Run("canonical username", func(t *testing.T) {
+		auth := newAuthenticator(t, newLDAPConfig(url))
+		user, err := auth.Authenticate(ctx, "ALICE", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Username, "the directory value, not the typed one")
+		assert.Equal(t, []string{"operator"}, user.Roles)
+
+		user, err = auth.Authenticate(ctx, "Bob", "bob-password")
+		require.NoError(t, err)
+		assert.Equal(t, "bob", user.Username, "sAMAccountName by default")
+		assert.Equal(t, []string{"viewer"}, user.Roles, "user permissions use the canonical username")
+	})
+
+	t.Run("username attribute", func(t *testing.T) {
+		d := newDirectory()
+		d.add(aliceDN, "uid", "alice", "mail", "alice@example.org", "objectClass", "person")
+		cfg := newLDAPConfig(d.start(t, false))
+		cfg.ServerSettings.LDAP.UserFilter = "(|(uid={username})(mail={username}))"
+		cfg.ServerSettings.LDAP.UsernameAttribute = "uid"
+		auth := newAuthenticator(t, cfg)
+		user, err := auth.Authenticate(ctx, "alice@example.org", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Username)
+
+		cfg.ServerSettings.LDAP.UsernameAttribute = "employeeNumber"
+		_, err = newAuthenticator(t, cfg).Authenticate(ctx, "alice", "alice-password")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInvalidCredentials, "a missing attribute is a configuration problem")
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		auth := newAuthenticator(t, newLDAPConfig(url))
+		tests := []struct {
+			name     string
+			username string
+			password string
+		}{
+			{"wrong password", "alice", "wrong"},
+			{"unknown user", "mallory", "alice-password"},
+			{"empty password", "alice", ""},
+			{"empty username", "", "alice-password"},
+			{"ambiguous user", "twin", "twin-password"},
+			{"filter injection", "*", "alice-password"},
+			{"filter injection with parentheses", "alice)(uid=*", "alice-password"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := auth.Authenticate(ctx, tt.username, tt.password)
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+			})
+		}
+	})
+
+	t.Run("empty password never binds", func(t *testing.T) {
+		d := newDirectory()
+		auth := newAuthenticator(t, newLDAPConfig(d.start(t, false)))
+		_, err := auth.Authenticate(ctx, "alice", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Empty(t, d.bindDNs())
+	})
+
+	t.Run("wrong service account password", func(t *testing.T) {
+		cfg := newLDAPConfig(url)
+		cfg.ServerSettings.LDAP.BindPassword = "wrong"
+		auth := newAuthenticator(t, cfg)
+		_, err := auth.Authenticate(ctx, "alice", "alice-password")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInvalidCredentials, "a broken service account is not the user's fault")
+	})
+
+	t.Run("group cycles end", func(t *testing.T) {
+		d := newDirectory()
+		d.add("cn=operators,ou=groups,dc=example,dc=org", "cn", "operators", "member", aliceDN,
+			"member", "cn=admins,ou=groups,dc=example,dc=org")
+		cfg := newLDAPConfig(d.start(t, false))
+		cfg.ServerSettings.LDAP.NestedGroups = true
+		auth := newAuthenticator(t, cfg)
+		user, err := auth.Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins", "operators"}, user.Groups)
+	})
+}
+
+func TestAuthenticateTLS(t *testing.T) {
+	tlsConfig, caPath := newTestTLSConfig(t)
+	ctx := context.Background()
+
+	t.Run("ldaps", func(t *testing.T) {
+		d := newDirectory()
+		d.tlsConfig = tlsConfig
+		cfg := newLDAPConfig(d.start(t, true))
+		cfg.ServerSettings.CustomCAPath = caPath
+		user, err := newAuthenticator(t, cfg).Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Username)
+	})
+
+	t.Run("start tls", func(t *testing.T) {
+		d := newDirectory()
+		d.tlsConfig = tlsConfig
+		cfg := newLDAPConfig(d.start(t, false))
+		cfg.ServerSettings.LDAP.StartTLS = true
+		cfg.ServerSettings.CustomCAPath = caPath
+		user, err := newAuthenticator(t, cfg).Authenticate(ctx, "alice", "alice-password")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Username)
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		d := newDirectory()
+		d.tlsConfig = tlsConfig
+		cfg := newLDAPConfig(d.start(t, true))
+		_, err := newAuthenticator(t, cfg).Authenticate(ctx, "alice", "alice-password")
+		assert.ErrorContains(t, err, "TLS handshake")
+		assert.Empty(t, d.bindDNs(), "credentials are not sent over an unverified connection")
+	})
+}
+
+func TestAuthenticateUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "ldap://" + listener.Addr().String()
+	listener.Close()
+
+	_, err = newAuthenticator(t, newLDAPConfig(url)).Authenticate(context.Background(), "alice", "alice-password")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthenticateTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		// Accept and never answer
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer netConn.Close()
+		}
+	}()
+
+	cfg := newLDAPConfig("ldap://" + listener.Addr().String())
+	cfg.ServerSettings.LDAP.Timeout = "100ms"
+	_, err = newAuthenticator(t, cfg).Authenticate(context.Background(), "alice", "alice-password")
+	assert.ErrorContains(t, err, "timeout")
+}
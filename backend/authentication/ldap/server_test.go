@@ -0,0 +1,271 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDirectory is an in-process LDAP server for tests. It supports simple binds, subtree
+// searches with the filters used here, StartTLS and ldaps.
+type testDirectory struct {
+	entries   map[string]map[string][]string // Attributes by DN, keyed by lowercase attribute name
+	passwords map[string]string              // Passwords by DN
+	tlsConfig *tls.Config
+
+	mutex    sync.Mutex
+	binds    []string // DNs of successful binds
+	searches []string // DNs bound while searching, "" when anonymous
+}
+
+// newTestDirectory returns an empty directory
+func newTestDirectory() *testDirectory {
+	return &testDirectory{
+		entries:   make(map[string]map[string][]string),
+		passwords: make(map[string]string),
+	}
+}
+
+// add adds an entry with attributes given as name, value pairs
+func (d *testDirectory) add(dn string, attributes ...string) {
+	entry := make(map[string][]string)
+	for i := 0; i+1 < len(attributes); i += 2 {
+		name := strings.ToLower(attributes[i])
+		entry[name] = append(entry[name], attributes[i+1])
+	}
+	d.entries[dn] = entry
+}
+
+// bindDNs returns the DNs of the successful binds so far
+func (d *testDirectory) bindDNs() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.binds...)
+}
+
+// searchBinds returns the DNs bound during the searches so far
+func (d *testDirectory) searchBinds() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.searches...)
+}
+
+// start listens on a local port and returns the url of the directory. With ldaps, connections
+// start with a TLS handshake.
+func (d *testDirectory) start(t *testing.T, ldaps bool) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if ldaps {
+				netConn = tls.Server(netConn, d.tlsConfig)
+			}
+			go d.serve(netConn)
+		}
+	}()
+
+	scheme := "ldap"
+	if ldaps {
+		scheme = "ldaps"
+	}
+	return scheme + "://" + listener.Addr().String()
+}
+
+// serve answers the requests of a connection until it is closed or unbound
+func (d *testDirectory) serve(netConn net.Conn) {
+	defer netConn.Close()
+	reader := bufio.NewReader(netConn)
+	boundDN := ""
+
+	for {
+		message, err := readPacket(reader)
+		if err != nil || len(message.children) < 2 {
+			return
+		}
+		id := message.child(0).int()
+		op := message.child(1)
+
+		reply := func(response *packet) bool {
+			encoded := newSequence(tagSequence, newInteger(tagInteger, id), response).encode()
+			_, err := netConn.Write(encoded)
+			return err == nil
+		}
+
+		switch op.tag {
+		case tagUnbindRequest:
+			return
+		case tagBindRequest:
+			dn, password := op.child(1).string(), op.child(2).string()
+			code := int64(resultSuccess)
+			if password != "" && d.passwords[dn] != password {
+				code = resultInvalidCredentials
+			}
+			if code == resultSuccess {
+				boundDN = dn
+				d.mutex.Lock()
+				d.binds = append(d.binds, dn)
+				d.mutex.Unlock()
+			}
+			if !reply(ldapResult(tagBindResponse, code)) {
+				return
+			}
+		case tagSearchRequest:
+			d.mutex.Lock()
+			d.searches = append(d.searches, boundDN)
+			d.mutex.Unlock()
+			for _, response := range d.search(op) {
+				if !reply(response) {
+					return
+				}
+			}
+		case tagExtendedRequest:
+			if op.child(0).string() != startTLSOID || d.tlsConfig == nil {
+				reply(ldapResult(tagExtendedResponse, 2))
+				return
+			}
+			if !reply(ldapResult(tagExtendedResponse, resultSuccess)) {
+				return
+			}
+			tlsConn := tls.Server(netConn, d.tlsConfig)
+			netConn, reader = tlsConn, bufio.NewReader(tlsConn)
+		default:
+			return
+		}
+	}
+}
+
+// search returns the entries matching a search request, followed by SearchResultDone
+func (d *testDirectory) search(op *packet) []*packet {
+	baseDN := strings.ToLower(op.child(0).string())
+	sizeLimit := op.child(3).int()
+	filter := op.child(6)
+	var requested []string
+	for _, attribute := range op.child(7).children {
+		requested = append(requested, strings.ToLower(attribute.string()))
+	}
+
+	var responses []*packet
+	for dn, attributes := range d.entries {
+		if !strings.HasSuffix(strings.ToLower(dn), baseDN) || !matches(filter, attributes) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(responses)) == sizeLimit {
+			return append(responses, ldapResult(tagSearchResultDone, resultSizeLimitExceeded))
+		}
+
+		list := newSequence(tagSequence)
+		for name, values := range attributes {
+			if len(requested) > 0 && !contains(requested, name) {
+				continue
+			}
+			set := newSequence(tagSet)
+			for _, value := range values {
+				set.children = append(set.children, newString(tagOctetString, value))
+			}
+			list.children = append(list.children, newSequence(tagSequence, newString(tagOctetString, name), set))
+		}
+		responses = append(responses, newSequence(tagSearchResultEntry, newString(tagOctetString, dn), list))
+	}
+	return append(responses, ldapResult(tagSearchResultDone, resultSuccess))
+}
+
+// matches evaluates a filter against the attributes of an entry. Values compare case-insensitively.
+func matches(filter *packet, attributes map[string][]string) bool {
+	switch filter.tag {
+	case filterAnd:
+		for _, child := range filter.children {
+			if !matches(child, attributes) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, child := range filter.children {
+			if matches(child, attributes) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return !matches(filter.child(0), attributes)
+	case filterPresent:
+		return len(attributes[strings.ToLower(filter.string())]) > 0
+	case filterEqualityMatch:
+		for _, value := range attributes[strings.ToLower(filter.child(0).string())] {
+			if strings.EqualFold(value, filter.child(1).string()) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// ldapResult returns a response carrying an LDAPResult
+func ldapResult(tag byte, code int64) *packet {
+	return newSequence(tag,
+		newInteger(tagEnumerated, code),
+		newString(tagOctetString, ""),
+		newString(tagOctetString, ""),
+	)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestTLSConfig returns a server TLS config with a self-signed certificate for 127.0.0.1,
+// and the path of the certificate in PEM format to trust it with custom_ca_path
+func newTestTLSConfig(t *testing.T) (*tls.Config, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test directory"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}, caPath
+}
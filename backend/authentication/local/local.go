@@ -2,12 +2,13 @@ package local
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"site-availability/config"
 )
 
-// LocalAuthenticator handles local admin authentication
+// LocalAuthenticator handles authentication of the local admin and the local users
 type LocalAuthenticator struct {
 	config *config.Config
 }
@@ -19,9 +20,9 @@ func NewLocalAuthenticator(cfg *config.Config) *LocalAuthenticator {
 	}
 }
 
-// IsEnabled returns whether local admin authentication is enabled
+// IsEnabled returns whether local admin or local user authentication is enabled
 func (la *LocalAuthenticator) IsEnabled() bool {
-	return la.config.ServerSettings.LocalAdmin.Enabled
+	return la.config.ServerSettings.LocalAdmin.Enabled || la.config.ServerSettings.LocalUsers.Enabled
 }
 
 // GetUsername returns the configured admin username
@@ -29,9 +30,9 @@ func (la *LocalAuthenticator) GetUsername() string {
 	return la.config.ServerSettings.LocalAdmin.Username
 }
 
-// Authenticate validates username and password for local admin
+// Authenticate validates username and password of the local admin or a local user
 func (la *LocalAuthenticator) Authenticate(username, password string) error {
-	// Check if local admin is enabled
+	// Check if local authentication is enabled
 	if !la.IsEnabled() {
 		return fmt.Errorf("local admin authentication is disabled")
 	}
@@ -45,27 +46,67 @@ func (la *LocalAuthenticator) Authenticate(username, password string) error {
 		return fmt.Errorf("password is required")
 	}
 
-	// Check username match
-	configUsername := la.config.ServerSettings.LocalAdmin.Username
-	if username != configUsername {
-		return fmt.Errorf("invalid credentials")
-	}
-
-	// Verify password with simple string comparison
-	// Note: The password is stored in the config file
-	// as the password (or hash) is already in memory. Security should come from:
+	// The local admin password may be plaintext or a bcrypt or argon2id hash.
+	// Security of plaintext passwords comes from:
 	// 1. File system permissions on the config file
 	// 2. Using proper secrets management (Kubernetes secrets, HashiCorp Vault, etc.)
-	configPassword := la.config.ServerSettings.LocalAdmin.Password
-	if password != configPassword {
+	if la.isAdmin(username) {
+		if !VerifyPassword(la.config.ServerSettings.LocalAdmin.Password, password) {
+			return fmt.Errorf("invalid credentials")
+		}
+		return nil
+	}
+
+	user, exists := la.localUser(username)
+	if !exists {
+		// Spend the time of a hash comparison, so timing doesn't reveal which users exist
+		VerifyPassword(dummyHash, password)
+		return fmt.Errorf("invalid credentials")
+	}
+	if !VerifyPassword(user.PasswordHash, password) {
 		return fmt.Errorf("invalid credentials")
 	}
 
 	return nil
 }
 
-// GetUserInfo returns user information for the local admin
+// HasUser reports whether username is the local admin or a local user
+func (la *LocalAuthenticator) HasUser(username string) bool {
+	_, exists := la.localUser(username)
+	return la.isAdmin(username) || exists
+}
+
+// isAdmin reports whether username is the enabled local admin
+func (la *LocalAuthenticator) isAdmin(username string) bool {
+	return la.config.ServerSettings.LocalAdmin.Enabled && username == la.config.ServerSettings.LocalAdmin.Username
+}
+
+// localUser returns an enabled local user
+func (la *LocalAuthenticator) localUser(username string) (config.LocalUserConfig, bool) {
+	if !la.config.ServerSettings.LocalUsers.Enabled {
+		return config.LocalUserConfig{}, false
+	}
+	user, exists := la.config.ServerSettings.LocalUsers.Users[username]
+	return user, exists
+}
+
+// GetUserInfo returns user information for the local admin or a local user. Roles of local users
+// are mapped like OIDC user roles, so the "admin" role grants full access.
 func (la *LocalAuthenticator) GetUserInfo(username string) UserInfo {
+	if user, exists := la.localUser(username); exists && !la.isAdmin(username) {
+		roles := slices.Clone(user.Roles)
+		if roles == nil {
+			roles = []string{}
+		}
+		return UserInfo{
+			Username:   username,
+			IsAdmin:    slices.Contains(roles, "admin"),
+			Roles:      roles,
+			Groups:     []string{}, // Local users have no groups
+			AuthMethod: "local",
+		}
+	}
+
 	return UserInfo{
 		Username:   username,
 		IsAdmin:    true,
@@ -249,3 +249,73 @@ func TestUserInfoStruct(t *testing.T) {
 		assert.Equal(t, "local", userInfo.AuthMethod)
 	})
 }
+
+func TestLocalUsers(t *testing.T) {
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			LocalAdmin: config.LocalAdminConfig{
+				Enabled:  true,
+				Username: "admin",
+				Password: testBcryptHash, // "alice-password"
+			},
+			LocalUsers: config.LocalUsersConfig{
+				Enabled: true,
+				Users: map[string]config.LocalUserConfig{
+					"alice": {PasswordHash: testBcryptHash, Roles: []string{"frontend", "qa"}},
+					"bob":   {PasswordHash: testArgon2idHash, Roles: []string{"admin"}},
+					"carol": {PasswordHash: testBcryptHash},
+				},
+			},
+		},
+	}
+	auth := NewLocalAuthenticator(cfg)
+
+	t.Run("authenticate", func(t *testing.T) {
+		assert.NoError(t, auth.Authenticate("alice", "alice-password"))
+		assert.NoError(t, auth.Authenticate("bob", "bob-password"))
+		assert.NoError(t, auth.Authenticate("admin", "alice-password"), "the admin password may be a hash")
+
+		for _, credentials := range [][2]string{{"alice", "bob-password"}, {"bob", "alice-password"}, {"dave", "alice-password"}, {"Alice", "alice-password"}} {
+			err := auth.Authenticate(credentials[0], credentials[1])
+			require.Error(t, err, credentials[0])
+			assert.Equal(t, "invalid credentials", err.Error())
+		}
+	})
+
+	t.Run("user info", func(t *testing.T) {
+		assert.Equal(t, UserInfo{
+			Username:   "alice",
+			IsAdmin:    false,
+			Roles:      []string{"frontend", "qa"},
+			Groups:     []string{},
+			AuthMethod: "local",
+		}, auth.GetUserInfo("alice"))
+		assert.True(t, auth.GetUserInfo("bob").IsAdmin, "the admin role grants full access like for OIDC users")
+		assert.Equal(t, []string{}, auth.GetUserInfo("carol").Roles)
+		assert.True(t, auth.GetUserInfo("admin").IsAdmin)
+	})
+
+	t.Run("has user", func(t *testing.T) {
+		assert.True(t, auth.HasUser("admin"))
+		assert.True(t, auth.HasUser("alice"))
+		assert.False(t, auth.HasUser("dave"))
+		assert.False(t, auth.HasUser("Alice"))
+	})
+
+	t.Run("local users only", func(t *testing.T) {
+		usersOnly := *cfg
+		usersOnly.ServerSettings.LocalAdmin = config.LocalAdminConfig{}
+		auth := NewLocalAuthenticator(&usersOnly)
+		assert.True(t, auth.IsEnabled())
+		assert.NoError(t, auth.Authenticate("alice", "alice-password"))
+		assert.Error(t, auth.Authenticate("admin", "alice-password"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := *cfg
+		disabled.ServerSettings.LocalUsers.Enabled = false
+		auth := NewLocalAuthenticator(&disabled)
+		assert.Error(t, auth.Authenticate("alice", "alice-password"))
+		assert.NoError(t, auth.Authenticate("admin", "alice-password"))
+	})
+}
@@ -0,0 +1,39 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// Hashes of "alice-password" (bcrypt cost 4) and "bob-password" (argon2id, 8 MiB, 1 iteration)
+	testBcryptHash   = "$2a$04$Z32EVzD2hOgi9c2o6E4TUupW0sGYMalo68/tepcKkzjgx8eaX7A9."
+	testArgon2idHash = "$argon2id$v=19$m=8192,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$tahiUkvr5syQpFiPJZLieCvPlMnPyk/v+w7DOD++EA0"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		want     bool
+	}{
+		{name: "bcrypt", stored: testBcryptHash, password: "alice-password", want: true},
+		{name: "bcrypt wrong password", stored: testBcryptHash, password: "wrong", want: false},
+		{name: "bcrypt $2b$", stored: "$2b$" + testBcryptHash[4:], password: "alice-password", want: true},
+		{name: "argon2id", stored: testArgon2idHash, password: "bob-password", want: true},
+		{name: "argon2id wrong password", stored: testArgon2idHash, password: "alice-password", want: false},
+		{name: "argon2id wrong version", stored: "$argon2id$v=16$m=8192,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$tahiUkvr5syQpFiPJZLieCvPlMnPyk/v+w7DOD++EA0", password: "bob-password", want: false},
+		{name: "argon2id malformed", stored: "$argon2id$v=19$m=8192,t=1,p=1$salt", password: "bob-password", want: false},
+		{name: "argon2id zero iterations", stored: "$argon2id$v=19$m=8192,t=0,p=1$MDEyMzQ1Njc4OWFiY2RlZg$tahiUkvr5syQpFiPJZLieCvPlMnPyk/v+w7DOD++EA0", password: "bob-password", want: false},
+		{name: "plaintext", stored: "password", password: "password", want: true},
+		{name: "plaintext wrong password", stored: "password", password: "passwort", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, VerifyPassword(tt.stored, tt.password))
+		})
+	}
+}
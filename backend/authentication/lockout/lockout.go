@@ -0,0 +1,194 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+
+	"site-availability/config"
+	"site-availability/logging"
+)
+
+// pruneThreshold is the number of tracked accounts above which stale entries are removed
+const pruneThreshold = 1000
+
+// busyRetryAfter is how long to wait when an account has as many logins in progress as failures left
+const busyRetryAfter = time.Second
+
+// entry tracks the failed logins of an account
+type entry struct {
+	failures    int
+	pending     int // Logins in progress, see Begin
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Tracker counts failed password logins per username and locks accounts after too many
+// failures in a row. Unknown usernames are tracked like existing ones, so lockouts don't reveal
+// which accounts exist. The tracker is safe for concurrent use.
+type Tracker struct {
+	entries map[string]*entry
+	mutex   sync.Mutex
+}
+
+// NewTracker creates a tracker without failed logins
+func NewTracker() *Tracker {
+	return &Tracker{entries: make(map[string]*entry)}
+}
+
+// Locked returns how long the account stays locked, or false when it is not locked
+func (t *Tracker) Locked(username string, now time.Time) (time.Duration, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e, exists := t.entries[username]
+	if !exists || !now.Before(e.lockedUntil) {
+		return 0, false
+	}
+	return e.lockedUntil.Sub(now), true
+}
+
+// Begin reserves a login before its password is checked. Logins in progress count like failures
+// until they end, so parallel guesses can't all pass the lock check while slow hashes are verified.
+// It returns how long to wait and true when the account is locked, or has as many logins in
+// progress as failures left. Otherwise the login must be ended with Fail, Succeed or Cancel.
+func (t *Tracker) Begin(username string, now time.Time, settings config.LoginLockoutConfig) (*Attempt, time.Duration, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e := t.entry(username, now, settings.LockDuration())
+	if now.Before(e.lockedUntil) {
+		return nil, e.lockedUntil.Sub(now), true
+	}
+	if now.Sub(e.lastFailure) > settings.LockDuration() {
+		e.failures = 0
+	}
+	if e.failures+e.pending >= settings.Attempts() {
+		return nil, busyRetryAfter, true
+	}
+	e.pending++
+	return &Attempt{tracker: t, username: username, settings: settings}, 0, false
+}
+
+// Fail records a failed login and reports whether it locked the account. Failures older than the
+// lock duration are forgotten.
+func (t *Tracker) Fail(username string, now time.Time, settings config.LoginLockoutConfig) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.fail(t.entry(username, now, settings.LockDuration()), username, now, settings)
+}
+
+// entry returns the entry of an account, adding it when missing. Caller must hold the lock.
+func (t *Tracker) entry(username string, now time.Time, duration time.Duration) *entry {
+	e, exists := t.entries[username]
+	if !exists {
+		if len(t.entries) >= pruneThreshold {
+			t.prune(now, duration)
+		}
+		e = &entry{}
+		t.entries[username] = e
+	}
+	return e
+}
+
+// fail records a failed login on an entry. Caller must hold the lock.
+func (t *Tracker) fail(e *entry, username string, now time.Time, settings config.LoginLockoutConfig) bool {
+	duration := settings.LockDuration()
+	if now.Sub(e.lastFailure) > duration {
+		e.failures = 0
+	}
+	e.failures++
+	e.lastFailure = now
+
+	if e.failures < settings.Attempts() {
+		return false
+	}
+	e.failures = 0
+	e.lockedUntil = now.Add(duration)
+	logging.Logger.WithFields(map[string]interface{}{
+		"username":     username,
+		"locked_until": e.lockedUntil,
+	}).Warn("Account locked after repeated failed logins")
+	return true
+}
+
+// Reset forgets the failed logins of an account after a successful login
+func (t *Tracker) Reset(username string) {
+	t.mutex.Lock()
+	t.reset(username)
+	t.mutex.Unlock()
+}
+
+// reset forgets the failed logins of an account, keeping its logins in progress. Caller must hold the lock.
+func (t *Tracker) reset(username string) {
+	e, exists := t.entries[username]
+	if !exists {
+		return
+	}
+	if e.pending == 0 {
+		delete(t.entries, username)
+		return
+	}
+	e.failures, e.lastFailure, e.lockedUntil = 0, time.Time{}, time.Time{}
+}
+
+// prune removes accounts that are not locked, have no login in progress and whose failures are
+// forgotten. Caller must hold the lock.
+func (t *Tracker) prune(now time.Time, duration time.Duration) {
+	for username, e := range t.entries {
+		if e.pending == 0 && !now.Before(e.lockedUntil) && now.Sub(e.lastFailure) > duration {
+			delete(t.entries, username)
+		}
+	}
+}
+
+// Attempt is a login in progress, reserved with Begin. Only the first call ending it has an effect.
+type Attempt struct {
+	tracker  *Tracker
+	username string
+	settings config.LoginLockoutConfig
+	ended    bool
+}
+
+// Fail ends the login as failed and reports whether it locked the account
+func (a *Attempt) Fail(now time.Time) bool {
+	t := a.tracker
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e, ok := a.end()
+	if !ok {
+		return false
+	}
+	return t.fail(e, a.username, now, a.settings)
+}
+
+// Succeed ends the login as successful, forgetting the failed logins of the account
+func (a *Attempt) Succeed() {
+	t := a.tracker
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, ok := a.end(); ok {
+		t.reset(a.username)
+	}
+}
+
+// Cancel ends a login whose outcome doesn't count, such as when the directory is unavailable
+func (a *Attempt) Cancel() {
+	a.tracker.mutex.Lock()
+	defer a.tracker.mutex.Unlock()
+
+	a.end()
+}
+
+// end releases the reservation of the login once. Caller must hold the lock of the tracker.
+func (a *Attempt) end() (*entry, bool) {
+	if a.ended {
+		return nil, false
+	}
+	a.ended = true
+	e := a.tracker.entries[a.username]
+	e.pending--
+	return e, true
+}
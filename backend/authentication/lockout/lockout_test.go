@@ -0,0 +1,142 @@
+package lockout
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"site-availability/config"
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	require.NoError(t, logging.Init())
+	settings := config.LoginLockoutConfig{MaxAttempts: 3, Duration: "10m"}
+	tracker := NewTracker()
+	now := time.Now()
+
+	assert.False(t, tracker.Fail("alice", now, settings))
+	assert.False(t, tracker.Fail("alice", now.Add(time.Second), settings))
+	_, locked := tracker.Locked("alice", now.Add(2*time.Second))
+	assert.False(t, locked)
+
+	assert.True(t, tracker.Fail("alice", now.Add(2*time.Second), settings), "the third failure locks the account")
+	remaining, locked := tracker.Locked("alice", now.Add(3*time.Second))
+	assert.True(t, locked)
+	assert.Equal(t, 10*time.Minute-time.Second, remaining)
+
+	_, locked = tracker.Locked("bob", now)
+	assert.False(t, locked, "other accounts are not locked")
+
+	_, locked = tracker.Locked("alice", now.Add(2*time.Second+10*time.Minute))
+	assert.False(t, locked, "the lock ends after the duration")
+	assert.False(t, tracker.Fail("alice", now.Add(11*time.Minute), settings), "failures start over after a lock")
+}
+
+func TestTrackerForgetsOldFailures(t *testing.T) {
+	require.NoError(t, logging.Init())
+	settings := config.LoginLockoutConfig{MaxAttempts: 2, Duration: "10m"}
+	tracker := NewTracker()
+	now := time.Now()
+
+	assert.False(t, tracker.Fail("alice", now, settings))
+	assert.False(t, tracker.Fail("alice", now.Add(11*time.Minute), settings), "failures older than the duration are forgotten")
+	assert.True(t, tracker.Fail("alice", now.Add(12*time.Minute), settings))
+}
+
+func TestTrackerReset(t *testing.T) {
+	require.NoError(t, logging.Init())
+	settings := config.LoginLockoutConfig{MaxAttempts: 2}
+	tracker := NewTracker()
+	now := time.Now()
+
+	assert.False(t, tracker.Fail("alice", now, settings))
+	tracker.Reset("alice")
+	assert.False(t, tracker.Fail("alice", now, settings), "a successful login resets the failures")
+}
+
+func TestTrackerPrune(t *testing.T) {
+	require.NoError(t, logging.Init())
+	settings := config.LoginLockoutConfig{MaxAttempts: 2, Duration: "1m"}
+	tracker := NewTracker()
+	now := time.Now()
+
+	tracker.Fail("locked", now, settings)
+	tracker.Fail("locked", now, settings)
+	for i := 1; i < pruneThreshold; i++ {
+		tracker.entries[fmt.Sprintf("user-%d", i)] = &entry{failures: 1, lastFailure: now.Add(-time.Hour)}
+	}
+
+	tracker.Fail("new", now, settings)
+	assert.Len(t, tracker.entries, 2, "stale accounts are pruned")
+	_, locked := tracker.Locked("locked", now)
+	assert.True(t, locked, "locked accounts are kept")
+}
+
+func TestTrackerBegin(t *testing.T) {
+	require.NoError(t, logging.Init())
+	settings := config.LoginLockoutConfig{MaxAttempts: 2, Duration: "10m"}
+	now := time.Now()
+
+	t.Run("logins in progress count like failures", func(t *testing.T) {
+		tracker := NewTracker()
+		first, _, locked := tracker.Begin("alice", now, settings)
+		require.False(t, locked)
+		second, _, locked := tracker.Begin("alice", now, settings)
+		require.False(t, locked)
+		_, remaining, locked := tracker.Begin("alice", now, settings)
+		assert.True(t, locked, "both remaining attempts are in progress")
+		assert.Equal(t, busyRetryAfter, remaining)
+
+		first.Cancel()
+		third, _, locked := tracker.Begin("alice", now, settings)
+		require.False(t, locked, "cancelled logins don't count")
+
+		assert.False(t, second.Fail(now))
+		assert.True(t, third.Fail(now))
+		assert.False(t, third.Fail(now), "only the first end counts")
+		remaining, locked = tracker.Locked("alice", now)
+		assert.True(t, locked)
+		assert.Equal(t, 10*time.Minute, remaining)
+	})
+
+	t.Run("success forgets failures", func(t *testing.T) {
+		tracker := NewTracker()
+		attempt, _, _ := tracker.Begin("alice", now, settings)
+		attempt.Fail(now)
+		attempt, _, _ = tracker.Begin("alice", now, settings)
+		attempt.Succeed()
+		attempt.Cancel()
+		assert.Empty(t, tracker.entries)
+	})
+
+	t.Run("concurrent logins", func(t *testing.T) {
+		tracker := NewTracker()
+		var started sync.WaitGroup
+		var mutex sync.Mutex
+		reserved := 0
+		for i := 0; i < 50; i++ {
+			started.Add(1)
+			go func() {
+				defer started.Done()
+				attempt, _, locked := tracker.Begin("alice", now, settings)
+				if locked {
+					return
+				}
+				mutex.Lock()
+				reserved++
+				mutex.Unlock()
+				attempt.Fail(now)
+			}()
+		}
+		started.Wait()
+
+		assert.LessOrEqual(t, reserved, settings.Attempts())
+		_, locked := tracker.Locked("alice", now)
+		assert.True(t, locked)
+	})
+}
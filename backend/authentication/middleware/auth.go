@@ -3,7 +3,10 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
+	"time"
 
+	"site-availability/authentication/apitoken"
 	"site-availability/authentication/session"
 	"site-availability/config"
 	"site-availability/logging"
@@ -22,13 +25,16 @@ const (
 type AuthMiddleware struct {
 	config         *config.Config
 	sessionManager *session.Manager
+	tokens         *apitoken.Store
 }
 
-// NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(cfg *config.Config, sessionManager *session.Manager) *AuthMiddleware {
+// NewAuthMiddleware creates a new authentication middleware. API tokens are accepted on the
+// /api/ routes when a token store is given.
+func NewAuthMiddleware(cfg *config.Config, sessionManager *session.Manager, tokens *apitoken.Store) *AuthMiddleware {
 	return &AuthMiddleware{
 		config:         cfg,
 		sessionManager: sessionManager,
+		tokens:         tokens,
 	}
 }
 
@@ -49,6 +55,12 @@ func (am *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Scripts authenticate to the API with a token instead of the session cookie
+		if bearer, ok := extractBearerToken(r); ok && strings.HasPrefix(r.URL.Path, "/api/") {
+			am.authenticateToken(w, r, bearer, next)
+			return
+		}
+
 		logging.Logger.WithField("path", r.URL.Path).Debug("Path requires authentication, checking session")
 
 		// Extract session from cookie
@@ -77,8 +89,11 @@ func (am *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 			"expires_at": sessionInfo.ExpiresAt,
 		}).Debug("Session validated successfully")
 
-		// Refresh session expiration
-		am.sessionManager.RefreshSession(sessionID)
+		// Refresh session expiration; cookie sessions are extended with a new cookie
+		if cookieValue, _ := am.sessionManager.RefreshSessionCookie(sessionID); cookieValue != "" {
+			sessionTimeout, _ := session.ParseTimeout(am.config.ServerSettings.SessionTimeout)
+			http.SetCookie(w, CreateSessionCookie(cookieValue, int(sessionTimeout.Seconds()), r, am.config.ServerSettings.TrustProxyHeaders))
+		}
 		logging.Logger.WithField("session_id", "****").Debug("Session refreshed")
 
 		// Add user and session info to request context
@@ -97,9 +112,39 @@ func (am *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// authenticateToken validates an API token and continues with the session of the token
+func (am *AuthMiddleware) authenticateToken(w http.ResponseWriter, r *http.Request, bearer string, next http.HandlerFunc) {
+	if am.tokens == nil {
+		am.sendUnauthorized(w, "Invalid or expired API token")
+		return
+	}
+
+	token, err := am.tokens.Authenticate(bearer, time.Now())
+	if err != nil {
+		logging.Logger.WithError(err).WithField("path", r.URL.Path).Debug("API token rejected")
+		am.sendUnauthorized(w, "Invalid or expired API token")
+		return
+	}
+
+	sessionInfo := token.Session()
+	ctx := context.WithValue(r.Context(), UserContextKey, sessionInfo)
+	ctx = context.WithValue(ctx, SessionContextKey, sessionInfo)
+	r = r.WithContext(ctx)
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"token_id": token.ID,
+		"username": sessionInfo.Username,
+		"path":     r.URL.Path,
+		"method":   r.Method,
+	}).Debug("Authenticated request with API token")
+
+	next.ServeHTTP(w, r)
+}
+
 // isAuthRequired checks if authentication is required based on configuration
 func (am *AuthMiddleware) isAuthRequired() bool {
-	return am.config.ServerSettings.LocalAdmin.Enabled || am.config.ServerSettings.OIDC.Enabled
+	serverSettings := am.config.ServerSettings
+	return serverSettings.LocalAdmin.Enabled || serverSettings.LocalUsers.Enabled || serverSettings.OIDC.Enabled || serverSettings.LDAP.Enabled
 }
 
 // extractSessionFromCookie extracts the session ID from the session cookie
@@ -116,6 +161,15 @@ func (am *AuthMiddleware) extractSessionFromCookie(r *http.Request) (string, err
 	return cookie.Value, nil
 }
 
+// extractBearerToken extracts the token of an "Authorization: Bearer" header
+func extractBearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
 // sendUnauthorized sends an unauthorized response
 func (am *AuthMiddleware) sendUnauthorized(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
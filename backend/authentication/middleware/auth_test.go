@@ -3,8 +3,13 @@ package middleware
 import (
 	"crypto/tls"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"site-availability/authentication/apitoken"
+	"site-availability/authentication/session"
 	"site-availability/config"
 )
 
@@ -167,7 +172,7 @@ func TestIsAuthRequired_OIDCOnly(t *testing.T) {
 				},
 			}
 
-			am := NewAuthMiddleware(cfg, nil)
+			am := NewAuthMiddleware(cfg, nil, nil)
 			isRequired := am.isAuthRequired()
 
 			if tt.shouldRequire && !isRequired {
@@ -180,3 +185,148 @@ func TestIsAuthRequired_OIDCOnly(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAuthRequired_LDAPOnly(t *testing.T) {
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			LDAP: config.LDAPConfig{Enabled: true},
+		},
+	}
+
+	am := NewAuthMiddleware(cfg, nil, nil)
+	if !am.isAuthRequired() {
+		t.Errorf("Expected authentication to be required when only LDAP is enabled, but it wasn't")
+	}
+}
+
+func TestRequireAuth_CookieSessions(t *testing.T) {
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			SessionTimeout: "1h",
+			LocalAdmin:     config.LocalAdminConfig{Enabled: true},
+		},
+	}
+	manager, err := session.NewCookieManager(time.Hour, []string{strings.Repeat("k", 32)}, session.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("Failed to create cookie session manager: %v", err)
+	}
+	defer manager.Close()
+	am := NewAuthMiddleware(cfg, manager, nil)
+
+	// A cookie refreshed more than a minute ago
+	sessionInfo, err := manager.CreateSession("alice", false, []string{"viewer"}, nil, "local")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	sessionInfo.ExpiresAt = time.Now().Add(50 * time.Minute)
+	cookieValue, err := manager.CookieValue(sessionInfo)
+	if err != nil {
+		t.Fatalf("Failed to seal session: %v", err)
+	}
+
+	request := func(value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/apps", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: value})
+		w := httptest.NewRecorder()
+		am.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r)
+			if !ok || user.Username != "alice" {
+				t.Errorf("Expected user alice in the request context, got %+v", user)
+			}
+			w.WriteHeader(http.StatusOK)
+		})(w, req)
+		return w
+	}
+
+	w := request(cookieValue)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected cookie session to be accepted, got status %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session_id" || cookies[0].Value == cookieValue {
+		t.Fatalf("Expected a refreshed session cookie, got %v", cookies)
+	}
+	if cookies[0].MaxAge != 3600 {
+		t.Errorf("Expected refreshed cookie MaxAge 3600, got %d", cookies[0].MaxAge)
+	}
+
+	// The refreshed cookie is fresh, so it is not replaced again
+	w = request(cookies[0].Value)
+	if w.Code != http.StatusOK || len(w.Result().Cookies()) != 0 {
+		t.Errorf("Expected fresh cookie to be accepted without a new cookie, got status %d and %v", w.Code, w.Result().Cookies())
+	}
+
+	manager.DeleteSession(cookies[0].Value)
+	for _, value := range []string{cookieValue, cookies[0].Value} {
+		if w := request(value); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected logged out cookie to be rejected, got status %d", w.Code)
+		}
+	}
+}
+
+func TestRequireAuth_APITokens(t *testing.T) {
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			SessionTimeout: "1h",
+			LocalAdmin:     config.LocalAdminConfig{Enabled: true},
+		},
+	}
+	tokens, err := apitoken.NewStore("")
+	if err != nil {
+		t.Fatalf("Failed to create token store: %v", err)
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	_, value, err := tokens.Create(apitoken.Token{Name: "ci", Owner: "alice", Roles: []string{"viewer"}, ExpiresAt: &expiresAt}, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	manager := session.NewManager(time.Hour)
+	defer manager.Close()
+	am := NewAuthMiddleware(cfg, manager, tokens)
+
+	request := func(path, authorization string) (int, *session.Session) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		var user *session.Session
+		am.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+			user, _ = GetUserFromContext(r)
+			w.WriteHeader(http.StatusOK)
+		})(w, req)
+		return w.Code, user
+	}
+
+	code, user := request("/api/apps", "Bearer "+value)
+	if code != http.StatusOK {
+		t.Fatalf("Expected API token to be accepted, got status %d", code)
+	}
+	if user == nil || user.Username != "alice" || user.AuthMethod != apitoken.AuthMethod || user.IsAdmin {
+		t.Errorf("Expected the token session of alice in the request context, got %+v", user)
+	}
+	if len(user.Roles) != 1 || user.Roles[0] != "viewer" {
+		t.Errorf("Expected the roles of the token, got %v", user.Roles)
+	}
+
+	if code, _ := request("/api/apps", "bearer "+value); code != http.StatusOK {
+		t.Errorf("Expected the bearer scheme to be case-insensitive, got status %d", code)
+	}
+	if code, _ := request("/api/apps", "Bearer "+value+"x"); code != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong token to be rejected, got status %d", code)
+	}
+	if code, _ := request("/auth/user", "Bearer "+value); code != http.StatusUnauthorized {
+		t.Errorf("Expected API tokens to be ignored outside /api/, got status %d", code)
+	}
+
+	withoutTokens := NewAuthMiddleware(cfg, manager, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/apps", nil)
+	req.Header.Set("Authorization", "Bearer "+value)
+	w := httptest.NewRecorder()
+	withoutTokens.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected API tokens to be rejected without a token store, got status %d", w.Code)
+	}
+}
@@ -30,6 +30,12 @@ type UserPermissions struct {
 	IsAdmin       bool
 	AllowedLabels map[string]LabelPermission
 	HasFullAccess bool
+	Actions       map[string]bool // Permissions granted by the roles, such as config.PermissionCheck
+}
+
+// Can checks if the user was granted a permission. Admins have every permission.
+func (p UserPermissions) Can(permission string) bool {
+	return p.IsAdmin || p.Actions[permission]
 }
 
 // GetUserPermissions returns the permissions for a user based on their roles
@@ -47,11 +53,16 @@ func (a *Authorizer) GetUserPermissions(userSession *session.Session) UserPermis
 		IsAdmin:       false,
 		AllowedLabels: make(map[string]LabelPermission),
 		HasFullAccess: false,
+		Actions:       make(map[string]bool),
 	}
 
 	// Process each role the user has
 	for _, roleName := range userSession.Roles {
 		if roleConfig, exists := a.config.ServerSettings.Roles[roleName]; exists {
+			for _, permission := range roleConfig.Permissions {
+				permissions.Actions[permission] = true
+			}
+
 			// Add all labels from this role to user's permissions
 			for labelKey, labelValue := range roleConfig.Labels {
 				if existing, hasLabel := permissions.AllowedLabels[labelKey]; hasLabel {
@@ -807,3 +807,33 @@ func BenchmarkFilterLabels(b *testing.B) {
 		authorizer.FilterLabels(permissions, allLabels)
 	}
 }
+
+func TestGetUserPermissions_Actions(t *testing.T) {
+	cfg := &config.Config{
+		ServerSettings: config.ServerSettings{
+			Roles: map[string]config.RoleConfig{
+				"viewer":   {Labels: map[string]string{"team": "frontend"}},
+				"operator": {Permissions: []string{config.PermissionCheck}, Labels: map[string]string{"team": "backend"}},
+			},
+		},
+	}
+	authorizer := NewAuthorizer(cfg)
+
+	viewer := authorizer.GetUserPermissions(&session.Session{Username: "viewer", Roles: []string{"viewer"}})
+	if viewer.Can(config.PermissionCheck) {
+		t.Error("Expected label-only role not to grant the check permission")
+	}
+
+	operator := authorizer.GetUserPermissions(&session.Session{Username: "operator", Roles: []string{"viewer", "operator"}})
+	if !operator.Can(config.PermissionCheck) {
+		t.Error("Expected operator role to grant the check permission")
+	}
+	if len(operator.AllowedLabels) != 1 {
+		t.Errorf("Expected permissions not to be treated as labels, got %v", operator.AllowedLabels)
+	}
+
+	admin := authorizer.GetUserPermissions(&session.Session{Username: "admin", IsAdmin: true})
+	if !admin.Can(config.PermissionCheck) {
+		t.Error("Expected admin to have every permission")
+	}
+}
@@ -0,0 +1,147 @@
+package session
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"site-availability/logging"
+)
+
+const (
+	// cookieContext is authenticated with every cookie session, so they can't be used elsewhere
+	cookieContext = "session_id cookie"
+	// maxCookieSize is the largest cookie value browsers reliably keep
+	maxCookieSize = 4000
+	// cookieRefreshInterval is how often a cookie session is sealed again to extend it
+	cookieRefreshInterval = time.Minute
+)
+
+// NewCookieManager creates a session manager keeping sessions in encrypted cookies, so validating
+// a session needs no server-side state. Sessions are sealed with the first key and opened with any
+// of them, so keys can be rotated. Logged out sessions are kept in the denylist until they expire.
+func NewCookieManager(sessionTimeout time.Duration, keys []string, denylist SessionStore) (*Manager, error) {
+	cookies, err := newSealer("session cookie", keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := NewManagerWithStore(sessionTimeout, denylist)
+	manager.cookies = cookies
+	return manager, nil
+}
+
+// Stateless reports whether sessions are kept in cookies rather than on the server
+func (m *Manager) Stateless() bool {
+	return m.cookies != nil
+}
+
+// CookieValue returns the value of the session cookie of a session: its ID, or the encrypted
+// session for cookie sessions
+func (m *Manager) CookieValue(session *Session) (string, error) {
+	if m.cookies == nil {
+		return session.ID, nil
+	}
+
+	sealed, err := m.cookies.seal(cookieContext, session)
+	if err != nil {
+		return "", err
+	}
+	value := base64.RawURLEncoding.EncodeToString(sealed)
+	if len(value) > maxCookieSize {
+		return "", fmt.Errorf("session of %s is too large for a cookie (%d bytes, %d roles, %d groups)",
+			session.Username, len(value), len(session.Roles), len(session.Groups))
+	}
+	return value, nil
+}
+
+// RefreshSessionCookie extends a session and returns the new cookie value when the cookie has to be
+// replaced, and whether the session is valid. Sessions in a store are extended in place and keep their
+// cookie. Cookie sessions are sealed again with a new expiration, at most once per cookieRefreshInterval.
+func (m *Manager) RefreshSessionCookie(cookieValue string) (string, bool) {
+	if m.cookies == nil {
+		return "", m.RefreshSession(cookieValue)
+	}
+
+	session, valid := m.openCookie(cookieValue)
+	if !valid {
+		return "", false
+	}
+	if time.Until(session.ExpiresAt) > m.timeout-cookieRefreshInterval {
+		return "", true
+	}
+
+	session.ExpiresAt = time.Now().Add(m.timeout)
+	refreshed, err := m.CookieValue(session)
+	if err != nil {
+		logging.Logger.WithError(err).Error("Failed to refresh session cookie")
+		return "", true
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"username":       session.Username,
+		"new_expires_at": session.ExpiresAt,
+	}).Debug("Session cookie refreshed")
+	return refreshed, true
+}
+
+// openCookie decrypts a cookie session and checks that it has not expired or been logged out
+func (m *Manager) openCookie(cookieValue string) (*Session, bool) {
+	sealed, err := base64.RawURLEncoding.DecodeString(cookieValue)
+	if err != nil {
+		logging.Logger.WithField("session_id", "****").Debug("Session cookie is not valid base64")
+		return nil, false
+	}
+	session, err := m.cookies.open(cookieContext, sealed)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("session_id", "****").Debug("Session cookie can't be decrypted")
+		return nil, false
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		logging.Logger.WithFields(map[string]interface{}{
+			"username":   session.Username,
+			"expires_at": session.ExpiresAt,
+		}).Debug("Session cookie expired")
+		return nil, false
+	}
+
+	_, revoked, err := m.store.Get(session.ID)
+	if err != nil {
+		// Fail closed, a logged out session must not become valid when the denylist is unavailable
+		logging.Logger.WithError(err).Error("Failed to check the session denylist")
+		return nil, false
+	}
+	if revoked {
+		logging.Logger.WithField("username", session.Username).Debug("Session cookie was logged out")
+		return nil, false
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"username":   session.Username,
+		"expires_at": session.ExpiresAt,
+	}).Debug("Session cookie validated successfully")
+	return session, true
+}
+
+// revokeCookie adds a cookie session to the denylist. The entry outlives every cookie of the
+// session, as refreshed cookies expire at most one timeout from now.
+func (m *Manager) revokeCookie(cookieValue string) {
+	session, valid := m.openCookie(cookieValue)
+	if !valid {
+		return
+	}
+
+	entry := &Session{
+		ID:         session.ID,
+		Username:   session.Username,
+		AuthMethod: session.AuthMethod,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(m.timeout),
+	}
+	if err := m.store.Save(entry); err != nil {
+		logging.Logger.WithError(err).WithField("username", session.Username).Error("Failed to add session to the denylist")
+		return
+	}
+	logging.Logger.WithField("username", session.Username).Debug("Session added to the denylist")
+}
@@ -0,0 +1,195 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"site-availability/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCookieManager returns a cookie session manager with an in-memory denylist
+func newTestCookieManager(t *testing.T, timeout time.Duration, keys ...string) *Manager {
+	t.Helper()
+	manager, err := NewCookieManager(timeout, keys, NewMemoryStore())
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+// newCookie creates a session and returns its cookie value
+func newCookie(t *testing.T, manager *Manager, username string) string {
+	t.Helper()
+	session, err := manager.CreateSession(username, false, []string{"viewer"}, []string{"sre"}, "oidc")
+	require.NoError(t, err)
+	value, err := manager.CookieValue(session)
+	require.NoError(t, err)
+	return value
+}
+
+func TestCookieManager(t *testing.T) {
+	manager := newTestCookieManager(t, time.Hour, testEncryptionKey)
+	assert.True(t, manager.Stateless())
+	assert.False(t, NewManager(time.Hour).Stateless())
+
+	value := newCookie(t, manager, "alice")
+	assert.NotContains(t, value, "alice", "cookie sessions are encrypted")
+	assert.Equal(t, 0, manager.GetSessionCount(), "cookie sessions are not kept on the server")
+
+	session, valid := manager.ValidateSession(value)
+	require.True(t, valid)
+	assert.Equal(t, "alice", session.Username)
+	assert.Equal(t, []string{"viewer"}, session.Roles)
+	assert.Equal(t, []string{"sre"}, session.Groups)
+	assert.Equal(t, "oidc", session.AuthMethod)
+	assert.True(t, manager.RefreshSession(value))
+
+	// Another replica (or a restart) with the same keys accepts the cookie without shared state
+	replica := newTestCookieManager(t, time.Hour, testEncryptionKey)
+	_, valid = replica.ValidateSession(value)
+	assert.True(t, valid)
+
+	t.Run("invalid cookies", func(t *testing.T) {
+		for _, value := range []string{"", "not base64!", "abc", value[:len(value)-2] + "AA"} {
+			_, valid := manager.ValidateSession(value)
+			assert.False(t, valid, value)
+		}
+
+		other := newTestCookieManager(t, time.Hour, strings.Repeat("x", 32))
+		_, valid := other.ValidateSession(value)
+		assert.False(t, valid, "cookies sealed with another key are rejected")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := newTestCookieManager(t, -time.Minute, testEncryptionKey)
+		value := newCookie(t, expired, "alice")
+		_, valid := expired.ValidateSession(value)
+		assert.False(t, valid)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		groups := make([]string, 500)
+		for i := range groups {
+			groups[i] = "group-with-a-long-name-" + strings.Repeat("x", 10)
+		}
+		session, err := manager.CreateSession("alice", false, nil, groups, "oidc")
+		require.NoError(t, err)
+		_, err = manager.CookieValue(session)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "too large for a cookie")
+	})
+}
+
+func TestCookieManagerKeyRotation(t *testing.T) {
+	oldKey, newKey := strings.Repeat("o", 32), strings.Repeat("n", 32)
+	before := newTestCookieManager(t, time.Hour, oldKey)
+	value := newCookie(t, before, "alice")
+
+	rotated := newTestCookieManager(t, time.Hour, newKey, oldKey)
+	_, valid := rotated.ValidateSession(value)
+	assert.True(t, valid, "cookies of the previous key stay valid while it is listed")
+
+	retired := newTestCookieManager(t, time.Hour, newKey)
+	_, valid = retired.ValidateSession(value)
+	assert.False(t, valid, "cookies of a removed key are rejected")
+	_, valid = retired.ValidateSession(newCookie(t, rotated, "bob"))
+	assert.True(t, valid, "new cookies are sealed with the first key")
+}
+
+func TestCookieManagerLogout(t *testing.T) {
+	denylist := NewMemoryStore()
+	manager, err := NewCookieManager(time.Hour, []string{testEncryptionKey}, denylist)
+	require.NoError(t, err)
+	defer manager.Close()
+
+	value := newCookie(t, manager, "alice")
+	other := newCookie(t, manager, "alice")
+
+	// A copy of the cookie refreshed before the logout
+	session, _ := manager.ValidateSession(value)
+	session.ExpiresAt = time.Now().Add(time.Hour)
+	refreshed, err := manager.CookieValue(session)
+	require.NoError(t, err)
+
+	manager.DeleteSession(value)
+	_, valid := manager.ValidateSession(value)
+	assert.False(t, valid, "logged out cookies are rejected")
+	_, valid = manager.ValidateSession(refreshed)
+	assert.False(t, valid, "refreshed copies of a logged out session are rejected")
+	assert.False(t, manager.RefreshSession(value))
+	_, valid = manager.ValidateSession(other)
+	assert.True(t, valid, "other sessions of the user stay valid")
+
+	count, err := denylist.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Logging out an invalid cookie adds nothing
+	manager.DeleteSession("garbage")
+	count, err = denylist.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestRefreshSessionCookie(t *testing.T) {
+	manager := newTestCookieManager(t, time.Hour, testEncryptionKey)
+	value := newCookie(t, manager, "alice")
+
+	refreshed, valid := manager.RefreshSessionCookie(value)
+	assert.True(t, valid)
+	assert.Empty(t, refreshed, "fresh cookies are not replaced on every request")
+
+	// A cookie that is older than the refresh interval is sealed again
+	session, _ := manager.ValidateSession(value)
+	session.ExpiresAt = time.Now().Add(time.Hour - 2*cookieRefreshInterval)
+	older, err := manager.CookieValue(session)
+	require.NoError(t, err)
+
+	refreshed, valid = manager.RefreshSessionCookie(older)
+	assert.True(t, valid)
+	require.NotEmpty(t, refreshed)
+	extended, valid := manager.ValidateSession(refreshed)
+	require.True(t, valid)
+	assert.True(t, extended.ExpiresAt.After(session.ExpiresAt))
+	assert.Equal(t, session.ID, extended.ID)
+
+	refreshed, valid = manager.RefreshSessionCookie("garbage")
+	assert.False(t, valid)
+	assert.Empty(t, refreshed)
+
+	// Sessions in a store keep their cookie
+	stored := NewManager(time.Hour)
+	storedSession, err := stored.CreateSession("alice", false, nil, nil, "local")
+	require.NoError(t, err)
+	cookieValue, err := stored.CookieValue(storedSession)
+	require.NoError(t, err)
+	assert.Equal(t, storedSession.ID, cookieValue)
+	refreshed, valid = stored.RefreshSessionCookie(cookieValue)
+	assert.True(t, valid)
+	assert.Empty(t, refreshed)
+}
+
+func TestNewManagerFromConfig(t *testing.T) {
+	manager, err := NewManagerFromConfig(time.Hour, config.SessionStoreConfig{Type: config.SessionStoreMemory})
+	require.NoError(t, err)
+	assert.False(t, manager.Stateless())
+	manager.Close()
+
+	manager, err = NewManagerFromConfig(time.Hour, config.SessionStoreConfig{
+		Type:          config.SessionStoreCookie,
+		CookieKeys:    []string{testEncryptionKey},
+		Denylist:      config.SessionStoreRedis,
+		Address:       newFakeRedis(t, "").address(),
+		EncryptionKey: testEncryptionKey,
+	})
+	require.NoError(t, err)
+	defer manager.Close()
+	assert.True(t, manager.Stateless())
+	assert.IsType(t, &RedisStore{}, manager.store, "logged out sessions are kept in the denylist store")
+
+	_, err = NewManagerFromConfig(time.Hour, config.SessionStoreConfig{Type: config.SessionStoreCookie})
+	assert.Error(t, err, "cookie keys are required")
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// sealer encrypts sessions kept outside the process. Sessions are encrypted with AES-256-GCM,
+// and stored under a keyed hash of their ID, so a copy of the store reveals neither the
+// session contents nor IDs that could be used as cookies.
+//
+// A sealer may hold several keys to rotate them: sessions are sealed with the first key
+// and opened with any of them.
+type sealer struct {
+	aeads  []cipher.AEAD
+	keyMAC []byte
+}
+
+// newSealer derives the encryption and key hashing keys for a purpose, e.g. "session", from secrets.
+// The first secret is used to seal sessions and hash storage keys.
+func newSealer(purpose string, secrets ...string) (*sealer, error) {
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("%s encryption key is required", purpose)
+	}
+
+	s := &sealer{}
+	for i, secret := range secrets {
+		if secret == "" {
+			return nil, fmt.Errorf("%s encryption key is required", purpose)
+		}
+
+		encryptionKey, err := hkdf.Key(sha256.New, []byte(secret), nil, "site-availability "+purpose+" encryption", 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s encryption key: %w", purpose, err)
+		}
+		block, err := aes.NewCipher(encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s cipher: %w", purpose, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s cipher: %w", purpose, err)
+		}
+		s.aeads = append(s.aeads, aead)
+
+		if i == 0 {
+			s.keyMAC, err = hkdf.Key(sha256.New, []byte(secret), nil, "site-availability "+purpose+" key", 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive %s key hashing key: %w", purpose, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// storageKey returns the key a session is stored under
+func (s *sealer) storageKey(sessionID string) string {
+	mac := hmac.New(sha256.New, s.keyMAC)
+	mac.Write([]byte(sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// seal encrypts a session with the first key. The storage key (or other context) is authenticated
+// with it, so an encrypted session can't be moved to another key.
+func (s *sealer) seal(key string, session *Session) ([]byte, error) {
+	plaintext, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(key)), nil
+}
+
+// open decrypts a session sealed under a storage key with any of the keys
+func (s *sealer) open(key string, data []byte) (*Session, error) {
+	var plaintext []byte
+	err := fmt.Errorf("encrypted session is too short")
+	for _, aead := range s.aeads {
+		if len(data) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if plaintext, err = aead.Open(nil, nonce, ciphertext, []byte(key)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(plaintext, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &session, nil
+}
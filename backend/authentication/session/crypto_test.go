@@ -0,0 +1,89 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealer(t *testing.T) {
+	sealer, err := newSealer("session", testEncryptionKey)
+	require.NoError(t, err)
+
+	session := newTestSession("alice", time.Hour)
+	key := sealer.storageKey(session.ID)
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, sealer.storageKey(session.ID), "storage keys are stable")
+	assert.NotEqual(t, key, sealer.storageKey(session.ID+"x"))
+
+	sealed, err := sealer.seal(key, session)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "alice")
+
+	opened, err := sealer.open(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, session.Username, opened.Username)
+	assert.Equal(t, session.ID, opened.ID)
+
+	other, err := sealer.seal(key, session)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, other, "every seal uses a new nonce")
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := append([]byte(nil), sealed...)
+		tampered[len(tampered)-1] ^= 1
+		_, err := sealer.open(key, tampered)
+		assert.Error(t, err)
+	})
+
+	t.Run("moved to another key", func(t *testing.T) {
+		_, err := sealer.open(sealer.storageKey("other"), sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		otherSealer, err := newSealer("session", strings.Repeat("x", 32))
+		require.NoError(t, err)
+		_, err = otherSealer.open(key, sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := sealer.open(key, []byte("abc"))
+		assert.Error(t, err)
+	})
+
+	_, err = newSealer("session", "")
+	assert.Error(t, err)
+	_, err = newSealer("session")
+	assert.Error(t, err)
+}
+
+func TestSealerKeyRotation(t *testing.T) {
+	oldKey, newKey := strings.Repeat("o", 32), strings.Repeat("n", 32)
+	old, err := newSealer("session cookie", oldKey)
+	require.NoError(t, err)
+	rotated, err := newSealer("session cookie", newKey, oldKey)
+	require.NoError(t, err)
+
+	session := newTestSession("alice", time.Hour)
+	sealedWithOld, err := old.seal("cookie", session)
+	require.NoError(t, err)
+
+	opened, err := rotated.open("cookie", sealedWithOld)
+	require.NoError(t, err, "sessions sealed with a previous key are still accepted")
+	assert.Equal(t, "alice", opened.Username)
+
+	sealedWithNew, err := rotated.seal("cookie", session)
+	require.NoError(t, err)
+	_, err = old.open("cookie", sealedWithNew)
+	assert.Error(t, err, "new sessions are sealed with the first key")
+
+	store, err := newSealer("session", oldKey)
+	require.NoError(t, err)
+	_, err = store.open("cookie", sealedWithOld)
+	assert.Error(t, err, "keys are derived per purpose")
+}
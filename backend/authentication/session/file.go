@@ -0,0 +1,299 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"site-availability/logging"
+)
+
+// fileCompactionSlack is the number of superseded records the session file may hold before it is rewritten
+const fileCompactionSlack = 1000
+
+// fileRecord is a line of the session file. A record replaces earlier records with the same key,
+// and a deleted record removes the session.
+type fileRecord struct {
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Data      []byte    `json:"data,omitempty"` // Encrypted session
+	Deleted   bool      `json:"deleted,omitempty"`
+}
+
+// FileStore persists encrypted sessions to an append-only JSON lines file, so sessions survive restarts.
+// The latest record of every session is also kept in memory, and the file is compacted when
+// superseded records pile up. The file must not be shared by several servers.
+type FileStore struct {
+	path    string
+	sealer  *sealer
+	records map[string]fileRecord // [storage key]record
+	lines   int                   // Records in the file, including superseded ones
+	file    *os.File
+	mutex   sync.Mutex
+}
+
+// NewFileStore opens (or creates) the session file at path and loads the sessions in it
+func NewFileStore(path, encryptionKey string) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("session file path is required")
+	}
+	sealer, err := newSealer("session", encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create session directory %s: %w", dir, err)
+		}
+	}
+
+	fs := &FileStore{
+		path:    path,
+		sealer:  sealer,
+		records: make(map[string]fileRecord),
+	}
+	if err := fs.load(); err != nil {
+		return nil, err
+	}
+
+	fs.mutex.Lock()
+	fs.removeExpired(time.Now())
+	err = fs.compact()
+	fs.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"path":     path,
+		"sessions": len(fs.records),
+	}).Info("Session file store initialized")
+
+	return fs, nil
+}
+
+// load reads the latest record of every session from the session file
+func (fs *FileStore) load() error {
+	file, err := os.Open(fs.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open session file %s: %w", fs.path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record fileRecord
+		if err := json.Unmarshal(line, &record); err != nil || record.Key == "" {
+			// A partially written last line must not prevent startup
+			logging.Logger.WithError(err).WithFields(map[string]interface{}{
+				"path": fs.path,
+				"line": lineNumber,
+			}).Warn("Skipping corrupt session record")
+			continue
+		}
+		fs.apply(record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read session file %s: %w", fs.path, err)
+	}
+	return nil
+}
+
+// apply updates the sessions in memory with a record. Caller must hold the lock.
+func (fs *FileStore) apply(record fileRecord) {
+	if record.Deleted {
+		delete(fs.records, record.Key)
+		return
+	}
+	fs.records[record.Key] = record
+}
+
+// removeExpired drops expired sessions from memory. Caller must hold the lock.
+func (fs *FileStore) removeExpired(now time.Time) int {
+	removed := 0
+	for key, record := range fs.records {
+		if now.After(record.ExpiresAt) {
+			delete(fs.records, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// compact atomically rewrites the session file with the sessions in memory
+// and reopens it for appending. Caller must hold the lock.
+func (fs *FileStore) compact() error {
+	tmpPath := fs.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary session file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, record := range fs.records {
+		if err := encoder.Encode(record); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode session record: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary session file: %w", err)
+	}
+
+	if fs.file != nil {
+		fs.file.Close()
+		fs.file = nil
+	}
+	if err := os.Rename(tmpPath, fs.path); err != nil {
+		return fmt.Errorf("failed to replace session file: %w", err)
+	}
+
+	file, err := os.OpenFile(fs.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open session file for appending: %w", err)
+	}
+	fs.file = file
+	fs.lines = len(fs.records)
+	return nil
+}
+
+// write applies a record and appends it to the session file, compacting the file
+// when it holds too many superseded records. Caller must hold the lock.
+func (fs *FileStore) write(record fileRecord) error {
+	fs.apply(record)
+
+	if fs.lines+1 > 2*len(fs.records)+fileCompactionSlack {
+		return fs.compact()
+	}
+
+	if fs.file == nil {
+		return fmt.Errorf("session file %s is closed", fs.path)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode session record: %w", err)
+	}
+	if _, err := fs.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append to session file: %w", err)
+	}
+	fs.lines++
+	return nil
+}
+
+// Save encrypts a session and appends it to the session file
+func (fs *FileStore) Save(session *Session) error {
+	key := fs.sealer.storageKey(session.ID)
+	data, err := fs.sealer.seal(key, session)
+	if err != nil {
+		return err
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return fs.write(fileRecord{Key: key, ExpiresAt: session.ExpiresAt, Data: data})
+}
+
+// Get decrypts a session
+func (fs *FileStore) Get(sessionID string) (*Session, bool, error) {
+	key := fs.sealer.storageKey(sessionID)
+
+	fs.mutex.Lock()
+	record, exists := fs.records[key]
+	fs.mutex.Unlock()
+	if !exists {
+		return nil, false, nil
+	}
+
+	session, err := fs.sealer.open(key, record.Data)
+	if err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// Refresh re-encrypts a session with a new expiry, holding the lock so a concurrent Delete is not undone
+func (fs *FileStore) Refresh(sessionID string, expiresAt time.Time) (bool, error) {
+	key := fs.sealer.storageKey(sessionID)
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	record, exists := fs.records[key]
+	if !exists {
+		return false, nil
+	}
+
+	session, err := fs.sealer.open(key, record.Data)
+	if err != nil {
+		return false, err
+	}
+	session.ExpiresAt = expiresAt
+	data, err := fs.sealer.seal(key, session)
+	if err != nil {
+		return false, err
+	}
+	return true, fs.write(fileRecord{Key: key, ExpiresAt: expiresAt, Data: data})
+}
+
+// Delete records that a session was removed
+func (fs *FileStore) Delete(sessionID string) error {
+	key := fs.sealer.storageKey(sessionID)
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if _, exists := fs.records[key]; !exists {
+		return nil
+	}
+	return fs.write(fileRecord{Key: key, Deleted: true})
+}
+
+// Count returns the number of sessions
+func (fs *FileStore) Count() (int, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return len(fs.records), nil
+}
+
+// DeleteExpired removes expired sessions and compacts the session file
+func (fs *FileStore) DeleteExpired(now time.Time) (int, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	removed := fs.removeExpired(now)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, fs.compact()
+}
+
+// Close closes the session file
+func (fs *FileStore) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.file.Close()
+	fs.file = nil
+	return err
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "sessions.db")
+
+	store, err := NewFileStore(path, testEncryptionKey)
+	require.NoError(t, err)
+	alice := newTestSession("alice", time.Hour)
+	bob := newTestSession("bob", time.Hour)
+	require.NoError(t, store.Save(alice))
+	require.NoError(t, store.Save(bob))
+	require.NoError(t, store.Delete(bob.ID))
+	require.NoError(t, store.Close())
+
+	// Sessions are encrypted and stored without their IDs
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "alice")
+	assert.NotContains(t, string(data), alice.ID)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Reopen, as after a restart
+	reopened, err := NewFileStore(path, testEncryptionKey)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	loaded, found, err := reopened.Get(alice.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "alice", loaded.Username)
+	_, found, err = reopened.Get(bob.ID)
+	require.NoError(t, err)
+	assert.False(t, found, "deleted sessions stay deleted")
+}
+
+func TestFileStoreWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewFileStore(path, testEncryptionKey)
+	require.NoError(t, err)
+	alice := newTestSession("alice", time.Hour)
+	require.NoError(t, store.Save(alice))
+	require.NoError(t, store.Close())
+
+	// Sessions are stored under a hash keyed with the encryption key, so another key doesn't find them
+	other, err := NewFileStore(path, strings.Repeat("x", 32))
+	require.NoError(t, err)
+	defer other.Close()
+	_, found, err := other.Get(alice.ID)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestFileStoreSkipsCorruptRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewFileStore(path, testEncryptionKey)
+	require.NoError(t, err)
+	alice := newTestSession("alice", time.Hour)
+	require.NoError(t, store.Save(alice))
+	require.NoError(t, store.Close())
+
+	// A partially written last line
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"key":"abc","data":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reopened, err := NewFileStore(path, testEncryptionKey)
+	require.NoError(t, err)
+	defer reopened.Close()
+	_, found, err := reopened.Get(alice.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewFileStore(path, testEncryptionKey)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Refreshing a session appends a record every time
+	session := newTestSession("alice", time.Hour)
+	for i := 0; i < fileCompactionSlack+10; i++ {
+		require.NoError(t, store.Save(session))
+	}
+	assert.LessOrEqual(t, store.lines, fileCompactionSlack+2)
+
+	require.NoError(t, store.Save(newTestSession("bob", -time.Minute)))
+	removed, err := store.DeleteExpired(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"), "expired sessions are removed from the file")
+}
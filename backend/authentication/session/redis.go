@@ -0,0 +1,340 @@
+package session
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"site-availability/logging"
+)
+
+const (
+	// redisTimeout bounds connecting to Redis and every command
+	redisTimeout = 5 * time.Second
+	// redisPoolSize is the number of idle connections kept open
+	redisPoolSize = 8
+	// redisScanCount is the number of keys Redis looks at per SCAN call
+	redisScanCount = 500
+)
+
+// RedisOptions configures the connection to a Redis server
+type RedisOptions struct {
+	Address   string // host:port
+	Password  string // Sent with AUTH when set
+	DB        int    // Selected with SELECT when not 0
+	KeyPrefix string // Prefix of the session keys
+}
+
+// RedisStore keeps encrypted sessions in Redis (or a server speaking its protocol), so they survive
+// restarts and are shared by replicas. Sessions are stored with a TTL, so Redis expires them itself.
+type RedisStore struct {
+	options RedisOptions
+	sealer  *sealer
+	pool    chan *redisConn
+}
+
+// redisConn is a connection to Redis
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from Redis
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedisStore connects to Redis and returns a store encrypting sessions with the key
+func NewRedisStore(options RedisOptions, encryptionKey string) (*RedisStore, error) {
+	if options.Address == "" {
+		return nil, fmt.Errorf("redis address is required")
+	}
+	sealer, err := newSealer("session", encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &RedisStore{
+		options: options,
+		sealer:  sealer,
+		pool:    make(chan *redisConn, redisPoolSize),
+	}
+
+	// Fail at startup rather than on the first login when Redis is unreachable
+	if _, err := rs.do("PING"); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", options.Address, err)
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"address":    options.Address,
+		"db":         options.DB,
+		"key_prefix": options.KeyPrefix,
+	}).Info("Session Redis store initialized")
+
+	return rs, nil
+}
+
+// key returns the Redis key of a session
+func (rs *RedisStore) key(sessionID string) string {
+	return rs.options.KeyPrefix + rs.sealer.storageKey(sessionID)
+}
+
+// Save encrypts a session and stores it until it expires
+func (rs *RedisStore) Save(session *Session) error {
+	key := rs.key(session.ID)
+	ttl := time.Until(session.ExpiresAt).Milliseconds()
+	if ttl <= 0 {
+		_, err := rs.do("DEL", key)
+		return err
+	}
+
+	data, err := rs.sealer.seal(key, session)
+	if err != nil {
+		return err
+	}
+	_, err = rs.do("SET", key, string(data), "PX", strconv.FormatInt(ttl, 10))
+	return err
+}
+
+// Get decrypts a session
+func (rs *RedisStore) Get(sessionID string) (*Session, bool, error) {
+	key := rs.key(sessionID)
+	reply, err := rs.do("GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, false, nil // Missing key
+	}
+
+	session, err := rs.sealer.open(key, data)
+	if err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// Refresh stores a session with a new expiry. SET XX only replaces an existing key, so a session
+// deleted by another replica since it was read is not brought back.
+func (rs *RedisStore) Refresh(sessionID string, expiresAt time.Time) (bool, error) {
+	session, found, err := rs.Get(sessionID)
+	if err != nil || !found {
+		return false, err
+	}
+
+	key := rs.key(sessionID)
+	ttl := time.Until(expiresAt).Milliseconds()
+	if ttl <= 0 {
+		_, err := rs.do("DEL", key)
+		return false, err
+	}
+	session.ExpiresAt = expiresAt
+	data, err := rs.sealer.seal(key, session)
+	if err != nil {
+		return false, err
+	}
+	reply, err := rs.do("SET", key, string(data), "PX", strconv.FormatInt(ttl, 10), "XX")
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil // Nil reply: the key is gone
+}
+
+// Delete removes a session
+func (rs *RedisStore) Delete(sessionID string) error {
+	_, err := rs.do("DEL", rs.key(sessionID))
+	return err
+}
+
+// Count returns the number of session keys
+func (rs *RedisStore) Count() (int, error) {
+	pattern := escapeRedisPattern(rs.options.KeyPrefix) + "*"
+	keys := make(map[string]bool)
+	cursor := "0"
+	for {
+		reply, err := rs.do("SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(redisScanCount))
+		if err != nil {
+			return 0, err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return 0, fmt.Errorf("unexpected SCAN reply %v", reply)
+		}
+		next, _ := parts[0].([]byte)
+		found, _ := parts[1].([]interface{})
+		for _, key := range found {
+			if key, ok := key.([]byte); ok {
+				keys[string(key)] = true // SCAN may return a key more than once
+			}
+		}
+
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return len(keys), nil
+		}
+	}
+}
+
+// DeleteExpired does nothing, Redis expires sessions with their TTL
+func (rs *RedisStore) DeleteExpired(time.Time) (int, error) {
+	return 0, nil
+}
+
+// Close closes the idle connections
+func (rs *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-rs.pool:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and returns its reply: a string for status replies, an int64, []byte or nil
+// for bulk strings, or []interface{} for arrays. Error replies are returned as redisError.
+func (rs *RedisStore) do(args ...string) (interface{}, error) {
+	conn, err := rs.get()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state
+		conn.conn.Close()
+		return nil, err
+	}
+	rs.put(conn)
+	return reply, err
+}
+
+// get returns an idle connection or opens a new one
+func (rs *RedisStore) get() (*redisConn, error) {
+	select {
+	case conn := <-rs.pool:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", rs.options.Address, redisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if rs.options.Password != "" {
+		if _, err := conn.do("AUTH", rs.options.Password); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if rs.options.DB != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(rs.options.DB)); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("failed to select redis db %d: %w", rs.options.DB, err)
+		}
+	}
+	return conn, nil
+}
+
+// put returns a connection to the pool, closing it when the pool is full
+func (rs *RedisStore) put(conn *redisConn) {
+	select {
+	case rs.pool <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+// do sends a command as an array of bulk strings and reads the reply
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(redisTimeout)); err != nil {
+		return nil, err
+	}
+
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, command.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(c.reader)
+}
+
+// readRedisReply reads a reply of the Redis serialization protocol (RESP2)
+func readRedisReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis bulk string length %q", line[1:])
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:length], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis array length %q", line[1:])
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readRedisReply(reader)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
+
+// escapeRedisPattern escapes the glob characters of a key prefix for MATCH
+func escapeRedisPattern(value string) string {
+	var escaped strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
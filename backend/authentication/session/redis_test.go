@@ -0,0 +1,269 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a local stand-in for Redis that implements the commands used by RedisStore
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	mutex    sync.Mutex
+	dbs      map[int]map[string]fakeRedisEntry
+	commands []string
+}
+
+type fakeRedisEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// newFakeRedis starts a fake Redis server that requires the password when it is not empty
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeRedis{
+		listener: listener,
+		password: password,
+		dbs:      make(map[int]map[string]fakeRedisEntry),
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (f *fakeRedis) address() string {
+	return f.listener.Addr().String()
+}
+
+// entries returns the live entries of a database
+func (f *fakeRedis) entries(db int) map[string]fakeRedisEntry {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	live := make(map[string]fakeRedisEntry)
+	for key, entry := range f.dbs[db] {
+		if time.Now().Before(entry.expiresAt) {
+			live[key] = entry
+		}
+	}
+	return live
+}
+
+// commandNames returns the names of the commands received so far
+func (f *fakeRedis) commandNames() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	db := 0
+
+	for {
+		request, err := readRedisReply(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := request.([]interface{})
+		args := make([]string, 0, len(parts))
+		for _, part := range parts {
+			data, _ := part.([]byte)
+			args = append(args, string(data))
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		f.mutex.Lock()
+		command := strings.ToUpper(args[0])
+		f.commands = append(f.commands, command)
+		var reply string
+		switch {
+		case command == "AUTH":
+			if len(args) == 2 && args[1] == f.password {
+				authenticated = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case command == "PING":
+			reply = "+PONG\r\n"
+		case command == "SELECT":
+			db, _ = strconv.Atoi(args[1])
+			reply = "+OK\r\n"
+		case command == "SET":
+			ttl, _ := strconv.Atoi(args[4])
+			if f.dbs[db] == nil {
+				f.dbs[db] = make(map[string]fakeRedisEntry)
+			}
+			entry, exists := f.dbs[db][args[1]]
+			if len(args) > 5 && strings.ToUpper(args[5]) == "XX" && (!exists || time.Now().After(entry.expiresAt)) {
+				reply = "$-1\r\n" // XX: only replace existing keys
+				break
+			}
+			f.dbs[db][args[1]] = fakeRedisEntry{value: []byte(args[2]), expiresAt: time.Now().Add(time.Duration(ttl) * time.Millisecond)}
+			reply = "+OK\r\n"
+		case command == "GET":
+			entry, ok := f.dbs[db][args[1]]
+			if !ok || time.Now().After(entry.expiresAt) {
+				reply = "$-1\r\n"
+			} else {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(entry.value), entry.value)
+			}
+		case command == "DEL":
+			_, ok := f.dbs[db][args[1]]
+			delete(f.dbs[db], args[1])
+			if ok {
+				reply = ":1\r\n"
+			} else {
+				reply = ":0\r\n"
+			}
+		case command == "SCAN":
+			// Returns two keys per call to exercise the cursor
+			cursor, _ := strconv.Atoi(args[1])
+			var keys []string
+			for key, entry := range f.dbs[db] {
+				if matched, _ := path.Match(args[3], key); matched && time.Now().Before(entry.expiresAt) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			end := min(cursor+2, len(keys))
+			next := strconv.Itoa(end)
+			if end == len(keys) {
+				next = "0"
+			}
+			page := keys[min(cursor, len(keys)):end]
+			reply = fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*%d\r\n", len(next), next, len(page))
+			for _, key := range page {
+				reply += fmt.Sprintf("$%d\r\n%s\r\n", len(key), key)
+			}
+		default:
+			reply = "-ERR unknown command '" + command + "'\r\n"
+		}
+		f.mutex.Unlock()
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisStoreAuthAndDB(t *testing.T) {
+	server := newFakeRedis(t, "hunter2")
+
+	store, err := NewRedisStore(RedisOptions{Address: server.address(), Password: "hunter2", DB: 3, KeyPrefix: "sa:session:"}, testEncryptionKey)
+	require.NoError(t, err)
+	defer store.Close()
+
+	session := newTestSession("alice", time.Hour)
+	require.NoError(t, store.Save(session))
+
+	entries := server.entries(3)
+	require.Len(t, entries, 1)
+	assert.Empty(t, server.entries(0), "sessions are kept in the selected database")
+	for key, entry := range entries {
+		assert.True(t, strings.HasPrefix(key, "sa:session:"))
+		assert.NotContains(t, key, session.ID, "session IDs are not stored")
+		assert.NotContains(t, string(entry.value), "alice", "sessions are encrypted")
+		assert.WithinDuration(t, session.ExpiresAt, entry.expiresAt, time.Second, "sessions expire with a TTL")
+	}
+	assert.Equal(t, []string{"AUTH", "SELECT", "PING", "SET"}, server.commandNames()[:4])
+}
+
+func TestRedisStoreConnectionErrors(t *testing.T) {
+	server := newFakeRedis(t, "hunter2")
+
+	_, err := NewRedisStore(RedisOptions{Address: server.address(), Password: "wrong"}, testEncryptionKey)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication failed")
+
+	_, err = NewRedisStore(RedisOptions{Address: server.address()}, testEncryptionKey)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NOAUTH")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	_, err = NewRedisStore(RedisOptions{Address: address}, testEncryptionKey)
+	assert.Error(t, err)
+}
+
+func TestRedisStoreKeyPrefix(t *testing.T) {
+	server := newFakeRedis(t, "")
+
+	first, err := NewRedisStore(RedisOptions{Address: server.address(), KeyPrefix: "a[1]:"}, testEncryptionKey)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := NewRedisStore(RedisOptions{Address: server.address(), KeyPrefix: "a1:"}, testEncryptionKey)
+	require.NoError(t, err)
+	defer second.Close()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, first.Save(newTestSession("alice", time.Hour)))
+	}
+	require.NoError(t, second.Save(newTestSession("bob", time.Hour)))
+
+	count, err := first.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 5, count, "glob characters in the prefix are matched literally")
+	count, err = second.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		errMsg   string
+	}{
+		{input: "+OK\r\n", expected: "OK"},
+		{input: ":42\r\n", expected: int64(42)},
+		{input: "$5\r\nhello\r\n", expected: []byte("hello")},
+		{input: "$0\r\n\r\n", expected: []byte{}},
+		{input: "$-1\r\n", expected: nil},
+		{input: "*2\r\n$1\r\na\r\n:1\r\n", expected: []interface{}{[]byte("a"), int64(1)}},
+		{input: "-ERR wrong\r\n", errMsg: "redis: ERR wrong"},
+		{input: "?\r\n", errMsg: "unexpected redis reply"},
+	}
+	for _, tt := range tests {
+		reply, err := readRedisReply(bufio.NewReader(strings.NewReader(tt.input)))
+		if tt.errMsg != "" {
+			require.Error(t, err, tt.input)
+			assert.Contains(t, err.Error(), tt.errMsg)
+			continue
+		}
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, reply, tt.input)
+	}
+}
@@ -17,23 +17,32 @@ type Session struct {
 	IsAdmin    bool      `json:"is_admin"`
 	Roles      []string  `json:"roles"`
 	Groups     []string  `json:"groups"`
-	AuthMethod string    `json:"auth_method"` // "local" or "oidc"
+	AuthMethod string    `json:"auth_method"` // "local", "oidc", "ldap" or "token" for API tokens
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiresAt  time.Time `json:"expires_at"`
 }
 
-// Manager handles session storage and management
+// Manager handles session storage and management. Sessions are kept in a store, or in
+// encrypted cookies when the manager is created with NewCookieManager.
 type Manager struct {
-	sessions map[string]*Session
-	mutex    sync.RWMutex
+	store    SessionStore // Sessions, or logged out cookie sessions in cookie mode
+	cookies  *sealer      // Seals cookie sessions; nil when sessions are kept in the store
 	timeout  time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
-// NewManager creates a new session manager
+// NewManager creates a new session manager keeping sessions in memory
 func NewManager(sessionTimeout time.Duration) *Manager {
+	return NewManagerWithStore(sessionTimeout, NewMemoryStore())
+}
+
+// NewManagerWithStore creates a new session manager keeping sessions in a store
+func NewManagerWithStore(sessionTimeout time.Duration, store SessionStore) *Manager {
 	manager := &Manager{
-		sessions: make(map[string]*Session),
-		timeout:  sessionTimeout,
+		store:   store,
+		timeout: sessionTimeout,
+		stop:    make(chan struct{}),
 	}
 
 	// Start cleanup routine
@@ -70,9 +79,12 @@ func (m *Manager) CreateSession(username string, isAdmin bool, roles, groups []s
 		ExpiresAt:  now.Add(m.timeout),
 	}
 
-	m.mutex.Lock()
-	m.sessions[sessionID] = session
-	m.mutex.Unlock()
+	if m.cookies == nil {
+		if err := m.store.Save(session); err != nil {
+			logging.Logger.WithError(err).Error("Failed to store session")
+			return nil, fmt.Errorf("failed to store session: %w", err)
+		}
+	}
 
 	logging.Logger.WithFields(map[string]interface{}{
 		"session_id":  "****", // Mask session ID for security
@@ -89,10 +101,15 @@ func (m *Manager) CreateSession(username string, isAdmin bool, roles, groups []s
 func (m *Manager) ValidateSession(sessionID string) (*Session, bool) {
 	logging.Logger.WithField("session_id", "****").Debug("Validating session")
 
-	m.mutex.RLock()
-	session, exists := m.sessions[sessionID]
-	m.mutex.RUnlock()
+	if m.cookies != nil {
+		return m.openCookie(sessionID)
+	}
 
+	session, exists, err := m.store.Get(sessionID)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("session_id", "****").Error("Failed to load session")
+		return nil, false
+	}
 	if !exists {
 		logging.Logger.WithField("session_id", "****").Debug("Session not found")
 		return nil, false
@@ -118,14 +135,21 @@ func (m *Manager) ValidateSession(sessionID string) (*Session, bool) {
 	return session, true
 }
 
-// RefreshSession extends the session expiration time
+// RefreshSession extends the session expiration time. Cookie sessions can only be extended
+// with a new cookie, see RefreshSessionCookie; for them it reports whether the session is valid.
 func (m *Manager) RefreshSession(sessionID string) bool {
 	logging.Logger.WithField("session_id", "****").Debug("Refreshing session")
 
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	if m.cookies != nil {
+		_, valid := m.openCookie(sessionID)
+		return valid
+	}
 
-	session, exists := m.sessions[sessionID]
+	session, exists, err := m.store.Get(sessionID)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("session_id", "****").Error("Failed to load session for refresh")
+		return false
+	}
 	if !exists {
 		logging.Logger.WithField("session_id", "****").Debug("Session not found for refresh")
 		return false
@@ -134,52 +158,88 @@ func (m *Manager) RefreshSession(sessionID string) bool {
 	// Check if session is expired
 	if time.Now().After(session.ExpiresAt) {
 		logging.Logger.WithField("session_id", "****").Debug("Session expired during refresh, deleting")
-		delete(m.sessions, sessionID)
+		m.DeleteSession(sessionID)
 		return false
 	}
 
-	// Extend expiration
-	oldExpiresAt := session.ExpiresAt
-	session.ExpiresAt = time.Now().Add(m.timeout)
+	// Extend expiration. The store only updates a session that still exists, so a logout
+	// running concurrently is not undone.
+	newExpiresAt := time.Now().Add(m.timeout)
+	found, err := m.store.Refresh(sessionID, newExpiresAt)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("session_id", "****").Error("Failed to store refreshed session")
+		return false
+	}
+	if !found {
+		logging.Logger.WithField("session_id", "****").Debug("Session deleted during refresh")
+		return false
+	}
 
 	logging.Logger.WithFields(map[string]interface{}{
 		"session_id":     "****", // Mask session ID for security
-		"old_expires_at": oldExpiresAt,
-		"new_expires_at": session.ExpiresAt,
+		"old_expires_at": session.ExpiresAt,
+		"new_expires_at": newExpiresAt,
 	}).Debug("Session refreshed successfully")
 
 	return true
 }
 
-// DeleteSession removes a session
+// DeleteSession removes a session. Cookie sessions are added to the denylist until they expire.
 func (m *Manager) DeleteSession(sessionID string) {
-	m.mutex.Lock()
-	delete(m.sessions, sessionID)
-	m.mutex.Unlock()
+	if m.cookies != nil {
+		m.revokeCookie(sessionID)
+		return
+	}
+	if err := m.store.Delete(sessionID); err != nil {
+		logging.Logger.WithError(err).WithField("session_id", "****").Error("Failed to delete session")
+	}
 }
 
-// GetSessionCount returns the number of active sessions
+// GetSessionCount returns the number of active sessions. Cookie sessions are not tracked, so it
+// returns 0 for them.
 func (m *Manager) GetSessionCount() int {
-	m.mutex.RLock()
-	count := len(m.sessions)
-	m.mutex.RUnlock()
+	if m.cookies != nil {
+		return 0
+	}
+
+	count, err := m.store.Count()
+	if err != nil {
+		logging.Logger.WithError(err).Error("Failed to count sessions")
+		return 0
+	}
 	return count
 }
 
-// cleanupExpiredSessions runs periodically to remove expired sessions
+// Close stops the cleanup routine and closes the session store
+func (m *Manager) Close() error {
+	m.stopOnce.Do(func() { close(m.stop) })
+	return m.store.Close()
+}
+
+// cleanupExpiredSessions runs periodically to remove expired sessions (or expired denylist entries)
 func (m *Manager) cleanupExpiredSessions() {
 	ticker := time.NewTicker(5 * time.Minute) // Cleanup every 5 minutes
 	defer ticker.Stop()
 
-	for range ticker.C {
-		now := time.Now()
-		m.mutex.Lock()
-		for sessionID, session := range m.sessions {
-			if now.After(session.ExpiresAt) {
-				delete(m.sessions, sessionID)
-			}
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			m.deleteExpiredSessions(now)
 		}
-		m.mutex.Unlock()
+	}
+}
+
+// deleteExpiredSessions removes the sessions that expired before now from the store
+func (m *Manager) deleteExpiredSessions(now time.Time) {
+	removed, err := m.store.DeleteExpired(now)
+	if err != nil {
+		logging.Logger.WithError(err).Error("Failed to remove expired sessions")
+		return
+	}
+	if removed > 0 {
+		logging.Logger.WithField("removed", removed).Debug("Removed expired sessions")
 	}
 }
 
@@ -38,8 +38,8 @@ func TestNewManager(t *testing.T) {
 
 	assert.NotNil(t, manager)
 	assert.Equal(t, timeout, manager.timeout)
-	assert.NotNil(t, manager.sessions)
-	assert.Equal(t, 0, len(manager.sessions))
+	assert.IsType(t, &MemoryStore{}, manager.store)
+	assert.Equal(t, 0, manager.GetSessionCount())
 }
 
 func TestManager_CreateSession(t *testing.T) {
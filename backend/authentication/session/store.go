@@ -0,0 +1,149 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"site-availability/config"
+)
+
+// SessionStore defines the interface for session backends. Stores are safe for concurrent use.
+type SessionStore interface {
+	// Save creates or replaces a session
+	Save(session *Session) error
+	// Get returns a session by ID; found is false when there is no such session
+	Get(sessionID string) (session *Session, found bool, err error)
+	// Refresh atomically moves the expiry of an existing session; found is false when there is no
+	// such session. A session deleted concurrently is never brought back.
+	Refresh(sessionID string, expiresAt time.Time) (found bool, err error)
+	// Delete removes a session; deleting a missing session is not an error
+	Delete(sessionID string) error
+	// Count returns the number of stored sessions
+	Count() (int, error)
+	// DeleteExpired removes the sessions that expired before now and returns how many were removed
+	DeleteExpired(now time.Time) (int, error)
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// NewStore creates the session store selected in the configuration
+func NewStore(cfg config.SessionStoreConfig) (SessionStore, error) {
+	switch cfg.Type {
+	case "", config.SessionStoreMemory:
+		return NewMemoryStore(), nil
+	case config.SessionStoreFile:
+		return NewFileStore(cfg.Path, cfg.EncryptionKey)
+	case config.SessionStoreRedis:
+		return NewRedisStore(RedisOptions{
+			Address:   cfg.Address,
+			Password:  cfg.Password,
+			DB:        cfg.DB,
+			KeyPrefix: cfg.KeyPrefix,
+		}, cfg.EncryptionKey)
+	default:
+		return nil, fmt.Errorf("unknown session store type %q", cfg.Type)
+	}
+}
+
+// NewManagerFromConfig creates the session manager selected in the configuration: sessions in a
+// store, or in encrypted cookies with logged out sessions in the denylist store
+func NewManagerFromConfig(sessionTimeout time.Duration, cfg config.SessionStoreConfig) (*Manager, error) {
+	if cfg.Type != config.SessionStoreCookie {
+		store, err := NewStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewManagerWithStore(sessionTimeout, store), nil
+	}
+
+	denylist, err := NewStore(cfg.DenylistStore())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize session denylist: %w", err)
+	}
+	manager, err := NewCookieManager(sessionTimeout, cfg.CookieKeys, denylist)
+	if err != nil {
+		denylist.Close()
+		return nil, err
+	}
+	return manager, nil
+}
+
+// MemoryStore keeps sessions in memory. Sessions are lost on restart and not shared between replicas.
+type MemoryStore struct {
+	sessions map[string]Session
+	mutex    sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory session store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]Session)}
+}
+
+// Save stores a copy of the session
+func (m *MemoryStore) Save(session *Session) error {
+	m.mutex.Lock()
+	m.sessions[session.ID] = *session
+	m.mutex.Unlock()
+	return nil
+}
+
+// Get returns a copy of a session
+func (m *MemoryStore) Get(sessionID string) (*Session, bool, error) {
+	m.mutex.RLock()
+	session, exists := m.sessions[sessionID]
+	m.mutex.RUnlock()
+	if !exists {
+		return nil, false, nil
+	}
+	return &session, true, nil
+}
+
+// Refresh moves the expiry of a session
+func (m *MemoryStore) Refresh(sessionID string, expiresAt time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return false, nil
+	}
+	session.ExpiresAt = expiresAt
+	m.sessions[sessionID] = session
+	return true, nil
+}
+
+// Delete removes a session
+func (m *MemoryStore) Delete(sessionID string) error {
+	m.mutex.Lock()
+	delete(m.sessions, sessionID)
+	m.mutex.Unlock()
+	return nil
+}
+
+// Count returns the number of sessions
+func (m *MemoryStore) Count() (int, error) {
+	m.mutex.RLock()
+	count := len(m.sessions)
+	m.mutex.RUnlock()
+	return count, nil
+}
+
+// DeleteExpired removes expired sessions
+func (m *MemoryStore) DeleteExpired(now time.Time) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	removed := 0
+	for sessionID, session := range m.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(m.sessions, sessionID)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Close does nothing, sessions in memory need no cleanup
+func (m *MemoryStore) Close() error {
+	return nil
+}
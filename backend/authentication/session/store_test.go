@@ -0,0 +1,264 @@
+package session
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"site-availability/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEncryptionKey = "0123456789abcdef0123456789abcdef"
+
+// newTestSession returns a session expiring after ttl
+func newTestSession(username string, ttl time.Duration) *Session {
+	id, err := generateSessionID()
+	if err != nil {
+		panic(err)
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &Session{
+		ID:         id,
+		Username:   username,
+		Roles:      []string{"viewer"},
+		Groups:     []string{"sre"},
+		AuthMethod: "oidc",
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+}
+
+// testStores returns a store of every backend
+func testStores(t *testing.T) map[string]SessionStore {
+	t.Helper()
+	fileStore, err := NewFileStore(filepath.Join(t.TempDir(), "sessions.db"), testEncryptionKey)
+	require.NoError(t, err)
+	redisStore, err := NewRedisStore(RedisOptions{Address: newFakeRedis(t, "").address(), KeyPrefix: "session:"}, testEncryptionKey)
+	require.NoError(t, err)
+
+	stores := map[string]SessionStore{
+		"memory": NewMemoryStore(),
+		"file":   fileStore,
+		"redis":  redisStore,
+	}
+	t.Cleanup(func() {
+		for _, store := range stores {
+			store.Close()
+		}
+	})
+	return stores
+}
+
+func TestSessionStores(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := newTestSession("alice", time.Hour)
+			bob := newTestSession("bob", time.Hour)
+			require.NoError(t, store.Save(alice))
+			require.NoError(t, store.Save(bob))
+
+			loaded, found, err := store.Get(alice.ID)
+			require.NoError(t, err)
+			require.True(t, found)
+			assert.Equal(t, "alice", loaded.Username)
+			assert.Equal(t, alice.Roles, loaded.Roles)
+			assert.Equal(t, alice.Groups, loaded.Groups)
+			assert.Equal(t, "oidc", loaded.AuthMethod)
+			assert.True(t, alice.ExpiresAt.Equal(loaded.ExpiresAt))
+
+			count, err := store.Count()
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			// Changes to a loaded session are only kept when it is saved
+			loaded.ExpiresAt = loaded.ExpiresAt.Add(time.Hour)
+			reloaded, _, err := store.Get(alice.ID)
+			require.NoError(t, err)
+			assert.True(t, alice.ExpiresAt.Equal(reloaded.ExpiresAt))
+			require.NoError(t, store.Save(loaded))
+			reloaded, _, err = store.Get(alice.ID)
+			require.NoError(t, err)
+			assert.True(t, loaded.ExpiresAt.Equal(reloaded.ExpiresAt))
+
+			require.NoError(t, store.Delete(bob.ID))
+			require.NoError(t, store.Delete("missing"))
+			_, found, err = store.Get(bob.ID)
+			require.NoError(t, err)
+			assert.False(t, found)
+
+			count, err = store.Count()
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestSessionStoresDeleteExpired(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			expiring := newTestSession("alice", 50*time.Millisecond)
+			active := newTestSession("bob", time.Hour)
+			require.NoError(t, store.Save(expiring))
+			require.NoError(t, store.Save(active))
+
+			time.Sleep(100 * time.Millisecond)
+			_, err := store.DeleteExpired(time.Now())
+			require.NoError(t, err)
+
+			count, err := store.Count()
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+			_, found, err := store.Get(active.ID)
+			require.NoError(t, err)
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestManagerWithStores(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			manager := NewManagerWithStore(time.Hour, store)
+
+			session, err := manager.CreateSession("alice", false, []string{"viewer"}, []string{"sre"}, "local")
+			require.NoError(t, err)
+
+			validated, valid := manager.ValidateSession(session.ID)
+			require.True(t, valid)
+			assert.Equal(t, "alice", validated.Username)
+
+			time.Sleep(10 * time.Millisecond)
+			require.True(t, manager.RefreshSession(session.ID))
+			refreshed, valid := manager.ValidateSession(session.ID)
+			require.True(t, valid)
+			assert.True(t, refreshed.ExpiresAt.After(session.ExpiresAt))
+
+			manager.DeleteSession(session.ID)
+			_, valid = manager.ValidateSession(session.ID)
+			assert.False(t, valid)
+			assert.Equal(t, 0, manager.GetSessionCount())
+		})
+	}
+}
+
+func TestSessionStoresRefresh(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := newTestSession("alice", time.Hour)
+			require.NoError(t, store.Save(alice))
+
+			expiresAt := alice.ExpiresAt.Add(time.Hour)
+			found, err := store.Refresh(alice.ID, expiresAt)
+			require.NoError(t, err)
+			require.True(t, found)
+			loaded, _, err := store.Get(alice.ID)
+			require.NoError(t, err)
+			assert.True(t, expiresAt.Equal(loaded.ExpiresAt))
+			assert.Equal(t, "alice", loaded.Username, "the rest of the session is kept")
+
+			require.NoError(t, store.Delete(alice.ID))
+			found, err = store.Refresh(alice.ID, expiresAt)
+			require.NoError(t, err)
+			assert.False(t, found)
+			_, found, err = store.Get(alice.ID)
+			require.NoError(t, err)
+			assert.False(t, found, "refreshing doesn't bring a deleted session back")
+		})
+	}
+}
+
+func TestManagerConcurrentLogoutAndRefresh(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			manager := NewManagerWithStore(time.Hour, store)
+
+			for round := 0; round < 20; round++ {
+				session, err := manager.CreateSession("alice", false, nil, nil, "local")
+				require.NoError(t, err)
+
+				var wg sync.WaitGroup
+				for i := 0; i < 4; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for j := 0; j < 5; j++ {
+							manager.RefreshSession(session.ID)
+						}
+					}()
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					manager.DeleteSession(session.ID)
+				}()
+				wg.Wait()
+
+				_, valid := manager.ValidateSession(session.ID)
+				require.False(t, valid, "a logout racing with refreshes must stick")
+			}
+		})
+	}
+}
+
+func TestManagersShareRedisStore(t *testing.T) {
+	address := newFakeRedis(t, "").address()
+	newReplica := func() *Manager {
+		store, err := NewRedisStore(RedisOptions{Address: address, KeyPrefix: "session:"}, testEncryptionKey)
+		require.NoError(t, err)
+		manager := NewManagerWithStore(time.Hour, store)
+		t.Cleanup(func() { manager.Close() })
+		return manager
+	}
+	first, second := newReplica(), newReplica()
+
+	session, err := first.CreateSession("alice", true, []string{"admin"}, nil, "local")
+	require.NoError(t, err)
+
+	validated, valid := second.ValidateSession(session.ID)
+	require.True(t, valid, "a session created by one replica is valid on the other")
+	assert.True(t, validated.IsAdmin)
+
+	second.DeleteSession(session.ID)
+	_, valid = first.ValidateSession(session.ID)
+	assert.False(t, valid, "logging out on one replica ends the session on the other")
+}
+
+func TestManagerDeleteExpiredSessions(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "sessions.db"), testEncryptionKey)
+	require.NoError(t, err)
+	manager := NewManagerWithStore(time.Hour, store)
+	defer manager.Close()
+
+	require.NoError(t, store.Save(newTestSession("alice", -time.Minute)))
+	_, err = manager.CreateSession("bob", false, nil, nil, "local")
+	require.NoError(t, err)
+
+	manager.deleteExpiredSessions(time.Now())
+	assert.Equal(t, 1, manager.GetSessionCount())
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore(config.SessionStoreConfig{})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	store, err = NewStore(config.SessionStoreConfig{Type: config.SessionStoreFile, Path: filepath.Join(t.TempDir(), "sessions.db"), EncryptionKey: testEncryptionKey})
+	require.NoError(t, err)
+	assert.IsType(t, &FileStore{}, store)
+	require.NoError(t, store.Close())
+
+	store, err = NewStore(config.SessionStoreConfig{Type: config.SessionStoreRedis, Address: newFakeRedis(t, "").address(), EncryptionKey: testEncryptionKey})
+	require.NoError(t, err)
+	assert.IsType(t, &RedisStore{}, store)
+	require.NoError(t, store.Close())
+
+	_, err = NewStore(config.SessionStoreConfig{Type: config.SessionStoreFile, Path: filepath.Join(t.TempDir(), "sessions.db")})
+	assert.Error(t, err, "persistent stores require an encryption key")
+
+	_, err = NewStore(config.SessionStoreConfig{Type: "bolt"})
+	assert.Error(t, err)
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"site-availability/labels"
 	"site-availability/logging"
+	"site-availability/maintenance/cron"
 	"site-availability/yaml"
 
 	goyaml "gopkg.in/yaml.v2"
@@ -19,6 +21,8 @@ type Config struct {
 	Documentation  Documentation    `yaml:"documentation"`
 	Locations      []Location       `yaml:"locations"`
 	Sources        []Source         `yaml:"sources"`
+	Notifications  Notifications    `yaml:"notifications,omitempty"`
+	Maintenance    Maintenance      `yaml:"maintenance,omitempty"`
 }
 
 type ServerSettings struct {
@@ -29,23 +33,137 @@ type ServerSettings struct {
 	Token             string                `yaml:"token"`
 	Labels            map[string]string     `yaml:"labels,omitempty"`
 	SessionTimeout    string                `yaml:"session_timeout,omitempty"`
+	SessionStore      SessionStoreConfig    `yaml:"session_store,omitempty"`
+	APITokens         APITokensConfig       `yaml:"api_tokens,omitempty"`
 	TrustProxyHeaders bool                  `yaml:"trust_proxy_headers,omitempty"`
 	LocalAdmin        LocalAdminConfig      `yaml:"local_admin,omitempty"`
+	LocalUsers        LocalUsersConfig      `yaml:"local_users,omitempty"`
+	LoginLockout      LoginLockoutConfig    `yaml:"login_lockout,omitempty"`
 	Roles             map[string]RoleConfig `yaml:"roles,omitempty"`
 	OIDC              OIDCConfig            `yaml:"oidc,omitempty"`
+	LDAP              LDAPConfig            `yaml:"ldap,omitempty"`
 	MetricsAuth       MetricsAuthConfig     `yaml:"metrics_auth,omitempty"`
+	ConfigReload      ConfigReloadSettings  `yaml:"config_reload,omitempty"`
 }
 
+// ConfigReloadSettings controls reloading config.yaml and credentials.yaml while the server runs.
+// A reload can always be triggered with SIGHUP.
+type ConfigReloadSettings struct {
+	Watch    *bool  `yaml:"watch,omitempty"`    // Reload when the files change. Default: true
+	Interval string `yaml:"interval,omitempty"` // How often the files are checked. Default: 10s
+}
+
+// IsWatchEnabled returns whether the config files are watched for changes (default: true)
+func (c ConfigReloadSettings) IsWatchEnabled() bool {
+	return c.Watch == nil || *c.Watch
+}
+
+// SessionStoreConfig selects where sessions are kept. Sessions in a file survive restarts,
+// and sessions in Redis are also shared by replicas. Cookie sessions are kept by the browser
+// in an encrypted cookie; the store settings then select where logged out sessions are kept.
+type SessionStoreConfig struct {
+	Type          string   `yaml:"type,omitempty"`           // "memory" (default), "file", "redis" or "cookie"
+	Path          string   `yaml:"path,omitempty"`           // Session file of the file store
+	Address       string   `yaml:"address,omitempty"`        // host:port of the Redis server
+	Password      string   `yaml:"password,omitempty"`       // Redis password
+	DB            int      `yaml:"db,omitempty"`             // Redis database number
+	KeyPrefix     string   `yaml:"key_prefix,omitempty"`     // Prefix of the Redis keys. Default: "site-availability:session:"
+	EncryptionKey string   `yaml:"encryption_key,omitempty"` // Secret the file and Redis stores encrypt sessions with
+	CookieKeys    []string `yaml:"cookie_keys,omitempty"`    // Secrets of cookie sessions; the first encrypts, all decrypt
+	Denylist      string   `yaml:"denylist,omitempty"`       // Store of logged out cookie sessions: "memory" (default), "file" or "redis"
+}
+
+// Session store types
+const (
+	SessionStoreMemory = "memory"
+	SessionStoreFile   = "file"
+	SessionStoreRedis  = "redis"
+	SessionStoreCookie = "cookie"
+)
+
+// DenylistStore returns the settings of the store keeping logged out cookie sessions
+func (s SessionStoreConfig) DenylistStore() SessionStoreConfig {
+	denylist := s
+	denylist.Type = s.Denylist
+	denylist.Denylist = ""
+	denylist.CookieKeys = nil
+	return denylist
+}
+
+// APITokensConfig controls API tokens, which authenticate scripts on the /api/ routes
+type APITokensConfig struct {
+	Path   string `yaml:"path,omitempty"`    // File the tokens are kept in; without it tokens are lost on restart
+	MaxTTL string `yaml:"max_ttl,omitempty"` // Longest lifetime of a new token, e.g. "2160h"; empty allows tokens that never expire
+}
+
+// minSessionEncryptionKeyLength is the shortest encryption_key accepted for persistent session stores
+const minSessionEncryptionKeyLength = 32
+
 type LocalAdminConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 	Username string `yaml:"username,omitempty"`
 	Password string `yaml:"password,omitempty"`
 }
 
+// LocalUsersConfig is a directory of users logging in with a password, for environments without an identity provider
+type LocalUsersConfig struct {
+	Enabled bool                       `yaml:"enabled"`
+	Users   map[string]LocalUserConfig `yaml:"users,omitempty"` // By username
+}
+
+// LocalUserConfig is a local user. Roles work like OIDC user roles: "admin" grants full access.
+type LocalUserConfig struct {
+	PasswordHash string   `yaml:"password_hash,omitempty"` // bcrypt or argon2id hash of the password
+	Roles        []string `yaml:"roles,omitempty"`
+}
+
+// LoginLockoutConfig locks an account for a while after repeated failed password logins
+type LoginLockoutConfig struct {
+	MaxAttempts int    `yaml:"max_attempts,omitempty"` // Failed logins in a row that lock the account. Default: 5
+	Duration    string `yaml:"duration,omitempty"`     // How long the account stays locked. Default: 15m
+}
+
+// Attempts returns the failed logins in a row that lock an account (default: 5)
+func (c LoginLockoutConfig) Attempts() int {
+	if c.MaxAttempts <= 0 {
+		return 5
+	}
+	return c.MaxAttempts
+}
+
+// LockDuration returns how long an account stays locked (default: 15m)
+func (c LoginLockoutConfig) LockDuration() time.Duration {
+	if duration, err := time.ParseDuration(c.Duration); err == nil && duration > 0 {
+		return duration
+	}
+	return 15 * time.Minute
+}
+
+// Password hash formats accepted for local users
+var passwordHashPrefixes = []string{"$2a$", "$2b$", "$2y$", "$argon2id$"}
+
+// IsPasswordHash reports whether a password is a bcrypt or argon2id hash rather than plaintext
+func IsPasswordHash(password string) bool {
+	for _, prefix := range passwordHashPrefixes {
+		if strings.HasPrefix(password, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 type RoleConfig struct {
-	Labels map[string]string `yaml:",inline"`
+	Permissions []string          `yaml:"permissions,omitempty"` // Actions granted beyond viewing, e.g. "check"
+	Labels      map[string]string `yaml:",inline"`
 }
 
+const (
+	// PermissionCheck allows triggering checks and scrapes on demand through the API
+	PermissionCheck = "check"
+	// PermissionMaintenance allows creating and deleting maintenance windows through the API
+	PermissionMaintenance = "maintenance"
+)
+
 type OIDCConfig struct {
 	Enabled     bool               `yaml:"enabled"`
 	Config      OIDCProviderConfig `yaml:"config,omitempty"`
@@ -66,10 +184,111 @@ type OIDCPermissions struct {
 	Groups map[string][]string `yaml:"groups,omitempty"`
 }
 
+// LDAPConfig configures password logins against an LDAP directory or Active Directory.
+// Users are found with a service account and verified by binding as them.
+type LDAPConfig struct {
+	Enabled            bool            `yaml:"enabled"`
+	Name               string          `yaml:"name,omitempty"`                 // Shown on the login form. Default: "LDAP"
+	URL                string          `yaml:"url,omitempty"`                  // ldap://host:389 or ldaps://host:636
+	StartTLS           bool            `yaml:"start_tls,omitempty"`            // Upgrade ldap:// connections with StartTLS
+	BindDN             string          `yaml:"bind_dn,omitempty"`              // Service account; empty searches anonymously
+	BindPassword       string          `yaml:"bind_password,omitempty"`        // Password of the service account
+	BaseDN             string          `yaml:"base_dn,omitempty"`              // Where users are searched
+	UserFilter         string          `yaml:"user_filter,omitempty"`          // Finds the user; {username} is replaced. Default: "(|(sAMAccountName={username})(uid={username}))"
+	UsernameAttribute  string          `yaml:"username_attribute,omitempty"`   // Holds the username used for sessions, roles, tokens and lockouts. Default: sAMAccountName, then uid
+	GroupBaseDN        string          `yaml:"group_base_dn,omitempty"`        // Where groups are searched. Default: base_dn
+	GroupFilter        string          `yaml:"group_filter,omitempty"`         // Finds the groups of a member; {dn} and {username} are replaced. Default: "(|(member={dn})(uniqueMember={dn}))"
+	GroupNameAttribute string          `yaml:"group_name_attribute,omitempty"` // Group name matched against permissions. Default: "cn"
+	NestedGroups       bool            `yaml:"nested_groups,omitempty"`        // Also resolve groups that contain the user's groups
+	Timeout            string          `yaml:"timeout,omitempty"`              // Limit of a login against the directory. Default: 10s
+	Permissions        OIDCPermissions `yaml:"permissions,omitempty"`          // Roles by username and group name, as for OIDC
+}
+
 type ScrapingSettings struct {
-	Interval    string `yaml:"interval"`
-	Timeout     string `yaml:"timeout"`
-	MaxParallel int    `yaml:"max_parallel"`
+	Interval    string           `yaml:"interval"`
+	Timeout     string           `yaml:"timeout"`
+	MaxParallel int              `yaml:"max_parallel"`
+	Jitter      *float64         `yaml:"jitter,omitempty"` // Fraction of the interval used to spread sources apart. Default: 0.1
+	History     HistorySettings  `yaml:"history,omitempty"`
+	OnDemand    OnDemandSettings `yaml:"on_demand,omitempty"`
+}
+
+// OnDemandSettings controls checks and scrapes triggered through the API
+type OnDemandSettings struct {
+	RateLimit int `yaml:"rate_limit,omitempty"` // Requests per user per minute. Default: 6
+}
+
+// DefaultJitter is the scrape jitter used when none is configured
+const DefaultJitter = 0.1
+
+// JitterFraction returns the configured scrape jitter (default: 0.1)
+func (s ScrapingSettings) JitterFraction() float64 {
+	if s.Jitter == nil {
+		return DefaultJitter
+	}
+	return *s.Jitter
+}
+
+// HistorySettings controls recording of app status transitions
+type HistorySettings struct {
+	Enabled   *bool  `yaml:"enabled,omitempty"`   // Default: true
+	Path      string `yaml:"path,omitempty"`      // History file; empty keeps history in memory only
+	Retention string `yaml:"retention,omitempty"` // Default: 720h (30 days)
+}
+
+// IsEnabled returns whether status history recording is enabled (default: true)
+func (h HistorySettings) IsEnabled() bool {
+	return h.Enabled == nil || *h.Enabled
+}
+
+// Notifications configures where app status changes are sent
+type Notifications struct {
+	Receivers []NotificationReceiver `yaml:"receivers,omitempty"`
+	Routes    []NotificationRoute    `yaml:"routes,omitempty"`
+}
+
+// NotificationReceiver is a destination for notifications
+type NotificationReceiver struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"` // "webhook", "alertmanager" or "slack"
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Template string            `yaml:"template,omitempty"` // Webhook only: Go template for the request body
+	Timeout  string            `yaml:"timeout,omitempty"`  // Default: 10s
+}
+
+// NotificationRoute selects the apps whose status changes are sent to a receiver
+type NotificationRoute struct {
+	Receiver       string   `yaml:"receiver"`
+	Matchers       []string `yaml:"matchers,omitempty"`        // Label matchers, e.g. env="production"
+	Statuses       []string `yaml:"statuses,omitempty"`        // Statuses that fire. Default: [down]
+	GroupBy        []string `yaml:"group_by,omitempty"`        // Labels used to batch alerts. Default: one group per route
+	GroupWait      string   `yaml:"group_wait,omitempty"`      // Default: 30s
+	RepeatInterval string   `yaml:"repeat_interval,omitempty"` // Default: 4h
+	SendResolved   *bool    `yaml:"send_resolved,omitempty"`   // Default: true
+}
+
+// ShouldSendResolved returns whether resolve notifications are sent (default: true)
+func (r NotificationRoute) ShouldSendResolved() bool {
+	return r.SendResolved == nil || *r.SendResolved
+}
+
+// Maintenance configures scheduled maintenance windows. More windows can be added through the API.
+type Maintenance struct {
+	Windows []MaintenanceWindow `yaml:"windows,omitempty"`
+}
+
+// MaintenanceWindow puts the apps selected by its matchers in maintenance, either once between Start and End
+// or repeatedly for Duration from every run of Schedule
+type MaintenanceWindow struct {
+	Name     string   `yaml:"name" json:"name"`
+	Matchers []string `yaml:"matchers" json:"matchers"`                     // e.g. name="payments", location=~"eu-.*", env="staging"
+	Start    string   `yaml:"start,omitempty" json:"start,omitempty"`       // RFC 3339. With a schedule, the window is not active before it
+	End      string   `yaml:"end,omitempty" json:"end,omitempty"`           // RFC 3339. With a schedule, the window is not active after it
+	Schedule string   `yaml:"schedule,omitempty" json:"schedule,omitempty"` // Cron expression, e.g. "0 2 * * sun"
+	Duration string   `yaml:"duration,omitempty" json:"duration,omitempty"` // Length of each scheduled window
+	Timezone string   `yaml:"timezone,omitempty" json:"timezone,omitempty"` // Timezone of the schedule. Default: UTC
+	Comment  string   `yaml:"comment,omitempty" json:"comment,omitempty"`
 }
 
 type Documentation struct {
@@ -84,10 +303,30 @@ type Location struct {
 }
 
 type Source struct {
-	Name   string                 `yaml:"name"`
-	Type   string                 `yaml:"type"`
-	Labels map[string]string      `yaml:"labels,omitempty"`
-	Config map[string]interface{} `yaml:"config"`
+	Name     string                 `yaml:"name"`
+	Type     string                 `yaml:"type"`
+	Labels   map[string]string      `yaml:"labels,omitempty"`
+	Interval string                 `yaml:"interval,omitempty"` // Overrides the scraping interval for this source
+	Timeout  string                 `yaml:"timeout,omitempty"`  // Overrides the scraping timeout for this source
+	Debounce *DebounceSettings      `yaml:"debounce,omitempty"`
+	Config   map[string]interface{} `yaml:"config"`
+}
+
+// DebounceSettings controls how many consecutive results are needed before a status change is published.
+// They can be set per source and overridden per app with a debounce section in the app config.
+type DebounceSettings struct {
+	FailuresBeforeDown int    `yaml:"failures_before_down,omitempty"` // Results needed to publish a worse status. Default: 1
+	SuccessesBeforeUp  int    `yaml:"successes_before_up,omitempty"`  // Results needed to publish a better status. Default: 1
+	FlapWindow         string `yaml:"flap_window,omitempty"`          // Window for flap detection; empty disables it
+	FlapThreshold      int    `yaml:"flap_threshold,omitempty"`       // Status changes within the window to flag flapping. Default: 5
+}
+
+// AppDebounceConfig is the part of a source config holding per-app debounce overrides
+type AppDebounceConfig struct {
+	Apps []struct {
+		Name     string            `yaml:"name"`
+		Debounce *DebounceSettings `yaml:"debounce"`
+	} `yaml:"apps"`
 }
 
 type MetricsAuthConfig struct {
@@ -112,9 +351,13 @@ func DecodeConfig[T any](cfg map[string]interface{}, sourceName string) (T, erro
 	return out, nil
 }
 
+// Files returns the paths of the config and credentials files
+func Files() (string, string) {
+	return GetEnv("CONFIG_FILE", "config.yaml"), GetEnv("CREDENTIALS_FILE", "credentials.yaml")
+}
+
 func LoadConfig() (*Config, error) {
-	configFile := GetEnv("CONFIG_FILE", "config.yaml")
-	credentialsFile := GetEnv("CREDENTIALS_FILE", "credentials.yaml")
+	configFile, credentialsFile := Files()
 
 	logging.Logger.WithFields(map[string]interface{}{
 		"config_file":      configFile,
@@ -142,6 +385,11 @@ func LoadConfig() (*Config, error) {
 
 	// Apply default values after validation
 	applyAuthDefaults(&config.ServerSettings)
+	applyHistoryDefaults(&config.Scraping.History)
+	applySessionStoreDefaults(&config.ServerSettings.SessionStore)
+	applyConfigReloadDefaults(&config.ServerSettings.ConfigReload)
+	applyOnDemandDefaults(&config.Scraping.OnDemand)
+	applyNotificationDefaults(&config.Notifications)
 
 	return &config, nil
 }
@@ -214,6 +462,36 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	if err := validateHistoryConfig(config.Scraping.History); err != nil {
+		return err
+	}
+
+	if jitter := config.Scraping.JitterFraction(); jitter < 0 || jitter > 1 {
+		return fmt.Errorf("scraping config error: jitter must be between 0 and 1, got %v", jitter)
+	}
+	if config.Scraping.OnDemand.RateLimit < 0 {
+		return fmt.Errorf("scraping config error: on_demand rate_limit must not be negative, got %d", config.Scraping.OnDemand.RateLimit)
+	}
+
+	if err := validateNotificationsConfig(config.Notifications); err != nil {
+		return err
+	}
+
+	windowNames := make(map[string]bool)
+	for _, window := range config.Maintenance.Windows {
+		if err := ValidateMaintenanceWindow(window); err != nil {
+			return fmt.Errorf("maintenance config error: %w", err)
+		}
+		if windowNames[window.Name] {
+			return fmt.Errorf("maintenance config error: duplicate window name %q", window.Name)
+		}
+		windowNames[window.Name] = true
+	}
+
+	if err := validatePositiveDuration(config.ServerSettings.ConfigReload.Interval, "config_reload interval"); err != nil {
+		return fmt.Errorf("server settings config error: %w", err)
+	}
+
 	sourceNames := make(map[string]bool)
 	for _, source := range config.Sources {
 		if source.Name == "" {
@@ -233,6 +511,25 @@ func validateConfig(config *Config) error {
 		if err := validateLabels(source.Labels, fmt.Sprintf("source %s", source.Name)); err != nil {
 			return err
 		}
+
+		if err := validatePositiveDuration(source.Interval, "interval"); err != nil {
+			return fmt.Errorf("source config error: source %s: %w", source.Name, err)
+		}
+		if err := validatePositiveDuration(source.Timeout, "timeout"); err != nil {
+			return fmt.Errorf("source config error: source %s: %w", source.Name, err)
+		}
+
+		if err := validateDebounceSettings(source.Debounce); err != nil {
+			return fmt.Errorf("source config error: source %s: %w", source.Name, err)
+		}
+		appDebounce, err := DecodeConfig[AppDebounceConfig](source.Config, source.Name)
+		if err == nil {
+			for _, app := range appDebounce.Apps {
+				if err := validateDebounceSettings(app.Debounce); err != nil {
+					return fmt.Errorf("source config error: source %s app %s: %w", source.Name, app.Name, err)
+				}
+			}
+		}
 	}
 
 	return nil
@@ -249,6 +546,32 @@ func validateAuthConfig(serverSettings *ServerSettings) error {
 		}
 	}
 
+	if serverSettings.LocalUsers.Enabled {
+		if len(serverSettings.LocalUsers.Users) == 0 {
+			return fmt.Errorf("auth config error: local_users users are required when local users are enabled")
+		}
+		for username, user := range serverSettings.LocalUsers.Users {
+			if strings.TrimSpace(username) == "" {
+				return fmt.Errorf("auth config error: local_users has a user without a username")
+			}
+			if serverSettings.LocalAdmin.Enabled && username == serverSettings.LocalAdmin.Username {
+				return fmt.Errorf("auth config error: local user %q has the username of the local admin", username)
+			}
+			if !IsPasswordHash(user.PasswordHash) {
+				return fmt.Errorf("auth config error: local user %q password_hash must be a bcrypt or argon2id hash", username)
+			}
+		}
+	}
+
+	if serverSettings.LoginLockout.MaxAttempts < 0 {
+		return fmt.Errorf("auth config error: login_lockout max_attempts must not be negative, got %d", serverSettings.LoginLockout.MaxAttempts)
+	}
+	if serverSettings.LoginLockout.Duration != "" {
+		if duration, err := time.ParseDuration(serverSettings.LoginLockout.Duration); err != nil || duration <= 0 {
+			return fmt.Errorf("auth config error: login_lockout duration must be a positive duration, got %q", serverSettings.LoginLockout.Duration)
+		}
+	}
+
 	// Validate session timeout format if provided
 	if serverSettings.SessionTimeout != "" {
 		if _, err := time.ParseDuration(serverSettings.SessionTimeout); err != nil {
@@ -257,6 +580,16 @@ func validateAuthConfig(serverSettings *ServerSettings) error {
 		}
 	}
 
+	if err := validateSessionStoreConfig(serverSettings.SessionStore); err != nil {
+		return err
+	}
+
+	if serverSettings.APITokens.MaxTTL != "" {
+		if maxTTL, err := time.ParseDuration(serverSettings.APITokens.MaxTTL); err != nil || maxTTL <= 0 {
+			return fmt.Errorf("auth config error: api_tokens max_ttl must be a positive duration, got %q", serverSettings.APITokens.MaxTTL)
+		}
+	}
+
 	// If OIDC is enabled, validate configuration
 	if serverSettings.OIDC.Enabled {
 		if strings.TrimSpace(serverSettings.OIDC.Config.Issuer) == "" {
@@ -270,6 +603,20 @@ func validateAuthConfig(serverSettings *ServerSettings) error {
 		}
 	}
 
+	if serverSettings.LDAP.Enabled {
+		if err := validateLDAPConfig(serverSettings.LDAP); err != nil {
+			return err
+		}
+	}
+
+	for roleName, role := range serverSettings.Roles {
+		for _, permission := range role.Permissions {
+			if permission != PermissionCheck && permission != PermissionMaintenance {
+				return fmt.Errorf("auth config error: role %q has unknown permission %q (supported: %s, %s)", roleName, permission, PermissionCheck, PermissionMaintenance)
+			}
+		}
+	}
+
 	// Validate metrics auth configuration
 	if serverSettings.MetricsAuth.Enabled {
 		if strings.TrimSpace(serverSettings.MetricsAuth.Type) == "" {
@@ -296,6 +643,88 @@ func validateAuthConfig(serverSettings *ServerSettings) error {
 	return nil
 }
 
+// validateSessionStoreConfig validates where sessions are kept
+func validateSessionStoreConfig(store SessionStoreConfig) error {
+	switch store.Type {
+	case "", SessionStoreMemory:
+		return nil
+	case SessionStoreFile:
+		if strings.TrimSpace(store.Path) == "" {
+			return fmt.Errorf("auth config error: session_store path is required for the file session store")
+		}
+	case SessionStoreRedis:
+		if strings.TrimSpace(store.Address) == "" {
+			return fmt.Errorf("auth config error: session_store address is required for the redis session store")
+		}
+		if store.DB < 0 {
+			return fmt.Errorf("auth config error: session_store db must not be negative, got %d", store.DB)
+		}
+	case SessionStoreCookie:
+		if len(store.CookieKeys) == 0 {
+			return fmt.Errorf("auth config error: session_store cookie_keys are required for cookie sessions")
+		}
+		for i, key := range store.CookieKeys {
+			if len(key) < minSessionEncryptionKeyLength {
+				return fmt.Errorf("auth config error: session_store cookie_keys[%d] must be at least %d characters", i, minSessionEncryptionKeyLength)
+			}
+		}
+		if store.Denylist == SessionStoreCookie {
+			return fmt.Errorf("auth config error: session_store denylist must be '%s', '%s' or '%s'", SessionStoreMemory, SessionStoreFile, SessionStoreRedis)
+		}
+		if err := validateSessionStoreConfig(store.DenylistStore()); err != nil {
+			return fmt.Errorf("%w (session_store denylist)", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("auth config error: invalid session_store type %q, must be '%s', '%s', '%s' or '%s'",
+			store.Type, SessionStoreMemory, SessionStoreFile, SessionStoreRedis, SessionStoreCookie)
+	}
+
+	if len(store.EncryptionKey) < minSessionEncryptionKeyLength {
+		return fmt.Errorf("auth config error: session_store encryption_key of at least %d characters is required for the %s session store",
+			minSessionEncryptionKeyLength, store.Type)
+	}
+	return nil
+}
+
+// validateLDAPConfig validates the LDAP provider. Filters are checked when the provider is created.
+func validateLDAPConfig(ldap LDAPConfig) error {
+	if !strings.HasPrefix(ldap.URL, "ldap://") && !strings.HasPrefix(ldap.URL, "ldaps://") {
+		return fmt.Errorf("auth config error: LDAP url must start with ldap:// or ldaps://, got %q", ldap.URL)
+	}
+	if ldap.StartTLS && strings.HasPrefix(ldap.URL, "ldaps://") {
+		return fmt.Errorf("auth config error: LDAP start_tls can only be used with ldap:// urls")
+	}
+	if strings.TrimSpace(ldap.BaseDN) == "" {
+		return fmt.Errorf("auth config error: LDAP base_dn is required when LDAP is enabled")
+	}
+	if ldap.BindDN != "" && ldap.BindPassword == "" {
+		return fmt.Errorf("auth config error: LDAP bind_password is required with bind_dn")
+	}
+	if ldap.UserFilter != "" && !strings.Contains(ldap.UserFilter, "{username}") {
+		return fmt.Errorf("auth config error: LDAP user_filter must contain {username}")
+	}
+	if ldap.Timeout != "" {
+		if timeout, err := time.ParseDuration(ldap.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("auth config error: LDAP timeout must be a positive duration, got %q", ldap.Timeout)
+		}
+	}
+	return nil
+}
+
+// applySessionStoreDefaults sets default values for the session store
+func applySessionStoreDefaults(store *SessionStoreConfig) {
+	if store.Type == "" {
+		store.Type = SessionStoreMemory
+	}
+	if store.Type == SessionStoreCookie && store.Denylist == "" {
+		store.Denylist = SessionStoreMemory
+	}
+	if (store.Type == SessionStoreRedis || store.Denylist == SessionStoreRedis) && store.KeyPrefix == "" {
+		store.KeyPrefix = "site-availability:session:"
+	}
+}
+
 // applyAuthDefaults sets default values for authentication configuration
 // This should be called after validation, during config loading
 func applyAuthDefaults(serverSettings *ServerSettings) {
@@ -307,6 +736,63 @@ func applyAuthDefaults(serverSettings *ServerSettings) {
 			serverSettings.OIDC.Config.UserNameScope = "preferred_username" // Default value
 		}
 	}
+
+	if serverSettings.LDAP.Enabled {
+		ldap := &serverSettings.LDAP
+		if ldap.Name == "" {
+			ldap.Name = "LDAP"
+		}
+		if ldap.UserFilter == "" {
+			ldap.UserFilter = "(|(sAMAccountName={username})(uid={username}))"
+		}
+		if ldap.GroupBaseDN == "" {
+			ldap.GroupBaseDN = ldap.BaseDN
+		}
+		if ldap.GroupFilter == "" {
+			ldap.GroupFilter = "(|(member={dn})(uniqueMember={dn}))"
+		}
+		if ldap.GroupNameAttribute == "" {
+			ldap.GroupNameAttribute = "cn"
+		}
+		if ldap.Timeout == "" {
+			ldap.Timeout = "10s"
+		}
+	}
+}
+
+// validateHistoryConfig validates the status history settings
+func validateHistoryConfig(history HistorySettings) error {
+	if history.Retention != "" {
+		retention, err := time.ParseDuration(history.Retention)
+		if err != nil {
+			return fmt.Errorf("history config error: invalid retention format %q: %w", history.Retention, err)
+		}
+		if retention <= 0 {
+			return fmt.Errorf("history config error: retention must be positive, got %q", history.Retention)
+		}
+	}
+	return nil
+}
+
+// applyHistoryDefaults sets default values for the status history configuration
+func applyHistoryDefaults(history *HistorySettings) {
+	if strings.TrimSpace(history.Retention) == "" {
+		history.Retention = "720h" // 30 days
+	}
+}
+
+// applyConfigReloadDefaults sets default values for config reloading
+func applyConfigReloadDefaults(reload *ConfigReloadSettings) {
+	if reload.Interval == "" {
+		reload.Interval = "10s"
+	}
+}
+
+// applyOnDemandDefaults sets default values for on-demand checks
+func applyOnDemandDefaults(onDemand *OnDemandSettings) {
+	if onDemand.RateLimit == 0 {
+		onDemand.RateLimit = 6
+	}
 }
 
 func GetEnv(name, defaultValue string) string {
@@ -315,3 +801,176 @@ func GetEnv(name, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// validateDebounceSettings validates optional debounce settings
+func validateDebounceSettings(debounce *DebounceSettings) error {
+	if debounce == nil {
+		return nil
+	}
+	if debounce.FailuresBeforeDown < 0 {
+		return fmt.Errorf("debounce failures_before_down must not be negative")
+	}
+	if debounce.SuccessesBeforeUp < 0 {
+		return fmt.Errorf("debounce successes_before_up must not be negative")
+	}
+	if debounce.FlapThreshold < 0 {
+		return fmt.Errorf("debounce flap_threshold must not be negative")
+	}
+	if err := validatePositiveDuration(debounce.FlapWindow, "flap_window"); err != nil {
+		return fmt.Errorf("debounce %w", err)
+	}
+	return nil
+}
+
+// validateNotificationsConfig validates notification receivers and routes
+func validateNotificationsConfig(notifications Notifications) error {
+	receiverNames := make(map[string]bool)
+	for _, receiver := range notifications.Receivers {
+		if strings.TrimSpace(receiver.Name) == "" {
+			return fmt.Errorf("notifications config error: receiver name is required")
+		}
+		if receiverNames[receiver.Name] {
+			return fmt.Errorf("notifications config error: duplicate receiver name %q", receiver.Name)
+		}
+		receiverNames[receiver.Name] = true
+
+		switch receiver.Type {
+		case "webhook", "alertmanager", "slack":
+		default:
+			return fmt.Errorf("notifications config error: receiver %s has invalid type %q, must be 'webhook', 'alertmanager' or 'slack'", receiver.Name, receiver.Type)
+		}
+
+		parsedURL, err := url.Parse(receiver.URL)
+		if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+			return fmt.Errorf("notifications config error: receiver %s has invalid url %q", receiver.Name, receiver.URL)
+		}
+		if receiver.Template != "" && receiver.Type != "webhook" {
+			return fmt.Errorf("notifications config error: receiver %s: template is only supported by webhook receivers", receiver.Name)
+		}
+		if err := validatePositiveDuration(receiver.Timeout, "timeout"); err != nil {
+			return fmt.Errorf("notifications config error: receiver %s: %w", receiver.Name, err)
+		}
+	}
+
+	validStatuses := map[string]bool{"up": true, "degraded": true, "down": true, "unavailable": true, "maintenance": true}
+	for i, route := range notifications.Routes {
+		if !receiverNames[route.Receiver] {
+			return fmt.Errorf("notifications config error: route %d references unknown receiver %q", i, route.Receiver)
+		}
+		if _, err := labels.ParseMatchers(route.Matchers); err != nil {
+			return fmt.Errorf("notifications config error: route %d: %w", i, err)
+		}
+		for _, status := range route.Statuses {
+			if !validStatuses[status] {
+				return fmt.Errorf("notifications config error: route %d has invalid status %q", i, status)
+			}
+		}
+		if err := validatePositiveDuration(route.GroupWait, "group_wait"); err != nil {
+			return fmt.Errorf("notifications config error: route %d: %w", i, err)
+		}
+		if err := validatePositiveDuration(route.RepeatInterval, "repeat_interval"); err != nil {
+			return fmt.Errorf("notifications config error: route %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateMaintenanceWindow validates a maintenance window from the config or the API
+func ValidateMaintenanceWindow(window MaintenanceWindow) error {
+	if strings.TrimSpace(window.Name) == "" {
+		return fmt.Errorf("window name is required")
+	}
+	if len(window.Matchers) == 0 {
+		return fmt.Errorf("window %s: at least one matcher is required", window.Name)
+	}
+	matchers, err := labels.ParseMatchers(window.Matchers)
+	if err != nil {
+		return fmt.Errorf("window %s: %w", window.Name, err)
+	}
+	for _, matcher := range matchers {
+		// The status of apps in maintenance is replaced, so it can't select them
+		if matcher.Name == "status" {
+			return fmt.Errorf("window %s: matchers can't select apps by status", window.Name)
+		}
+	}
+
+	var start, end time.Time
+	if window.Start != "" {
+		parsed, err := time.Parse(time.RFC3339, window.Start)
+		if err != nil {
+			return fmt.Errorf("window %s: invalid start %q, must be RFC 3339 (e.g. 2025-01-15T02:00:00Z)", window.Name, window.Start)
+		}
+		start = parsed
+	}
+	if window.End != "" {
+		parsed, err := time.Parse(time.RFC3339, window.End)
+		if err != nil {
+			return fmt.Errorf("window %s: invalid end %q, must be RFC 3339 (e.g. 2025-01-15T04:00:00Z)", window.Name, window.End)
+		}
+		end = parsed
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return fmt.Errorf("window %s: end must be after start", window.Name)
+	}
+
+	if window.Schedule != "" {
+		if _, err := cron.Parse(window.Schedule); err != nil {
+			return fmt.Errorf("window %s: %w", window.Name, err)
+		}
+		if window.Duration == "" {
+			return fmt.Errorf("window %s: duration is required with a schedule", window.Name)
+		}
+		if err := validatePositiveDuration(window.Duration, "duration"); err != nil {
+			return fmt.Errorf("window %s: %w", window.Name, err)
+		}
+	} else {
+		if window.Duration != "" {
+			return fmt.Errorf("window %s: duration is only supported with a schedule", window.Name)
+		}
+		if start.IsZero() || end.IsZero() {
+			return fmt.Errorf("window %s: either start and end or a schedule and duration are required", window.Name)
+		}
+	}
+
+	if _, err := time.LoadLocation(window.Timezone); err != nil {
+		return fmt.Errorf("window %s: invalid timezone %q: %w", window.Name, window.Timezone, err)
+	}
+	return nil
+}
+
+// validatePositiveDuration checks an optional duration setting
+func validatePositiveDuration(value, name string) error {
+	if value == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s format %q: %w", name, value, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("%s must be positive, got %q", name, value)
+	}
+	return nil
+}
+
+// applyNotificationDefaults sets default values for notification receivers and routes
+func applyNotificationDefaults(notifications *Notifications) {
+	for i := range notifications.Receivers {
+		if notifications.Receivers[i].Timeout == "" {
+			notifications.Receivers[i].Timeout = "10s"
+		}
+	}
+	for i := range notifications.Routes {
+		route := &notifications.Routes[i]
+		if len(route.Statuses) == 0 {
+			route.Statuses = []string{"down"}
+		}
+		if route.GroupWait == "" {
+			route.GroupWait = "30s"
+		}
+		if route.RepeatInterval == "" {
+			route.RepeatInterval = "4h"
+		}
+	}
+}
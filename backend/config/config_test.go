@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	goyaml "gopkg.in/yaml.v2"
 )
@@ -945,3 +946,549 @@ func TestLoadConfig_AppliesDefaultsAfterValidation(t *testing.T) {
 			config.ServerSettings.OIDC.Config.UserNameScope)
 	}
 }
+
+func TestValidateHistoryConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		history HistorySettings
+		wantErr bool
+	}{
+		{name: "empty settings", history: HistorySettings{}, wantErr: false},
+		{name: "valid retention", history: HistorySettings{Retention: "168h"}, wantErr: false},
+		{name: "invalid retention", history: HistorySettings{Retention: "one week"}, wantErr: true},
+		{name: "negative retention", history: HistorySettings{Retention: "-1h"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHistoryConfig(tt.history)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateHistoryConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHistorySettingsDefaults(t *testing.T) {
+	history := HistorySettings{}
+	applyHistoryDefaults(&history)
+
+	if history.Retention != "720h" {
+		t.Errorf("Expected default retention '720h', got %q", history.Retention)
+	}
+	if !history.IsEnabled() {
+		t.Error("Expected history to be enabled by default")
+	}
+
+	disabled := false
+	history.Enabled = &disabled
+	if history.IsEnabled() {
+		t.Error("Expected history to be disabled when enabled is false")
+	}
+}
+
+func TestValidateSessionStoreConfig(t *testing.T) {
+	key := strings.Repeat("k", 32)
+	tests := []struct {
+		name    string
+		store   SessionStoreConfig
+		wantErr string
+	}{
+		{name: "default", store: SessionStoreConfig{}},
+		{name: "memory", store: SessionStoreConfig{Type: "memory"}},
+		{name: "file", store: SessionStoreConfig{Type: "file", Path: "/data/sessions.db", EncryptionKey: key}},
+		{name: "redis", store: SessionStoreConfig{Type: "redis", Address: "redis:6379", DB: 2, EncryptionKey: key}},
+		{name: "unknown type", store: SessionStoreConfig{Type: "bolt"}, wantErr: "invalid session_store type"},
+		{name: "file without path", store: SessionStoreConfig{Type: "file", EncryptionKey: key}, wantErr: "path is required"},
+		{name: "redis without address", store: SessionStoreConfig{Type: "redis", EncryptionKey: key}, wantErr: "address is required"},
+		{name: "negative db", store: SessionStoreConfig{Type: "redis", Address: "redis:6379", DB: -1, EncryptionKey: key}, wantErr: "db must not be negative"},
+		{name: "missing key", store: SessionStoreConfig{Type: "file", Path: "sessions.db"}, wantErr: "encryption_key"},
+		{name: "short key", store: SessionStoreConfig{Type: "redis", Address: "redis:6379", EncryptionKey: "secret"}, wantErr: "at least 32 characters"},
+		{name: "cookie", store: SessionStoreConfig{Type: "cookie", CookieKeys: []string{key, key + "old"}}},
+		{name: "cookie with redis denylist", store: SessionStoreConfig{Type: "cookie", CookieKeys: []string{key}, Denylist: "redis", Address: "redis:6379", EncryptionKey: key}},
+		{name: "cookie without keys", store: SessionStoreConfig{Type: "cookie"}, wantErr: "cookie_keys are required"},
+		{name: "short cookie key", store: SessionStoreConfig{Type: "cookie", CookieKeys: []string{key, "old"}}, wantErr: "cookie_keys[1] must be at least 32 characters"},
+		{name: "cookie denylist", store: SessionStoreConfig{Type: "cookie", CookieKeys: []string{key}, Denylist: "cookie"}, wantErr: "denylist must be"},
+		{name: "invalid denylist", store: SessionStoreConfig{Type: "cookie", CookieKeys: []string{key}, Denylist: "file"}, wantErr: "path is required for the file session store (session_store denylist)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuthConfig(&ServerSettings{SessionStore: tt.store})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected session store to be valid, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSessionStoreDefaults(t *testing.T) {
+	store := SessionStoreConfig{}
+	applySessionStoreDefaults(&store)
+	if store.Type != SessionStoreMemory {
+		t.Errorf("Expected default session store type %q, got %q", SessionStoreMemory, store.Type)
+	}
+	if store.KeyPrefix != "" {
+		t.Errorf("Expected no key prefix for the memory store, got %q", store.KeyPrefix)
+	}
+
+	store = SessionStoreConfig{Type: SessionStoreRedis}
+	applySessionStoreDefaults(&store)
+	if store.KeyPrefix != "site-availability:session:" {
+		t.Errorf("Expected default Redis key prefix, got %q", store.KeyPrefix)
+	}
+
+	store = SessionStoreConfig{Type: SessionStoreCookie, CookieKeys: []string{"key"}}
+	applySessionStoreDefaults(&store)
+	if store.Denylist != SessionStoreMemory {
+		t.Errorf("Expected cookie sessions to keep the denylist in memory by default, got %q", store.Denylist)
+	}
+	denylist := store.DenylistStore()
+	if denylist.Type != SessionStoreMemory || denylist.CookieKeys != nil {
+		t.Errorf("Expected denylist store settings without cookie keys, got %+v", denylist)
+	}
+}
+
+func TestValidateNotificationsConfig(t *testing.T) {
+	webhook := NotificationReceiver{Name: "ops", Type: "webhook", URL: "https://hooks.example.com/ops"}
+
+	tests := []struct {
+		name          string
+		notifications Notifications
+		wantErr       string
+	}{
+		{name: "empty settings", notifications: Notifications{}},
+		{
+			name: "valid receivers and route",
+			notifications: Notifications{
+				Receivers: []NotificationReceiver{
+					webhook,
+					{Name: "am", Type: "alertmanager", URL: "http://alertmanager:9093", Timeout: "5s"},
+				},
+				Routes: []NotificationRoute{
+					{Receiver: "ops", Matchers: []string{`env="production"`}, Statuses: []string{"down", "unavailable"}, GroupWait: "10s", RepeatInterval: "1h"},
+				},
+			},
+		},
+		{
+			name:          "missing receiver name",
+			notifications: Notifications{Receivers: []NotificationReceiver{{Type: "slack", URL: "https://hooks.slack.com/x"}}},
+			wantErr:       "receiver name is required",
+		},
+		{
+			name:          "duplicate receiver",
+			notifications: Notifications{Receivers: []NotificationReceiver{webhook, webhook}},
+			wantErr:       "duplicate receiver name",
+		},
+		{
+			name:          "invalid type",
+			notifications: Notifications{Receivers: []NotificationReceiver{{Name: "pager", Type: "pagerduty", URL: "https://example.com"}}},
+			wantErr:       "invalid type",
+		},
+		{
+			name:          "invalid url",
+			notifications: Notifications{Receivers: []NotificationReceiver{{Name: "ops", Type: "webhook", URL: "hooks.example.com"}}},
+			wantErr:       "invalid url",
+		},
+		{
+			name:          "template on slack receiver",
+			notifications: Notifications{Receivers: []NotificationReceiver{{Name: "chat", Type: "slack", URL: "https://hooks.slack.com/x", Template: "{{ .Status }}"}}},
+			wantErr:       "template is only supported by webhook receivers",
+		},
+		{
+			name:          "unknown route receiver",
+			notifications: Notifications{Receivers: []NotificationReceiver{webhook}, Routes: []NotificationRoute{{Receiver: "missing"}}},
+			wantErr:       "unknown receiver",
+		},
+		{
+			name:          "invalid matcher",
+			notifications: Notifications{Receivers: []NotificationReceiver{webhook}, Routes: []NotificationRoute{{Receiver: "ops", Matchers: []string{"env"}}}},
+			wantErr:       "invalid matcher",
+		},
+		{
+			name:          "invalid status",
+			notifications: Notifications{Receivers: []NotificationReceiver{webhook}, Routes: []NotificationRoute{{Receiver: "ops", Statuses: []string{"broken"}}}},
+			wantErr:       "invalid status",
+		},
+		{
+			name:          "negative repeat interval",
+			notifications: Notifications{Receivers: []NotificationReceiver{webhook}, Routes: []NotificationRoute{{Receiver: "ops", RepeatInterval: "-1h"}}},
+			wantErr:       "repeat_interval must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotificationsConfig(tt.notifications)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateNotificationsConfig() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateNotificationsConfig() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotificationDefaults(t *testing.T) {
+	notifications := Notifications{
+		Receivers: []NotificationReceiver{{Name: "ops", Type: "webhook", URL: "https://hooks.example.com"}},
+		Routes:    []NotificationRoute{{Receiver: "ops"}},
+	}
+	applyNotificationDefaults(&notifications)
+
+	if notifications.Receivers[0].Timeout != "10s" {
+		t.Errorf("Expected default timeout '10s', got %q", notifications.Receivers[0].Timeout)
+	}
+	route := notifications.Routes[0]
+	if len(route.Statuses) != 1 || route.Statuses[0] != "down" {
+		t.Errorf("Expected default statuses [down], got %v", route.Statuses)
+	}
+	if route.GroupWait != "30s" || route.RepeatInterval != "4h" {
+		t.Errorf("Expected default group_wait '30s' and repeat_interval '4h', got %q and %q", route.GroupWait, route.RepeatInterval)
+	}
+	if !route.ShouldSendResolved() {
+		t.Error("Expected resolved notifications to be sent by default")
+	}
+}
+
+func TestValidateDebounceSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		debounce *DebounceSettings
+		wantErr  string
+	}{
+		{name: "not set"},
+		{name: "valid settings", debounce: &DebounceSettings{FailuresBeforeDown: 3, SuccessesBeforeUp: 2, FlapWindow: "10m", FlapThreshold: 4}},
+		{name: "negative failures", debounce: &DebounceSettings{FailuresBeforeDown: -1}, wantErr: "failures_before_down"},
+		{name: "negative successes", debounce: &DebounceSettings{SuccessesBeforeUp: -1}, wantErr: "successes_before_up"},
+		{name: "negative threshold", debounce: &DebounceSettings{FlapThreshold: -2}, wantErr: "flap_threshold"},
+		{name: "invalid window", debounce: &DebounceSettings{FlapWindow: "soon"}, wantErr: "flap_window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDebounceSettings(tt.debounce)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateDebounceSettings() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateDebounceSettings() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("per-app settings are validated", func(t *testing.T) {
+		cfg := &Config{
+			ServerSettings: ServerSettings{Port: "8080", HostURL: "https://example.com"},
+			Locations:      []Location{{Name: "Test Location", Latitude: 40.7128, Longitude: -74.0060}},
+			Sources: []Source{{
+				Name: "http-checks",
+				Type: "http",
+				Config: map[string]interface{}{
+					"apps": []interface{}{
+						map[string]interface{}{"name": "payments", "debounce": map[string]interface{}{"flap_window": "-5m"}},
+					},
+				},
+			}},
+		}
+		err := validateConfig(cfg)
+		if err == nil || !strings.Contains(err.Error(), "app payments") {
+			t.Errorf("validateConfig() error = %v, want error for app payments", err)
+		}
+	})
+}
+
+func TestConfigReloadSettings(t *testing.T) {
+	var reload ConfigReloadSettings
+	if !reload.IsWatchEnabled() {
+		t.Error("IsWatchEnabled() = false, want true by default")
+	}
+	applyConfigReloadDefaults(&reload)
+	if reload.Interval != "10s" {
+		t.Errorf("Interval = %q, want 10s", reload.Interval)
+	}
+
+	watch := false
+	reload = ConfigReloadSettings{Watch: &watch, Interval: "1m"}
+	applyConfigReloadDefaults(&reload)
+	if reload.IsWatchEnabled() || reload.Interval != "1m" {
+		t.Errorf("explicit settings were changed: %+v", reload)
+	}
+}
+
+func TestValidateScheduleSettings(t *testing.T) {
+	jitter := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name    string
+		jitter  *float64
+		source  Source
+		wantErr string
+	}{
+		{name: "defaults", source: Source{Name: "ping", Type: "http"}},
+		{name: "source overrides", jitter: jitter(0.25), source: Source{Name: "fleet", Type: "prometheus", Interval: "5m", Timeout: "45s"}},
+		{name: "jitter disabled", jitter: jitter(0), source: Source{Name: "ping", Type: "http"}},
+		{name: "jitter too large", jitter: jitter(1.5), source: Source{Name: "ping", Type: "http"}, wantErr: "jitter"},
+		{name: "negative jitter", jitter: jitter(-0.1), source: Source{Name: "ping", Type: "http"}, wantErr: "jitter"},
+		{name: "invalid interval", source: Source{Name: "ping", Type: "http", Interval: "often"}, wantErr: "interval"},
+		{name: "zero timeout", source: Source{Name: "ping", Type: "http", Timeout: "0s"}, wantErr: "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ServerSettings: ServerSettings{Port: "8080", HostURL: "https://example.com"},
+				Scraping:       ScrapingSettings{Interval: "60s", Timeout: "10s", Jitter: tt.jitter},
+				Locations:      []Location{{Name: "Test Location", Latitude: 40.7128, Longitude: -74.0060}},
+				Sources:        []Source{tt.source},
+			}
+			err := validateConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateConfig() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateConfig() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := (ScrapingSettings{}).JitterFraction(); got != DefaultJitter {
+		t.Errorf("JitterFraction() = %v, want default %v", got, DefaultJitter)
+	}
+}
+
+func TestOnDemandSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    map[string]RoleConfig
+		onDemand OnDemandSettings
+		wantErr  string
+	}{
+		{name: "defaults"},
+		{name: "check permission", roles: map[string]RoleConfig{"operator": {Permissions: []string{PermissionCheck}, Labels: map[string]string{"team": "sre"}}}},
+		{name: "unknown permission", roles: map[string]RoleConfig{"operator": {Permissions: []string{"delete"}}}, wantErr: "unknown permission"},
+		{name: "negative rate limit", onDemand: OnDemandSettings{RateLimit: -1}, wantErr: "rate_limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ServerSettings: ServerSettings{Port: "8080", HostURL: "https://example.com", Roles: tt.roles},
+				Scraping:       ScrapingSettings{Interval: "60s", Timeout: "10s", OnDemand: tt.onDemand},
+				Locations:      []Location{{Name: "Test Location", Latitude: 40.7128, Longitude: -74.0060}},
+			}
+			err := validateConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateConfig() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateConfig() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	onDemand := OnDemandSettings{}
+	applyOnDemandDefaults(&onDemand)
+	if onDemand.RateLimit != 6 {
+		t.Errorf("applyOnDemandDefaults() rate_limit = %d, want 6", onDemand.RateLimit)
+	}
+}
+
+func TestValidateMaintenanceConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []MaintenanceWindow
+		wantErr string
+	}{
+		{name: "no windows"},
+		{name: "fixed window", windows: []MaintenanceWindow{{Name: "upgrade", Matchers: []string{`name="payments"`}, Start: "2025-01-15T02:00:00Z", End: "2025-01-15T04:00:00Z"}}},
+		{name: "scheduled window", windows: []MaintenanceWindow{{Name: "weekly", Matchers: []string{`location=~"eu-.*"`}, Schedule: "0 2 * * sun", Duration: "2h", Timezone: "Europe/Berlin"}}},
+		{name: "missing name", windows: []MaintenanceWindow{{Matchers: []string{`name="payments"`}, Schedule: "0 2 * * *", Duration: "1h"}}, wantErr: "name is required"},
+		{name: "duplicate name", windows: []MaintenanceWindow{
+			{Name: "weekly", Matchers: []string{`name="payments"`}, Schedule: "0 2 * * *", Duration: "1h"},
+			{Name: "weekly", Matchers: []string{`name="search"`}, Schedule: "0 3 * * *", Duration: "1h"},
+		}, wantErr: "duplicate window name"},
+		{name: "missing matchers", windows: []MaintenanceWindow{{Name: "all", Schedule: "0 2 * * *", Duration: "1h"}}, wantErr: "at least one matcher"},
+		{name: "invalid matcher", windows: []MaintenanceWindow{{Name: "bad", Matchers: []string{`name`}, Schedule: "0 2 * * *", Duration: "1h"}}, wantErr: "window bad"},
+		{name: "end before start", windows: []MaintenanceWindow{{Name: "upgrade", Matchers: []string{`name="payments"`}, Start: "2025-01-15T04:00:00Z", End: "2025-01-15T02:00:00Z"}}, wantErr: "end must be after start"},
+		{name: "missing end", windows: []MaintenanceWindow{{Name: "upgrade", Matchers: []string{`name="payments"`}, Start: "2025-01-15T02:00:00Z"}}, wantErr: "either start and end"},
+		{name: "invalid start", windows: []MaintenanceWindow{{Name: "upgrade", Matchers: []string{`name="payments"`}, Start: "tomorrow", End: "2025-01-15T02:00:00Z"}}, wantErr: "invalid start"},
+		{name: "invalid schedule", windows: []MaintenanceWindow{{Name: "weekly", Matchers: []string{`name="payments"`}, Schedule: "0 2 * *", Duration: "1h"}}, wantErr: "invalid cron expression"},
+		{name: "schedule without duration", windows: []MaintenanceWindow{{Name: "weekly", Matchers: []string{`name="payments"`}, Schedule: "0 2 * * *"}}, wantErr: "duration is required"},
+		{name: "duration without schedule", windows: []MaintenanceWindow{{Name: "upgrade", Matchers: []string{`name="payments"`}, Start: "2025-01-15T02:00:00Z", End: "2025-01-15T04:00:00Z", Duration: "1h"}}, wantErr: "only supported with a schedule"},
+		{name: "invalid timezone", windows: []MaintenanceWindow{{Name: "weekly", Matchers: []string{`name="payments"`}, Schedule: "0 2 * * *", Duration: "1h", Timezone: "Mars/Olympus"}}, wantErr: "invalid timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ServerSettings: ServerSettings{Port: "8080", HostURL: "https://example.com"},
+				Scraping:       ScrapingSettings{Interval: "60s", Timeout: "10s"},
+				Locations:      []Location{{Name: "Test Location", Latitude: 40.7128, Longitude: -74.0060}},
+				Maintenance:    Maintenance{Windows: tt.windows},
+			}
+			err := validateConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateConfig() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateConfig() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAPITokensConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  APITokensConfig
+		wantErr bool
+	}{
+		{name: "default", tokens: APITokensConfig{}},
+		{name: "file and max ttl", tokens: APITokensConfig{Path: "/data/api-tokens.json", MaxTTL: "2160h"}},
+		{name: "invalid max ttl", tokens: APITokensConfig{MaxTTL: "90 days"}, wantErr: true},
+		{name: "negative max ttl", tokens: APITokensConfig{MaxTTL: "-1h"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuthConfig(&ServerSettings{APITokens: tt.tokens})
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "api_tokens max_ttl")) {
+				t.Errorf("Expected api_tokens max_ttl error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected API tokens config to be valid, got error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateLocalUsersConfig(t *testing.T) {
+	bcryptHash := "$2b$12$rDKx8UXp3F8P7xYV9oGzTeBN6K8aHVWHZxXzGQQJ8E1QXh8l2F9Da"
+	argon2Hash := "$argon2id$v=19$m=65536,t=3,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG"
+	admin := LocalAdminConfig{Enabled: true, Username: "admin", Password: "password"}
+	tests := []struct {
+		name     string
+		settings ServerSettings
+		wantErr  string
+	}{
+		{name: "disabled", settings: ServerSettings{LocalUsers: LocalUsersConfig{Users: map[string]LocalUserConfig{"alice": {}}}}},
+		{name: "bcrypt and argon2id", settings: ServerSettings{LocalAdmin: admin, LocalUsers: LocalUsersConfig{Enabled: true, Users: map[string]LocalUserConfig{
+			"alice": {PasswordHash: bcryptHash, Roles: []string{"viewer"}},
+			"bob":   {PasswordHash: argon2Hash},
+		}}}},
+		{name: "no users", settings: ServerSettings{LocalUsers: LocalUsersConfig{Enabled: true}}, wantErr: "users are required"},
+		{name: "plaintext password", settings: ServerSettings{LocalUsers: LocalUsersConfig{Enabled: true, Users: map[string]LocalUserConfig{"alice": {PasswordHash: "secret"}}}}, wantErr: `local user "alice" password_hash must be a bcrypt or argon2id hash`},
+		{name: "admin username", settings: ServerSettings{LocalAdmin: admin, LocalUsers: LocalUsersConfig{Enabled: true, Users: map[string]LocalUserConfig{"admin": {PasswordHash: bcryptHash}}}}, wantErr: "username of the local admin"},
+		{name: "negative attempts", settings: ServerSettings{LoginLockout: LoginLockoutConfig{MaxAttempts: -1}}, wantErr: "max_attempts must not be negative"},
+		{name: "invalid lockout duration", settings: ServerSettings{LoginLockout: LoginLockoutConfig{Duration: "forever"}}, wantErr: "login_lockout duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuthConfig(&tt.settings)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected local users to be valid, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoginLockoutDefaults(t *testing.T) {
+	var lockout LoginLockoutConfig
+	if lockout.Attempts() != 5 {
+		t.Errorf("Expected 5 attempts by default, got %d", lockout.Attempts())
+	}
+	if lockout.LockDuration() != 15*time.Minute {
+		t.Errorf("Expected a 15m lock by default, got %v", lockout.LockDuration())
+	}
+
+	lockout = LoginLockoutConfig{MaxAttempts: 3, Duration: "1h"}
+	if lockout.Attempts() != 3 || lockout.LockDuration() != time.Hour {
+		t.Errorf("Expected 3 attempts and a 1h lock, got %d and %v", lockout.Attempts(), lockout.LockDuration())
+	}
+}
+
+func TestValidateLDAPConfig(t *testing.T) {
+	valid := LDAPConfig{
+		Enabled:      true,
+		URL:          "ldaps://dc.example.com:636",
+		BindDN:       "CN=svc,OU=Service,DC=example,DC=com",
+		BindPassword: "secret",
+		BaseDN:       "DC=example,DC=com",
+	}
+	tests := []struct {
+		name    string
+		modify  func(*LDAPConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*LDAPConfig) {}},
+		{name: "disabled", modify: func(l *LDAPConfig) { l.Enabled = false; l.URL = "" }},
+		{name: "anonymous search", modify: func(l *LDAPConfig) { l.BindDN = ""; l.BindPassword = "" }},
+		{name: "start tls", modify: func(l *LDAPConfig) { l.URL = "ldap://dc.example.com"; l.StartTLS = true }},
+		{name: "invalid url", modify: func(l *LDAPConfig) { l.URL = "dc.example.com:389" }, wantErr: "must start with ldap://"},
+		{name: "start tls with ldaps", modify: func(l *LDAPConfig) { l.StartTLS = true }, wantErr: "start_tls can only be used"},
+		{name: "missing base dn", modify: func(l *LDAPConfig) { l.BaseDN = "" }, wantErr: "base_dn is required"},
+		{name: "missing bind password", modify: func(l *LDAPConfig) { l.BindPassword = "" }, wantErr: "bind_password is required"},
+		{name: "user filter without username", modify: func(l *LDAPConfig) { l.UserFilter = "(uid=alice)" }, wantErr: "must contain {username}"},
+		{name: "invalid timeout", modify: func(l *LDAPConfig) { l.Timeout = "soon" }, wantErr: "timeout must be a positive duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ldap := valid
+			tt.modify(&ldap)
+			err := validateAuthConfig(&ServerSettings{LDAP: ldap})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected LDAP config to be valid, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestApplyAuthDefaults_LDAP(t *testing.T) {
+	settings := ServerSettings{LDAP: LDAPConfig{Enabled: true, BaseDN: "dc=example,dc=com"}}
+	applyAuthDefaults(&settings)
+
+	ldap := settings.LDAP
+	if ldap.Name != "LDAP" || ldap.GroupBaseDN != "dc=example,dc=com" || ldap.GroupNameAttribute != "cn" || ldap.Timeout != "10s" {
+		t.Errorf("Expected LDAP defaults, got %+v", ldap)
+	}
+	if !strings.Contains(ldap.UserFilter, "{username}") || !strings.Contains(ldap.GroupFilter, "{dn}") {
+		t.Errorf("Expected default LDAP filters, got %q and %q", ldap.UserFilter, ldap.GroupFilter)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"site-availability/logging"
+)
+
+// Watcher polls files and reports when their content changes. Comparing content instead of
+// file events also catches atomic replacements such as Kubernetes ConfigMap symlink swaps.
+type Watcher struct {
+	files    []string
+	interval time.Duration
+	hashes   map[string][sha256.Size]byte
+}
+
+// NewWatcher creates a watcher for the given files, remembering their current content
+func NewWatcher(interval time.Duration, files ...string) *Watcher {
+	w := &Watcher{files: files, interval: interval, hashes: make(map[string][sha256.Size]byte)}
+	w.Changed()
+	return w
+}
+
+// Changed reports whether any file changed since the previous call.
+// A missing file counts as empty, so creating or deleting an optional file is a change too.
+func (w *Watcher) Changed() bool {
+	changed := false
+	for _, file := range w.files {
+		content, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			logging.Logger.WithError(err).WithField("file", file).Warn("Failed to read watched config file")
+			continue
+		}
+
+		hash := sha256.Sum256(content)
+		if previous, ok := w.hashes[file]; ok && previous != hash {
+			changed = true
+		}
+		w.hashes[file] = hash
+	}
+	return changed
+}
+
+// Run calls onChange after every change until stop is closed
+func (w *Watcher) Run(stop <-chan struct{}, onChange func()) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if w.Changed() {
+				onChange()
+			}
+		}
+	}
+}
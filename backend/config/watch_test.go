@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherChanged(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	credentialsFile := filepath.Join(dir, "credentials.yaml")
+	if err := os.WriteFile(configFile, []byte("scraping:\n  interval: 10s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := NewWatcher(time.Hour, configFile, credentialsFile)
+	if watcher.Changed() {
+		t.Error("Changed() = true without any change")
+	}
+
+	if err := os.WriteFile(configFile, []byte("scraping:\n  interval: 20s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !watcher.Changed() {
+		t.Error("Changed() = false after modifying the config file")
+	}
+	if watcher.Changed() {
+		t.Error("Changed() = true twice for the same change")
+	}
+
+	// A file that did not exist before is a change too
+	if err := os.WriteFile(credentialsFile, []byte("server_settings:\n  token: secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !watcher.Changed() {
+		t.Error("Changed() = false after creating the credentials file")
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := NewWatcher(10*time.Millisecond, configFile)
+	changes := make(chan struct{}, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go watcher.Run(stop, func() { changes <- struct{}{} })
+
+	if err := os.WriteFile(configFile, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a change notification")
+	}
+}
@@ -0,0 +1,268 @@
+package debounce
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/logging"
+)
+
+// DefaultFlapThreshold is the number of status changes within the flap window that marks an app as flapping
+const DefaultFlapThreshold = 5
+
+// Settings are the resolved debounce settings of an app
+type Settings struct {
+	FailuresBeforeDown int
+	SuccessesBeforeUp  int
+	FlapWindow         time.Duration
+	FlapThreshold      int
+}
+
+// enabled reports whether the settings change anything compared to publishing every result
+func (s Settings) enabled() bool {
+	return s.FailuresBeforeDown > 1 || s.SuccessesBeforeUp > 1 || s.FlapWindow > 0
+}
+
+// merge returns the settings with the fields set in override replaced
+func (s Settings) merge(override *config.DebounceSettings) (Settings, error) {
+	if override == nil {
+		return s, nil
+	}
+	if override.FailuresBeforeDown > 0 {
+		s.FailuresBeforeDown = override.FailuresBeforeDown
+	}
+	if override.SuccessesBeforeUp > 0 {
+		s.SuccessesBeforeUp = override.SuccessesBeforeUp
+	}
+	if override.FlapThreshold > 0 {
+		s.FlapThreshold = override.FlapThreshold
+	}
+	if override.FlapWindow != "" {
+		window, err := time.ParseDuration(override.FlapWindow)
+		if err != nil {
+			return s, fmt.Errorf("invalid flap_window: %w", err)
+		}
+		s.FlapWindow = window
+	}
+	return s, nil
+}
+
+// SourceSettings resolves the debounce settings of a source and the per-app overrides from its config
+func SourceSettings(source config.Source) (Settings, map[string]Settings, error) {
+	defaults := Settings{FailuresBeforeDown: 1, SuccessesBeforeUp: 1, FlapThreshold: DefaultFlapThreshold}
+	sourceSettings, err := defaults.merge(source.Debounce)
+	if err != nil {
+		return defaults, nil, err
+	}
+
+	appSettings := make(map[string]Settings)
+	appConfig, err := config.DecodeConfig[config.AppDebounceConfig](source.Config, source.Name)
+	if err != nil {
+		// Sources without an apps list have no per-app overrides
+		return sourceSettings, appSettings, nil
+	}
+	for _, app := range appConfig.Apps {
+		if app.Debounce == nil {
+			continue
+		}
+		settings, err := sourceSettings.merge(app.Debounce)
+		if err != nil {
+			return sourceSettings, nil, fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		appSettings[app.Name] = settings
+	}
+	return sourceSettings, appSettings, nil
+}
+
+// appState tracks the raw results of an app
+type appState struct {
+	published string      // status last written to the cache
+	reason    string      // failure reason of the result that confirmed the published status
+	lastRaw   string      // status of the latest raw result
+	pending   int         // consecutive results pointing away from the published status
+	direction int         // +1 when pending results are worse than published, -1 when better
+	changes   []time.Time // raw status changes within the flap window
+}
+
+// Debouncer decides which of the raw scrape results are published as app status.
+// A change to a worse status is published after failures_before_down consecutive results,
+// a change to a better status after successes_before_up. Changes between statuses of the
+// same severity (down and unavailable) are published immediately.
+type Debouncer struct {
+	mu     sync.Mutex
+	states map[string]map[string]*appState // source -> origin|app -> state
+}
+
+// New creates an empty debouncer
+func New() *Debouncer {
+	return &Debouncer{states: make(map[string]map[string]*appState)}
+}
+
+// Apply returns the statuses to publish for one scrape of a source. Statuses are returned unchanged
+// when the source has no debounce settings. State of apps that are no longer reported is dropped.
+// Apply is called from the scrape loop of the source; different sources may call it concurrently.
+func (d *Debouncer) Apply(source config.Source, statuses []handlers.AppStatus, now time.Time) []handlers.AppStatus {
+	return d.apply(source, statuses, now, false)
+}
+
+// ApplyApps is like Apply for a scrape that only checked some apps of the source.
+// The state of the other apps is kept.
+func (d *Debouncer) ApplyApps(source config.Source, statuses []handlers.AppStatus, now time.Time) []handlers.AppStatus {
+	return d.apply(source, statuses, now, true)
+}
+
+// apply debounces the statuses of one scrape. A partial scrape keeps the state of apps it did not report.
+func (d *Debouncer) apply(source config.Source, statuses []handlers.AppStatus, now time.Time, partial bool) []handlers.AppStatus {
+	sourceSettings, appSettings, err := SourceSettings(source)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("source", source.Name).Error("Invalid debounce settings, publishing raw results")
+		return statuses
+	}
+
+	previous := d.sourceStates(source.Name)
+	current := make(map[string]*appState, len(statuses))
+	if partial {
+		for key, state := range previous {
+			current[key] = state
+		}
+	}
+	result := make([]handlers.AppStatus, len(statuses))
+
+	for i, status := range statuses {
+		result[i] = status
+
+		settings, ok := appSettings[status.Name]
+		if !ok {
+			settings = sourceSettings
+		}
+		if !settings.enabled() {
+			continue
+		}
+
+		key := status.OriginURL + "|" + status.Name
+		state, ok := previous[key]
+		if !ok {
+			state = &appState{published: status.Status, lastRaw: status.Status}
+		}
+		current[key] = state
+
+		result[i].Status = state.observe(status.Status, settings, now)
+		result[i].Flapping = state.flapping(settings, now)
+		if result[i].Status == status.Status {
+			state.reason = status.Reason
+		} else {
+			// The reason of a held back result would contradict the published status
+			result[i].Reason = state.reason
+		}
+
+		if result[i].Status != status.Status {
+			logging.Logger.WithFields(map[string]interface{}{
+				"source":    source.Name,
+				"app":       status.Name,
+				"raw":       status.Status,
+				"published": result[i].Status,
+				"pending":   state.pending,
+			}).Debug("Holding back status change")
+		}
+	}
+
+	d.setSourceStates(source.Name, current)
+	return result
+}
+
+// Remove drops the state of a source, so a source that is removed from the configuration leaves nothing behind
+func (d *Debouncer) Remove(source string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.states, source)
+}
+
+// sourceStates returns the app states of a source
+func (d *Debouncer) sourceStates(source string) map[string]*appState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.states[source]
+}
+
+// setSourceStates replaces the app states of a source
+func (d *Debouncer) setSourceStates(source string, states map[string]*appState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(states) == 0 {
+		delete(d.states, source)
+		return
+	}
+	d.states[source] = states
+}
+
+// observe records a raw result and returns the status to publish
+func (s *appState) observe(raw string, settings Settings, now time.Time) string {
+	if raw != s.lastRaw {
+		s.changes = append(s.changes, now)
+		s.lastRaw = raw
+	}
+
+	rawSeverity, publishedSeverity := severity(raw), severity(s.published)
+	switch {
+	case raw == s.published:
+		s.pending = 0
+	case rawSeverity == publishedSeverity:
+		s.publish(raw)
+	default:
+		direction := 1
+		required := settings.FailuresBeforeDown
+		if rawSeverity < publishedSeverity {
+			direction = -1
+			required = settings.SuccessesBeforeUp
+		}
+		if direction != s.direction {
+			s.direction = direction
+			s.pending = 0
+		}
+		s.pending++
+		if s.pending >= required {
+			s.publish(raw)
+		}
+	}
+	return s.published
+}
+
+// publish makes a raw status the published one
+func (s *appState) publish(status string) {
+	s.published = status
+	s.pending = 0
+	s.direction = 0
+}
+
+// flapping drops changes outside the flap window and reports whether the app is flapping
+func (s *appState) flapping(settings Settings, now time.Time) bool {
+	if settings.FlapWindow <= 0 {
+		s.changes = nil
+		return false
+	}
+
+	cutoff := now.Add(-settings.FlapWindow)
+	kept := s.changes[:0]
+	for _, change := range s.changes {
+		if change.After(cutoff) {
+			kept = append(kept, change)
+		}
+	}
+	s.changes = kept
+	return len(s.changes) >= settings.FlapThreshold
+}
+
+// severity orders statuses from healthy to failing
+func severity(status string) int {
+	switch status {
+	case "up":
+		return 0
+	case "degraded":
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,215 @@
+package debounce
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+}
+
+func appStatus(name, status string) handlers.AppStatus {
+	return handlers.AppStatus{Name: name, Location: "eu-west", Status: status, Source: "http-checks", OriginURL: "https://status.example.com"}
+}
+
+// publishSequence feeds one raw status per scrape and returns the published statuses
+func publishSequence(d *Debouncer, source config.Source, raw ...string) []string {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	published := make([]string, 0, len(raw))
+	for i, status := range raw {
+		result := d.Apply(source, []handlers.AppStatus{appStatus("payments", status)}, start.Add(time.Duration(i)*time.Minute))
+		published = append(published, result[0].Status)
+	}
+	return published
+}
+
+func TestSourceSettings(t *testing.T) {
+	source := config.Source{
+		Name:     "http-checks",
+		Debounce: &config.DebounceSettings{FailuresBeforeDown: 3, FlapWindow: "10m"},
+		Config: map[string]interface{}{
+			"apps": []interface{}{
+				map[string]interface{}{"name": "payments", "url": "https://payments.example.com", "debounce": map[string]interface{}{"successes_before_up": 2}},
+				map[string]interface{}{"name": "search", "url": "https://search.example.com"},
+			},
+		},
+	}
+
+	sourceSettings, appSettings, err := SourceSettings(source)
+	require.NoError(t, err)
+	assert.Equal(t, Settings{FailuresBeforeDown: 3, SuccessesBeforeUp: 1, FlapWindow: 10 * time.Minute, FlapThreshold: DefaultFlapThreshold}, sourceSettings)
+	assert.Equal(t, Settings{FailuresBeforeDown: 3, SuccessesBeforeUp: 2, FlapWindow: 10 * time.Minute, FlapThreshold: DefaultFlapThreshold}, appSettings["payments"])
+	assert.NotContains(t, appSettings, "search")
+
+	t.Run("invalid flap window", func(t *testing.T) {
+		_, _, err := SourceSettings(config.Source{Name: "bad", Debounce: &config.DebounceSettings{FlapWindow: "soon"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyWithoutSettings(t *testing.T) {
+	d := New()
+	source := config.Source{Name: "http-checks"}
+
+	assert.Equal(t, []string{"up", "down", "up"}, publishSequence(d, source, "up", "down", "up"))
+	assert.Empty(t, d.states)
+}
+
+func TestApplyHysteresis(t *testing.T) {
+	source := config.Source{
+		Name:     "http-checks",
+		Debounce: &config.DebounceSettings{FailuresBeforeDown: 3, SuccessesBeforeUp: 2},
+	}
+
+	t.Run("first result is published immediately", func(t *testing.T) {
+		assert.Equal(t, []string{"down"}, publishSequence(New(), source, "down"))
+	})
+
+	t.Run("single failure is held back", func(t *testing.T) {
+		published := publishSequence(New(), source, "up", "down", "up", "up")
+		assert.Equal(t, []string{"up", "up", "up", "up"}, published)
+	})
+
+	t.Run("consecutive failures and successes", func(t *testing.T) {
+		published := publishSequence(New(), source, "up", "down", "down", "down", "up", "up")
+		assert.Equal(t, []string{"up", "up", "up", "down", "down", "up"}, published)
+	})
+
+	t.Run("mixed failures count together", func(t *testing.T) {
+		published := publishSequence(New(), source, "up", "down", "degraded", "unavailable")
+		assert.Equal(t, []string{"up", "up", "up", "unavailable"}, published)
+	})
+
+	t.Run("same severity changes are immediate", func(t *testing.T) {
+		published := publishSequence(New(), source, "up", "down", "down", "down", "unavailable", "down")
+		assert.Equal(t, []string{"up", "up", "up", "down", "unavailable", "down"}, published)
+	})
+
+	t.Run("recovery interrupted by a failure starts over", func(t *testing.T) {
+		published := publishSequence(New(), source, "down", "up", "down", "up", "up")
+		assert.Equal(t, []string{"down", "down", "down", "down", "up"}, published)
+	})
+}
+
+func TestApplyAppOverride(t *testing.T) {
+	source := config.Source{
+		Name:     "http-checks",
+		Debounce: &config.DebounceSettings{FailuresBeforeDown: 3},
+		Config: map[string]interface{}{
+			"apps": []interface{}{
+				map[string]interface{}{"name": "payments", "debounce": map[string]interface{}{"failures_before_down": 1}},
+			},
+		},
+	}
+	d := New()
+	start := time.Now()
+
+	d.Apply(source, []handlers.AppStatus{appStatus("payments", "up"), appStatus("search", "up")}, start)
+	result := d.Apply(source, []handlers.AppStatus{appStatus("payments", "down"), appStatus("search", "down")}, start.Add(time.Minute))
+
+	assert.Equal(t, "down", result[0].Status)
+	assert.Equal(t, "up", result[1].Status)
+}
+
+func TestApplyFlapDetection(t *testing.T) {
+	source := config.Source{
+		Name:     "http-checks",
+		Debounce: &config.DebounceSettings{FailuresBeforeDown: 2, FlapWindow: "10m", FlapThreshold: 3},
+	}
+	d := New()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	apply := func(status string, offset time.Duration) handlers.AppStatus {
+		return d.Apply(source, []handlers.AppStatus{appStatus("payments", status)}, start.Add(offset))[0]
+	}
+
+	assert.False(t, apply("up", 0).Flapping)
+	assert.False(t, apply("down", time.Minute).Flapping)
+	assert.False(t, apply("up", 2*time.Minute).Flapping)
+
+	result := apply("down", 3*time.Minute)
+	assert.True(t, result.Flapping)
+	assert.Equal(t, "up", result.Status, "flapping does not bypass the debounce")
+
+	// Changes age out of the window
+	assert.False(t, apply("down", 20*time.Minute).Flapping)
+}
+
+func TestApplyDropsRemovedApps(t *testing.T) {
+	source := config.Source{Name: "http-checks", Debounce: &config.DebounceSettings{FailuresBeforeDown: 2}}
+	d := New()
+	now := time.Now()
+
+	d.Apply(source, []handlers.AppStatus{appStatus("payments", "up"), appStatus("search", "up")}, now)
+	d.Apply(source, []handlers.AppStatus{appStatus("payments", "up")}, now.Add(time.Minute))
+	assert.Len(t, d.states["http-checks"], 1)
+
+	// A returning app starts from its first result again
+	result := d.Apply(source, []handlers.AppStatus{appStatus("search", "down")}, now.Add(2*time.Minute))
+	assert.Equal(t, "down", result[0].Status)
+}
+
+func TestApplyAppsKeepsOtherApps(t *testing.T) {
+	source := config.Source{Name: "http-checks", Debounce: &config.DebounceSettings{FailuresBeforeDown: 2}}
+	d := New()
+	now := time.Now()
+
+	d.Apply(source, []handlers.AppStatus{appStatus("payments", "up"), appStatus("search", "up")}, now)
+	d.Apply(source, []handlers.AppStatus{appStatus("payments", "up"), appStatus("search", "down")}, now.Add(time.Minute))
+
+	// Checking payments alone neither drops nor resets the pending failure of search
+	result := d.ApplyApps(source, []handlers.AppStatus{appStatus("payments", "down")}, now.Add(90*time.Second))
+	assert.Equal(t, "up", result[0].Status)
+	assert.Len(t, d.states["http-checks"], 2)
+
+	result = d.Apply(source, []handlers.AppStatus{appStatus("payments", "down"), appStatus("search", "down")}, now.Add(2*time.Minute))
+	assert.Equal(t, "down", result[0].Status)
+	assert.Equal(t, "down", result[1].Status)
+}
+
+func TestApplyKeepsReasonOfPublishedStatus(t *testing.T) {
+	source := config.Source{Name: "http-checks", Debounce: &config.DebounceSettings{FailuresBeforeDown: 2, SuccessesBeforeUp: 2}}
+	d := New()
+	now := time.Now()
+
+	apply := func(status, reason string, offset time.Duration) handlers.AppStatus {
+		app := appStatus("payments", status)
+		app.Reason = reason
+		return d.Apply(source, []handlers.AppStatus{app}, now.Add(offset))[0]
+	}
+
+	apply("up", "", 0)
+	result := apply("down", "connection refused", time.Minute)
+	assert.Equal(t, "up", result.Status)
+	assert.Empty(t, result.Reason, "a held back failure does not publish its reason")
+
+	result = apply("down", "timeout", 2*time.Minute)
+	assert.Equal(t, "down", result.Status)
+	assert.Equal(t, "timeout", result.Reason)
+
+	result = apply("up", "", 3*time.Minute)
+	assert.Equal(t, "down", result.Status)
+	assert.Equal(t, "timeout", result.Reason, "a pending recovery keeps the reason of the published failure")
+}
+
+func TestRemove(t *testing.T) {
+	source := config.Source{Name: "http-checks", Debounce: &config.DebounceSettings{FailuresBeforeDown: 2}}
+	d := New()
+
+	d.Apply(source, []handlers.AppStatus{appStatus("payments", "up")}, time.Now())
+	require.Len(t, d.states, 1)
+
+	d.Remove("http-checks")
+	assert.Empty(t, d.states)
+}
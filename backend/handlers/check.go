@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/logging"
+)
+
+// Checker runs checks outside the scrape schedule. It is implemented by the scraping package.
+type Checker interface {
+	// CheckApp checks a single app of a source, updates the caches and returns the published statuses of the app
+	CheckApp(ctx context.Context, sourceName, appName string) ([]AppStatus, error)
+	// ScrapeSource scrapes a source, updates the caches and returns the published statuses of its apps
+	ScrapeSource(ctx context.Context, sourceName string) ([]AppStatus, error)
+}
+
+var (
+	// ErrSourceNotFound is returned by a Checker for a source that is not scraped
+	ErrSourceNotFound = errors.New("source not found")
+	// ErrAppNotFound is returned by a Checker for an app the source does not report
+	ErrAppNotFound = errors.New("app not found")
+
+	checker      Checker
+	checkerMutex sync.RWMutex
+
+	// checkLimiter limits on-demand checks per user
+	checkLimiter = newRateLimiter()
+)
+
+// SetChecker sets the checker used by the on-demand check endpoints
+func SetChecker(c Checker) {
+	checkerMutex.Lock()
+	defer checkerMutex.Unlock()
+	checker = c
+}
+
+// getChecker returns the checker used by the on-demand check endpoints
+func getChecker() Checker {
+	checkerMutex.RLock()
+	defer checkerMutex.RUnlock()
+	return checker
+}
+
+// CheckAppWithAuthz handles POST /api/apps/{source}/{name}/check
+func CheckAppWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	sourceName, appName := r.PathValue("source"), r.PathValue("name")
+	logging.Logger.WithFields(map[string]interface{}{
+		"source": sourceName,
+		"app":    appName,
+	}).Debug("Handling on-demand app check")
+
+	target := func(app AppStatus) bool { return app.Name == appName }
+	runCheck(w, r, cfg, sourceName, target, func(c Checker) ([]AppStatus, error) {
+		return c.CheckApp(r.Context(), sourceName, appName)
+	})
+}
+
+// ScrapeSourceWithAuthz handles POST /api/sources/{name}/scrape
+func ScrapeSourceWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	sourceName := r.PathValue("name")
+	logging.Logger.WithField("source", sourceName).Debug("Handling on-demand source scrape")
+
+	target := func(AppStatus) bool { return true }
+	runCheck(w, r, cfg, sourceName, target, func(c Checker) ([]AppStatus, error) {
+		return c.ScrapeSource(r.Context(), sourceName)
+	})
+}
+
+// runCheck authorizes and rate limits an on-demand check, runs it and writes the statuses the user can access.
+// Users without full access must be able to access one of the cached apps selected by target, so the
+// endpoints don't reveal or check apps they can't see.
+func runCheck(w http.ResponseWriter, r *http.Request, cfg *config.Config, sourceName string, target func(AppStatus) bool, check func(Checker) ([]AppStatus, error)) {
+	c := getChecker()
+	if c == nil {
+		http.Error(w, "On-demand checks are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
+	if hasPermissions && !userPermissions.Can(config.PermissionCheck) {
+		http.Error(w, fmt.Sprintf("Permission %q required", config.PermissionCheck), http.StatusForbidden)
+		return
+	}
+
+	canAccess := func(AppStatus) bool { return true }
+	if hasPermissions && !userPermissions.HasFullAccess {
+		authorizer := rbac.NewAuthorizer(cfg)
+		canAccess = func(app AppStatus) bool { return authorizer.CanAccessApp(userPermissions, app.Labels) }
+
+		if !slices.ContainsFunc(GetSourceAppStatuses(sourceName), func(app AppStatus) bool { return target(app) && canAccess(app) }) {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	if allowed, retryAfter := checkLimiter.allow(rateLimitKey(r), cfg.Scraping.OnDemand.RateLimit, time.Now()); !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		http.Error(w, "Too many on-demand checks, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	statuses, err := check(c)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("source", sourceName).Warn("On-demand check failed")
+		switch {
+		case errors.Is(err, ErrSourceNotFound), errors.Is(err, ErrAppNotFound):
+			http.Error(w, "Not found", http.StatusNotFound)
+		case r.Context().Err() != nil:
+			http.Error(w, "Check cancelled", http.StatusServiceUnavailable)
+		default:
+			http.Error(w, fmt.Sprintf("Check failed: %v", err), http.StatusBadGateway)
+		}
+		return
+	}
+
+	authorized := make([]AppStatus, 0, len(statuses))
+	for _, app := range statuses {
+		if canAccess(app) {
+			authorized = append(authorized, app)
+		}
+	}
+
+	writeJSONResponse(w, AppsResponse{Apps: authorized, Total: len(authorized)}, "check")
+}
+
+// rateLimitKey identifies the client of a request: the user when authenticated, the remote address otherwise
+func rateLimitKey(r *http.Request) string {
+	if user, ok := middleware.GetUserFromContext(r); ok {
+		return "user:" + user.Username
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+// rateLimiter is a token bucket per client that refills limit tokens per minute
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow takes a token from the bucket of key. When the bucket is empty, it returns how long until the next token.
+func (l *rateLimiter) allow(key string, limit int, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit <= 0 {
+		return true, 0
+	}
+	perSecond := float64(limit) / 60
+
+	// Buckets that have been idle long enough are full again and can be forgotten
+	for k, b := range l.buckets {
+		if now.Sub(b.updated) > time.Minute {
+			delete(l.buckets, k)
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.updated).Seconds()*perSecond)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+}
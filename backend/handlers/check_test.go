@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChecker marks the checked app as down, or fails with err
+type fakeChecker struct {
+	err   error
+	calls int
+}
+
+func (f *fakeChecker) CheckApp(ctx context.Context, sourceName, appName string) ([]AppStatus, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, app := range GetSourceAppStatuses(sourceName) {
+		if app.Name == appName {
+			app.Status = "down"
+			return []AppStatus{app}, nil
+		}
+	}
+	return nil, ErrAppNotFound
+}
+
+func (f *fakeChecker) ScrapeSource(ctx context.Context, sourceName string) ([]AppStatus, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return GetSourceAppStatuses(sourceName), nil
+}
+
+func checkRequest(path, source, name string, permissions *rbac.UserPermissions) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.SetPathValue("source", source)
+	req.SetPathValue("name", name)
+	if permissions != nil {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.PermissionsContextKey, *permissions))
+	}
+	return req
+}
+
+func TestCheckAppWithAuthz(t *testing.T) {
+	ResetCacheForTesting()
+	defer SetChecker(nil)
+
+	appStatusCache["http://localhost:8080"] = map[string]map[string]AppStatus{
+		"http-checks": {
+			"payments": {Name: "payments", Location: "loc1", Status: "up", Source: "http-checks", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+			"search":   {Name: "search", Location: "loc1", Status: "up", Source: "http-checks", Labels: []labels.Label{{Key: "team", Value: "search"}}},
+		},
+	}
+	cfg := &config.Config{Scraping: config.ScrapingSettings{OnDemand: config.OnDemandSettings{RateLimit: 100}}}
+
+	operator := &rbac.UserPermissions{
+		AllowedLabels: map[string]rbac.LabelPermission{"team": {Key: "team", AllowedValues: []string{"checkout"}}},
+		Actions:       map[string]bool{config.PermissionCheck: true},
+	}
+	viewer := &rbac.UserPermissions{
+		AllowedLabels: map[string]rbac.LabelPermission{"team": {Key: "team", AllowedValues: []string{"checkout"}}},
+	}
+
+	t.Run("checker not set", func(t *testing.T) {
+		SetChecker(nil)
+		w := httptest.NewRecorder()
+		CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/payments/check", "http-checks", "payments", operator), cfg)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("returns the fresh status", func(t *testing.T) {
+		checkLimiter = newRateLimiter()
+		SetChecker(&fakeChecker{})
+		w := httptest.NewRecorder()
+		CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/payments/check", "http-checks", "payments", operator), cfg)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response AppsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Apps, 1)
+		assert.Equal(t, "down", response.Apps[0].Status)
+	})
+
+	t.Run("requires the check permission", func(t *testing.T) {
+		checker := &fakeChecker{}
+		SetChecker(checker)
+		w := httptest.NewRecorder()
+		CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/payments/check", "http-checks", "payments", viewer), cfg)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Zero(t, checker.calls)
+	})
+
+	t.Run("apps the user can't access are not found", func(t *testing.T) {
+		checker := &fakeChecker{}
+		SetChecker(checker)
+		w := httptest.NewRecorder()
+		CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/search/check", "http-checks", "search", operator), cfg)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Zero(t, checker.calls)
+	})
+
+	t.Run("checker errors", func(t *testing.T) {
+		checkLimiter = newRateLimiter()
+		SetChecker(&fakeChecker{err: ErrAppNotFound})
+		w := httptest.NewRecorder()
+		CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/payments/check", "http-checks", "payments", nil), cfg)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		SetChecker(&fakeChecker{err: errors.New("connection refused")})
+		w = httptest.NewRecorder()
+		CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/payments/check", "http-checks", "payments", nil), cfg)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Contains(t, w.Body.String(), "connection refused")
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		checkLimiter = newRateLimiter()
+		checker := &fakeChecker{}
+		SetChecker(checker)
+		limited := &config.Config{Scraping: config.ScrapingSettings{OnDemand: config.OnDemandSettings{RateLimit: 2}}}
+
+		codes := make([]int, 0, 3)
+		for i := 0; i < 3; i++ {
+			w := httptest.NewRecorder()
+			CheckAppWithAuthz(w, checkRequest("/api/apps/http-checks/payments/check", "http-checks", "payments", operator), limited)
+			codes = append(codes, w.Code)
+			if w.Code == http.StatusTooManyRequests {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+		assert.Equal(t, 2, checker.calls)
+	})
+}
+
+func TestScrapeSourceWithAuthz(t *testing.T) {
+	ResetCacheForTesting()
+	defer SetChecker(nil)
+	checkLimiter = newRateLimiter()
+
+	appStatusCache["http://localhost:8080"] = map[string]map[string]AppStatus{
+		"http-checks": {
+			"payments": {Name: "payments", Location: "loc1", Status: "up", Source: "http-checks", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+			"search":   {Name: "search", Location: "loc1", Status: "up", Source: "http-checks", Labels: []labels.Label{{Key: "team", Value: "search"}}},
+		},
+	}
+	cfg := &config.Config{}
+	SetChecker(&fakeChecker{})
+
+	operator := &rbac.UserPermissions{
+		AllowedLabels: map[string]rbac.LabelPermission{"team": {Key: "team", AllowedValues: []string{"checkout"}}},
+		Actions:       map[string]bool{config.PermissionCheck: true},
+	}
+	w := httptest.NewRecorder()
+	ScrapeSourceWithAuthz(w, checkRequest("/api/sources/http-checks/scrape", "", "http-checks", operator), cfg)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response AppsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Apps, 1, "only apps the user can access are returned")
+	assert.Equal(t, "payments", response.Apps[0].Name)
+
+	w = httptest.NewRecorder()
+	ScrapeSourceWithAuthz(w, checkRequest("/api/sources/other/scrape", "", "other", operator), cfg)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.allow("alice", 3, now)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter := limiter.allow("alice", 3, now)
+	assert.False(t, allowed)
+	assert.Equal(t, 20*time.Second, retryAfter)
+
+	allowed, _ = limiter.allow("bob", 3, now)
+	assert.True(t, allowed, "clients have separate buckets")
+
+	allowed, _ = limiter.allow("alice", 3, now.Add(20*time.Second))
+	assert.True(t, allowed, "a token is refilled every minute / limit")
+}
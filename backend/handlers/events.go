@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+	"site-availability/logging"
+)
+
+// Event types sent on /api/events
+const (
+	EventApp      = "app"      // An app changed status
+	EventLocation = "location" // The status of a location changed
+	EventReset    = "reset"    // Events were missed; the client should reload the full state
+)
+
+const (
+	// eventBufferSize is the number of app events kept to resume streams with Last-Event-ID
+	eventBufferSize = 1000
+	// subscriberBufferSize is the number of batches queued for a slow stream before it is closed
+	subscriberBufferSize = 64
+	// eventKeepaliveInterval is how often a comment is sent on idle streams, so proxies keep them open
+	eventKeepaliveInterval = 30 * time.Second
+)
+
+var events = newEventBroker()
+
+// appEvent is a status change of an app with its event ID
+type appEvent struct {
+	id         uint64
+	transition history.Transition
+}
+
+// LocationEvent is the status of a location after apps in it changed status
+type LocationEvent struct {
+	Name        string  `json:"name"`
+	Status      *string `json:"status"`
+	Up          int     `json:"up"`
+	Down        int     `json:"down"`
+	Unavailable int     `json:"unavailable"`
+	Degraded    int     `json:"degraded"`
+	Maintenance int     `json:"maintenance"`
+}
+
+// eventBroker assigns IDs to status changes, keeps the latest ones and fans them out to the open streams
+type eventBroker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	buffer      []appEvent // Latest events, oldest first
+	subscribers map[*eventSubscriber]struct{}
+}
+
+// eventSubscriber is an open event stream
+type eventSubscriber struct {
+	batches chan []appEvent
+	done    chan struct{} // Closed when the stream fell behind or the server shuts down
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[*eventSubscriber]struct{})}
+}
+
+// publish sends status changes to every open stream. Streams that can't keep up are closed,
+// and their clients resume from their last event.
+func (b *eventBroker) publish(transitions []history.Transition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch := make([]appEvent, 0, len(transitions))
+	for _, transition := range transitions {
+		b.lastID++
+		batch = append(batch, appEvent{id: b.lastID, transition: transition})
+	}
+	b.buffer = append(b.buffer, batch...)
+	if len(b.buffer) > eventBufferSize {
+		b.buffer = append([]appEvent(nil), b.buffer[len(b.buffer)-eventBufferSize:]...)
+	}
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber.batches <- batch:
+		default:
+			logging.Logger.Debug("Closing event stream that fell behind")
+			b.remove(subscriber)
+		}
+	}
+}
+
+// subscribe opens a stream. With a last event ID, it returns the events missed since then;
+// resumed is false when some of them are no longer buffered.
+func (b *eventBroker) subscribe(lastEventID string) (subscriber *eventSubscriber, missed []appEvent, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriber = &eventSubscriber{
+		batches: make(chan []appEvent, subscriberBufferSize),
+		done:    make(chan struct{}),
+	}
+	b.subscribers[subscriber] = struct{}{}
+
+	if lastEventID == "" {
+		return subscriber, nil, true
+	}
+	id, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || id > b.lastID {
+		// Unknown ID, e.g. from before a restart
+		return subscriber, nil, false
+	}
+	if id < b.lastID && (len(b.buffer) == 0 || b.buffer[0].id > id+1) {
+		return subscriber, nil, false
+	}
+	for _, event := range b.buffer {
+		if event.id > id {
+			missed = append(missed, event)
+		}
+	}
+	return subscriber, missed, true
+}
+
+// unsubscribe closes a stream
+func (b *eventBroker) unsubscribe(subscriber *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(subscriber)
+}
+
+// remove closes a stream. The caller must hold the lock.
+func (b *eventBroker) remove(subscriber *eventSubscriber) {
+	if _, ok := b.subscribers[subscriber]; ok {
+		delete(b.subscribers, subscriber)
+		close(subscriber.done)
+	}
+}
+
+// currentID returns the ID of the latest event
+func (b *eventBroker) currentID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+// CloseEventStreams ends all open event streams, so they don't delay a graceful shutdown
+func CloseEventStreams() {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	for subscriber := range events.subscribers {
+		events.remove(subscriber)
+	}
+}
+
+// eventStream writes the events a user may see to an open /api/events response
+type eventStream struct {
+	w          io.Writer
+	controller *http.ResponseController
+	canAccess  func(appLabels []labels.Label) bool
+	filter     AppFilter
+	locations  map[string]LocationEvent // Last location status sent
+}
+
+// GetEventsWithAuthz handles GET /api/events, a Server-Sent Events stream of app and location status changes.
+// It accepts the filters of /api/apps and resumes after the Last-Event-ID header or last_event_id parameter.
+func GetEventsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	logging.Logger.Debug("Handling /api/events request")
+
+	// last_event_id resumes the stream rather than filtering it
+	queryParams := r.URL.Query()
+	lastEventIDParam := queryParams.Get("last_event_id")
+	queryParams.Del("last_event_id")
+	filter, err := parseFilters(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stream := &eventStream{
+		w:          w,
+		controller: http.NewResponseController(w),
+		canAccess:  func([]labels.Label) bool { return true },
+		filter:     filter,
+		locations:  make(map[string]LocationEvent),
+	}
+	if userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r); hasPermissions && !userPermissions.HasFullAccess {
+		authorizer := rbac.NewAuthorizer(cfg)
+		stream.canAccess = func(appLabels []labels.Label) bool {
+			return authorizer.CanAccessApp(userPermissions, appLabels)
+		}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = lastEventIDParam
+	}
+	subscriber, missed, resumed := events.subscribe(lastEventID)
+	defer events.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable response buffering in nginx
+	w.WriteHeader(http.StatusOK)
+
+	if !resumed {
+		logging.Logger.WithField("last_event_id", lastEventID).Debug("Event stream can't be resumed, sending reset")
+		if err := stream.write(EventReset, events.currentID(), struct{}{}); err != nil {
+			return
+		}
+	} else if err := stream.controller.Flush(); err != nil {
+		logging.Logger.WithError(err).Warn("Event stream can't be flushed")
+		return
+	}
+	if err := stream.send(missed); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(eventKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-subscriber.done:
+			return
+		case batch := <-subscriber.batches:
+			if err := stream.send(batch); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			if err := stream.controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send writes the app events the user may see, then the locations whose status changed for the user
+func (s *eventStream) send(batch []appEvent) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	touched := make(map[string]bool)
+	for _, event := range batch {
+		transition := event.transition
+		if !s.canAccess(transition.Labels) || !s.matches(transition) {
+			continue
+		}
+		touched[transition.Location] = true
+		if err := s.write(EventApp, event.id, transition); err != nil {
+			return err
+		}
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+
+	apps := make([]AppStatus, 0)
+	for _, app := range GetAppStatusCache() {
+		if s.canAccess(app.Labels) {
+			apps = append(apps, app)
+		}
+	}
+	apps, _ = filterApps(apps, s.filter)
+
+	lastID := batch[len(batch)-1].id
+	for name := range touched {
+		location := LocationEvent{Name: name, Status: calculateLocationStatus(name, apps)}
+		location.Up, location.Down, location.Unavailable, location.Degraded, location.Maintenance = calculateLocationStatusCounts(name, apps)
+		if previous, ok := s.locations[name]; ok && sameLocationEvent(previous, location) {
+			continue
+		}
+		s.locations[name] = location
+		if err := s.write(EventLocation, lastID, location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches reports whether an app enters or leaves the filter of the stream with a status change
+func (s *eventStream) matches(transition history.Transition) bool {
+	fields := map[string]string{
+		"name":       transition.App,
+		"location":   transition.Location,
+		"source":     transition.Source,
+		"origin_url": transition.OriginURL,
+	}
+	for _, label := range transition.Labels {
+		fields["labels."+label.Key] = label.Value
+	}
+
+	fields["status"] = transition.Status
+	if s.filter.Matches(fields) {
+		return true
+	}
+	fields["status"] = transition.PreviousStatus
+	return transition.PreviousStatus != "" && s.filter.Matches(fields)
+}
+
+// write sends a single event
+func (s *eventStream) write(eventType string, id uint64, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logging.Logger.WithError(err).Errorf("Failed to encode %s event", eventType)
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\nid: %d\ndata: %s\n\n", eventType, id, payload); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
+
+// sameLocationEvent reports whether two location events have the same status and counts
+func sameLocationEvent(a, b LocationEvent) bool {
+	statusA, statusB := "", ""
+	if a.Status != nil {
+		statusA = *a.Status
+	}
+	if b.Status != nil {
+		statusB = *b.Status
+	}
+	a.Status, b.Status = nil, nil
+	return statusA == statusB && a == b
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is an event read from a stream
+type sseEvent struct {
+	Type string
+	ID   uint64
+	Data map[string]interface{}
+}
+
+// openEventStream connects to /api/events and returns the events read from it
+func openEventStream(t *testing.T, query string, lastEventID string, permissions *rbac.UserPermissions) <-chan sseEvent {
+	t.Helper()
+	cfg := &config.Config{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if permissions != nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.PermissionsContextKey, *permissions))
+		}
+		GetEventsWithAuthz(w, r, cfg)
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events"+query, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	received := make(chan sseEvent, 100)
+	go func() {
+		defer resp.Body.Close()
+		defer close(received)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "id: "):
+				event.ID, _ = strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64)
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			case line == "" && event.Type != "":
+				received <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+
+	// Wait for the stream to be subscribed before publishing
+	require.Eventually(t, func() bool {
+		events.mu.Lock()
+		defer events.mu.Unlock()
+		return len(events.subscribers) > 0
+	}, time.Second, 5*time.Millisecond)
+	return received
+}
+
+// nextEvent returns the next event of a stream, failing after a timeout
+func nextEvent(t *testing.T, received <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-received:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return sseEvent{}
+	}
+}
+
+func TestEventBrokerResume(t *testing.T) {
+	broker := newEventBroker()
+	for i := 0; i < eventBufferSize+5; i++ {
+		broker.publish([]history.Transition{{App: "app" + strconv.Itoa(i), Status: "down"}})
+	}
+	require.Equal(t, uint64(eventBufferSize+5), broker.currentID())
+
+	subscriber, missed, resumed := broker.subscribe("")
+	assert.True(t, resumed)
+	assert.Empty(t, missed)
+	broker.unsubscribe(subscriber)
+
+	_, missed, resumed = broker.subscribe(strconv.Itoa(eventBufferSize + 2))
+	assert.True(t, resumed)
+	require.Len(t, missed, 3)
+	assert.Equal(t, uint64(eventBufferSize+3), missed[0].id)
+
+	_, missed, resumed = broker.subscribe(strconv.Itoa(eventBufferSize + 5))
+	assert.True(t, resumed, "up to date")
+	assert.Empty(t, missed)
+
+	_, _, resumed = broker.subscribe("4")
+	assert.False(t, resumed, "event 5 is no longer buffered")
+
+	_, _, resumed = broker.subscribe("5")
+	assert.True(t, resumed, "the oldest buffered event follows")
+
+	_, _, resumed = broker.subscribe("999999")
+	assert.False(t, resumed, "ID from before a restart")
+}
+
+func TestEventBrokerClosesSlowSubscribers(t *testing.T) {
+	broker := newEventBroker()
+	subscriber, _, _ := broker.subscribe("")
+	for i := 0; i <= subscriberBufferSize; i++ {
+		broker.publish([]history.Transition{{App: "payments", Status: "down"}})
+	}
+
+	select {
+	case <-subscriber.done:
+	default:
+		t.Fatal("subscriber that fell behind is still open")
+	}
+	assert.Empty(t, broker.subscribers)
+}
+
+func TestGetEventsWithAuthz(t *testing.T) {
+	ResetCacheForTesting()
+	defer CloseEventStreams()
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+		{Name: "search", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "discovery"}}},
+	})
+
+	checkout := &rbac.UserPermissions{
+		AllowedLabels: map[string]rbac.LabelPermission{"team": {Key: "team", AllowedValues: []string{"checkout"}}},
+	}
+
+	t.Run("status changes of accessible apps", func(t *testing.T) {
+		received := openEventStream(t, "", "", checkout)
+		updateAppStatusTest("prom", []AppStatus{
+			{Name: "payments", Location: "loc1", Status: "down", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+			{Name: "search", Location: "loc1", Status: "down", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "discovery"}}},
+		})
+
+		event := nextEvent(t, received)
+		assert.Equal(t, EventApp, event.Type)
+		assert.Equal(t, "payments", event.Data["app"])
+		assert.Equal(t, "down", event.Data["status"])
+		assert.Equal(t, "up", event.Data["previous_status"])
+
+		event = nextEvent(t, received)
+		assert.Equal(t, EventLocation, event.Type)
+		assert.Equal(t, "loc1", event.Data["name"])
+		assert.Equal(t, "down", event.Data["status"])
+		assert.Equal(t, float64(1), event.Data["down"], "only accessible apps are counted")
+		assert.Equal(t, float64(0), event.Data["up"])
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		received := openEventStream(t, `?filter=name="search"`, "", nil)
+		updateAppStatusTest("prom", []AppStatus{
+			{Name: "payments", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+			{Name: "search", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "discovery"}}},
+		})
+
+		event := nextEvent(t, received)
+		assert.Equal(t, EventApp, event.Type)
+		assert.Equal(t, "search", event.Data["app"])
+		assert.Equal(t, EventLocation, nextEvent(t, received).Type)
+	})
+
+	t.Run("resume after the last event", func(t *testing.T) {
+		lastID := events.currentID()
+		updateAppStatusTest("prom", []AppStatus{
+			{Name: "payments", Location: "loc1", Status: "down", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+			{Name: "search", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "discovery"}}},
+		})
+
+		received := openEventStream(t, "", strconv.FormatUint(lastID, 10), nil)
+		event := nextEvent(t, received)
+		assert.Equal(t, EventApp, event.Type)
+		assert.Equal(t, "payments", event.Data["app"])
+		assert.Equal(t, lastID+1, event.ID)
+	})
+
+	t.Run("resume with the last_event_id parameter", func(t *testing.T) {
+		lastID := events.currentID()
+		updateAppStatusTest("prom", []AppStatus{
+			{Name: "payments", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+			{Name: "search", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "discovery"}}},
+		})
+
+		received := openEventStream(t, "?last_event_id="+strconv.FormatUint(lastID, 10)+`&filter=name="payments"`, "", nil)
+		event := nextEvent(t, received)
+		assert.Equal(t, EventApp, event.Type)
+		assert.Equal(t, "payments", event.Data["app"])
+		assert.Equal(t, "up", event.Data["status"])
+		assert.Equal(t, lastID+1, event.ID)
+	})
+
+	t.Run("reset when events were missed", func(t *testing.T) {
+		received := openEventStream(t, "", "999999999", nil)
+		event := nextEvent(t, received)
+		assert.Equal(t, EventReset, event.Type)
+		assert.Equal(t, events.currentID(), event.ID)
+	})
+
+	t.Run("closed on shutdown", func(t *testing.T) {
+		received := openEventStream(t, "", "", nil)
+		CloseEventStreams()
+		select {
+		case _, ok := <-received:
+			assert.False(t, ok)
+		case <-time.After(2 * time.Second):
+			t.Fatal("stream still open")
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetEventsWithAuthz(w, httptest.NewRequest(http.MethodGet, "/api/events?filter=team=%22checkout%22", nil), &config.Config{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
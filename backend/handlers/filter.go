@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"site-availability/labels"
+	"site-availability/logging"
+)
+
+// filterParam is the query parameter holding a list of matchers, e.g. ?filter={name=~"pay.*",labels.env!="dev"}
+const filterParam = "filter"
+
+// filterFields are the system fields apps can be filtered by; labels use the "labels." prefix
+var filterFields = map[string]bool{
+	"name":       true,
+	"location":   true,
+	"status":     true,
+	"source":     true,
+	"origin_url": true,
+}
+
+// AppFilter selects apps with Prometheus-style matchers. An app is selected when it satisfies
+// all matchers of at least one group. A filter without groups selects every app.
+type AppFilter struct {
+	groups [][]*labels.Matcher
+}
+
+// IsEmpty reports whether the filter selects every app
+func (f AppFilter) IsEmpty() bool {
+	return len(f.groups) == 0
+}
+
+// String returns the filter in a readable form, with groups separated by " or "
+func (f AppFilter) String() string {
+	groups := make([]string, 0, len(f.groups))
+	for _, group := range f.groups {
+		matchers := make([]string, 0, len(group))
+		for _, matcher := range group {
+			matchers = append(matchers, matcher.String())
+		}
+		groups = append(groups, "{"+strings.Join(matchers, ",")+"}")
+	}
+	return strings.Join(groups, " or ")
+}
+
+// parseFilters builds the app filter from query parameters.
+//
+// Field parameters match exactly, and repeating one matches any of its values:
+// ?location=siteA&labels.env=production&status=up&status=down
+//
+// Each filter parameter is a list of matchers that must all match, and repeating it matches any of the lists:
+// ?filter={name=~"pay.*",labels.env!="dev"}&filter=labels.team="sre"
+// Field parameters apply on top of every list. A label is present with labels.team!="" and absent with labels.team="".
+// Unknown parameters are an error, so a misspelled filter doesn't silently select every app.
+func parseFilters(queryParams url.Values) (AppFilter, error) {
+	var common []*labels.Matcher
+	var lists [][]*labels.Matcher
+
+	keys := make([]string, 0, len(queryParams))
+	for key := range queryParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := nonEmpty(queryParams[key])
+		if pageParams[key] {
+			continue
+		}
+		if key != filterParam {
+			// Checked before skipping empty values, so ?name!~"pay.*" (no "=", so no value) isn't dropped silently
+			if err := validateFieldParam(key, values); err != nil {
+				return AppFilter{}, err
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		if key == filterParam {
+			for _, value := range values {
+				matchers, err := labels.ParseMatcherList(value)
+				if err != nil {
+					return AppFilter{}, fmt.Errorf("invalid filter: %w", err)
+				}
+				for _, matcher := range matchers {
+					if err := validateFilterField(matcher.Name); err != nil {
+						return AppFilter{}, fmt.Errorf("invalid filter %q: %w", value, err)
+					}
+				}
+				lists = append(lists, matchers)
+			}
+			continue
+		}
+
+		matcher, err := fieldMatcher(key, values)
+		if err != nil {
+			return AppFilter{}, fmt.Errorf("invalid query parameter %q: %w", key, err)
+		}
+		common = append(common, matcher)
+	}
+
+	var filter AppFilter
+	switch {
+	case len(lists) > 0:
+		for _, list := range lists {
+			filter.groups = append(filter.groups, append(append([]*labels.Matcher(nil), common...), list...))
+		}
+	case len(common) > 0:
+		filter.groups = [][]*labels.Matcher{common}
+	}
+
+	logging.Logger.WithField("filter", filter.String()).Debug("Parsed filter from query parameters")
+	return filter, nil
+}
+
+// Matches reports whether the filter selects an app with the given fields, keyed like filter fields
+// (name, location, status, source, origin_url and labels.<key>)
+func (f AppFilter) Matches(fields map[string]string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, group := range f.groups {
+		if labels.MatchesAll(group, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFieldParam checks a field parameter. Other operators than equality end up in the key
+// (?labels.env!=dev is key "labels.env!") or the value (?name=~pay.* is value "~pay.*"),
+// so they are rejected instead of being matched literally.
+func validateFieldParam(key string, values []string) error {
+	field := fieldName(key)
+	operatorInValue := false
+	for _, value := range values {
+		if strings.HasPrefix(value, "~") {
+			operatorInValue = true
+		}
+	}
+	if field != key || operatorInValue {
+		return fmt.Errorf("invalid query parameter %q: field parameters only match exactly, use the filter parameter for other operators, e.g. filter=%s!~\"value\"", key, field)
+	}
+	if err := validateFilterField(key); err != nil {
+		return fmt.Errorf("invalid query parameter: %w", err)
+	}
+	return nil
+}
+
+// knownFilterParams drops the query parameters that are not filters or page parameters. /sync uses it,
+// so sites running another version can send parameters this site doesn't know.
+func knownFilterParams(queryParams url.Values) url.Values {
+	known := make(url.Values, len(queryParams))
+	for key, values := range queryParams {
+		field := fieldName(key)
+		if key == filterParam || pageParams[key] || filterFields[field] ||
+			strings.HasPrefix(field, "labels.") || strings.HasPrefix(field, "label[") {
+			known[key] = values
+			continue
+		}
+		logging.Logger.WithField("param", key).Debug("Ignoring query parameter that is not a filter")
+	}
+	return known
+}
+
+// fieldName strips an operator from a query parameter key, e.g. "labels.env" from "labels.env!"
+func fieldName(key string) string {
+	if i := strings.IndexAny(key, "!=~"); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// validateFilterField checks that apps can be filtered by a field
+func validateFilterField(name string) error {
+	if filterFields[name] || (strings.HasPrefix(name, "labels.") && name != "labels.") {
+		return nil
+	}
+	if strings.HasPrefix(name, "label[") && strings.HasSuffix(name, "]") {
+		labelKey := strings.TrimSuffix(strings.TrimPrefix(name, "label["), "]")
+		return fmt.Errorf("unknown field %q (should be \"labels.%s\")", name, labelKey)
+	}
+	return fmt.Errorf("unknown field %q (expected name, location, status, source, origin_url or labels.<key>)", name)
+}
+
+// fieldMatcher matches any of the values of a field parameter exactly
+func fieldMatcher(name string, values []string) (*labels.Matcher, error) {
+	if len(values) == 1 {
+		return labels.NewMatcher(name, labels.MatchEqual, values[0])
+	}
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	return labels.NewMatcher(name, labels.MatchRegexp, strings.Join(quoted, "|"))
+}
+
+// nonEmpty returns the values that are not empty
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// filterApps returns the apps selected by the filter, sorted by name, and the number of apps left out.
+// Matchers are evaluated against the LabelManager index, so apps are not scanned per matcher.
+func filterApps(apps []AppStatus, filter AppFilter) ([]AppStatus, int) {
+	start := time.Now()
+
+	if filter.IsEmpty() {
+		return apps, 0 // No filters
+	}
+
+	// Union of the groups
+	selected := make(map[string]bool)
+	for _, group := range filter.groups {
+		for _, appID := range labelManager.FindAppsByMatchers(group) {
+			selected[appID] = true
+		}
+	}
+
+	filteredApps := make([]AppStatus, 0, len(selected))
+	for _, app := range apps {
+		if selected[app.Source+":"+app.Name] {
+			filteredApps = append(filteredApps, app)
+		}
+	}
+
+	// Sort filtered apps by name for deterministic ordering
+	sort.SliceStable(filteredApps, func(i, j int) bool {
+		return filteredApps[i].Name < filteredApps[j].Name
+	})
+
+	filteredCount := len(apps) - len(filteredApps)
+	duration := time.Since(start)
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"total_apps":    len(apps),
+		"filtered_apps": len(filteredApps),
+		"filtered_out":  filteredCount,
+		"duration_μs":   duration.Microseconds(),
+		"filter":        filter.String(),
+	}).Debug("Applied filter using field manager")
+
+	return filteredApps, filteredCount
+}
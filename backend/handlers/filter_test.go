@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"site-availability/config"
+	"site-availability/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fieldFilter builds the filter of field query parameters, with "|" separating the values of a field
+func fieldFilter(t *testing.T, fields map[string]string) AppFilter {
+	t.Helper()
+	queryParams := url.Values{}
+	for key, value := range fields {
+		queryParams[key] = strings.Split(value, "|")
+	}
+	filter, err := parseFilters(queryParams)
+	require.NoError(t, err)
+	return filter
+}
+
+// filteredNames returns the names of the apps selected by a query string
+func filteredNames(t *testing.T, apps []AppStatus, query string) []string {
+	t.Helper()
+	queryParams, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	filter, err := parseFilters(queryParams)
+	require.NoError(t, err)
+
+	filtered, _ := filterApps(apps, filter)
+	names := make([]string, 0, len(filtered))
+	for _, app := range filtered {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+func TestParseFilters(t *testing.T) {
+	t.Run("field parameters", func(t *testing.T) {
+		filter, err := parseFilters(url.Values{
+			"location":   {"siteA"},
+			"labels.env": {"production", "staging"},
+			"status":     {""},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{labels.env=~"production|staging",location="siteA"}`, filter.String())
+	})
+
+	t.Run("filter parameters are alternatives", func(t *testing.T) {
+		filter, err := parseFilters(url.Values{
+			"status": {"down"},
+			"filter": {`{name=~"pay.*",labels.env!="dev"}`, `labels.team="sre"`},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{status="down",name=~"pay.*",labels.env!="dev"} or {status="down",labels.team="sre"}`, filter.String())
+	})
+
+	t.Run("no filters", func(t *testing.T) {
+		filter, err := parseFilters(url.Values{"status": {""}})
+		require.NoError(t, err)
+		assert.True(t, filter.IsEmpty())
+	})
+
+	t.Run("sync ignores unknown parameters", func(t *testing.T) {
+		filter, err := parseFilters(knownFilterParams(url.Values{"team": {"platform"}, "site": {"remote"}, "location": {"siteA"}}))
+		require.NoError(t, err)
+		assert.Equal(t, `{location="siteA"}`, filter.String())
+
+		_, err = parseFilters(knownFilterParams(url.Values{"labels.env!": {"dev"}}))
+		assert.Error(t, err, "operators are still rejected")
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		tests := []struct {
+			query  string
+			errMsg string
+		}{
+			{query: "team=platform", errMsg: `unknown field "team"`},
+			{query: "nmae=payments", errMsg: `unknown field "nmae"`},
+			{query: "labels.env!=dev", errMsg: "use the filter parameter"},
+			{query: `name=~"pay.*"`, errMsg: "use the filter parameter"},
+			{query: `name!~"pay.*"`, errMsg: "use the filter parameter"},
+			{query: "label[env]=production", errMsg: `should be "labels.env"`},
+			{query: "labels.=production", errMsg: `unknown field "labels."`},
+			{query: `filter=team="platform"`, errMsg: `unknown field "team"`},
+			{query: `filter=name=~"(unclosed"`, errMsg: "invalid regular expression"},
+			{query: `filter=name`, errMsg: "expected <name><operator><value>"},
+			{query: `filter={}`, errMsg: "empty matcher list"},
+		}
+		for _, tt := range tests {
+			queryParams, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			_, err = parseFilters(queryParams)
+			require.Error(t, err, tt.query)
+			assert.Contains(t, err.Error(), tt.errMsg, tt.query)
+		}
+	})
+}
+
+func TestFilterAppsWithMatchers(t *testing.T) {
+	ResetCacheForTesting()
+
+	apps := []AppStatus{
+		{Name: "payments", Location: "loc1", Status: "up", Source: "test", Labels: []labels.Label{{Key: "env", Value: "production"}, {Key: "team", Value: "checkout"}}},
+		{Name: "payouts", Location: "loc2", Status: "down", Source: "test", Labels: []labels.Label{{Key: "env", Value: "dev"}, {Key: "team", Value: "checkout"}}},
+		{Name: "search", Location: "loc1", Status: "down", Source: "test", Labels: []labels.Label{{Key: "env", Value: "production"}}},
+		{Name: "status-page", Location: "loc2", Status: "up", Source: "test"},
+	}
+	updateAppStatusTest("test", apps)
+	apps = GetAppStatusCache()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "negation", query: `filter=labels.env!="dev"`, want: []string{"payments", "search", "status-page"}},
+		{name: "regex", query: `filter=name=~"pay.*"`, want: []string{"payments", "payouts"}},
+		{name: "negated regex", query: `filter=name!~"pay.*"`, want: []string{"search", "status-page"}},
+		{name: "matchers of a filter must all match", query: `filter={name=~"pay.*",labels.env="production"}`, want: []string{"payments"}},
+		{name: "or across fields", query: `filter=location="loc2"&filter=labels.team="checkout"`, want: []string{"payments", "payouts", "status-page"}},
+		{name: "field parameters apply to every filter", query: `status=down&filter=location="loc2"&filter=labels.team="checkout"`, want: []string{"payouts"}},
+		{name: "or on any field parameter", query: "location=loc1&labels.team=checkout&labels.team=search", want: []string{"payments"}},
+		{name: "label present", query: `filter=labels.team!=""`, want: []string{"payments", "payouts"}},
+		{name: "label absent", query: `filter=labels.team=""`, want: []string{"search", "status-page"}},
+		{name: "no match", query: `filter=labels.team="sre"`, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, filteredNames(t, apps, tt.query))
+		})
+	}
+}
+
+func TestInvalidFilterReturnsBadRequest(t *testing.T) {
+	ResetCacheForTesting()
+	cfg := &config.Config{ServerSettings: config.ServerSettings{SyncEnable: true}}
+
+	handlers := map[string]func(http.ResponseWriter, *http.Request, *config.Config){
+		"/api/apps":      GetAppsWithAuthz,
+		"/api/locations": GetLocationsWithAuthz,
+		"/api/sla":       GetSLAWithAuthz,
+		"/sync":          HandleSyncRequest,
+	}
+	for path, handler := range handlers {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, path+`?filter=`+url.QueryEscape(`name=~"(unclosed"`), nil), cfg)
+		if path == "/api/sla" && GetHistoryStore() == nil {
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Contains(t, w.Body.String(), "invalid regular expression", path)
+	}
+}
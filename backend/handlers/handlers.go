@@ -7,9 +7,10 @@ import (
 	"net/url"
 	"site-availability/authentication/hmac"
 	"site-availability/config"
+	"site-availability/history"
 	"site-availability/labels"
 	"site-availability/logging"
-	"sort"
+	"site-availability/maintenance"
 	"strings"
 	"sync"
 	"time"
@@ -49,17 +50,36 @@ func normalizeOriginURL(originURL string) string {
 }
 
 type AppStatus struct {
-	Name      string         `json:"name"`
-	Location  string         `json:"location"`
-	Status    string         `json:"status"`
-	Source    string         `json:"source"`
-	OriginURL string         `json:"origin_url,omitempty"` // URL where app originally came from
-	Labels    []labels.Label `json:"labels,omitempty"`     // App labels (merged from app + source + server)
+	Name           string         `json:"name"`
+	Location       string         `json:"location"`
+	Status         string         `json:"status"`
+	Source         string         `json:"source"`
+	OriginURL      string         `json:"origin_url,omitempty"`       // URL where app originally came from
+	Labels         []labels.Label `json:"labels,omitempty"`           // App labels (merged from app + source + server)
+	Flapping       bool           `json:"flapping,omitempty"`         // Status changed too often within the flap window
+	LastCheck      time.Time      `json:"last_check,omitzero"`        // When the last check started
+	LastChange     time.Time      `json:"last_change,omitzero"`       // When the status last changed
+	ResponseTimeMs float64        `json:"response_time_ms,omitempty"` // Duration of the last check
+	Reason         string         `json:"reason,omitempty"`           // Why the last check failed
+	Maintenance    string         `json:"maintenance,omitempty"`      // Name of the maintenance window the app is in
+
+	// Status and maintenance window as scraped, before the maintenance windows of this site are applied
+	scrapedStatus, scrapedMaintenance string
+}
+
+// RecordCheck stores when a check started, how long it took and, when it failed, why
+func (a *AppStatus) RecordCheck(start time.Time, duration time.Duration, err error) {
+	a.LastCheck = start
+	a.ResponseTimeMs = float64(duration.Microseconds()) / 1000
+	if err != nil {
+		a.Reason = err.Error()
+	}
 }
 
 type StatusResponse struct {
-	Locations []Location  `json:"locations"`
-	Apps      []AppStatus `json:"apps"`
+	Locations   []Location           `json:"locations"`
+	Apps        []AppStatus          `json:"apps"`
+	Maintenance []maintenance.Window `json:"maintenance,omitempty"` // Windows defined on the responding site
 }
 
 type Location struct {
@@ -67,10 +87,12 @@ type Location struct {
 	Latitude    float64 `yaml:"latitude" json:"latitude"`
 	Longitude   float64 `yaml:"longitude" json:"longitude"`
 	Source      string  `json:"source"`
-	Status      *string `json:"status"`      // "up", "down", "unavailable", or nil for no apps
+	Status      *string `json:"status"`      // "up", "degraded", "down", "unavailable", "maintenance", or nil for no apps
 	Up          int     `json:"up"`          // Number of apps that are up
 	Down        int     `json:"down"`        // Number of apps that are down
 	Unavailable int     `json:"unavailable"` // Number of apps that are unavailable
+	Degraded    int     `json:"degraded"`    // Number of apps that are degraded
+	Maintenance int     `json:"maintenance"` // Number of apps in maintenance
 }
 
 // FilterParams represents both system field and label filters
@@ -114,6 +136,20 @@ func GetAppStatusCache() []AppStatus {
 	return apps
 }
 
+// GetSourceAppStatuses returns a copy of the cached apps of a single source, across all origin URLs
+func GetSourceAppStatuses(sourceName string) []AppStatus {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	var apps []AppStatus
+	for _, originApps := range appStatusCache {
+		for _, status := range originApps[sourceName] {
+			apps = append(apps, status)
+		}
+	}
+	return apps
+}
+
 // GetLocationCache returns a copy of all locations from all sources
 func GetLocationCache() []Location {
 	cacheMutex.RLock()
@@ -192,6 +228,24 @@ func UpdateLocationCache(sourceName string, newLocations []Location, configuredL
 	locationCache[sourceName] = locations
 }
 
+// RemoveSource drops the apps and locations of a source that is no longer configured
+func RemoveSource(sourceName string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	for originURL := range appStatusCache {
+		delete(appStatusCache[originURL], sourceName)
+		if len(appStatusCache[originURL]) == 0 {
+			delete(appStatusCache, originURL)
+		}
+	}
+	delete(locationCache, sourceName)
+	updateLabelManager()
+	GetMaintenanceStore().SetSynced(sourceName, nil)
+
+	logging.Logger.WithField("source", sourceName).Info("Removed source from caches")
+}
+
 // UpdateAppStatusResult contains the result of an update operation
 type UpdateAppStatusResult struct {
 	AppsAdded   int
@@ -224,6 +278,12 @@ func UpdateAppStatus(sourceName string, newStatuses []AppStatus, source config.S
 		"source": sourceName,
 	}).Info("Updating app status cache for source")
 
+	// Status transitions are published after the cache lock is released
+	var transitions []history.Transition
+	defer func() {
+		publishTransitions(transitions)
+	}()
+
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
 
@@ -287,7 +347,7 @@ func UpdateAppStatus(sourceName string, newStatuses []AppStatus, source config.S
 		}
 
 		// Validate status
-		validStatuses := map[string]bool{"up": true, "down": true, "unavailable": true}
+		validStatuses := map[string]bool{"up": true, "degraded": true, "down": true, "unavailable": true, StatusMaintenance: true}
 		if !validStatuses[app.Status] {
 			logging.Logger.WithFields(map[string]interface{}{
 				"source":   sourceName,
@@ -341,16 +401,25 @@ func UpdateAppStatus(sourceName string, newStatuses []AppStatus, source config.S
 	}
 
 	// Now update the cache: replace entire source for each origin_url
+	now := time.Now()
+	previousByOrigin := make(map[string]map[string]AppStatus, len(appsByOrigin))
 	for normalizedOriginURL, apps := range appsByOrigin {
 		// Initialize origin_url cache if needed
 		if _, ok := appStatusCache[normalizedOriginURL]; !ok {
 			appStatusCache[normalizedOriginURL] = make(map[string]map[string]AppStatus)
 		}
 
+		// Keep the previous statuses to detect transitions
+		previousByOrigin[normalizedOriginURL] = appStatusCache[normalizedOriginURL][sourceName]
+
 		// Replace entire source cache for this origin_url
 		appStatusCache[normalizedOriginURL][sourceName] = make(map[string]AppStatus)
 
 		for _, app := range apps {
+			// Apps taken from the cache (on-demand checks) keep their scraped status
+			if app.scrapedStatus == "" {
+				app.scrapedStatus, app.scrapedMaintenance = app.Status, app.Maintenance
+			}
 			appStatusCache[normalizedOriginURL][sourceName][app.Name] = app
 		}
 
@@ -366,6 +435,42 @@ func UpdateAppStatus(sourceName string, newStatuses []AppStatus, source config.S
 	// Update label manager for fast label queries
 	updateLabelManager()
 
+	// Maintenance windows select apps through the label index, so they are applied once the new apps are indexed
+	windows := maintenanceApps(now)
+	for normalizedOriginURL, apps := range appsByOrigin {
+		previousApps := previousByOrigin[normalizedOriginURL]
+		sourceApps := appStatusCache[normalizedOriginURL][sourceName]
+
+		for _, scraped := range apps {
+			app := sourceApps[scraped.Name]
+			applyMaintenance(&app, windows[app.Source+":"+app.Name])
+
+			previous, existed := previousApps[app.Name]
+			changed := !existed || previous.Status != app.Status
+
+			// Apps synced from other sites keep the times reported by their origin
+			if app.LastCheck.IsZero() {
+				app.LastCheck = now
+			}
+			if app.LastChange.IsZero() {
+				app.LastChange = now
+				if !changed && !previous.LastChange.IsZero() {
+					app.LastChange = previous.LastChange
+				}
+			}
+
+			sourceApps[app.Name] = app
+
+			if changed {
+				transitions = append(transitions, newTransition(app, sourceName, previous.Status, now))
+			}
+		}
+	}
+	if len(windows) > 0 {
+		// Index the maintenance status
+		updateLabelManager()
+	}
+
 	// Update metrics
 	updateMetrics.totalUpdates++
 	updateMetrics.totalAppsAdded += int64(result.AppsAdded)
@@ -437,21 +542,28 @@ func updateLabelManager() {
 }
 
 // calculateLocationStatusCounts calculates the status counts for a location based on its apps
-// Returns: up count, down count, unavailable count
-func calculateLocationStatusCounts(locationName string, apps []AppStatus) (int, int, int) {
+// Returns: up count, down count, unavailable count, degraded count, maintenance count
+// Apps in maintenance are only counted as such, not as down or unavailable.
+func calculateLocationStatusCounts(locationName string, apps []AppStatus) (int, int, int, int, int) {
 	upCount := 0
 	downCount := 0
 	unavailableCount := 0
+	degradedCount := 0
+	maintenanceCount := 0
 
 	for _, app := range apps {
 		if app.Location == locationName {
 			switch app.Status {
 			case "up":
 				upCount++
+			case "degraded":
+				degradedCount++
 			case "down":
 				downCount++
 			case "unavailable":
 				unavailableCount++
+			case StatusMaintenance:
+				maintenanceCount++
 			default:
 				// Unknown status treated as unavailable
 				unavailableCount++
@@ -459,25 +571,37 @@ func calculateLocationStatusCounts(locationName string, apps []AppStatus) (int,
 		}
 	}
 
-	return upCount, downCount, unavailableCount
+	return upCount, downCount, unavailableCount, degradedCount, maintenanceCount
 }
 
 // calculateLocationStatus calculates the status of a location based on its apps
-// Returns: "up" if all apps are up, "down" if any app is down, "unavailable" if any app is unavailable but none down, nil if no apps
+// Returns: "up" if all apps are up, "down" if any app is down, "unavailable" if any app is unavailable but none down,
+// "degraded" if any app is degraded but none down or unavailable, nil if no apps.
+// Apps in maintenance are ignored; "maintenance" is returned when all apps are in maintenance.
 func calculateLocationStatus(locationName string, apps []AppStatus) *string {
 	appsInLocation := make([]AppStatus, 0)
+	maintenanceCount := 0
 	for _, app := range apps {
 		if app.Location == locationName {
+			if app.Status == StatusMaintenance {
+				maintenanceCount++
+				continue
+			}
 			appsInLocation = append(appsInLocation, app)
 		}
 	}
 
 	if len(appsInLocation) == 0 {
+		if maintenanceCount > 0 {
+			status := StatusMaintenance
+			return &status
+		}
 		return nil // No apps means location status is null
 	}
 
 	hasDown := false
 	hasUnavailable := false
+	hasDegraded := false
 	allUp := true
 
 	for _, app := range appsInLocation {
@@ -488,6 +612,9 @@ func calculateLocationStatus(locationName string, apps []AppStatus) *string {
 		case "unavailable":
 			hasUnavailable = true
 			allUp = false
+		case "degraded":
+			hasDegraded = true
+			allUp = false
 		case "up":
 			// Keep allUp true
 		default:
@@ -506,6 +633,9 @@ func calculateLocationStatus(locationName string, apps []AppStatus) *string {
 	} else if hasUnavailable {
 		status := "unavailable"
 		return &status
+	} else if hasDegraded {
+		status := "degraded"
+		return &status
 	}
 
 	status := "unavailable" // Default fallback
@@ -525,7 +655,7 @@ func GetLocationsWithStatus() []Location {
 		for _, location := range sourceLocations {
 			// Calculate status and counts for this location
 			status := calculateLocationStatus(location.Name, apps)
-			upCount, downCount, unavailableCount := calculateLocationStatusCounts(location.Name, apps)
+			upCount, downCount, unavailableCount, degradedCount, maintenanceCount := calculateLocationStatusCounts(location.Name, apps)
 			locationWithStatus := Location{
 				Name:        location.Name,
 				Latitude:    location.Latitude,
@@ -535,6 +665,8 @@ func GetLocationsWithStatus() []Location {
 				Up:          upCount,
 				Down:        downCount,
 				Unavailable: unavailableCount,
+				Degraded:    degradedCount,
+				Maintenance: maintenanceCount,
 			}
 			locations = append(locations, locationWithStatus)
 		}
@@ -559,7 +691,7 @@ func convertToHandlersLocation(configLocations []config.Location) []Location {
 
 		// Calculate status and counts for this location
 		status := calculateLocationStatus(loc.Name, apps)
-		upCount, downCount, unavailableCount := calculateLocationStatusCounts(loc.Name, apps)
+		upCount, downCount, unavailableCount, degradedCount, maintenanceCount := calculateLocationStatusCounts(loc.Name, apps)
 
 		locations = append(locations, Location{
 			Name:        loc.Name,
@@ -570,6 +702,8 @@ func convertToHandlersLocation(configLocations []config.Location) []Location {
 			Up:          upCount,
 			Down:        downCount,
 			Unavailable: unavailableCount,
+			Degraded:    degradedCount,
+			Maintenance: maintenanceCount,
 		})
 	}
 	return locations
@@ -595,207 +729,6 @@ func parseLabelFilters(queryParams url.Values) map[string]string {
 	return labelFilters
 }
 
-// parseFilters extracts both system field and label filters from query parameters
-// Returns a single map where system fields use their direct names (location, status, etc.)
-// and user labels use "labels." prefix (labels.env, labels.team, etc.)
-// Special handling for status: multiple status values are joined with "|" for OR logic
-// Supports: ?location=siteA&origin_url=http://a.com&labels.env=production&status=up&status=down
-func parseFilters(queryParams url.Values) map[string]string {
-	filters := make(map[string]string)
-	unrecognizedParams := make([]string, 0)
-
-	// Define allowed system fields for filtering
-	allowedSystemFields := map[string]bool{
-		"name":       true,
-		"location":   true,
-		"status":     true,
-		"source":     true,
-		"origin_url": true,
-	}
-
-	for key, values := range queryParams {
-		if len(values) == 0 || values[0] == "" {
-			continue
-		}
-
-		if strings.HasPrefix(key, "labels.") {
-			// Label filter: labels.env=production -> store as "labels.env"
-			filters[key] = values[0]
-		} else if allowedSystemFields[key] {
-			// Special handling for status field to support OR logic
-			if key == "status" && len(values) > 1 {
-				// Multiple status values: join with "|" for OR logic
-				// Remove empty values
-				validStatuses := make([]string, 0, len(values))
-				for _, status := range values {
-					if status != "" {
-						validStatuses = append(validStatuses, status)
-					}
-				}
-				if len(validStatuses) > 0 {
-					filters[key] = strings.Join(validStatuses, "|")
-				}
-			} else {
-				// System field filter: location=siteA -> store as "location"
-				filters[key] = values[0]
-			}
-		} else {
-			// Check for common mistakes and log them
-			if strings.HasPrefix(key, "label[") && strings.HasSuffix(key, "]") {
-				// Extract key from label[key] format
-				labelKey := strings.TrimSuffix(strings.TrimPrefix(key, "label["), "]")
-				unrecognizedParams = append(unrecognizedParams, fmt.Sprintf("'%s' (should be 'labels.%s')", key, labelKey))
-			} else {
-				unrecognizedParams = append(unrecognizedParams, fmt.Sprintf("'%s'", key))
-			}
-		}
-	}
-
-	if len(unrecognizedParams) > 0 {
-		logging.Logger.WithFields(map[string]interface{}{
-			"unrecognized_params": unrecognizedParams,
-			"recognized_filters":  filters,
-		}).Warn("Some query parameters were not recognized as valid filters")
-	}
-
-	logging.Logger.WithFields(map[string]interface{}{
-		"filters": filters,
-	}).Debug("Parsed unified filters from query parameters")
-
-	return filters
-}
-
-// filterApps applies unified filtering using the LabelManager for O(1) performance
-// Works for both system fields (name, location, etc.) and user labels (labels.env, etc.)
-// Special handling: status field supports OR logic when multiple values are provided (separated by "|")
-func filterApps(apps []AppStatus, filters map[string]string) ([]AppStatus, int) {
-	start := time.Now()
-
-	if len(filters) == 0 {
-		return apps, 0 // No filters
-	}
-
-	// Handle status OR logic specially
-	var statusFilteredApps []AppStatus
-	statusFilter, hasStatusFilter := filters["status"]
-
-	if hasStatusFilter && strings.Contains(statusFilter, "|") {
-		// Multiple status values - use OR logic
-		statusValues := strings.Split(statusFilter, "|")
-		statusSet := make(map[string]bool)
-		for _, status := range statusValues {
-			statusSet[status] = true
-		}
-
-		// Filter apps by OR logic for status
-		for _, app := range apps {
-			if statusSet[app.Status] {
-				statusFilteredApps = append(statusFilteredApps, app)
-			}
-		}
-
-		// Remove status from filters for remaining AND logic processing
-		remainingFilters := make(map[string]string)
-		for k, v := range filters {
-			if k != "status" {
-				remainingFilters[k] = v
-			}
-		}
-
-		// If no other filters, return status-filtered apps
-		if len(remainingFilters) == 0 {
-			filteredCount := len(apps) - len(statusFilteredApps)
-			duration := time.Since(start)
-
-			logging.Logger.WithFields(map[string]interface{}{
-				"total_apps":     len(apps),
-				"filtered_apps":  len(statusFilteredApps),
-				"filtered_out":   filteredCount,
-				"duration_μs":    duration.Microseconds(),
-				"status_filters": statusValues,
-			}).Debug("Applied status OR filter")
-
-			return statusFilteredApps, filteredCount
-		}
-
-		// Apply remaining filters with AND logic to status-filtered apps
-		if len(remainingFilters) > 0 {
-			// Use LabelManager for remaining filters
-			matchingAppIDs := labelManager.FindAppsByLabels(remainingFilters)
-
-			// Convert status-filtered apps to ID map
-			idToApp := make(map[string]AppStatus)
-			for _, app := range statusFilteredApps {
-				uniqueID := app.Source + ":" + app.Name
-				idToApp[uniqueID] = app
-			}
-
-			// Intersect status-filtered apps with label-filtered apps
-			finalApps := make([]AppStatus, 0)
-			for _, appID := range matchingAppIDs {
-				if app, exists := idToApp[appID]; exists {
-					finalApps = append(finalApps, app)
-				}
-			}
-
-			// Sort filtered apps by name for deterministic ordering
-			sort.Slice(finalApps, func(i, j int) bool {
-				return finalApps[i].Name < finalApps[j].Name
-			})
-
-			filteredCount := len(apps) - len(finalApps)
-			duration := time.Since(start)
-
-			logging.Logger.WithFields(map[string]interface{}{
-				"total_apps":        len(apps),
-				"status_filtered":   len(statusFilteredApps),
-				"final_filtered":    len(finalApps),
-				"filtered_out":      filteredCount,
-				"duration_μs":       duration.Microseconds(),
-				"status_filters":    statusValues,
-				"remaining_filters": remainingFilters,
-			}).Debug("Applied status OR filter with additional AND filters")
-
-			return finalApps, filteredCount
-		}
-	}
-
-	// Standard filtering using LabelManager (AND logic for all filters)
-	matchingAppIDs := labelManager.FindAppsByLabels(filters)
-
-	// Convert unique IDs back to full AppStatus objects
-	idToApp := make(map[string]AppStatus, len(apps))
-	for _, app := range apps {
-		uniqueID := app.Source + ":" + app.Name
-		idToApp[uniqueID] = app
-	}
-
-	filteredApps := make([]AppStatus, 0, len(matchingAppIDs))
-	for _, appID := range matchingAppIDs {
-		if app, exists := idToApp[appID]; exists {
-			filteredApps = append(filteredApps, app)
-		}
-	}
-
-	// Sort filtered apps by name for deterministic ordering
-	sort.Slice(filteredApps, func(i, j int) bool {
-		return filteredApps[i].Name < filteredApps[j].Name
-	})
-
-	filteredCount := len(apps) - len(filteredApps)
-	duration := time.Since(start)
-
-	logging.Logger.WithFields(map[string]interface{}{
-		"total_apps":    len(apps),
-		"filtered_apps": len(filteredApps),
-		"filtered_out":  filteredCount,
-		"duration_μs":   duration.Microseconds(),
-		"filters":       filters,
-	}).Debug("Applied unified filters using field manager")
-
-	return filteredApps, filteredCount
-}
-
 // ResetCacheForTesting resets all global cache state for test isolation
 func ResetCacheForTesting() {
 	cacheMutex.Lock()
@@ -806,6 +739,9 @@ func ResetCacheForTesting() {
 
 	labelManager = labels.NewLabelManager()
 
+	SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+	SetMaintenanceStore(maintenance.NewStore())
+
 	// Reset metrics
 	updateMetrics.totalUpdates = 0
 	updateMetrics.totalAppsAdded = 0
@@ -934,22 +870,27 @@ func HandleSyncRequest(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	}
 
 	// Parse query parameters for both system field and label filtering
-	filters := parseFilters(r.URL.Query())
+	filter, err := parseFilters(knownFilterParams(r.URL.Query()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Return current statuses and locations from the global cache
 	apps := GetAppStatusCache()
 	locations := GetLocationCache()
 
 	// Apply all filters if any were specified
-	filteredApps, filteredCount := filterApps(apps, filters)
+	filteredApps, filteredCount := filterApps(apps, filter)
 
 	// Add server's own locations from config with empty source
 	serverLocations := convertToHandlersLocation(cfg.Locations)
 	locations = append(locations, serverLocations...)
 
 	response := StatusResponse{
-		Locations: locations,
-		Apps:      filteredApps,
+		Locations:   locations,
+		Apps:        filteredApps,
+		Maintenance: GetMaintenanceStore().Local(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -961,7 +902,7 @@ func HandleSyncRequest(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 		"locations_count":  len(response.Locations),
 		"apps":             response.Apps,
 		"locations":        response.Locations,
-		"system_filters":   filters,
+		"filter":           filter.String(),
 		"filtered_count":   filteredCount,
 		"response_headers": w.Header(),
 		"remote_addr":      r.RemoteAddr,
@@ -976,7 +917,7 @@ func HandleSyncRequest(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	logging.Logger.WithFields(map[string]interface{}{
 		"apps":           len(response.Apps),
 		"locations":      len(response.Locations),
-		"system_filters": filters,
+		"filter":         filter.String(),
 		"filtered_count": filteredCount,
 		"remote_addr":    r.RemoteAddr,
 	}).Debug("Sync response sent successfully")
@@ -989,7 +930,9 @@ type LocationStatusResponse struct {
 
 // AppsResponse represents the response for /api/apps
 type AppsResponse struct {
-	Apps []AppStatus `json:"apps"`
+	Apps       []AppStatus `json:"apps"`
+	Total      int         `json:"total"`                 // Number of apps matching the filters, across all pages
+	NextCursor string      `json:"next_cursor,omitempty"` // Cursor of the next page, when limit leaves apps out
 }
 
 // LabelsResponse represents the response for /api/labels
@@ -1002,7 +945,11 @@ func GetLocations(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	logging.Logger.Debug("Handling /api/locations request")
 
 	// Parse query parameters for filtering
-	filters := parseFilters(r.URL.Query())
+	filter, err := parseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get all apps first
 	allApps := GetAppStatusCache()
@@ -1011,10 +958,10 @@ func GetLocations(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	var filteredApps []AppStatus
 	var filteredCount int
 
-	if len(filters) > 0 {
-		filteredApps, filteredCount = filterApps(allApps, filters)
+	if !filter.IsEmpty() {
+		filteredApps, filteredCount = filterApps(allApps, filter)
 		logging.Logger.WithFields(map[string]interface{}{
-			"filters":        filters,
+			"filter":         filter.String(),
 			"filtered_count": filteredCount,
 			"filtered_apps":  len(filteredApps),
 		}).Debug("Applied filters to apps for location status calculation")
@@ -1028,25 +975,29 @@ func GetLocations(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	// Recalculate status and counts for each location based on filtered apps
 	for i := range locations {
 		locations[i].Status = calculateLocationStatus(locations[i].Name, filteredApps)
-		upCount, downCount, unavailableCount := calculateLocationStatusCounts(locations[i].Name, filteredApps)
+		upCount, downCount, unavailableCount, degradedCount, maintenanceCount := calculateLocationStatusCounts(locations[i].Name, filteredApps)
 		locations[i].Up = upCount
 		locations[i].Down = downCount
 		locations[i].Unavailable = unavailableCount
+		locations[i].Degraded = degradedCount
+		locations[i].Maintenance = maintenanceCount
 	}
 
 	// Add server's own locations from config with status calculated from filtered apps
 	serverLocations := convertToHandlersLocation(cfg.Locations)
 	for i := range serverLocations {
 		serverLocations[i].Status = calculateLocationStatus(serverLocations[i].Name, filteredApps)
-		upCount, downCount, unavailableCount := calculateLocationStatusCounts(serverLocations[i].Name, filteredApps)
+		upCount, downCount, unavailableCount, degradedCount, maintenanceCount := calculateLocationStatusCounts(serverLocations[i].Name, filteredApps)
 		serverLocations[i].Up = upCount
 		serverLocations[i].Down = downCount
 		serverLocations[i].Unavailable = unavailableCount
+		serverLocations[i].Degraded = degradedCount
+		serverLocations[i].Maintenance = maintenanceCount
 	}
 	locations = append(locations, serverLocations...)
 
 	// If filtering is applied, only return locations that have apps matching the filter
-	if len(filters) > 0 {
+	if !filter.IsEmpty() {
 		locationsWithApps := make([]Location, 0)
 		locationHasApps := make(map[string]bool)
 
@@ -1077,7 +1028,7 @@ func GetLocations(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 
 	logging.Logger.WithFields(map[string]interface{}{
 		"locations":      len(response.Locations),
-		"filters":        filters,
+		"filter":         filter.String(),
 		"filtered_count": filteredCount,
 	}).Debug("Locations response sent with filtered status calculation")
 }
@@ -1086,35 +1037,36 @@ func GetLocations(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 func GetApps(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	logging.Logger.Debug("Handling /api/apps request")
 
-	// Parse all query parameters for filtering (including location)
-	filters := parseFilters(r.URL.Query())
+	// Parse all query parameters for filtering (including location), sorting and paging
+	filter, err := parseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	apps := GetAppStatusCache()
 
 	// Apply all filters if any were specified
-	if len(filters) > 0 {
+	if !filter.IsEmpty() {
 		var filteredCount int
-		apps, filteredCount = filterApps(apps, filters)
+		apps, filteredCount = filterApps(apps, filter)
 		logging.Logger.WithFields(map[string]interface{}{
-			"filters":        filters,
+			"filter":         filter.String(),
 			"filtered_count": filteredCount,
 		}).Debug("Applied filters to apps")
 	}
 
-	response := AppsResponse{
-		Apps: apps,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logging.Logger.WithError(err).Error("Failed to encode apps response")
-		http.Error(w, "Failed to encode apps", http.StatusInternalServerError)
-		return
-	}
+	response := writeAppsPage(w, page, apps)
 
 	logging.Logger.WithFields(map[string]interface{}{
-		"apps":    len(response.Apps),
-		"filters": filters,
+		"apps":   len(response.Apps),
+		"total":  response.Total,
+		"filter": filter.String(),
 	}).Debug("Apps response sent")
 }
 
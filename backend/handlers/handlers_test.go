@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"site-availability/config"
 	"site-availability/labels"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -289,6 +291,22 @@ func TestGetApps(t *testing.T) {
 		require.NoError(t, err)
 		assert.Empty(t, response.Apps)
 	})
+
+	t.Run("unknown and operator parameters are rejected", func(t *testing.T) {
+		setupTest()
+		updateAppStatusTest("test-source", []AppStatus{
+			{Name: "app1", Location: "location1", Status: "up", Source: "test-source"},
+		})
+
+		for _, query := range []string{"nmae=app1", "labels.env!=dev", "name=~%22app.*%22"} {
+			req := httptest.NewRequest("GET", "/api/apps?"+query, nil)
+			w := httptest.NewRecorder()
+
+			GetApps(w, req, &config.Config{})
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
 }
 
 func TestConvertToHandlersLocation(t *testing.T) {
@@ -330,6 +348,124 @@ func TestConvertToHandlersLocation(t *testing.T) {
 	})
 }
 
+func TestCalculateLocationStatus(t *testing.T) {
+	app := func(status string) AppStatus {
+		return AppStatus{Name: "app-" + status, Location: "loc1", Status: status}
+	}
+	stringPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		apps     []AppStatus
+		expected *string
+	}{
+		{name: "no apps", apps: nil, expected: nil},
+		{name: "all up", apps: []AppStatus{app("up"), app("up")}, expected: stringPtr("up")},
+		{name: "degraded", apps: []AppStatus{app("up"), app("degraded")}, expected: stringPtr("degraded")},
+		{name: "unavailable beats degraded", apps: []AppStatus{app("degraded"), app("unavailable")}, expected: stringPtr("unavailable")},
+		{name: "down beats everything", apps: []AppStatus{app("degraded"), app("unavailable"), app("down")}, expected: stringPtr("down")},
+		{name: "other locations are ignored", apps: []AppStatus{app("up"), {Name: "other", Location: "loc2", Status: "down"}}, expected: stringPtr("up")},
+		{name: "maintenance is ignored", apps: []AppStatus{app("up"), app("maintenance")}, expected: stringPtr("up")},
+		{name: "all in maintenance", apps: []AppStatus{app("maintenance")}, expected: stringPtr("maintenance")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, calculateLocationStatus("loc1", tt.apps))
+		})
+	}
+}
+
+func TestCalculateLocationStatusCounts(t *testing.T) {
+	apps := []AppStatus{
+		{Name: "a", Location: "loc1", Status: "up"},
+		{Name: "b", Location: "loc1", Status: "degraded"},
+		{Name: "c", Location: "loc1", Status: "degraded"},
+		{Name: "d", Location: "loc1", Status: "down"},
+		{Name: "e", Location: "loc1", Status: "unavailable"},
+		{Name: "f", Location: "loc1", Status: "unknown"},
+		{Name: "g", Location: "loc2", Status: "degraded"},
+		{Name: "h", Location: "loc1", Status: "maintenance"},
+	}
+
+	up, down, unavailable, degraded, maintenance := calculateLocationStatusCounts("loc1", apps)
+	assert.Equal(t, 1, up)
+	assert.Equal(t, 1, down)
+	assert.Equal(t, 2, unavailable)
+	assert.Equal(t, 2, degraded)
+	assert.Equal(t, 1, maintenance, "apps in maintenance are not counted as down or unavailable")
+}
+
+func TestUpdateAppStatusAcceptsDegraded(t *testing.T) {
+	setupTest()
+
+	updateAppStatusTest("test-source", []AppStatus{
+		{Name: "slow-app", Location: "loc1", Status: "degraded", Source: "test-source"},
+	})
+
+	apps := GetAppStatusCache()
+	require.Len(t, apps, 1)
+	assert.Equal(t, "degraded", apps[0].Status)
+
+	filtered, _ := filterApps(apps, fieldFilter(t, map[string]string{"status": "degraded"}))
+	assert.Len(t, filtered, 1)
+	filtered, _ = filterApps(apps, fieldFilter(t, map[string]string{"status": "up|degraded"}))
+	assert.Len(t, filtered, 1)
+	filtered, _ = filterApps(apps, fieldFilter(t, map[string]string{"status": "up"}))
+	assert.Empty(t, filtered)
+}
+
+func TestRemoveSource(t *testing.T) {
+	setupTest()
+
+	updateAppStatusTest("removed-source", []AppStatus{{Name: "old-app", Location: "loc1", Status: "up", Source: "removed-source"}})
+	updateAppStatusTest("kept-source", []AppStatus{{Name: "app", Location: "loc1", Status: "up", Source: "kept-source"}})
+	UpdateLocationCache("removed-source", []Location{{Name: "remote", Latitude: 1, Longitude: 2}}, nil)
+
+	RemoveSource("removed-source")
+
+	apps := GetAppStatusCache()
+	require.Len(t, apps, 1)
+	assert.Equal(t, "kept-source", apps[0].Source)
+	assert.Empty(t, GetLocationCache())
+}
+
+func TestUpdateAppStatusCheckMetadata(t *testing.T) {
+	setupTest()
+
+	lastChange := func() time.Time {
+		apps := GetAppStatusCache()
+		require.Len(t, apps, 1)
+		return apps[0].LastChange
+	}
+
+	updateAppStatusTest("meta-source", []AppStatus{{Name: "app", Location: "loc1", Status: "up", Source: "meta-source"}})
+	firstChange := lastChange()
+	require.False(t, firstChange.IsZero())
+	assert.False(t, GetAppStatusCache()[0].LastCheck.IsZero(), "last check defaults to the update time")
+
+	time.Sleep(5 * time.Millisecond)
+	updateAppStatusTest("meta-source", []AppStatus{{Name: "app", Location: "loc1", Status: "up", Source: "meta-source"}})
+	assert.Equal(t, firstChange, lastChange(), "unchanged status keeps the last change")
+
+	checked := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	down := AppStatus{Name: "app", Location: "loc1", Status: "down", Source: "meta-source"}
+	down.RecordCheck(checked, 1500*time.Microsecond, fmt.Errorf("status code 503 is in blocked codes"))
+	updateAppStatusTest("meta-source", []AppStatus{down})
+
+	app := GetAppStatusCache()[0]
+	assert.True(t, app.LastChange.After(firstChange))
+	assert.Equal(t, checked, app.LastCheck)
+	assert.Equal(t, 1.5, app.ResponseTimeMs)
+	assert.Equal(t, "status code 503 is in blocked codes", app.Reason)
+
+	t.Run("synced apps keep the times of their origin", func(t *testing.T) {
+		remote := AppStatus{Name: "app", Location: "loc1", Status: "up", Source: "meta-source", LastCheck: checked, LastChange: checked}
+		updateAppStatusTest("meta-source", []AppStatus{remote})
+		assert.Equal(t, checked, lastChange())
+	})
+}
+
 func TestGetScrapeInterval(t *testing.T) {
 	t.Run("valid interval", func(t *testing.T) {
 		cfg := &config.Config{
@@ -492,6 +628,30 @@ func TestHandleSyncRequest(t *testing.T) {
 		assert.Len(t, response.Locations, 0)
 	})
 
+	t.Run("sync ignores parameters that are not filters", func(t *testing.T) {
+		setupTest()
+
+		cfg := &config.Config{
+			ServerSettings: config.ServerSettings{
+				SyncEnable: true,
+			},
+		}
+		updateAppStatusTest("test-source", []AppStatus{
+			{Name: "app1", Location: "loc1", Status: "up", Source: "test-source", OriginURL: "http://test-origin.com"},
+		})
+
+		// Sites running other versions may send parameters this site doesn't know
+		req := httptest.NewRequest("GET", "/sync?site=remote&location=loc1", nil)
+		w := httptest.NewRecorder()
+
+		HandleSyncRequest(w, req, cfg)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response StatusResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		require.Len(t, response.Apps, 1)
+	})
+
 	t.Run("sync enabled with token but no signature", func(t *testing.T) {
 		setupTest()
 
@@ -655,7 +815,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 
 	t.Run("no filters returns all apps", func(t *testing.T) {
 		filters := map[string]string{}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 4)
 		assert.Equal(t, 0, filteredCount)
@@ -664,7 +824,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 
 	t.Run("single filter matches multiple apps", func(t *testing.T) {
 		filters := map[string]string{"labels.env": "production"}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 2)
 		assert.Equal(t, 2, filteredCount)
@@ -674,7 +834,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 
 	t.Run("single filter matches single app", func(t *testing.T) {
 		filters := map[string]string{"labels.env": "staging"}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 1)
 		assert.Equal(t, 3, filteredCount)
@@ -686,7 +846,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 			"labels.env":  "production",
 			"labels.tier": "backend",
 		}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 1)
 		assert.Equal(t, 3, filteredCount)
@@ -695,7 +855,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 
 	t.Run("filter with no matches", func(t *testing.T) {
 		filters := map[string]string{"labels.env": "development"}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 0)
 		assert.Equal(t, 4, filteredCount)
@@ -703,7 +863,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 
 	t.Run("filter excludes apps without required labels", func(t *testing.T) {
 		filters := map[string]string{"labels.team": "platform"}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 2)
 		assert.Equal(t, 2, filteredCount)
@@ -718,7 +878,7 @@ func TestFilterAppsByLabels(t *testing.T) {
 			"labels.team":    "security",
 			"labels.version": "v2.0.0",
 		}
-		filteredApps, filteredCount := filterApps(testApps, filters)
+		filteredApps, filteredCount := filterApps(testApps, fieldFilter(t, filters))
 
 		assert.Len(t, filteredApps, 1)
 		assert.Equal(t, 3, filteredCount)
@@ -777,7 +937,7 @@ func TestFilterAppsBySpecificLabel_ShouldNotReturnAppsWithoutLabel(t *testing.T)
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Should only return the one app with version=v1.0
 		assert.Equal(t, 1, len(filteredApps), "Should only return 1 app with version=v1.0")
@@ -801,7 +961,7 @@ func TestFilterAppsBySpecificLabel_ShouldNotReturnAppsWithoutLabel(t *testing.T)
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Should only return the one app with version=v2.0
 		assert.Equal(t, 1, len(filteredApps), "Should only return 1 app with version=v2.0")
@@ -814,7 +974,7 @@ func TestFilterAppsBySpecificLabel_ShouldNotReturnAppsWithoutLabel(t *testing.T)
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Should return no apps
 		assert.Equal(t, 0, len(filteredApps), "Should return no apps with non-existent version")
@@ -826,7 +986,7 @@ func TestFilterAppsBySpecificLabel_ShouldNotReturnAppsWithoutLabel(t *testing.T)
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Check that none of the returned apps are missing the version label
 		for _, app := range filteredApps {
@@ -927,7 +1087,7 @@ func TestRealWorldBug_VersionLabelFiltering(t *testing.T) {
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Should only return app1 which has both location=Houston AND version=v1.0
 		assert.Equal(t, 1, len(filteredApps), "Should only return 1 app with location=Houston AND version=v1.0")
@@ -965,7 +1125,7 @@ func TestRealWorldBug_VersionLabelFiltering(t *testing.T) {
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Should return all 3 apps in Houston
 		assert.Equal(t, 3, len(filteredApps), "Should return all 3 apps in Houston")
@@ -977,7 +1137,7 @@ func TestRealWorldBug_VersionLabelFiltering(t *testing.T) {
 		}
 
 		allApps := GetAppStatusCache()
-		filteredApps, _ := filterApps(allApps, filters)
+		filteredApps, _ := filterApps(allApps, fieldFilter(t, filters))
 
 		// Should only return app1
 		assert.Equal(t, 1, len(filteredApps), "Should only return 1 app with version=v1.0")
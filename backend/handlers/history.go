@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/logging"
+)
+
+var (
+	// historyStore records status transitions detected in UpdateAppStatus
+	historyStore history.Store = history.NewMemoryStore(history.DefaultRetention)
+	historyMutex sync.RWMutex
+
+	// transitionListeners are notified of status transitions after they are recorded
+	transitionListeners []TransitionListener
+	listenersMutex      sync.RWMutex
+)
+
+// TransitionListener receives the status transitions detected in a single UpdateAppStatus call
+type TransitionListener func(transitions []history.Transition)
+
+// HistoryEntry represents a single segment of an app's status timeline
+type HistoryEntry struct {
+	history.Transition
+	EndedAt         *time.Time `json:"ended_at,omitempty"` // nil while the status is still current
+	DurationSeconds float64    `json:"duration_seconds"`
+}
+
+// AppHistoryResponse represents the response for /api/apps/{name}/history
+type AppHistoryResponse struct {
+	App      string         `json:"app"`
+	Timeline []HistoryEntry `json:"timeline"`
+}
+
+// SetHistoryStore replaces the store used to record status transitions
+func SetHistoryStore(store history.Store) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	historyStore = store
+}
+
+// GetHistoryStore returns the store used to record status transitions
+func GetHistoryStore() history.Store {
+	historyMutex.RLock()
+	defer historyMutex.RUnlock()
+	return historyStore
+}
+
+// AddTransitionListener registers a listener for status transitions
+func AddTransitionListener(listener TransitionListener) {
+	listenersMutex.Lock()
+	defer listenersMutex.Unlock()
+	transitionListeners = append(transitionListeners, listener)
+}
+
+// ClearTransitionListeners removes all registered transition listeners
+func ClearTransitionListeners() {
+	listenersMutex.Lock()
+	defer listenersMutex.Unlock()
+	transitionListeners = nil
+}
+
+// publishTransitions records transitions in the history store and passes them to the listeners
+func publishTransitions(transitions []history.Transition) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	recordTransitions(transitions)
+
+	listenersMutex.RLock()
+	listeners := append([]TransitionListener(nil), transitionListeners...)
+	listenersMutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(transitions)
+	}
+
+	events.publish(transitions)
+}
+
+// newTransition creates a history transition for an app entering a new status
+func newTransition(app AppStatus, sourceName, previousStatus string, timestamp time.Time) history.Transition {
+	return history.Transition{
+		Timestamp:      timestamp,
+		App:            app.Name,
+		Source:         sourceName,
+		OriginURL:      app.OriginURL,
+		Location:       app.Location,
+		Status:         app.Status,
+		PreviousStatus: previousStatus,
+		Labels:         app.Labels,
+	}
+}
+
+// recordTransitions stores transitions in the history store, logging failures
+func recordTransitions(transitions []history.Transition) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	store := GetHistoryStore()
+	if store == nil {
+		return
+	}
+
+	if err := store.Record(transitions...); err != nil {
+		logging.Logger.WithError(err).WithField("transitions", len(transitions)).Error("Failed to record status history")
+		return
+	}
+
+	logging.Logger.WithField("transitions", len(transitions)).Debug("Recorded status transitions")
+}
+
+// parseHistoryTime parses a history time parameter.
+// Accepts RFC3339 timestamps or a duration relative to now (e.g., "24h" means 24 hours ago).
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// buildTimeline converts transitions into timeline entries with end times and durations
+func buildTimeline(transitions []history.Transition, until, now time.Time) []HistoryEntry {
+	timeline := make([]HistoryEntry, 0, len(transitions))
+
+	// Transitions are ordered by time, so the next transition of the same series ends the current one
+	lastIndex := make(map[string]int)
+	for _, t := range transitions {
+		key := t.Key()
+		if i, ok := lastIndex[key]; ok {
+			endedAt := t.Timestamp
+			timeline[i].EndedAt = &endedAt
+			timeline[i].DurationSeconds = endedAt.Sub(timeline[i].Timestamp).Seconds()
+		}
+		lastIndex[key] = len(timeline)
+		timeline = append(timeline, HistoryEntry{Transition: t})
+	}
+
+	// Current statuses last until now (or the end of the requested range)
+	end := now
+	if !until.IsZero() && until.Before(now) {
+		end = until
+	}
+	for i := range timeline {
+		if timeline[i].EndedAt == nil {
+			timeline[i].DurationSeconds = end.Sub(timeline[i].Timestamp).Seconds()
+		}
+	}
+
+	return timeline
+}
+
+// GetAppHistoryWithAuthz handles the /api/apps/{name}/history endpoint with authorization filtering
+// Supports: ?source=prom&origin_url=https://a.com&since=24h&until=2025-01-01T00:00:00Z&limit=100
+func GetAppHistoryWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	appName := r.PathValue("name")
+	logging.Logger.WithField("app", appName).Debug("Handling /api/apps/{name}/history request with authorization")
+
+	if strings.TrimSpace(appName) == "" {
+		http.Error(w, "App name is required", http.StatusBadRequest)
+		return
+	}
+
+	queryParams := r.URL.Query()
+	now := time.Now()
+	query := history.Query{
+		App:       appName,
+		Source:    queryParams.Get("source"),
+		OriginURL: queryParams.Get("origin_url"),
+	}
+
+	if since := queryParams.Get("since"); since != "" {
+		parsed, err := parseHistoryTime(since, now)
+		if err != nil {
+			http.Error(w, "Invalid 'since' parameter: use RFC3339 or a duration like 24h", http.StatusBadRequest)
+			return
+		}
+		query.Since = parsed
+	}
+
+	var until time.Time
+	if untilParam := queryParams.Get("until"); untilParam != "" {
+		parsed, err := parseHistoryTime(untilParam, now)
+		if err != nil {
+			http.Error(w, "Invalid 'until' parameter: use RFC3339 or a duration like 1h", http.StatusBadRequest)
+			return
+		}
+		until = parsed
+	}
+
+	limit := 0
+	if limitParam := queryParams.Get("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid 'limit' parameter", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	store := GetHistoryStore()
+	if store == nil {
+		http.Error(w, "Status history is disabled", http.StatusNotFound)
+		return
+	}
+
+	transitions, err := store.Query(query)
+	if err != nil {
+		logging.Logger.WithError(err).WithField("app", appName).Error("Failed to query status history")
+		http.Error(w, "Failed to query status history", http.StatusInternalServerError)
+		return
+	}
+
+	// Apply authorization filters if user doesn't have full access
+	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
+	if hasPermissions && !userPermissions.HasFullAccess {
+		authorizer := rbac.NewAuthorizer(cfg)
+
+		var authorized []history.Transition
+		for _, t := range transitions {
+			if authorizer.CanAccessApp(userPermissions, t.Labels) {
+				authorized = append(authorized, t)
+			}
+		}
+		transitions = authorized
+	}
+
+	// Don't reveal whether an app exists to users who can't access it
+	if len(transitions) == 0 {
+		http.Error(w, "No history found for app", http.StatusNotFound)
+		return
+	}
+
+	// Durations are computed from the full range before trimming to 'until' and 'limit'
+	timeline := buildTimeline(transitions, until, now)
+	if !until.IsZero() {
+		trimmed := make([]HistoryEntry, 0, len(timeline))
+		for _, entry := range timeline {
+			if !entry.Timestamp.After(until) {
+				trimmed = append(trimmed, entry)
+			}
+		}
+		timeline = trimmed
+	}
+	if limit > 0 && len(timeline) > limit {
+		timeline = timeline[len(timeline)-limit:]
+	}
+
+	response := AppHistoryResponse{
+		App:      appName,
+		Timeline: timeline,
+	}
+
+	writeJSONResponse(w, response, "app history")
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"app":             appName,
+		"entries":         len(response.Timeline),
+		"has_permissions": hasPermissions,
+		"is_admin":        userPermissions.HasFullAccess,
+	}).Debug("App history response sent")
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAppStatusRecordsTransitions(t *testing.T) {
+	setupTest()
+	SetHistoryStore(history.NewMemoryStore(time.Hour))
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments-api", Location: "eu-west", Status: "up", Source: "prom"},
+		{Name: "checkout", Location: "eu-west", Status: "up", Source: "prom"},
+	})
+	// Unchanged status must not produce a new transition
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments-api", Location: "eu-west", Status: "up", Source: "prom"},
+		{Name: "checkout", Location: "eu-west", Status: "up", Source: "prom"},
+	})
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments-api", Location: "eu-west", Status: "down", Source: "prom"},
+		{Name: "checkout", Location: "eu-west", Status: "up", Source: "prom"},
+	})
+
+	transitions, err := GetHistoryStore().Query(history.Query{App: "payments-api"})
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+
+	assert.Equal(t, "up", transitions[0].Status)
+	assert.Equal(t, "", transitions[0].PreviousStatus)
+	assert.Equal(t, "down", transitions[1].Status)
+	assert.Equal(t, "up", transitions[1].PreviousStatus)
+	assert.Equal(t, "prom", transitions[1].Source)
+	assert.Equal(t, "eu-west", transitions[1].Location)
+	assert.Equal(t, "https://test-origin.com", transitions[1].OriginURL)
+	assert.NotEmpty(t, transitions[1].Labels, "merged labels should be recorded")
+
+	checkout, err := GetHistoryStore().Query(history.Query{App: "checkout"})
+	require.NoError(t, err)
+	assert.Len(t, checkout, 1)
+}
+
+func TestUpdateAppStatusWithoutHistoryStore(t *testing.T) {
+	setupTest()
+	SetHistoryStore(nil)
+	defer SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "app1", Location: "loc1", Status: "up", Source: "prom"},
+	})
+	assert.Len(t, GetAppStatusCache(), 1)
+}
+
+func TestTransitionListeners(t *testing.T) {
+	setupTest()
+	SetHistoryStore(nil)
+	defer SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+	defer ClearTransitionListeners()
+
+	var received [][]history.Transition
+	AddTransitionListener(func(transitions []history.Transition) {
+		received = append(received, transitions)
+	})
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "app1", Location: "loc1", Status: "up", Source: "prom"},
+	})
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "app1", Location: "loc1", Status: "up", Source: "prom"},
+	})
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "app1", Location: "loc1", Status: "down", Source: "prom"},
+	})
+
+	// Listeners are called even without a history store, and only when something changed
+	require.Len(t, received, 2)
+	require.Len(t, received[1], 1)
+	assert.Equal(t, "down", received[1][0].Status)
+	assert.Equal(t, "up", received[1][0].PreviousStatus)
+
+	ClearTransitionListeners()
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "app1", Location: "loc1", Status: "up", Source: "prom"},
+	})
+	assert.Len(t, received, 2)
+}
+
+func TestGetAppHistoryWithAuthz(t *testing.T) {
+	setupTest()
+
+	base := time.Now().Add(-time.Hour).UTC()
+	store := history.NewMemoryStore(24 * time.Hour)
+	require.NoError(t, store.Record(
+		history.Transition{Timestamp: base, App: "payments-api", Source: "prom", OriginURL: "https://a.com", Location: "eu-west", Status: "up",
+			Labels: []labels.Label{{Key: "team", Value: "payments"}}},
+		history.Transition{Timestamp: base.Add(30 * time.Minute), App: "payments-api", Source: "prom", OriginURL: "https://a.com", Location: "eu-west", Status: "down", PreviousStatus: "up",
+			Labels: []labels.Label{{Key: "team", Value: "payments"}}},
+		history.Transition{Timestamp: base.Add(10 * time.Minute), App: "payments-api", Source: "http", OriginURL: "https://a.com", Location: "us-east", Status: "up",
+			Labels: []labels.Label{{Key: "team", Value: "platform"}}},
+	))
+	SetHistoryStore(store)
+	defer SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+
+	cfg := &config.Config{}
+
+	newRequest := func(target, app string, permissions *rbac.UserPermissions) *http.Request {
+		req := httptest.NewRequest("GET", target, nil)
+		req.SetPathValue("name", app)
+		if permissions != nil {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.PermissionsContextKey, *permissions))
+		}
+		return req
+	}
+
+	t.Run("full timeline", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetAppHistoryWithAuthz(w, newRequest("/api/apps/payments-api/history", "payments-api", nil), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AppHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "payments-api", response.App)
+		require.Len(t, response.Timeline, 3)
+
+		// First prom segment ended when the app went down
+		first := response.Timeline[0]
+		assert.Equal(t, "prom", first.Source)
+		require.NotNil(t, first.EndedAt)
+		assert.InDelta(t, 1800, first.DurationSeconds, 1)
+
+		// Latest segments are still current
+		assert.Nil(t, response.Timeline[1].EndedAt)
+		assert.Nil(t, response.Timeline[2].EndedAt)
+		assert.Equal(t, "down", response.Timeline[2].Status)
+	})
+
+	t.Run("filter by source and limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetAppHistoryWithAuthz(w, newRequest("/api/apps/payments-api/history?source=prom&limit=1", "payments-api", nil), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AppHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Timeline, 1)
+		assert.Equal(t, "down", response.Timeline[0].Status)
+	})
+
+	t.Run("relative since", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetAppHistoryWithAuthz(w, newRequest("/api/apps/payments-api/history?since=40m", "payments-api", nil), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AppHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Timeline, 1)
+		assert.Equal(t, "down", response.Timeline[0].Status)
+	})
+
+	t.Run("restricted user only sees authorized transitions", func(t *testing.T) {
+		permissions := &rbac.UserPermissions{
+			AllowedLabels: map[string]rbac.LabelPermission{
+				"team": {Key: "team", AllowedValues: []string{"platform"}},
+			},
+		}
+		w := httptest.NewRecorder()
+		GetAppHistoryWithAuthz(w, newRequest("/api/apps/payments-api/history", "payments-api", permissions), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AppHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Timeline, 1)
+		assert.Equal(t, "http", response.Timeline[0].Source)
+	})
+
+	t.Run("unauthorized user gets not found", func(t *testing.T) {
+		permissions := &rbac.UserPermissions{AllowedLabels: map[string]rbac.LabelPermission{}}
+		w := httptest.NewRecorder()
+		GetAppHistoryWithAuthz(w, newRequest("/api/apps/payments-api/history", "payments-api", permissions), cfg)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unknown app", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetAppHistoryWithAuthz(w, newRequest("/api/apps/missing/history", "missing", nil), cfg)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, target := range []string{
+			"/api/apps/payments-api/history?since=yesterday",
+			"/api/apps/payments-api/history?until=tomorrow",
+			"/api/apps/payments-api/history?limit=-1",
+		} {
+			w := httptest.NewRecorder()
+			GetAppHistoryWithAuthz(w, newRequest(target, "payments-api", nil), cfg)
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		}
+	})
+}
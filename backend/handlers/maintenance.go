@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/logging"
+	"site-availability/maintenance"
+)
+
+// StatusMaintenance is the status of apps in an active maintenance window
+const StatusMaintenance = "maintenance"
+
+var (
+	maintenanceStore      = maintenance.NewStore()
+	maintenanceStoreMutex sync.RWMutex
+)
+
+// SetMaintenanceStore replaces the store holding the maintenance windows
+func SetMaintenanceStore(store *maintenance.Store) {
+	maintenanceStoreMutex.Lock()
+	defer maintenanceStoreMutex.Unlock()
+	maintenanceStore = store
+}
+
+// GetMaintenanceStore returns the store holding the maintenance windows
+func GetMaintenanceStore() *maintenance.Store {
+	maintenanceStoreMutex.RLock()
+	defer maintenanceStoreMutex.RUnlock()
+	return maintenanceStore
+}
+
+// maintenanceApps returns the active windows by unique app identifier ("source:name"), using the label index.
+// The caller must hold cacheMutex.
+func maintenanceApps(now time.Time) map[string][]*maintenance.Window {
+	active := GetMaintenanceStore().Active(now)
+	if len(active) == 0 {
+		return nil
+	}
+
+	result := make(map[string][]*maintenance.Window)
+	for _, window := range active {
+		for _, appID := range labelManager.FindAppsByMatchers(window.LabelMatchers()) {
+			result[appID] = append(result[appID], window)
+		}
+	}
+	return result
+}
+
+// applyMaintenance sets the published status of an app from its scraped status and the active windows selecting it.
+// Windows received from another site only apply to the apps of that site.
+func applyMaintenance(app *AppStatus, windows []*maintenance.Window) {
+	if app.scrapedStatus == "" {
+		app.scrapedStatus, app.scrapedMaintenance = app.Status, app.Maintenance
+	}
+	app.Status, app.Maintenance = app.scrapedStatus, app.scrapedMaintenance
+
+	for _, window := range windows {
+		if window.Kind == maintenance.KindSync && normalizeOriginURL(window.OriginURL) != normalizeOriginURL(app.OriginURL) {
+			continue
+		}
+		app.Status = StatusMaintenance
+		app.Maintenance = window.Name
+		return
+	}
+}
+
+// RefreshMaintenance applies the maintenance windows to all cached apps, recording the resulting status changes.
+// It runs periodically, so windows start and end without waiting for the next scrape, and after windows change.
+func RefreshMaintenance() {
+	var transitions []history.Transition
+	defer func() {
+		publishTransitions(transitions)
+	}()
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	now := time.Now()
+	windows := maintenanceApps(now)
+	for _, originApps := range appStatusCache {
+		for sourceName, sourceApps := range originApps {
+			for name, app := range sourceApps {
+				previousStatus := app.Status
+				applyMaintenance(&app, windows[app.Source+":"+app.Name])
+				if app.Status != previousStatus {
+					app.LastChange = now
+					transitions = append(transitions, newTransition(app, sourceName, previousStatus, now))
+				}
+				sourceApps[name] = app
+			}
+		}
+	}
+
+	if len(transitions) > 0 {
+		updateLabelManager()
+		logging.Logger.WithField("changed_apps", len(transitions)).Info("Applied maintenance windows")
+	}
+}
+
+// MaintenanceWindowStatus is a maintenance window with its current or next occurrence
+type MaintenanceWindowStatus struct {
+	maintenance.Window
+	Active   bool       `json:"active"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// MaintenanceResponse represents the response for /api/maintenance
+type MaintenanceResponse struct {
+	Windows []MaintenanceWindowStatus `json:"windows"`
+}
+
+// GetMaintenanceWithAuthz handles GET /api/maintenance. Users without full access or the maintenance
+// permission only see the windows selecting an app they may access.
+func GetMaintenanceWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	logging.Logger.Debug("Handling /api/maintenance request")
+
+	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
+	var authorizedApps map[string]bool
+	if hasPermissions && !userPermissions.HasFullAccess && !userPermissions.Can(config.PermissionMaintenance) {
+		authorizer := rbac.NewAuthorizer(cfg)
+		authorizedApps = make(map[string]bool)
+		for _, app := range GetAppStatusCache() {
+			if authorizer.CanAccessApp(userPermissions, app.Labels) {
+				authorizedApps[app.Source+":"+app.Name] = true
+			}
+		}
+	}
+
+	now := time.Now()
+	windows := GetMaintenanceStore().All()
+	response := MaintenanceResponse{Windows: make([]MaintenanceWindowStatus, 0, len(windows))}
+	for _, window := range windows {
+		if authorizedApps != nil && !selectsAnyApp(&window, authorizedApps) {
+			continue
+		}
+		status := MaintenanceWindowStatus{Window: window}
+		if start, end, ok := window.Occurrence(now); ok {
+			status.Active = !now.Before(start) && now.Before(end)
+			status.StartsAt, status.EndsAt = &start, &end
+		}
+		response.Windows = append(response.Windows, status)
+	}
+
+	writeJSONResponse(w, response, "maintenance")
+}
+
+// selectsAnyApp reports whether a window selects one of the apps, keyed by unique app identifier ("source:name")
+func selectsAnyApp(window *maintenance.Window, apps map[string]bool) bool {
+	for _, appID := range labelManager.FindAppsByMatchers(window.LabelMatchers()) {
+		if apps[appID] {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateMaintenanceWithAuthz handles POST /api/maintenance. The body is a window like in the config,
+// except that a window without a schedule starts now when start is not set and can end after a duration.
+func CreateMaintenanceWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if !canChangeMaintenance(w, r) {
+		return
+	}
+
+	var spec config.MaintenanceWindow
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&spec); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if spec.Schedule == "" {
+		if spec.Start == "" {
+			spec.Start = now.UTC().Format(time.RFC3339)
+		}
+		if spec.End == "" && spec.Duration != "" {
+			start, err := time.Parse(time.RFC3339, spec.Start)
+			duration, durationErr := time.ParseDuration(spec.Duration)
+			if err == nil && durationErr == nil {
+				spec.End = start.Add(duration).Format(time.RFC3339)
+				spec.Duration = ""
+			}
+		}
+	}
+
+	createdBy := ""
+	if user, ok := middleware.GetUserFromContext(r); ok {
+		createdBy = user.Username
+	}
+
+	window, err := GetMaintenanceStore().Add(spec, createdBy, cfg.ServerSettings.HostURL, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	RefreshMaintenance()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(window); err != nil {
+		logging.Logger.WithError(err).Error("Failed to encode maintenance window")
+	}
+}
+
+// DeleteMaintenanceWithAuthz handles DELETE /api/maintenance/{id}
+func DeleteMaintenanceWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if !canChangeMaintenance(w, r) {
+		return
+	}
+
+	err := GetMaintenanceStore().Delete(r.PathValue("id"))
+	switch {
+	case errors.Is(err, maintenance.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	case errors.Is(err, maintenance.ErrReadOnly):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	RefreshMaintenance()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canChangeMaintenance checks that the user may create and delete maintenance windows, writing an error if not
+func canChangeMaintenance(w http.ResponseWriter, r *http.Request) bool {
+	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
+	if hasPermissions && !userPermissions.Can(config.PermissionMaintenance) {
+		http.Error(w, fmt.Sprintf("Permission %q required", config.PermissionMaintenance), http.StatusForbidden)
+		return false
+	}
+	return true
+}
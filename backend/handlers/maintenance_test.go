@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+	"site-availability/maintenance"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activeWindow returns a window for the matchers that is active for the next hour
+func activeWindow(name string, matchers ...string) config.MaintenanceWindow {
+	now := time.Now().UTC()
+	return config.MaintenanceWindow{
+		Name:     name,
+		Matchers: matchers,
+		Start:    now.Add(-time.Minute).Format(time.RFC3339),
+		End:      now.Add(time.Hour).Format(time.RFC3339),
+	}
+}
+
+// appStatusesByName returns the cached status of every app by name
+func appStatusesByName() map[string]AppStatus {
+	result := make(map[string]AppStatus)
+	for _, app := range GetAppStatusCache() {
+		result[app.Name] = app
+	}
+	return result
+}
+
+func TestUpdateAppStatusAppliesMaintenance(t *testing.T) {
+	ResetCacheForTesting()
+	defer ResetCacheForTesting()
+	defer ClearTransitionListeners()
+
+	var received []history.Transition
+	AddTransitionListener(func(transitions []history.Transition) {
+		received = append(received, transitions...)
+	})
+
+	store := GetMaintenanceStore()
+	require.NoError(t, store.Configure([]config.MaintenanceWindow{activeWindow("eu upgrade", `location=~"eu-.*"`, `team="payments"`)}, "https://test-server.com"))
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments", Location: "eu-west", Status: "down", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "payments"}}},
+		{Name: "search", Location: "eu-west", Status: "down", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "search"}}},
+		{Name: "checkout", Location: "us-east", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "payments"}}},
+	})
+
+	apps := appStatusesByName()
+	assert.Equal(t, "maintenance", apps["payments"].Status)
+	assert.Equal(t, "eu upgrade", apps["payments"].Maintenance)
+	assert.Equal(t, "down", apps["search"].Status)
+	assert.Empty(t, apps["search"].Maintenance)
+	assert.Equal(t, "up", apps["checkout"].Status)
+
+	filtered, _ := filterApps(GetAppStatusCache(), fieldFilter(t, map[string]string{"status": "maintenance"}))
+	require.Len(t, filtered, 1, "the maintenance status is indexed")
+
+	up, down, _, _, inMaintenance := calculateLocationStatusCounts("eu-west", GetAppStatusCache())
+	assert.Equal(t, 0, up)
+	assert.Equal(t, 1, down)
+	assert.Equal(t, 1, inMaintenance)
+
+	t.Run("windows ending restore the scraped status", func(t *testing.T) {
+		received = nil
+		require.NoError(t, store.Configure(nil, "https://test-server.com"))
+		RefreshMaintenance()
+
+		apps := appStatusesByName()
+		assert.Equal(t, "down", apps["payments"].Status)
+		assert.Empty(t, apps["payments"].Maintenance)
+		require.Len(t, received, 1)
+		assert.Equal(t, "down", received[0].Status)
+		assert.Equal(t, "maintenance", received[0].PreviousStatus)
+	})
+
+	t.Run("windows starting between scrapes", func(t *testing.T) {
+		received = nil
+		_, err := store.Add(activeWindow("search", `name="search"`), "alice", "https://test-server.com", time.Now())
+		require.NoError(t, err)
+		RefreshMaintenance()
+
+		assert.Equal(t, "maintenance", appStatusesByName()["search"].Status)
+		require.Len(t, received, 1)
+		assert.Equal(t, "maintenance", received[0].Status)
+
+		// The next scrape keeps the app in maintenance without a new transition
+		received = nil
+		updateAppStatusTest("prom", []AppStatus{
+			{Name: "search", Location: "eu-west", Status: "down", Source: "prom"},
+		})
+		assert.Equal(t, "maintenance", appStatusesByName()["search"].Status)
+		assert.Empty(t, received)
+	})
+}
+
+func TestSyncedMaintenanceWindowsOnlyApplyToTheirSite(t *testing.T) {
+	ResetCacheForTesting()
+	defer ResetCacheForTesting()
+
+	GetMaintenanceStore().SetSynced("site-b", []maintenance.Window{{
+		MaintenanceWindow: activeWindow("remote", `name="payments"`),
+		OriginURL:         "https://site-b.example.com",
+	}})
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments", Location: "loc1", Status: "down", Source: "prom", OriginURL: "https://site-a.example.com"},
+	})
+	updateAppStatusTest("site-b", []AppStatus{
+		{Name: "payments", Location: "loc2", Status: "down", Source: "site-b", OriginURL: "https://site-b.example.com/"},
+	})
+
+	statuses := make(map[string]string)
+	for _, app := range GetAppStatusCache() {
+		statuses[app.OriginURL] = app.Status
+	}
+	assert.Equal(t, map[string]string{
+		"https://site-a.example.com":  "down",
+		"https://site-b.example.com/": "maintenance",
+	}, statuses)
+}
+
+func TestMaintenanceAPI(t *testing.T) {
+	ResetCacheForTesting()
+	defer ResetCacheForTesting()
+	cfg := &config.Config{ServerSettings: config.ServerSettings{HostURL: "https://test-server.com"}}
+	require.NoError(t, GetMaintenanceStore().Configure([]config.MaintenanceWindow{{
+		Name:     "weekly",
+		Matchers: []string{`location="loc1"`},
+		Schedule: "0 2 * * sun",
+		Duration: "2h",
+	}}, cfg.ServerSettings.HostURL))
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments", Location: "loc1", Status: "down", Source: "prom"},
+	})
+
+	withPermissions := func(req *http.Request, permissions rbac.UserPermissions) *http.Request {
+		return req.WithContext(context.WithValue(req.Context(), middleware.PermissionsContextKey, permissions))
+	}
+	viewer := rbac.UserPermissions{HasFullAccess: true}
+	operator := rbac.UserPermissions{HasFullAccess: true, Actions: map[string]bool{config.PermissionMaintenance: true}}
+
+	body := []byte(`{"name": "hotfix", "matchers": ["name=\"payments\""], "duration": "30m", "comment": "Deploying a fix"}`)
+
+	t.Run("requires the maintenance permission", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		CreateMaintenanceWithAuthz(w, withPermissions(httptest.NewRequest(http.MethodPost, "/api/maintenance", bytes.NewReader(body)), viewer), cfg)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid windows", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		CreateMaintenanceWithAuthz(w, withPermissions(httptest.NewRequest(http.MethodPost, "/api/maintenance", bytes.NewReader([]byte(`{"name": "hotfix"}`))), operator), cfg)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	var created maintenance.Window
+	t.Run("create", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		CreateMaintenanceWithAuthz(w, withPermissions(httptest.NewRequest(http.MethodPost, "/api/maintenance", bytes.NewReader(body)), operator), cfg)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		assert.Equal(t, maintenance.KindAPI, created.Kind)
+		assert.NotEmpty(t, created.Start, "starts now")
+		assert.NotEmpty(t, created.End, "ends after the duration")
+		assert.Equal(t, "maintenance", appStatusesByName()["payments"].Status, "applied immediately")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetMaintenanceWithAuthz(w, withPermissions(httptest.NewRequest(http.MethodGet, "/api/maintenance", nil), viewer), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response MaintenanceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Windows, 2)
+		assert.Equal(t, "hotfix", response.Windows[0].Name)
+		assert.True(t, response.Windows[0].Active)
+		assert.Equal(t, "weekly", response.Windows[1].Name)
+		require.NotNil(t, response.Windows[1].StartsAt)
+		assert.Equal(t, time.Sunday, response.Windows[1].StartsAt.Weekday())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		deleteRequest := func(id string, permissions rbac.UserPermissions) int {
+			req := httptest.NewRequest(http.MethodDelete, "/api/maintenance/"+id, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			DeleteMaintenanceWithAuthz(w, withPermissions(req, permissions), cfg)
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusForbidden, deleteRequest(created.ID, viewer))
+		assert.Equal(t, http.StatusConflict, deleteRequest("config-weekly", operator))
+		assert.Equal(t, http.StatusNotFound, deleteRequest("missing", operator))
+		assert.Equal(t, http.StatusNoContent, deleteRequest(created.ID, operator))
+		assert.Equal(t, "down", appStatusesByName()["payments"].Status)
+	})
+}
+
+func TestMaintenanceAPIAuthorization(t *testing.T) {
+	ResetCacheForTesting()
+	defer ResetCacheForTesting()
+	cfg := &config.Config{ServerSettings: config.ServerSettings{HostURL: "https://test-server.com"}}
+	require.NoError(t, GetMaintenanceStore().Configure([]config.MaintenanceWindow{
+		activeWindow("payments upgrade", `labels.team="payments"`),
+		activeWindow("search upgrade", `labels.team="search"`),
+	}, cfg.ServerSettings.HostURL))
+	defer func() { _ = GetMaintenanceStore().Configure(nil, cfg.ServerSettings.HostURL) }()
+
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "payments"}}},
+		{Name: "search", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "search"}}},
+	})
+
+	payments := map[string]rbac.LabelPermission{"team": {AllowedValues: []string{"payments"}}}
+	tests := []struct {
+		name        string
+		permissions rbac.UserPermissions
+		expected    []string
+	}{
+		{name: "admin", permissions: rbac.UserPermissions{HasFullAccess: true}, expected: []string{"payments upgrade", "search upgrade"}},
+		{name: "restricted user", permissions: rbac.UserPermissions{AllowedLabels: payments}, expected: []string{"payments upgrade"}},
+		{name: "no access", permissions: rbac.UserPermissions{}, expected: []string{}},
+		{
+			name:        "maintenance permission",
+			permissions: rbac.UserPermissions{AllowedLabels: payments, Actions: map[string]bool{config.PermissionMaintenance: true}},
+			expected:    []string{"payments upgrade", "search upgrade"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/maintenance", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.PermissionsContextKey, tt.permissions))
+			w := httptest.NewRecorder()
+
+			GetMaintenanceWithAuthz(w, req, cfg)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response MaintenanceResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			names := []string{}
+			for _, window := range response.Windows {
+				names = append(names, window.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestSyncIncludesMaintenanceWindows(t *testing.T) {
+	ResetCacheForTesting()
+	defer ResetCacheForTesting()
+	cfg := &config.Config{ServerSettings: config.ServerSettings{HostURL: "https://test-server.com", SyncEnable: true}}
+	require.NoError(t, GetMaintenanceStore().Configure([]config.MaintenanceWindow{activeWindow("upgrade", `name="payments"`)}, cfg.ServerSettings.HostURL))
+
+	w := httptest.NewRecorder()
+	HandleSyncRequest(w, httptest.NewRequest(http.MethodGet, "/sync", nil), cfg)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response StatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Maintenance, 1)
+	assert.Equal(t, "upgrade", response.Maintenance[0].Name)
+	assert.Equal(t, "https://test-server.com", response.Maintenance[0].OriginURL)
+}
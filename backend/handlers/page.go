@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"site-availability/labels"
+)
+
+// maxPageLimit is the largest page of apps that can be requested
+const maxPageLimit = 1000
+
+// pageParams are the query parameters of /api/apps that select the page of apps rather than filter them
+var pageParams = map[string]bool{
+	"sort":   true,
+	"limit":  true,
+	"offset": true,
+	"cursor": true,
+	"fields": true,
+}
+
+// tieBreakers order apps with equal sort keys, so every app has a unique position
+var tieBreakers = []sortKey{{field: "name"}, {field: "source"}, {field: "origin_url"}}
+
+// appFields are the JSON field names of AppStatus, which fields= can select
+var appFields = jsonFieldNames(reflect.TypeOf(AppStatus{}))
+
+// sortKey is a field apps are sorted by
+type sortKey struct {
+	field      string
+	descending bool
+}
+
+// appsPage selects the order, page and fields of the apps returned by /api/apps
+type appsPage struct {
+	sortKeys []sortKey
+	limit    int      // 0 returns all apps
+	offset   int      // Number of apps skipped
+	cursor   []string // Sort values of the last app of the previous page
+	fields   []string // Fields of the apps returned; all when empty
+}
+
+// parsePage reads the page of apps from query parameters:
+// ?sort=-status,labels.team&limit=100&cursor=...&fields=name,status
+func parsePage(queryParams url.Values) (appsPage, error) {
+	var page appsPage
+
+	if value := queryParams.Get("sort"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			key := sortKey{field: strings.TrimPrefix(field, "-"), descending: strings.HasPrefix(field, "-")}
+			if err := validateFilterField(key.field); err != nil {
+				return appsPage{}, fmt.Errorf("invalid sort: %w", err)
+			}
+			page.sortKeys = append(page.sortKeys, key)
+		}
+	}
+	page.sortKeys = append(page.sortKeys, tieBreakers...)
+
+	if value := queryParams.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return appsPage{}, fmt.Errorf("invalid limit %q: must be between 1 and %d", value, maxPageLimit)
+		}
+		page.limit = limit
+	}
+
+	offset, cursor := queryParams.Get("offset"), queryParams.Get("cursor")
+	if offset != "" && cursor != "" {
+		return appsPage{}, fmt.Errorf("offset and cursor cannot be used together")
+	}
+	if offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return appsPage{}, fmt.Errorf("invalid offset %q: must be a non-negative number", offset)
+		}
+		page.offset = value
+	}
+	if cursor != "" {
+		values, err := decodeCursor(cursor)
+		if err != nil || len(values) != len(page.sortKeys) {
+			return appsPage{}, fmt.Errorf("invalid cursor: it must come from a request with the same sort")
+		}
+		page.cursor = values
+	}
+
+	if value := queryParams.Get("fields"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if !appFields[field] {
+				return appsPage{}, fmt.Errorf("invalid fields: unknown field %q", field)
+			}
+			page.fields = append(page.fields, field)
+		}
+	}
+
+	return page, nil
+}
+
+// apply sorts the apps and returns the requested page, with the cursor of the next page when there is one
+func (p appsPage) apply(apps []AppStatus) ([]AppStatus, string) {
+	values := make([][]string, len(apps))
+	for i, app := range apps {
+		values[i] = p.sortValues(app)
+	}
+	order := make([]int, len(apps))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return p.compare(values[order[i]], values[order[j]]) < 0
+	})
+
+	start := p.offset
+	if p.cursor != nil {
+		start = sort.Search(len(order), func(i int) bool {
+			return p.compare(values[order[i]], p.cursor) > 0
+		})
+	}
+	start = min(start, len(order))
+	end := len(order)
+	if p.limit > 0 {
+		end = min(start+p.limit, len(order))
+	}
+
+	result := make([]AppStatus, 0, end-start)
+	for _, index := range order[start:end] {
+		result = append(result, apps[index])
+	}
+
+	nextCursor := ""
+	if p.limit > 0 && end < len(order) && end > start {
+		nextCursor = encodeCursor(values[order[end-1]])
+	}
+	return result, nextCursor
+}
+
+// sortValues returns the values of the sort keys of an app. A missing label has the empty value.
+func (p appsPage) sortValues(app AppStatus) []string {
+	values := make([]string, len(p.sortKeys))
+	for i, key := range p.sortKeys {
+		switch key.field {
+		case "name":
+			values[i] = app.Name
+		case "location":
+			values[i] = app.Location
+		case "status":
+			values[i] = app.Status
+		case "source":
+			values[i] = app.Source
+		case "origin_url":
+			values[i] = app.OriginURL
+		default:
+			values[i] = labels.LabelsSliceToMap(app.Labels)[strings.TrimPrefix(key.field, "labels.")]
+		}
+	}
+	return values
+}
+
+// compare orders two sets of sort values
+func (p appsPage) compare(a, b []string) int {
+	for i, key := range p.sortKeys {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			if key.descending {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// encodeCursor encodes the sort values of the last app of a page
+func encodeCursor(values []string) string {
+	data, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor decodes the sort values of a cursor
+func decodeCursor(cursor string) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// projectedAppsResponse is an AppsResponse with only the selected fields of the apps
+type projectedAppsResponse struct {
+	Apps       []map[string]json.RawMessage `json:"apps"`
+	Total      int                          `json:"total"`
+	NextCursor string                       `json:"next_cursor,omitempty"`
+}
+
+// writeAppsPage sorts the apps and writes the requested page with the selected fields
+func writeAppsPage(w http.ResponseWriter, page appsPage, apps []AppStatus) AppsResponse {
+	pageApps, nextCursor := page.apply(apps)
+	response := AppsResponse{
+		Apps:       pageApps,
+		Total:      len(apps),
+		NextCursor: nextCursor,
+	}
+
+	if len(page.fields) == 0 {
+		writeJSONResponse(w, response, "apps")
+		return response
+	}
+
+	projected := projectedAppsResponse{
+		Apps:       make([]map[string]json.RawMessage, 0, len(pageApps)),
+		Total:      response.Total,
+		NextCursor: response.NextCursor,
+	}
+	for _, app := range pageApps {
+		data, err := json.Marshal(app)
+		if err != nil {
+			http.Error(w, "Failed to encode apps", http.StatusInternalServerError)
+			return response
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			http.Error(w, "Failed to encode apps", http.StatusInternalServerError)
+			return response
+		}
+
+		selected := make(map[string]json.RawMessage, len(page.fields))
+		for _, field := range page.fields {
+			if value, ok := all[field]; ok {
+				selected[field] = value
+			}
+		}
+		projected.Apps = append(projected.Apps, selected)
+	}
+	writeJSONResponse(w, projected, "apps")
+	return response
+}
+
+// jsonFieldNames returns the JSON names of the exported fields of a struct
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
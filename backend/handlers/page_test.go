@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"site-availability/config"
+	"site-availability/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pageApps() []AppStatus {
+	return []AppStatus{
+		{Name: "search", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "discovery"}}},
+		{Name: "payments", Location: "loc2", Status: "down", Source: "http", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+		{Name: "payments", Location: "loc1", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "checkout"}}},
+		{Name: "status-page", Location: "loc2", Status: "degraded", Source: "http"},
+	}
+}
+
+// pageIDs returns "source:name" of the apps in order
+func pageIDs(apps []AppStatus) []string {
+	ids := make([]string, 0, len(apps))
+	for _, app := range apps {
+		ids = append(ids, app.Source+":"+app.Name)
+	}
+	return ids
+}
+
+func mustParsePage(t *testing.T, query string) appsPage {
+	t.Helper()
+	queryParams, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	page, err := parsePage(queryParams)
+	require.NoError(t, err)
+	return page
+}
+
+func TestParsePageErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		errMsg string
+	}{
+		{query: "sort=team", errMsg: `unknown field "team"`},
+		{query: "sort=-", errMsg: `unknown field ""`},
+		{query: "limit=0", errMsg: "must be between 1 and 1000"},
+		{query: "limit=1001", errMsg: "must be between 1 and 1000"},
+		{query: "offset=-1", errMsg: "must be a non-negative number"},
+		{query: "offset=1&cursor=abc", errMsg: "cannot be used together"},
+		{query: "cursor=not-a-cursor", errMsg: "invalid cursor"},
+		{query: "sort=status&cursor=" + encodeCursor([]string{"payments", "prom", ""}), errMsg: "same sort"},
+		{query: "fields=name,password", errMsg: `unknown field "password"`},
+	}
+	for _, tt := range tests {
+		queryParams, err := url.ParseQuery(tt.query)
+		require.NoError(t, err)
+		_, err = parsePage(queryParams)
+		require.Error(t, err, tt.query)
+		assert.Contains(t, err.Error(), tt.errMsg, tt.query)
+	}
+}
+
+func TestAppsPageApply(t *testing.T) {
+	t.Run("sorted by name by default", func(t *testing.T) {
+		apps, nextCursor := mustParsePage(t, "").apply(pageApps())
+		assert.Equal(t, []string{"http:payments", "prom:payments", "prom:search", "http:status-page"}, pageIDs(apps))
+		assert.Empty(t, nextCursor)
+	})
+
+	t.Run("sort keys and directions", func(t *testing.T) {
+		apps, _ := mustParsePage(t, "sort=-status,labels.team").apply(pageApps())
+		assert.Equal(t, []string{"prom:payments", "prom:search", "http:payments", "http:status-page"}, pageIDs(apps))
+
+		apps, _ = mustParsePage(t, "sort=labels.team").apply(pageApps())
+		assert.Equal(t, "http:status-page", pageIDs(apps)[0], "apps without the label sort first")
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		apps, nextCursor := mustParsePage(t, "offset=1&limit=2").apply(pageApps())
+		assert.Equal(t, []string{"prom:payments", "prom:search"}, pageIDs(apps))
+		assert.NotEmpty(t, nextCursor)
+
+		apps, nextCursor = mustParsePage(t, "offset=10&limit=2").apply(pageApps())
+		assert.Empty(t, apps)
+		assert.Empty(t, nextCursor)
+	})
+
+	t.Run("cursor pages through all apps", func(t *testing.T) {
+		all, _ := mustParsePage(t, "sort=location").apply(pageApps())
+
+		var paged []AppStatus
+		cursor := ""
+		for i := 0; i < 10; i++ {
+			apps, nextCursor := mustParsePage(t, "sort=location&limit=3&cursor="+cursor).apply(pageApps())
+			paged = append(paged, apps...)
+			if nextCursor == "" {
+				break
+			}
+			cursor = nextCursor
+		}
+		assert.Equal(t, pageIDs(all), pageIDs(paged))
+	})
+
+	t.Run("cursor is stable when apps change", func(t *testing.T) {
+		first, nextCursor := mustParsePage(t, "limit=2").apply(pageApps())
+		assert.Equal(t, []string{"http:payments", "prom:payments"}, pageIDs(first))
+
+		// An app sorting before the cursor doesn't shift the next page
+		apps := append(pageApps(), AppStatus{Name: "auth", Location: "loc1", Status: "up", Source: "http"})
+		second, _ := mustParsePage(t, "limit=2&cursor="+nextCursor).apply(apps)
+		assert.Equal(t, []string{"prom:search", "http:status-page"}, pageIDs(second))
+	})
+}
+
+func TestGetAppsPaged(t *testing.T) {
+	ResetCacheForTesting()
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments", Location: "loc1", Status: "up", Source: "prom"},
+		{Name: "search", Location: "loc1", Status: "down", Source: "prom"},
+		{Name: "status-page", Location: "loc2", Status: "up", Source: "prom"},
+	})
+	cfg := &config.Config{}
+
+	t.Run("page with total and fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetAppsWithAuthz(w, httptest.NewRequest(http.MethodGet, "/api/apps?status=up&limit=1&fields=name,status", nil), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(2), response["total"])
+		assert.NotEmpty(t, response["next_cursor"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "payments", "status": "up"}}, response["apps"])
+
+		w = httptest.NewRecorder()
+		GetAppsWithAuthz(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/apps?status=up&limit=1&fields=name&cursor=%s", response["next_cursor"]), nil), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"apps": [{"name": "status-page"}], "total": 2}`, w.Body.String())
+	})
+
+	t.Run("all apps without limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetApps(w, httptest.NewRequest(http.MethodGet, "/api/apps?sort=-name", nil), cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AppsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Total)
+		assert.Equal(t, []string{"prom:status-page", "prom:search", "prom:payments"}, pageIDs(response.Apps))
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GetAppsWithAuthz(w, httptest.NewRequest(http.MethodGet, "/api/apps?limit=abc", nil), cfg)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -115,8 +115,17 @@ func GetLabelsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Conf
 func GetAppsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	logging.Logger.Debug("Handling /api/apps request with authorization")
 
-	// Parse all query parameters for filtering (including location)
-	filters := parseFilters(r.URL.Query())
+	// Parse all query parameters for filtering (including location), sorting and paging
+	filter, err := parseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get user permissions from context
 	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
@@ -138,24 +147,21 @@ func GetAppsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config
 	}
 
 	// Apply regular filters if any were specified
-	if len(filters) > 0 {
+	if !filter.IsEmpty() {
 		var filteredCount int
-		apps, filteredCount = filterApps(apps, filters)
+		apps, filteredCount = filterApps(apps, filter)
 		logging.Logger.WithFields(map[string]interface{}{
-			"filters":        filters,
+			"filter":         filter.String(),
 			"filtered_count": filteredCount,
 		}).Debug("Applied regular filters to apps")
 	}
 
-	response := AppsResponse{
-		Apps: apps,
-	}
-
-	writeJSONResponse(w, response, "apps")
+	response := writeAppsPage(w, page, apps)
 
 	logging.Logger.WithFields(map[string]interface{}{
 		"apps":            len(response.Apps),
-		"filters":         filters,
+		"total":           response.Total,
+		"filter":          filter.String(),
 		"has_permissions": hasPermissions,
 		"is_admin":        userPermissions.HasFullAccess,
 	}).Debug("Filtered apps response sent")
@@ -166,7 +172,11 @@ func GetLocationsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.C
 	logging.Logger.Debug("Handling /api/locations request with authorization")
 
 	// Parse query parameters for filtering
-	filters := parseFilters(r.URL.Query())
+	filter, err := parseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get user permissions from context
 	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
@@ -193,10 +203,10 @@ func GetLocationsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.C
 	// Apply regular filters if specified
 	var filteredApps []AppStatus
 	var filteredCount int
-	if len(filters) > 0 {
-		filteredApps, filteredCount = filterApps(authorizedApps, filters)
+	if !filter.IsEmpty() {
+		filteredApps, filteredCount = filterApps(authorizedApps, filter)
 		logging.Logger.WithFields(map[string]interface{}{
-			"filters":        filters,
+			"filter":         filter.String(),
 			"filtered_count": filteredCount,
 			"filtered_apps":  len(filteredApps),
 		}).Debug("Applied filters to apps for location status calculation")
@@ -210,25 +220,29 @@ func GetLocationsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.C
 	// Recalculate status and counts for each location based on filtered apps
 	for i := range locations {
 		locations[i].Status = calculateLocationStatus(locations[i].Name, filteredApps)
-		upCount, downCount, unavailableCount := calculateLocationStatusCounts(locations[i].Name, filteredApps)
+		upCount, downCount, unavailableCount, degradedCount, maintenanceCount := calculateLocationStatusCounts(locations[i].Name, filteredApps)
 		locations[i].Up = upCount
 		locations[i].Down = downCount
 		locations[i].Unavailable = unavailableCount
+		locations[i].Degraded = degradedCount
+		locations[i].Maintenance = maintenanceCount
 	}
 
 	// Add server's own locations from config with status calculated from filtered apps
 	serverLocations := convertToHandlersLocation(cfg.Locations)
 	for i := range serverLocations {
 		serverLocations[i].Status = calculateLocationStatus(serverLocations[i].Name, filteredApps)
-		upCount, downCount, unavailableCount := calculateLocationStatusCounts(serverLocations[i].Name, filteredApps)
+		upCount, downCount, unavailableCount, degradedCount, maintenanceCount := calculateLocationStatusCounts(serverLocations[i].Name, filteredApps)
 		serverLocations[i].Up = upCount
 		serverLocations[i].Down = downCount
 		serverLocations[i].Unavailable = unavailableCount
+		serverLocations[i].Degraded = degradedCount
+		serverLocations[i].Maintenance = maintenanceCount
 	}
 	locations = append(locations, serverLocations...)
 
 	// If filtering is applied (either auth or regular), only return locations that have apps matching the filter
-	if len(filters) > 0 || (hasPermissions && !userPermissions.HasFullAccess) {
+	if !filter.IsEmpty() || (hasPermissions && !userPermissions.HasFullAccess) {
 		locationsWithApps := make([]Location, 0)
 		locationHasApps := make(map[string]bool)
 
@@ -257,7 +271,7 @@ func GetLocationsWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.C
 		"authorized_apps": len(authorizedApps),
 		"filtered_apps":   len(filteredApps),
 		"locations":       len(response.Locations),
-		"filters":         filters,
+		"filter":          filter.String(),
 		"has_permissions": hasPermissions,
 		"is_admin":        userPermissions.HasFullAccess,
 	}).Debug("Filtered locations response sent")
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+	"site-availability/logging"
+)
+
+// SLAWindow is a rolling window availability is calculated over
+type SLAWindow struct {
+	Name     string
+	Duration time.Duration
+}
+
+// SLAWindows are the rolling windows reported by /api/sla and the SLA metrics
+var SLAWindows = []SLAWindow{
+	{Name: "1h", Duration: time.Hour},
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Duration: 30 * 24 * time.Hour},
+}
+
+// WindowAvailability maps a window name to its availability percentage.
+// A nil value means there is no up/down data for that window.
+type WindowAvailability map[string]*float64
+
+// AppSLA represents the availability of a single app
+type AppSLA struct {
+	Name         string             `json:"name"`
+	Location     string             `json:"location"`
+	Source       string             `json:"source"`
+	OriginURL    string             `json:"origin_url,omitempty"`
+	Labels       []labels.Label     `json:"labels,omitempty"`
+	Availability WindowAvailability `json:"availability"`
+}
+
+// LocationSLA represents the aggregated availability of all apps in a location
+type LocationSLA struct {
+	Name         string             `json:"name"`
+	Apps         int                `json:"apps"`
+	Availability WindowAvailability `json:"availability"`
+}
+
+// LabelSLA represents the aggregated availability of all apps with a label value
+type LabelSLA struct {
+	Key          string             `json:"key"`
+	Value        string             `json:"value"`
+	Apps         int                `json:"apps"`
+	Availability WindowAvailability `json:"availability"`
+}
+
+// SLAResponse represents the response for /api/sla
+type SLAResponse struct {
+	Windows   []string      `json:"windows"`
+	Apps      []AppSLA      `json:"apps"`
+	Locations []LocationSLA `json:"locations"`
+	Labels    []LabelSLA    `json:"labels"`
+}
+
+// slaAccumulator sums availability over several apps for every window
+type slaAccumulator struct {
+	apps         int
+	availability []history.Availability // indexed like SLAWindows
+}
+
+func (acc *slaAccumulator) add(availability []history.Availability) {
+	if acc.availability == nil {
+		acc.availability = make([]history.Availability, len(availability))
+	}
+	acc.apps++
+	for i := range availability {
+		acc.availability[i].Add(availability[i])
+	}
+}
+
+// toWindowAvailability converts per-window availability into percentages keyed by window name
+func toWindowAvailability(availability []history.Availability) WindowAvailability {
+	result := make(WindowAvailability, len(SLAWindows))
+	for i, window := range SLAWindows {
+		result[window.Name] = nil
+		if i >= len(availability) {
+			continue
+		}
+		if percentage, ok := availability[i].Percentage(); ok {
+			result[window.Name] = &percentage
+		}
+	}
+	return result
+}
+
+// appAvailability calculates the availability of an app for every SLA window
+func appAvailability(store history.Store, app AppStatus, now time.Time) ([]history.Availability, error) {
+	longest := SLAWindows[len(SLAWindows)-1].Duration
+
+	// A single query for the longest window covers all shorter ones
+	transitions, err := store.Query(history.Query{
+		App:             app.Name,
+		Source:          app.Source,
+		OriginURL:       app.OriginURL,
+		Since:           now.Add(-longest),
+		IncludePrevious: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	availability := make([]history.Availability, len(SLAWindows))
+	for i, window := range SLAWindows {
+		availability[i] = history.CalculateAvailability(transitions, now.Add(-window.Duration), now)
+	}
+	return availability, nil
+}
+
+// CalculateSLA computes availability percentages per app, location and label value.
+// Location and label availability is the combined up time of their apps over their combined up and down time.
+func CalculateSLA(apps []AppStatus, now time.Time) SLAResponse {
+	response := SLAResponse{
+		Windows:   make([]string, 0, len(SLAWindows)),
+		Apps:      make([]AppSLA, 0, len(apps)),
+		Locations: make([]LocationSLA, 0),
+		Labels:    make([]LabelSLA, 0),
+	}
+	for _, window := range SLAWindows {
+		response.Windows = append(response.Windows, window.Name)
+	}
+
+	store := GetHistoryStore()
+	if store == nil {
+		return response
+	}
+
+	type labelValue struct {
+		key   string
+		value string
+	}
+	byLocation := make(map[string]*slaAccumulator)
+	byLabel := make(map[labelValue]*slaAccumulator)
+
+	for _, app := range apps {
+		availability, err := appAvailability(store, app, now)
+		if err != nil {
+			logging.Logger.WithError(err).WithFields(map[string]interface{}{
+				"app":    app.Name,
+				"source": app.Source,
+			}).Error("Failed to calculate app availability")
+			continue
+		}
+
+		response.Apps = append(response.Apps, AppSLA{
+			Name:         app.Name,
+			Location:     app.Location,
+			Source:       app.Source,
+			OriginURL:    app.OriginURL,
+			Labels:       app.Labels,
+			Availability: toWindowAvailability(availability),
+		})
+
+		if byLocation[app.Location] == nil {
+			byLocation[app.Location] = &slaAccumulator{}
+		}
+		byLocation[app.Location].add(availability)
+
+		for _, label := range app.Labels {
+			if label.Value == "" {
+				continue
+			}
+			key := labelValue{key: label.Key, value: label.Value}
+			if byLabel[key] == nil {
+				byLabel[key] = &slaAccumulator{}
+			}
+			byLabel[key].add(availability)
+		}
+	}
+
+	for name, acc := range byLocation {
+		response.Locations = append(response.Locations, LocationSLA{
+			Name:         name,
+			Apps:         acc.apps,
+			Availability: toWindowAvailability(acc.availability),
+		})
+	}
+	for key, acc := range byLabel {
+		response.Labels = append(response.Labels, LabelSLA{
+			Key:          key.key,
+			Value:        key.value,
+			Apps:         acc.apps,
+			Availability: toWindowAvailability(acc.availability),
+		})
+	}
+
+	// Sort for deterministic ordering
+	sort.Slice(response.Apps, func(i, j int) bool {
+		if response.Apps[i].Name != response.Apps[j].Name {
+			return response.Apps[i].Name < response.Apps[j].Name
+		}
+		if response.Apps[i].Source != response.Apps[j].Source {
+			return response.Apps[i].Source < response.Apps[j].Source
+		}
+		return response.Apps[i].OriginURL < response.Apps[j].OriginURL
+	})
+	sort.Slice(response.Locations, func(i, j int) bool {
+		return response.Locations[i].Name < response.Locations[j].Name
+	})
+	sort.Slice(response.Labels, func(i, j int) bool {
+		if response.Labels[i].Key != response.Labels[j].Key {
+			return response.Labels[i].Key < response.Labels[j].Key
+		}
+		return response.Labels[i].Value < response.Labels[j].Value
+	})
+
+	return response
+}
+
+// GetSLAWithAuthz handles the /api/sla endpoint with authorization filtering
+// Supports the same filters as /api/apps: ?location=siteA&labels.team=platform&status=up
+func GetSLAWithAuthz(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	logging.Logger.Debug("Handling /api/sla request with authorization")
+
+	if GetHistoryStore() == nil {
+		http.Error(w, "Status history is disabled", http.StatusNotFound)
+		return
+	}
+
+	// Parse all query parameters for filtering
+	filter, err := parseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get user permissions from context
+	userPermissions, hasPermissions := middleware.GetPermissionsFromContext(r)
+
+	apps := GetAppStatusCache()
+
+	// Apply authorization filters if user doesn't have full access
+	if hasPermissions && !userPermissions.HasFullAccess {
+		authorizer := rbac.NewAuthorizer(cfg)
+
+		var authorizedApps []AppStatus
+		for _, app := range apps {
+			if authorizer.CanAccessApp(userPermissions, app.Labels) {
+				authorizedApps = append(authorizedApps, app)
+			}
+		}
+		apps = authorizedApps
+	}
+
+	// Apply regular filters if any were specified
+	if !filter.IsEmpty() {
+		apps, _ = filterApps(apps, filter)
+	}
+
+	response := CalculateSLA(apps, time.Now())
+
+	writeJSONResponse(w, response, "sla")
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"apps":            len(response.Apps),
+		"locations":       len(response.Locations),
+		"labels":          len(response.Labels),
+		"filter":          filter.String(),
+		"has_permissions": hasPermissions,
+		"is_admin":        userPermissions.HasFullAccess,
+	}).Debug("SLA response sent")
+}
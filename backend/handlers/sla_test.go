@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/authentication/middleware"
+	"site-availability/authentication/rbac"
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSLATest populates the cache and a history store with known transitions
+func setupSLATest(t *testing.T) {
+	setupTest()
+	updateAppStatusTest("prom", []AppStatus{
+		{Name: "payments-api", Location: "eu-west", Status: "down", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "payments"}}},
+		{Name: "checkout", Location: "eu-west", Status: "up", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "platform"}}},
+		{Name: "search", Location: "us-east", Status: "unavailable", Source: "prom", Labels: []labels.Label{{Key: "team", Value: "platform"}}},
+	})
+
+	now := time.Now()
+	transition := func(app, status string, ts time.Time) history.Transition {
+		return history.Transition{Timestamp: ts, App: app, Source: "prom", OriginURL: "https://test-origin.com", Status: status}
+	}
+	store := history.NewMemoryStore(history.DefaultRetention)
+	require.NoError(t, store.Record(
+		transition("payments-api", "up", now.Add(-2*time.Hour)),
+		transition("payments-api", "down", now.Add(-30*time.Minute)),
+		transition("checkout", "up", now.Add(-2*time.Hour)),
+		transition("search", "unavailable", now.Add(-2*time.Hour)),
+	))
+	SetHistoryStore(store)
+}
+
+func findAppSLA(t *testing.T, response SLAResponse, name string) AppSLA {
+	for _, app := range response.Apps {
+		if app.Name == name {
+			return app
+		}
+	}
+	t.Fatalf("app %s not found in SLA response", name)
+	return AppSLA{}
+}
+
+func TestCalculateSLA(t *testing.T) {
+	setupSLATest(t)
+	defer SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+
+	response := CalculateSLA(GetAppStatusCache(), time.Now())
+
+	assert.Equal(t, []string{"1h", "24h", "7d", "30d"}, response.Windows)
+	require.Len(t, response.Apps, 3)
+
+	payments := findAppSLA(t, response, "payments-api")
+	require.NotNil(t, payments.Availability["1h"])
+	assert.InDelta(t, 50.0, *payments.Availability["1h"], 0.1)
+	require.NotNil(t, payments.Availability["30d"])
+	assert.InDelta(t, 75.0, *payments.Availability["30d"], 0.1)
+
+	checkout := findAppSLA(t, response, "checkout")
+	require.NotNil(t, checkout.Availability["24h"])
+	assert.Equal(t, 100.0, *checkout.Availability["24h"])
+
+	// Only unavailable time means there's nothing to compute a percentage from
+	search := findAppSLA(t, response, "search")
+	assert.Nil(t, search.Availability["1h"])
+
+	t.Run("locations", func(t *testing.T) {
+		require.Len(t, response.Locations, 2)
+		assert.Equal(t, "eu-west", response.Locations[0].Name)
+		assert.Equal(t, 2, response.Locations[0].Apps)
+		require.NotNil(t, response.Locations[0].Availability["1h"])
+		assert.InDelta(t, 75.0, *response.Locations[0].Availability["1h"], 0.1)
+
+		assert.Equal(t, "us-east", response.Locations[1].Name)
+		assert.Nil(t, response.Locations[1].Availability["1h"])
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		var platform *LabelSLA
+		for i := range response.Labels {
+			if response.Labels[i].Key == "team" && response.Labels[i].Value == "platform" {
+				platform = &response.Labels[i]
+			}
+		}
+		require.NotNil(t, platform)
+		assert.Equal(t, 2, platform.Apps)
+		require.NotNil(t, platform.Availability["7d"])
+		assert.Equal(t, 100.0, *platform.Availability["7d"])
+	})
+}
+
+func TestGetSLAWithAuthz(t *testing.T) {
+	setupSLATest(t)
+	defer SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+
+	cfg := &config.Config{}
+
+	t.Run("filters", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/sla?location=eu-west&labels.team=payments", nil)
+		w := httptest.NewRecorder()
+		GetSLAWithAuthz(w, req, cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response SLAResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Apps, 1)
+		assert.Equal(t, "payments-api", response.Apps[0].Name)
+		require.Len(t, response.Locations, 1)
+		assert.InDelta(t, 50.0, *response.Locations[0].Availability["1h"], 0.1)
+	})
+
+	t.Run("restricted user", func(t *testing.T) {
+		permissions := rbac.UserPermissions{
+			AllowedLabels: map[string]rbac.LabelPermission{
+				"team": {Key: "team", AllowedValues: []string{"platform"}},
+			},
+		}
+		req := httptest.NewRequest("GET", "/api/sla", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.PermissionsContextKey, permissions))
+		w := httptest.NewRecorder()
+		GetSLAWithAuthz(w, req, cfg)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response SLAResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Apps, 2)
+		for _, app := range response.Apps {
+			assert.NotEqual(t, "payments-api", app.Name)
+		}
+	})
+
+	t.Run("history disabled", func(t *testing.T) {
+		SetHistoryStore(nil)
+		w := httptest.NewRecorder()
+		GetSLAWithAuthz(w, httptest.NewRequest("GET", "/api/sla", nil), cfg)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,79 @@
+package history
+
+import "time"
+
+// Availability accumulates how long an app spent in each status
+type Availability struct {
+	Up          time.Duration
+	Degraded    time.Duration
+	Down        time.Duration
+	Unavailable time.Duration
+	Maintenance time.Duration
+}
+
+// Add merges another availability into this one
+func (a *Availability) Add(other Availability) {
+	a.Up += other.Up
+	a.Degraded += other.Degraded
+	a.Down += other.Down
+	a.Unavailable += other.Unavailable
+	a.Maintenance += other.Maintenance
+}
+
+// Percentage returns the share of time spent up or degraded (slow but working), between 0 and 100.
+// Time in unavailable status (the state could not be determined) and in maintenance is excluded.
+// Returns false when there is no up, degraded or down time to compute a percentage from.
+func (a Availability) Percentage() (float64, bool) {
+	working := a.Up + a.Degraded
+	known := working + a.Down
+	if known <= 0 {
+		return 0, false
+	}
+	return float64(working) / float64(known) * 100, true
+}
+
+// CalculateAvailability computes the time spent in each status between start and end
+// for a single app series. Transitions must be ordered by timestamp; the last transition
+// before start (see Query.IncludePrevious) defines the status at the start of the range.
+// Time before the first known transition is not accounted for.
+func CalculateAvailability(transitions []Transition, start, end time.Time) Availability {
+	var result Availability
+	if !end.After(start) {
+		return result
+	}
+
+	for i, t := range transitions {
+		segmentStart := t.Timestamp
+		segmentEnd := end
+		if i+1 < len(transitions) {
+			segmentEnd = transitions[i+1].Timestamp
+		}
+
+		// Clamp the segment to the requested range
+		if segmentStart.Before(start) {
+			segmentStart = start
+		}
+		if segmentEnd.After(end) {
+			segmentEnd = end
+		}
+		if !segmentEnd.After(segmentStart) {
+			continue
+		}
+
+		duration := segmentEnd.Sub(segmentStart)
+		switch t.Status {
+		case "up":
+			result.Up += duration
+		case "degraded":
+			result.Degraded += duration
+		case "down":
+			result.Down += duration
+		case "maintenance":
+			result.Maintenance += duration
+		default:
+			result.Unavailable += duration
+		}
+	}
+
+	return result
+}
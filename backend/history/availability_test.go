@@ -0,0 +1,125 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateAvailability(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("status at range start comes from the previous transition", func(t *testing.T) {
+		transitions := []Transition{
+			newTransition("app", "src", "up", base.Add(-time.Hour)),
+			newTransition("app", "src", "down", base.Add(45*time.Minute)),
+		}
+		availability := CalculateAvailability(transitions, base, base.Add(time.Hour))
+		assert.Equal(t, 45*time.Minute, availability.Up)
+		assert.Equal(t, 15*time.Minute, availability.Down)
+
+		percentage, ok := availability.Percentage()
+		assert.True(t, ok)
+		assert.InDelta(t, 75.0, percentage, 0.001)
+	})
+
+	t.Run("time before the first transition is not counted", func(t *testing.T) {
+		transitions := []Transition{
+			newTransition("app", "src", "up", base.Add(30*time.Minute)),
+		}
+		availability := CalculateAvailability(transitions, base, base.Add(time.Hour))
+		assert.Equal(t, 30*time.Minute, availability.Up)
+		assert.Zero(t, availability.Down)
+
+		percentage, ok := availability.Percentage()
+		assert.True(t, ok)
+		assert.Equal(t, 100.0, percentage)
+	})
+
+	t.Run("unavailable time is excluded from the percentage", func(t *testing.T) {
+		transitions := []Transition{
+			newTransition("app", "src", "up", base),
+			newTransition("app", "src", "unavailable", base.Add(20*time.Minute)),
+			newTransition("app", "src", "down", base.Add(40*time.Minute)),
+			newTransition("app", "src", "up", base.Add(50*time.Minute)),
+		}
+		availability := CalculateAvailability(transitions, base, base.Add(time.Hour))
+		assert.Equal(t, 30*time.Minute, availability.Up)
+		assert.Equal(t, 10*time.Minute, availability.Down)
+		assert.Equal(t, 20*time.Minute, availability.Unavailable)
+
+		percentage, ok := availability.Percentage()
+		assert.True(t, ok)
+		assert.InDelta(t, 75.0, percentage, 0.001)
+	})
+
+	t.Run("maintenance time is excluded from the percentage", func(t *testing.T) {
+		transitions := []Transition{
+			newTransition("app", "src", "up", base),
+			newTransition("app", "src", "maintenance", base.Add(15*time.Minute)),
+			newTransition("app", "src", "down", base.Add(45*time.Minute)),
+		}
+		availability := CalculateAvailability(transitions, base, base.Add(time.Hour))
+		assert.Equal(t, 15*time.Minute, availability.Up)
+		assert.Equal(t, 30*time.Minute, availability.Maintenance)
+		assert.Equal(t, 15*time.Minute, availability.Down)
+		assert.Zero(t, availability.Unavailable)
+
+		percentage, ok := availability.Percentage()
+		assert.True(t, ok)
+		assert.InDelta(t, 50.0, percentage, 0.001)
+	})
+
+	t.Run("degraded time counts as available", func(t *testing.T) {
+		transitions := []Transition{
+			newTransition("app", "src", "up", base),
+			newTransition("app", "src", "degraded", base.Add(30*time.Minute)),
+			newTransition("app", "src", "down", base.Add(45*time.Minute)),
+		}
+		availability := CalculateAvailability(transitions, base, base.Add(time.Hour))
+		assert.Equal(t, 30*time.Minute, availability.Up)
+		assert.Equal(t, 15*time.Minute, availability.Degraded)
+		assert.Equal(t, 15*time.Minute, availability.Down)
+
+		percentage, ok := availability.Percentage()
+		assert.True(t, ok)
+		assert.InDelta(t, 75.0, percentage, 0.001)
+	})
+
+	t.Run("transitions after the range are ignored", func(t *testing.T) {
+		transitions := []Transition{
+			newTransition("app", "src", "down", base),
+			newTransition("app", "src", "up", base.Add(2*time.Hour)),
+		}
+		availability := CalculateAvailability(transitions, base, base.Add(time.Hour))
+		assert.Zero(t, availability.Up)
+		assert.Equal(t, time.Hour, availability.Down)
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		availability := CalculateAvailability(nil, base, base.Add(time.Hour))
+		_, ok := availability.Percentage()
+		assert.False(t, ok)
+
+		onlyUnavailable := CalculateAvailability([]Transition{
+			newTransition("app", "src", "unavailable", base),
+		}, base, base.Add(time.Hour))
+		_, ok = onlyUnavailable.Percentage()
+		assert.False(t, ok)
+	})
+}
+
+func TestAvailabilityAdd(t *testing.T) {
+	a := Availability{Up: time.Hour}
+	a.Add(Availability{Up: time.Hour, Degraded: time.Hour, Down: 4 * time.Hour, Unavailable: time.Minute})
+
+	assert.Equal(t, 2*time.Hour, a.Up)
+	assert.Equal(t, time.Hour, a.Degraded)
+	assert.Equal(t, 4*time.Hour, a.Down)
+	assert.Equal(t, time.Minute, a.Unavailable)
+
+	percentage, ok := a.Percentage()
+	assert.True(t, ok)
+	assert.InDelta(t, 42.857, percentage, 0.001)
+}
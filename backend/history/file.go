@@ -0,0 +1,206 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"site-availability/logging"
+)
+
+// FileStore persists transitions to an append-only JSON lines file.
+// All transitions within the retention are also kept in memory for fast queries,
+// and the file is compacted whenever old transitions are pruned.
+type FileStore struct {
+	memory *MemoryStore
+	path   string
+	file   *os.File
+	mutex  sync.Mutex
+}
+
+// NewFileStore opens (or creates) the history file at path and loads existing transitions
+func NewFileStore(path string, retention time.Duration) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("history file path is required")
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create history directory %s: %w", dir, err)
+		}
+	}
+
+	fs := &FileStore{
+		memory: NewMemoryStore(retention),
+		path:   path,
+	}
+
+	loaded, err := fs.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite the file so it only contains transitions within the retention
+	fs.memory.mutex.Lock()
+	fs.memory.prune(time.Now().Add(-fs.memory.retention))
+	err = fs.compact()
+	fs.memory.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"path":       path,
+		"loaded":     loaded,
+		"retention":  fs.memory.retention,
+		"app_series": len(fs.memory.transitions),
+	}).Info("Status history store initialized")
+
+	return fs, nil
+}
+
+// load reads all transitions from the history file into memory
+func (fs *FileStore) load() (int, error) {
+	file, err := os.Open(fs.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open history file %s: %w", fs.path, err)
+	}
+	defer file.Close()
+
+	var transitions []Transition
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var t Transition
+		if err := json.Unmarshal(line, &t); err != nil {
+			// A partially written last line must not prevent startup
+			logging.Logger.WithError(err).WithFields(map[string]interface{}{
+				"path": fs.path,
+				"line": lineNumber,
+			}).Warn("Skipping corrupt status history entry")
+			continue
+		}
+		transitions = append(transitions, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read history file %s: %w", fs.path, err)
+	}
+
+	fs.memory.mutex.Lock()
+	fs.memory.add(transitions)
+	fs.memory.mutex.Unlock()
+
+	return len(transitions), nil
+}
+
+// compact atomically rewrites the history file with the transitions currently in memory
+// and reopens it for appending. Caller must hold the memory store lock.
+func (fs *FileStore) compact() error {
+	tmpPath := fs.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary history file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, t := range fs.memory.all() {
+		if err := encoder.Encode(t); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary history file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary history file: %w", err)
+	}
+
+	if fs.file != nil {
+		fs.file.Close()
+		fs.file = nil
+	}
+	if err := os.Rename(tmpPath, fs.path); err != nil {
+		return fmt.Errorf("failed to replace history file: %w", err)
+	}
+
+	file, err := os.OpenFile(fs.path, os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open history file for appending: %w", err)
+	}
+	fs.file = file
+	return nil
+}
+
+// Record adds transitions to memory and appends them to the history file
+func (fs *FileStore) Record(transitions ...Transition) error {
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.memory.mutex.Lock()
+	defer fs.memory.mutex.Unlock()
+
+	fs.memory.add(transitions)
+
+	if time.Since(fs.memory.lastPrune) >= pruneInterval {
+		if dropped := fs.memory.prune(time.Now().Add(-fs.memory.retention)); dropped > 0 {
+			// Compaction writes everything in memory, including the new transitions
+			return fs.compact()
+		}
+	}
+
+	if fs.file == nil {
+		return fmt.Errorf("history file %s is closed", fs.path)
+	}
+
+	var data []byte
+	for _, t := range transitions {
+		line, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+	if _, err := fs.file.Write(data); err != nil {
+		return fmt.Errorf("failed to append to history file: %w", err)
+	}
+	return nil
+}
+
+// Query returns transitions matching the query, oldest first
+func (fs *FileStore) Query(q Query) ([]Transition, error) {
+	return fs.memory.Query(q)
+}
+
+// Close flushes and closes the history file
+func (fs *FileStore) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.file.Close()
+	fs.file = nil
+	return err
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "history.jsonl")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	store, err := NewFileStore(path, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Record(
+		newTransition("payments-api", "prom", "up", now.Add(-20*time.Minute)),
+		newTransition("payments-api", "prom", "down", now.Add(-10*time.Minute)),
+	))
+	require.NoError(t, store.Close())
+
+	// Reopen and verify transitions were loaded from disk
+	reopened, err := NewFileStore(path, time.Hour)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	result, err := reopened.Query(Query{App: "payments-api"})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "up", result[0].Status)
+	assert.Equal(t, "down", result[1].Status)
+	assert.True(t, now.Add(-10*time.Minute).Equal(result[1].Timestamp))
+
+	// New records are appended after reopening
+	require.NoError(t, reopened.Record(newTransition("payments-api", "prom", "up", now)))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"))
+}
+
+func TestFileStoreCompactsOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC()
+
+	store, err := NewFileStore(path, 24*time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, store.Record(
+		newTransition("app", "src", "up", now.Add(-72*time.Hour)),
+		newTransition("app", "src", "down", now.Add(-48*time.Hour)),
+		newTransition("app", "src", "up", now.Add(-time.Hour)),
+	))
+	require.NoError(t, store.Close())
+
+	reopened, err := NewFileStore(path, 24*time.Hour)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	result, err := reopened.Query(Query{App: "app"})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "up", result[0].Status)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+}
+
+func TestFileStoreSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC().Format(time.RFC3339)
+	content := `{"timestamp":"` + now + `","app":"app","source":"src","origin_url":"o","location":"l","status":"up"}` + "\n" +
+		`{"timestamp":"` + now + `","app":"app","sou` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0640))
+
+	store, err := NewFileStore(path, time.Hour)
+	require.NoError(t, err)
+	defer store.Close()
+
+	result, err := store.Query(Query{App: "app"})
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+}
+
+func TestFileStoreRequiresPath(t *testing.T) {
+	_, err := NewFileStore("", time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package history
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"site-availability/labels"
+	"site-availability/logging"
+)
+
+// DefaultRetention is used when no retention is configured (30 days)
+const DefaultRetention = 30 * 24 * time.Hour
+
+// pruneInterval controls how often stores drop transitions older than the retention
+const pruneInterval = time.Hour
+
+// Transition represents a single recorded status change of an app
+type Transition struct {
+	Timestamp      time.Time      `json:"timestamp"`
+	App            string         `json:"app"`
+	Source         string         `json:"source"`
+	OriginURL      string         `json:"origin_url"`
+	Location       string         `json:"location"`
+	Status         string         `json:"status"`
+	PreviousStatus string         `json:"previous_status,omitempty"` // Empty for the first observation of an app
+	Labels         []labels.Label `json:"labels,omitempty"`
+}
+
+// Key returns the identifier of the app series the transition belongs to
+func (t Transition) Key() string {
+	return t.OriginURL + "|" + t.Source + "|" + t.App
+}
+
+// Query describes which transitions should be returned from a store
+type Query struct {
+	App       string    // Required: app name
+	Source    string    // Optional: restrict to a single source
+	OriginURL string    // Optional: restrict to a single origin URL
+	Since     time.Time // Optional: only transitions at or after this time
+	Until     time.Time // Optional: only transitions at or before this time
+	Limit     int       // Optional: return only the most recent N transitions
+	// IncludePrevious also returns the last transition before Since for every series,
+	// so callers know the status each app was in when the range started
+	IncludePrevious bool
+}
+
+// Store defines the interface for status history backends
+type Store interface {
+	// Record persists one or more transitions
+	Record(transitions ...Transition) error
+	// Query returns matching transitions ordered by timestamp (oldest first)
+	Query(q Query) ([]Transition, error)
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// MemoryStore keeps transitions in memory, grouped per app series.
+// It is used directly when no history file is configured and as the index for FileStore.
+type MemoryStore struct {
+	transitions map[string][]Transition // [origin_url|source|app][]Transition
+	retention   time.Duration
+	lastPrune   time.Time
+	mutex       sync.RWMutex
+}
+
+// NewMemoryStore creates an in-memory history store with the given retention
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &MemoryStore{
+		transitions: make(map[string][]Transition),
+		retention:   retention,
+		lastPrune:   time.Now(),
+	}
+}
+
+// Record adds transitions to the store
+func (m *MemoryStore) Record(transitions ...Transition) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.add(transitions)
+
+	if time.Since(m.lastPrune) >= pruneInterval {
+		m.prune(time.Now().Add(-m.retention))
+	}
+	return nil
+}
+
+// add appends transitions keeping every series ordered by timestamp. Caller must hold the lock.
+func (m *MemoryStore) add(transitions []Transition) {
+	for _, t := range transitions {
+		key := t.Key()
+		series := m.transitions[key]
+		series = append(series, t)
+
+		// Transitions normally arrive in order, only sort when they don't
+		if n := len(series); n > 1 && series[n-1].Timestamp.Before(series[n-2].Timestamp) {
+			sort.SliceStable(series, func(i, j int) bool {
+				return series[i].Timestamp.Before(series[j].Timestamp)
+			})
+		}
+		m.transitions[key] = series
+	}
+}
+
+// Query returns transitions matching the query, oldest first
+func (m *MemoryStore) Query(q Query) ([]Transition, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var result []Transition
+	if q.Source != "" && q.OriginURL != "" {
+		// A fully qualified app is a single series, look it up instead of scanning all of them
+		key := Transition{App: q.App, Source: q.Source, OriginURL: q.OriginURL}.Key()
+		result = appendMatching(result, m.transitions[key], q)
+	} else {
+		for _, series := range m.transitions {
+			if len(series) == 0 {
+				continue
+			}
+			first := series[0]
+			if first.App != q.App {
+				continue
+			}
+			if q.Source != "" && first.Source != q.Source {
+				continue
+			}
+			if q.OriginURL != "" && first.OriginURL != q.OriginURL {
+				continue
+			}
+			result = appendMatching(result, series, q)
+		}
+
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Timestamp.Before(result[j].Timestamp)
+		})
+	}
+
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+
+	return result, nil
+}
+
+// appendMatching appends the transitions of an ordered series that fall in the time range of the query
+func appendMatching(result []Transition, series []Transition, q Query) []Transition {
+	for i, t := range series {
+		if !q.Since.IsZero() && t.Timestamp.Before(q.Since) {
+			// Only the last transition before the range is kept, and only when requested
+			lastBefore := i+1 == len(series) || !series[i+1].Timestamp.Before(q.Since)
+			if !q.IncludePrevious || !lastBefore {
+				continue
+			}
+		}
+		if !q.Until.IsZero() && t.Timestamp.After(q.Until) {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// Close is a no-op for the memory store
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// all returns every stored transition ordered by timestamp. Caller must hold the lock.
+func (m *MemoryStore) all() []Transition {
+	var result []Transition
+	for _, series := range m.transitions {
+		result = append(result, series...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+// prune drops transitions older than the cutoff. The most recent transition of every
+// series is always kept so the current state of an app remains known.
+// Caller must hold the lock. Returns the number of dropped transitions.
+func (m *MemoryStore) prune(cutoff time.Time) int {
+	dropped := 0
+	for key, series := range m.transitions {
+		keepFrom := 0
+		for keepFrom < len(series)-1 && series[keepFrom].Timestamp.Before(cutoff) {
+			keepFrom++
+		}
+		if keepFrom > 0 {
+			dropped += keepFrom
+			m.transitions[key] = append([]Transition(nil), series[keepFrom:]...)
+		}
+	}
+	m.lastPrune = time.Now()
+
+	if dropped > 0 {
+		logging.Logger.WithFields(map[string]interface{}{
+			"dropped": dropped,
+			"cutoff":  cutoff,
+		}).Debug("Pruned status history beyond retention")
+	}
+	return dropped
+}
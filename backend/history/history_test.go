@@ -0,0 +1,157 @@
+package history
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+	os.Exit(m.Run())
+}
+
+func newTransition(app, source, status string, ts time.Time) Transition {
+	return Transition{
+		Timestamp: ts,
+		App:       app,
+		Source:    source,
+		OriginURL: "https://origin.example.com",
+		Location:  "eu-west",
+		Status:    status,
+	}
+}
+
+func TestMemoryStoreQuery(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore(0)
+	require.NoError(t, store.Record(
+		newTransition("payments-api", "prom", "up", base),
+		newTransition("payments-api", "prom", "down", base.Add(10*time.Minute)),
+		newTransition("payments-api", "http", "up", base.Add(5*time.Minute)),
+		newTransition("checkout", "prom", "up", base),
+	))
+	require.NoError(t, store.Record(newTransition("payments-api", "prom", "up", base.Add(20*time.Minute))))
+
+	t.Run("all sources ordered by time", func(t *testing.T) {
+		result, err := store.Query(Query{App: "payments-api"})
+		require.NoError(t, err)
+		require.Len(t, result, 4)
+		for i := 1; i < len(result); i++ {
+			assert.False(t, result[i].Timestamp.Before(result[i-1].Timestamp))
+		}
+	})
+
+	t.Run("filter by source", func(t *testing.T) {
+		result, err := store.Query(Query{App: "payments-api", Source: "prom"})
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+		assert.Equal(t, []string{"up", "down", "up"}, []string{result[0].Status, result[1].Status, result[2].Status})
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		result, err := store.Query(Query{
+			App:   "payments-api",
+			Since: base.Add(5 * time.Minute),
+			Until: base.Add(10 * time.Minute),
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "http", result[0].Source)
+		assert.Equal(t, "down", result[1].Status)
+	})
+
+	t.Run("limit keeps most recent", func(t *testing.T) {
+		result, err := store.Query(Query{App: "payments-api", Source: "prom", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, base.Add(20*time.Minute), result[0].Timestamp)
+	})
+
+	t.Run("include previous transition", func(t *testing.T) {
+		result, err := store.Query(Query{
+			App:             "payments-api",
+			Source:          "prom",
+			Since:           base.Add(15 * time.Minute),
+			IncludePrevious: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "down", result[0].Status)
+		assert.Equal(t, base.Add(10*time.Minute), result[0].Timestamp)
+		assert.Equal(t, "up", result[1].Status)
+	})
+
+	t.Run("fully qualified app", func(t *testing.T) {
+		result, err := store.Query(Query{
+			App:             "payments-api",
+			Source:          "prom",
+			OriginURL:       "https://origin.example.com",
+			Since:           base.Add(15 * time.Minute),
+			IncludePrevious: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "down", result[0].Status)
+		assert.Equal(t, "up", result[1].Status)
+
+		result, err = store.Query(Query{App: "payments-api", Source: "prom", OriginURL: "https://other.example.com"})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("unknown app", func(t *testing.T) {
+		result, err := store.Query(Query{App: "missing"})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+}
+
+func TestMemoryStoreOutOfOrderRecords(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(time.Hour)
+
+	require.NoError(t, store.Record(newTransition("app", "src", "down", base.Add(time.Minute))))
+	require.NoError(t, store.Record(newTransition("app", "src", "up", base)))
+
+	result, err := store.Query(Query{App: "app"})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "up", result[0].Status)
+	assert.Equal(t, "down", result[1].Status)
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore(time.Hour)
+
+	require.NoError(t, store.Record(
+		newTransition("old-only", "src", "down", now.Add(-3*time.Hour)),
+		newTransition("mixed", "src", "up", now.Add(-3*time.Hour)),
+		newTransition("mixed", "src", "down", now.Add(-2*time.Hour)),
+		newTransition("mixed", "src", "up", now.Add(-10*time.Minute)),
+	))
+
+	store.mutex.Lock()
+	dropped := store.prune(now.Add(-time.Hour))
+	store.mutex.Unlock()
+	assert.Equal(t, 2, dropped)
+
+	// The latest transition of a series is kept even when it is older than the retention
+	oldOnly, err := store.Query(Query{App: "old-only"})
+	require.NoError(t, err)
+	require.Len(t, oldOnly, 1)
+	assert.Equal(t, "down", oldOnly[0].Status)
+
+	mixed, err := store.Query(Query{App: "mixed"})
+	require.NoError(t, err)
+	require.Len(t, mixed, 1)
+	assert.Equal(t, "up", mixed[0].Status)
+}
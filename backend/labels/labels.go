@@ -249,6 +249,78 @@ func (lm *LabelManager) FindAppsByLabels(labels map[string]string) []string {
 	return candidateApps
 }
 
+// systemFields are the app fields indexed without the "labels." prefix
+var systemFields = map[string]bool{"name": true, "location": true, "status": true, "source": true, "origin_url": true}
+
+// FindAppsByMatchers returns the unique identifiers of the apps that satisfy ALL matchers.
+// Matcher names are system fields (name, location, status, source, origin_url) or label keys,
+// with or without the "labels." prefix. Like MatchesAll, an app without the field has the empty value.
+func (lm *LabelManager) FindAppsByMatchers(matchers []*Matcher) []string {
+	if len(matchers) == 0 {
+		return []string{}
+	}
+
+	lm.mutex.RLock()
+	defer lm.mutex.RUnlock()
+
+	// Every indexed app has a name
+	allApps := make(map[string]bool)
+	for _, appIDs := range lm.appsByField["name"] {
+		for _, appID := range appIDs {
+			allApps[appID] = true
+		}
+	}
+
+	var candidateApps []string
+	for i, matcher := range matchers {
+		fieldName := matcher.Name
+		if !systemFields[fieldName] && !strings.HasPrefix(fieldName, "labels.") {
+			fieldName = "labels." + fieldName
+		}
+
+		matching := make(map[string]bool)
+		if matcher.Matches("") {
+			// Apps without the field match, so start from all apps and remove those with a non-matching value
+			for appID := range allApps {
+				matching[appID] = true
+			}
+			for value, appIDs := range lm.appsByField[fieldName] {
+				if !matcher.Matches(value) {
+					for _, appID := range appIDs {
+						delete(matching, appID)
+					}
+				}
+			}
+		} else {
+			for value, appIDs := range lm.appsByField[fieldName] {
+				if matcher.Matches(value) {
+					for _, appID := range appIDs {
+						matching[appID] = true
+					}
+				}
+			}
+		}
+
+		currentApps := make([]string, 0, len(matching))
+		for appID := range matching {
+			currentApps = append(currentApps, appID)
+		}
+
+		if i == 0 {
+			candidateApps = currentApps
+		} else {
+			candidateApps = intersectSlices(candidateApps, currentApps)
+		}
+
+		// Early exit if no apps match current matcher
+		if len(candidateApps) == 0 {
+			return []string{}
+		}
+	}
+
+	return candidateApps
+}
+
 // GetLabelKeys returns all available label keys across all apps
 func (lm *LabelManager) GetLabelKeys() []string {
 	lm.mutex.RLock()
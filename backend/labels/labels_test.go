@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMergeLabels(t *testing.T) {
@@ -391,6 +392,30 @@ func TestLabelManager(t *testing.T) {
 
 		// Verify stats are correct
 	})
+
+	t.Run("find apps by matchers", func(t *testing.T) {
+		lm := NewLabelManager()
+		lm.UpdateAppLabels([]AppInfo{
+			{Name: "payments", Location: "eu-west", Source: "prom1", Labels: []Label{{Key: "env", Value: "production"}}},
+			{Name: "search", Location: "eu-central", Source: "prom1", Labels: []Label{{Key: "env", Value: "staging"}}},
+			{Name: "checkout", Location: "us-east", Source: "prom2"},
+		})
+
+		find := func(exprs ...string) []string {
+			matchers, err := ParseMatchers(exprs)
+			require.NoError(t, err)
+			return lm.FindAppsByMatchers(matchers)
+		}
+
+		assert.ElementsMatch(t, []string{"prom1:payments"}, find(`name="payments"`))
+		assert.ElementsMatch(t, []string{"prom1:payments", "prom1:search"}, find(`location=~"eu-.*"`))
+		assert.ElementsMatch(t, []string{"prom1:search"}, find(`location=~"eu-.*"`, `env!="production"`))
+		assert.ElementsMatch(t, []string{"prom1:search"}, find(`labels.env="staging"`))
+		assert.ElementsMatch(t, []string{"prom2:checkout"}, find(`env=""`), "apps without the label have the empty value")
+		assert.ElementsMatch(t, []string{"prom1:search", "prom2:checkout"}, find(`env!="production"`))
+		assert.Empty(t, find(`source="prom2"`, `name="payments"`))
+		assert.Empty(t, lm.FindAppsByMatchers(nil))
+	})
 }
 
 func TestIntersectSlices(t *testing.T) {
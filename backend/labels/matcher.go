@@ -0,0 +1,168 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MatchType is the comparison operator of a Matcher
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// matchOperators lists the operators in parse order, two-character operators first
+var matchOperators = []MatchType{MatchNotEqual, MatchRegexp, MatchNotRegexp, MatchEqual}
+
+// Matcher matches a label value using Prometheus-style semantics: a missing label has the empty value
+// and regular expressions are fully anchored.
+type Matcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+
+	re *regexp.Regexp
+}
+
+// NewMatcher creates a matcher, compiling the value when it is a regular expression
+func NewMatcher(name string, matchType MatchType, value string) (*Matcher, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("matcher label name cannot be empty")
+	}
+
+	matcher := &Matcher{Name: name, Type: matchType, Value: value}
+	switch matchType {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q in matcher for %q: %w", value, name, err)
+		}
+		matcher.re = re
+	default:
+		return nil, fmt.Errorf("unknown match type %q", matchType)
+	}
+	return matcher, nil
+}
+
+// ParseMatcher parses a matcher such as `env="production"`, `team=~"platform|sre"` or `tier!=dev`.
+// Quoting the value is optional.
+func ParseMatcher(expr string) (*Matcher, error) {
+	expr = strings.TrimSpace(expr)
+
+	// Find the first operator; the name can't contain operator characters
+	index := strings.IndexAny(expr, "=!")
+	if index <= 0 {
+		return nil, fmt.Errorf("invalid matcher %q: expected <name><operator><value>", expr)
+	}
+
+	name := strings.TrimSpace(expr[:index])
+	rest := expr[index:]
+	for _, operator := range matchOperators {
+		if !strings.HasPrefix(rest, string(operator)) {
+			continue
+		}
+
+		value := strings.TrimSpace(strings.TrimPrefix(rest, string(operator)))
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid matcher %q: malformed quoted value", expr)
+			}
+			value = unquoted
+		}
+
+		matcher, err := NewMatcher(name, operator, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", expr, err)
+		}
+		return matcher, nil
+	}
+
+	return nil, fmt.Errorf("invalid matcher %q: unknown operator", expr)
+}
+
+// ParseMatchers parses a list of matchers
+func ParseMatchers(exprs []string) ([]*Matcher, error) {
+	matchers := make([]*Matcher, 0, len(exprs))
+	for _, expr := range exprs {
+		matcher, err := ParseMatcher(expr)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// ParseMatcherList parses comma separated matchers such as `env="production",team=~"platform|sre"`,
+// optionally enclosed in braces like a Prometheus selector. Commas inside quoted values don't separate matchers.
+func ParseMatcherList(expr string) ([]*Matcher, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "{") && strings.HasSuffix(expr, "}") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	if expr == "" {
+		return nil, fmt.Errorf("empty matcher list")
+	}
+
+	var exprs []string
+	start, inQuotes := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if inQuotes {
+				i++ // Skip the escaped character
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				exprs = append(exprs, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("invalid matcher list %q: unterminated quoted value", expr)
+	}
+	exprs = append(exprs, expr[start:])
+
+	return ParseMatchers(exprs)
+}
+
+// Matches reports whether the value satisfies the matcher
+func (m *Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// String returns the matcher in its parseable form
+func (m *Matcher) String() string {
+	return m.Name + string(m.Type) + strconv.Quote(m.Value)
+}
+
+// MatchesAll reports whether the labels satisfy every matcher
+func MatchesAll(matchers []*Matcher, labels map[string]string) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMatcher(t *testing.T) {
+	tests := []struct {
+		expr      string
+		name      string
+		matchType MatchType
+		value     string
+		errMsg    string
+	}{
+		{expr: `env="production"`, name: "env", matchType: MatchEqual, value: "production"},
+		{expr: `env=production`, name: "env", matchType: MatchEqual, value: "production"},
+		{expr: ` tier != dev `, name: "tier", matchType: MatchNotEqual, value: "dev"},
+		{expr: `team=~"platform|sre"`, name: "team", matchType: MatchRegexp, value: "platform|sre"},
+		{expr: `name!~"test-.*"`, name: "name", matchType: MatchNotRegexp, value: "test-.*"},
+		{expr: `team=""`, name: "team", matchType: MatchEqual, value: ""},
+		{expr: `msg="a \"quoted\" value"`, name: "msg", matchType: MatchEqual, value: `a "quoted" value`},
+		{expr: `=value`, errMsg: "expected <name><operator><value>"},
+		{expr: `env`, errMsg: "expected <name><operator><value>"},
+		{expr: `env!dev`, errMsg: "unknown operator"},
+		{expr: `env="unterminated`, errMsg: "malformed quoted value"},
+		{expr: `env=~"(unclosed"`, errMsg: "invalid regular expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			matcher, err := ParseMatcher(tt.expr)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, matcher.Name)
+			assert.Equal(t, tt.matchType, matcher.Type)
+			assert.Equal(t, tt.value, matcher.Value)
+		})
+	}
+}
+
+func TestParseMatcherList(t *testing.T) {
+	matchers, err := ParseMatcherList(`{labels.env!="dev", name=~"pay.*|search"}`)
+	require.NoError(t, err)
+	require.Len(t, matchers, 2)
+	assert.Equal(t, `labels.env!="dev"`, matchers[0].String())
+	assert.Equal(t, `name=~"pay.*|search"`, matchers[1].String())
+
+	matchers, err = ParseMatcherList(`team=~"a{1,3}",msg="x,\"y\""`)
+	require.NoError(t, err)
+	require.Len(t, matchers, 2, "commas in quoted values don't separate matchers")
+	assert.Equal(t, "a{1,3}", matchers[0].Value)
+	assert.Equal(t, `x,"y"`, matchers[1].Value)
+
+	for _, expr := range []string{"", "{}", `env="dev",`, `env="dev`, `env`} {
+		_, err := ParseMatcherList(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	mustParse := func(expr string) *Matcher {
+		matcher, err := ParseMatcher(expr)
+		require.NoError(t, err)
+		return matcher
+	}
+
+	assert.True(t, mustParse(`env="prod"`).Matches("prod"))
+	assert.False(t, mustParse(`env="prod"`).Matches("production"))
+	assert.True(t, mustParse(`env!="prod"`).Matches("dev"))
+	assert.True(t, mustParse(`env=~"prod|staging"`).Matches("staging"))
+	assert.False(t, mustParse(`env=~"prod"`).Matches("production"), "regular expressions are anchored")
+	assert.True(t, mustParse(`env!~"dev.*"`).Matches("prod"))
+	assert.False(t, mustParse(`env!~"dev.*"`).Matches("dev-1"))
+
+	// A missing label has the empty value
+	assert.True(t, mustParse(`team=""`).Matches(""))
+	assert.True(t, mustParse(`team!="platform"`).Matches(""))
+	assert.False(t, mustParse(`team=~".+"`).Matches(""))
+}
+
+func TestMatchesAll(t *testing.T) {
+	matchers, err := ParseMatchers([]string{`env="prod"`, `team=~"platform|sre"`})
+	require.NoError(t, err)
+
+	assert.True(t, MatchesAll(matchers, map[string]string{"env": "prod", "team": "sre", "extra": "x"}))
+	assert.False(t, MatchesAll(matchers, map[string]string{"env": "prod"}))
+	assert.True(t, MatchesAll(nil, map[string]string{"env": "prod"}))
+
+	_, err = ParseMatchers([]string{`env="prod"`, `bad`})
+	assert.Error(t, err)
+}
+
+func TestMatcherString(t *testing.T) {
+	matcher, err := NewMatcher("team", MatchRegexp, "platform|sre")
+	require.NoError(t, err)
+	assert.Equal(t, `team=~"platform|sre"`, matcher.String())
+
+	_, err = NewMatcher("team", MatchType("=="), "x")
+	assert.Error(t, err)
+}
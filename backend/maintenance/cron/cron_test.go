@@ -0,0 +1,75 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	base := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{"* * * * *", base, time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", base, time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", base, time.Date(2025, 1, 16, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * sun", base, time.Date(2025, 1, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", base, time.Date(2025, 1, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 22 * * mon-fri", base, time.Date(2025, 1, 15, 22, 0, 0, 0, time.UTC)},
+		{"30 4 1,15 * *", base, time.Date(2025, 2, 1, 4, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", base, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 20 * mon", base, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 17 * mon", base, time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", base, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(tt.from))
+		})
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	schedule, err := Parse("0 2 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, time.Date(2025, 1, 16, 7, 0, 0, 0, time.UTC), next.UTC())
+
+	// 2:00 doesn't exist on the day daylight saving time starts
+	next = schedule.Next(time.Date(2025, 3, 9, 0, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2025, 3, 10, 2, 0, 0, 0, loc), next)
+}
@@ -0,0 +1,327 @@
+package maintenance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"site-availability/config"
+	"site-availability/labels"
+	"site-availability/logging"
+	"site-availability/maintenance/cron"
+)
+
+// Kinds of maintenance windows
+const (
+	KindConfig = "config" // Defined in config.yaml
+	KindAPI    = "api"    // Created through the API
+	KindSync   = "sync"   // Received from another site
+)
+
+var (
+	// ErrNotFound is returned when deleting a window that doesn't exist
+	ErrNotFound = errors.New("maintenance window not found")
+	// ErrReadOnly is returned when deleting a window that was not created through the API
+	ErrReadOnly = errors.New("maintenance window can only be changed where it is defined")
+)
+
+// Window is a maintenance window with its matchers and schedule compiled
+type Window struct {
+	config.MaintenanceWindow
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	OriginURL string    `json:"origin_url"` // Site the window was defined on
+
+	matchers   []*labels.Matcher
+	schedule   *cron.Schedule
+	location   *time.Location
+	start, end time.Time
+	duration   time.Duration
+}
+
+// NewWindow validates a window definition and compiles its matchers and schedule
+func NewWindow(spec config.MaintenanceWindow) (*Window, error) {
+	window := &Window{MaintenanceWindow: spec}
+	if err := window.compile(); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// compile parses the window definition. Windows received through sync are compiled after decoding.
+func (w *Window) compile() error {
+	if err := config.ValidateMaintenanceWindow(w.MaintenanceWindow); err != nil {
+		return err
+	}
+
+	// Validation guarantees that everything below parses
+	w.matchers, _ = labels.ParseMatchers(w.Matchers)
+	w.location, _ = time.LoadLocation(w.Timezone)
+	if w.Start != "" {
+		w.start, _ = time.Parse(time.RFC3339, w.Start)
+	}
+	if w.End != "" {
+		w.end, _ = time.Parse(time.RFC3339, w.End)
+	}
+	if w.Schedule != "" {
+		w.schedule, _ = cron.Parse(w.Schedule)
+		w.duration, _ = time.ParseDuration(w.Duration)
+	}
+	return nil
+}
+
+// LabelMatchers returns the compiled matchers selecting the apps of the window
+func (w *Window) LabelMatchers() []*labels.Matcher {
+	return w.matchers
+}
+
+// Occurrence returns the occurrence of the window that is active at now or, if none is, the next one.
+// It reports false when the window has no occurrence left.
+func (w *Window) Occurrence(now time.Time) (start, end time.Time, ok bool) {
+	if w.schedule == nil {
+		return w.start, w.end, now.Before(w.end)
+	}
+	if !w.end.IsZero() && !now.Before(w.end) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	// Occurrences starting after now - duration are still running at now
+	from := now.Add(-w.duration)
+	if from.Before(w.start) {
+		from = w.start.Add(-time.Minute)
+	}
+	start = w.schedule.Next(from.In(w.location))
+	if start.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	end = start.Add(w.duration)
+
+	// The start and end of the window bound its occurrences
+	if start.Before(w.start) {
+		start = w.start
+	}
+	if !w.end.IsZero() {
+		if !start.Before(w.end) {
+			return time.Time{}, time.Time{}, false
+		}
+		if end.After(w.end) {
+			end = w.end
+		}
+	}
+	return start, end, true
+}
+
+// IsActive reports whether apps selected by the window are in maintenance at now
+func (w *Window) IsActive(now time.Time) bool {
+	start, end, ok := w.Occurrence(now)
+	return ok && !now.Before(start) && now.Before(end)
+}
+
+// Store holds the maintenance windows from the config, the API and other sites
+type Store struct {
+	mu         sync.RWMutex
+	configured []*Window
+	created    []*Window
+	synced     map[string][]*Window // by source name
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{synced: make(map[string][]*Window)}
+}
+
+// Configure replaces the windows defined in the config
+func (s *Store) Configure(specs []config.MaintenanceWindow, originURL string) error {
+	windows, err := ConfigWindows(specs, originURL)
+	if err != nil {
+		return err
+	}
+	s.SetConfigured(windows)
+	return nil
+}
+
+// ConfigWindows validates and compiles the windows defined in the config, so a reload can check
+// them before changing anything
+func ConfigWindows(specs []config.MaintenanceWindow, originURL string) ([]*Window, error) {
+	windows := make([]*Window, 0, len(specs))
+	for _, spec := range specs {
+		window, err := NewWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		window.ID = "config-" + spec.Name
+		window.Kind = KindConfig
+		window.OriginURL = originURL
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// SetConfigured replaces the windows defined in the config with windows built by ConfigWindows
+func (s *Store) SetConfigured(windows []*Window) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configured = windows
+
+	logging.Logger.WithField("windows", len(windows)).Debug("Configured maintenance windows")
+}
+
+// Add creates a window through the API
+func (s *Store) Add(spec config.MaintenanceWindow, createdBy, originURL string, now time.Time) (*Window, error) {
+	window, err := NewWindow(spec)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	window.ID = id
+	window.Kind = KindAPI
+	window.CreatedBy = createdBy
+	window.CreatedAt = now.UTC()
+	window.OriginURL = originURL
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created = append(s.created, window)
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"id":         window.ID,
+		"name":       window.Name,
+		"created_by": createdBy,
+	}).Info("Created maintenance window")
+	return window, nil
+}
+
+// Delete removes a window created through the API
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, window := range s.created {
+		if window.ID == id {
+			s.created = append(s.created[:i], s.created[i+1:]...)
+			logging.Logger.WithFields(map[string]interface{}{
+				"id":   id,
+				"name": window.Name,
+			}).Info("Deleted maintenance window")
+			return nil
+		}
+	}
+	for _, window := range s.all() {
+		if window.ID == id {
+			return ErrReadOnly
+		}
+	}
+	return ErrNotFound
+}
+
+// SetSynced replaces the windows received from another site through a source.
+// Invalid windows are skipped.
+func (s *Store) SetSynced(sourceName string, received []Window) {
+	windows := make([]*Window, 0, len(received))
+	for _, window := range received {
+		window := window
+		if err := window.compile(); err != nil {
+			logging.Logger.WithError(err).WithField("source", sourceName).Warn("Skipping invalid maintenance window from remote site")
+			continue
+		}
+		window.Kind = KindSync
+		windows = append(windows, &window)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(windows) == 0 {
+		delete(s.synced, sourceName)
+		return
+	}
+	s.synced[sourceName] = windows
+}
+
+// Local returns the windows defined on this site, which are shared with other sites
+func (s *Store) Local() []Window {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Window, 0, len(s.configured)+len(s.created))
+	for _, window := range s.configured {
+		result = append(result, *window)
+	}
+	for _, window := range s.created {
+		result = append(result, *window)
+	}
+	return result
+}
+
+// All returns every window, sorted by name
+func (s *Store) All() []Window {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := s.all()
+	result := make([]Window, 0, len(all))
+	for _, window := range all {
+		result = append(result, *window)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Active returns the windows that are active at now. API windows without occurrences left are removed.
+func (s *Store) Active(now time.Time) []*Window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := s.created[:0]
+	for _, window := range s.created {
+		if _, _, ok := window.Occurrence(now); ok {
+			created = append(created, window)
+		} else {
+			logging.Logger.WithFields(map[string]interface{}{
+				"id":   window.ID,
+				"name": window.Name,
+			}).Info("Maintenance window ended")
+		}
+	}
+	s.created = created
+
+	var active []*Window
+	for _, window := range s.all() {
+		if window.IsActive(now) {
+			active = append(active, window)
+		}
+	}
+	return active
+}
+
+// all returns every window. The caller must hold the lock.
+func (s *Store) all() []*Window {
+	all := append(append([]*Window(nil), s.configured...), s.created...)
+
+	sources := make([]string, 0, len(s.synced))
+	for source := range s.synced {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		all = append(all, s.synced[source]...)
+	}
+	return all
+}
+
+// newID returns a random window identifier
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate maintenance window id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
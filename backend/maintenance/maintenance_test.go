@@ -0,0 +1,183 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"site-availability/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowOccurrence(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("fixed window", func(t *testing.T) {
+		window, err := NewWindow(config.MaintenanceWindow{
+			Name:     "upgrade",
+			Matchers: []string{`name="payments"`},
+			Start:    "2025-01-15T11:00:00Z",
+			End:      "2025-01-15T13:00:00Z",
+		})
+		require.NoError(t, err)
+
+		assert.True(t, window.IsActive(now))
+		assert.False(t, window.IsActive(now.Add(-2*time.Hour)), "before the start")
+		assert.False(t, window.IsActive(now.Add(time.Hour)), "the end is exclusive")
+
+		_, _, ok := window.Occurrence(now.Add(-2 * time.Hour))
+		assert.True(t, ok, "upcoming")
+		_, _, ok = window.Occurrence(now.Add(time.Hour))
+		assert.False(t, ok, "over")
+	})
+
+	t.Run("scheduled window", func(t *testing.T) {
+		window, err := NewWindow(config.MaintenanceWindow{
+			Name:     "daily",
+			Matchers: []string{`location="eu-west"`},
+			Schedule: "30 11 * * *",
+			Duration: "1h",
+		})
+		require.NoError(t, err)
+
+		start, end, ok := window.Occurrence(now)
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2025, 1, 15, 11, 30, 0, 0, time.UTC), start)
+		assert.Equal(t, time.Date(2025, 1, 15, 12, 30, 0, 0, time.UTC), end)
+		assert.True(t, window.IsActive(now))
+
+		assert.False(t, window.IsActive(now.Add(30*time.Minute)))
+		start, _, ok = window.Occurrence(now.Add(30 * time.Minute))
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2025, 1, 16, 11, 30, 0, 0, time.UTC), start, "next occurrence")
+	})
+
+	t.Run("schedule in a timezone", func(t *testing.T) {
+		window, err := NewWindow(config.MaintenanceWindow{
+			Name:     "nightly",
+			Matchers: []string{`name="payments"`},
+			Schedule: "0 13 * * *",
+			Duration: "30m",
+			Timezone: "Europe/Berlin",
+		})
+		require.NoError(t, err)
+
+		// 13:00 in Berlin is 12:00 UTC in winter
+		assert.True(t, window.IsActive(now))
+		assert.False(t, window.IsActive(now.Add(time.Hour)))
+	})
+
+	t.Run("schedule bounded by start and end", func(t *testing.T) {
+		window, err := NewWindow(config.MaintenanceWindow{
+			Name:     "migration",
+			Matchers: []string{`name="payments"`},
+			Schedule: "0 * * * *",
+			Duration: "15m",
+			Start:    "2025-01-16T00:00:00Z",
+			End:      "2025-01-17T00:00:00Z",
+		})
+		require.NoError(t, err)
+
+		assert.False(t, window.IsActive(now), "before the start")
+		start, _, ok := window.Occurrence(now)
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), start)
+
+		assert.True(t, window.IsActive(time.Date(2025, 1, 16, 5, 10, 0, 0, time.UTC)))
+		_, _, ok = window.Occurrence(time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC))
+		assert.False(t, ok, "after the end")
+	})
+}
+
+func TestStore(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	store := NewStore()
+
+	err := store.Configure([]config.MaintenanceWindow{{
+		Name:     "daily",
+		Matchers: []string{`location="eu-west"`},
+		Schedule: "30 11 * * *",
+		Duration: "1h",
+	}}, "https://site-a.example.com")
+	require.NoError(t, err)
+
+	created, err := store.Add(config.MaintenanceWindow{
+		Name:     "upgrade",
+		Matchers: []string{`name="payments"`},
+		Start:    "2025-01-15T11:00:00Z",
+		End:      "2025-01-15T12:30:00Z",
+	}, "alice", "https://site-a.example.com", now)
+	require.NoError(t, err)
+	assert.Equal(t, KindAPI, created.Kind)
+	assert.Equal(t, "alice", created.CreatedBy)
+	assert.NotEmpty(t, created.ID)
+
+	_, err = store.Add(config.MaintenanceWindow{Name: "invalid"}, "alice", "https://site-a.example.com", now)
+	assert.Error(t, err)
+
+	assert.Len(t, store.All(), 2)
+	assert.Len(t, store.Local(), 2)
+	assert.Len(t, store.Active(now), 2)
+
+	t.Run("invalid config windows keep the current ones", func(t *testing.T) {
+		_, err := ConfigWindows([]config.MaintenanceWindow{
+			{Name: "nightly", Matchers: []string{`location="eu-west"`}, Schedule: "0 2 * * *", Duration: "1h"},
+			{Name: "broken", Schedule: "0 2 * * *"},
+		}, "https://site-a.example.com")
+		assert.ErrorContains(t, err, "window broken")
+
+		assert.Error(t, store.Configure([]config.MaintenanceWindow{{Name: "broken"}}, "https://site-a.example.com"))
+		assert.Len(t, store.All(), 2)
+	})
+
+	t.Run("synced windows", func(t *testing.T) {
+		remote := NewStore()
+		require.NoError(t, remote.Configure([]config.MaintenanceWindow{{
+			Name:     "remote",
+			Matchers: []string{`name="search"`},
+			Schedule: "0 12 * * *",
+			Duration: "1h",
+		}}, "https://site-b.example.com"))
+
+		// Windows are sent to other sites as JSON
+		data, err := json.Marshal(remote.Local())
+		require.NoError(t, err)
+		var received []Window
+		require.NoError(t, json.Unmarshal(data, &received))
+
+		store.SetSynced("site-b", received)
+		all := store.All()
+		require.Len(t, all, 3)
+		assert.Equal(t, KindSync, all[1].Kind)
+		assert.Equal(t, "https://site-b.example.com", all[1].OriginURL)
+		assert.Len(t, store.Local(), 2, "synced windows are not shared again")
+		assert.Len(t, store.Active(now), 3, "synced windows are compiled")
+
+		store.SetSynced("site-b", nil)
+		assert.Len(t, store.All(), 2)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.ErrorIs(t, store.Delete("config-daily"), ErrReadOnly)
+		assert.ErrorIs(t, store.Delete("missing"), ErrNotFound)
+		require.NoError(t, store.Delete(created.ID))
+		assert.Len(t, store.All(), 1)
+	})
+
+	t.Run("ended API windows are removed", func(t *testing.T) {
+		_, err := store.Add(config.MaintenanceWindow{
+			Name:     "short",
+			Matchers: []string{`name="payments"`},
+			Start:    "2025-01-15T11:00:00Z",
+			End:      "2025-01-15T12:30:00Z",
+		}, "alice", "https://site-a.example.com", now)
+		require.NoError(t, err)
+		assert.Len(t, store.All(), 2)
+
+		assert.Len(t, store.Active(now.Add(time.Hour)), 0)
+		assert.Len(t, store.All(), 1, "only the config window is left")
+	})
+}
@@ -3,6 +3,7 @@ package metrics
 import (
 	"net/http"
 	"site-availability/handlers"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -42,6 +43,83 @@ var (
 		},
 		[]string{"location", "source"},
 	)
+	siteAvailabilityAppsDegraded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_apps_degraded",
+			Help: "Count of apps in degraded status per location",
+		},
+		[]string{"location", "source"},
+	)
+	siteAvailabilityAppsMaintenance = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_apps_maintenance",
+			Help: "Count of apps in maintenance per location",
+		},
+		[]string{"location", "source"},
+	)
+
+	siteAvailabilityAppFlapping = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_app_flapping",
+			Help: "Whether an app changed status too often within its flap window (1 = flapping)",
+		},
+		[]string{"name", "location", "source", "origin_url"},
+	)
+
+	siteAvailabilityAppMaintenance = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_app_maintenance",
+			Help: "Whether an app is in an active maintenance window (1 = in maintenance)",
+		},
+		[]string{"name", "location", "source", "origin_url"},
+	)
+
+	siteAvailabilityAppResponseTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_app_response_time_seconds",
+			Help: "Duration of the last check of an app",
+		},
+		[]string{"name", "location", "source", "origin_url"},
+	)
+
+	siteAvailabilityAppLastCheck = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_app_last_check_timestamp_seconds",
+			Help: "Timestamp of the last check of an app",
+		},
+		[]string{"name", "location", "source", "origin_url"},
+	)
+
+	siteAvailabilityAppLastChange = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_app_last_change_timestamp_seconds",
+			Help: "Timestamp of the last status change of an app",
+		},
+		[]string{"name", "location", "source", "origin_url"},
+	)
+
+	// SLA metrics (availability percentage over rolling windows)
+	siteAvailabilityAppSLA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_app_sla_percent",
+			Help: "Availability percentage of an app over a rolling window",
+		},
+		[]string{"name", "location", "source", "origin_url", "window"},
+	)
+	siteAvailabilityLocationSLA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_location_sla_percent",
+			Help: "Availability percentage of all apps in a location over a rolling window",
+		},
+		[]string{"location", "window"},
+	)
+	siteAvailabilityLabelSLA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "site_availability_label_sla_percent",
+			Help: "Availability percentage of all apps with a label value over a rolling window",
+		},
+		[]string{"label", "value", "window"},
+	)
 
 	// Global metrics
 	siteAvailabilityTotalApps = prometheus.NewGauge(
@@ -68,6 +146,50 @@ var (
 			Help: "Total apps in unavailable status across all locations",
 		},
 	)
+	siteAvailabilityTotalAppsDegraded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "site_availability_total_apps_degraded",
+			Help: "Total apps in degraded status across all locations",
+		},
+	)
+	siteAvailabilityTotalAppsMaintenance = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "site_availability_total_apps_maintenance",
+			Help: "Total apps in maintenance across all locations",
+		},
+	)
+
+	// Check metrics
+	siteAvailabilityCheckAttempts = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "site_availability_check_attempts",
+			Help:    "Number of attempts per check, including retries",
+			Buckets: []float64{1, 2, 3, 4, 5, 7, 10},
+		},
+		[]string{"type"},
+	)
+	siteAvailabilityScrapesSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "site_availability_scrapes_skipped_total",
+			Help: "Scheduled scrapes skipped because the previous scrape of the source was still running",
+		},
+		[]string{"source"},
+	)
+
+	// Config reload metrics
+	configReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "site_availability_config_reloads_total",
+			Help: "Total number of config reloads by result",
+		},
+		[]string{"result"},
+	)
+	configLastReloadSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "site_availability_config_last_reload_success_timestamp",
+			Help: "Timestamp of last successful config reload",
+		},
+	)
 
 	// Site sync metrics
 	siteSyncAttempts = prometheus.NewCounter(
@@ -122,6 +244,13 @@ func SetupMetricsHandler() http.Handler {
 	siteAvailabilityAppsUp.Reset()
 	siteAvailabilityAppsDown.Reset()
 	siteAvailabilityAppsUnavailable.Reset()
+	siteAvailabilityAppsDegraded.Reset()
+	siteAvailabilityAppsMaintenance.Reset()
+	siteAvailabilityAppFlapping.Reset()
+	siteAvailabilityAppMaintenance.Reset()
+	siteAvailabilityAppResponseTime.Reset()
+	siteAvailabilityAppLastCheck.Reset()
+	siteAvailabilityAppLastChange.Reset()
 
 	// Step 4: Set values for each app with its specific labels
 	for _, appStatus := range appStatuses {
@@ -130,18 +259,47 @@ func SetupMetricsHandler() http.Handler {
 
 		// Set the status value
 		statusValue := 0.0
-		if appStatus.Status == "up" {
+		switch appStatus.Status {
+		case "up":
 			statusValue = 1.0
+		case "degraded":
+			statusValue = 0.5
 		}
 
 		if siteAvailabilityStatus != nil {
 			siteAvailabilityStatus.WithLabelValues(labelValues...).Set(statusValue)
 		}
+
+		flapping := 0.0
+		if appStatus.Flapping {
+			flapping = 1.0
+		}
+		siteAvailabilityAppFlapping.WithLabelValues(appStatus.Name, appStatus.Location, appStatus.Source, appStatus.OriginURL).Set(flapping)
+
+		inMaintenance := 0.0
+		if appStatus.Status == handlers.StatusMaintenance {
+			inMaintenance = 1.0
+		}
+		siteAvailabilityAppMaintenance.WithLabelValues(appStatus.Name, appStatus.Location, appStatus.Source, appStatus.OriginURL).Set(inMaintenance)
+
+		// Check metadata; the failure reason is only exposed through the API to keep label cardinality low
+		appLabels := []string{appStatus.Name, appStatus.Location, appStatus.Source, appStatus.OriginURL}
+		if appStatus.ResponseTimeMs > 0 {
+			siteAvailabilityAppResponseTime.WithLabelValues(appLabels...).Set(appStatus.ResponseTimeMs / 1000)
+		}
+		if !appStatus.LastCheck.IsZero() {
+			siteAvailabilityAppLastCheck.WithLabelValues(appLabels...).Set(float64(appStatus.LastCheck.Unix()))
+		}
+		if !appStatus.LastChange.IsZero() {
+			siteAvailabilityAppLastChange.WithLabelValues(appLabels...).Set(float64(appStatus.LastChange.Unix()))
+		}
 	}
 
 	// Step 5: Update aggregated metrics (unchanged logic)
 	updateAggregatedMetrics(appStatuses)
 
+	// SLA metrics query the status history, so they are updated periodically by UpdateSLAMetrics instead of on every scrape
+
 	return promhttp.Handler()
 }
 
@@ -202,7 +360,7 @@ func createDynamicMetric(labelKeys []string) {
 	siteAvailabilityStatus = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "site_availability_status",
-			Help: "Site availability status by app and location (1=up, 0=down)",
+			Help: "Site availability status by app and location (1=up, 0.5=degraded, 0=down)",
 		},
 		labelKeys,
 	)
@@ -254,6 +412,8 @@ func updateAggregatedMetrics(appStatuses []handlers.AppStatus) {
 	totalUp := 0
 	totalDown := 0
 	totalUnavailable := 0
+	totalDegraded := 0
+	totalMaintenance := 0
 
 	// Track per-location and per-source counts
 	type locsrc struct {
@@ -265,6 +425,8 @@ func updateAggregatedMetrics(appStatuses []handlers.AppStatus) {
 		up          int
 		down        int
 		unavailable int
+		degraded    int
+		maintenance int
 	})
 
 	for _, appStatus := range appStatuses {
@@ -275,7 +437,7 @@ func updateAggregatedMetrics(appStatuses []handlers.AppStatus) {
 		key := locsrc{location, source}
 		if _, exists := locationSourceCounts[key]; !exists {
 			locationSourceCounts[key] = struct {
-				total, up, down, unavailable int
+				total, up, down, unavailable, degraded, maintenance int
 			}{}
 		}
 		counts := locationSourceCounts[key]
@@ -286,9 +448,15 @@ func updateAggregatedMetrics(appStatuses []handlers.AppStatus) {
 		case "up":
 			counts.up++
 			totalUp++
+		case "degraded":
+			counts.degraded++
+			totalDegraded++
 		case "down":
 			counts.down++
 			totalDown++
+		case handlers.StatusMaintenance:
+			counts.maintenance++
+			totalMaintenance++
 		default:
 			counts.unavailable++
 			totalUnavailable++
@@ -303,6 +471,8 @@ func updateAggregatedMetrics(appStatuses []handlers.AppStatus) {
 		siteAvailabilityAppsUp.WithLabelValues(key.location, key.source).Set(float64(counts.up))
 		siteAvailabilityAppsDown.WithLabelValues(key.location, key.source).Set(float64(counts.down))
 		siteAvailabilityAppsUnavailable.WithLabelValues(key.location, key.source).Set(float64(counts.unavailable))
+		siteAvailabilityAppsDegraded.WithLabelValues(key.location, key.source).Set(float64(counts.degraded))
+		siteAvailabilityAppsMaintenance.WithLabelValues(key.location, key.source).Set(float64(counts.maintenance))
 	}
 
 	// Update global metrics
@@ -310,6 +480,41 @@ func updateAggregatedMetrics(appStatuses []handlers.AppStatus) {
 	siteAvailabilityTotalAppsUp.Set(float64(totalUp))
 	siteAvailabilityTotalAppsDown.Set(float64(totalDown))
 	siteAvailabilityTotalAppsUnavailable.Set(float64(totalUnavailable))
+	siteAvailabilityTotalAppsDegraded.Set(float64(totalDegraded))
+	siteAvailabilityTotalAppsMaintenance.Set(float64(totalMaintenance))
+}
+
+// UpdateSLAMetrics recalculates the availability percentages per app, location and label value
+// of the cached apps from the status history
+func UpdateSLAMetrics() {
+	sla := handlers.CalculateSLA(handlers.GetAppStatusCache(), time.Now())
+
+	siteAvailabilityAppSLA.Reset()
+	siteAvailabilityLocationSLA.Reset()
+	siteAvailabilityLabelSLA.Reset()
+
+	// Windows without up/down data are left unset instead of reporting 0%
+	for _, app := range sla.Apps {
+		for window, percentage := range app.Availability {
+			if percentage != nil {
+				siteAvailabilityAppSLA.WithLabelValues(app.Name, app.Location, app.Source, app.OriginURL, window).Set(*percentage)
+			}
+		}
+	}
+	for _, location := range sla.Locations {
+		for window, percentage := range location.Availability {
+			if percentage != nil {
+				siteAvailabilityLocationSLA.WithLabelValues(location.Name, window).Set(*percentage)
+			}
+		}
+	}
+	for _, label := range sla.Labels {
+		for window, percentage := range label.Availability {
+			if percentage != nil {
+				siteAvailabilityLabelSLA.WithLabelValues(label.Key, label.Value, window).Set(*percentage)
+			}
+		}
+	}
 }
 
 // Init registers all Prometheus metrics
@@ -319,10 +524,26 @@ func Init() {
 	prometheus.MustRegister(siteAvailabilityAppsUp)
 	prometheus.MustRegister(siteAvailabilityAppsDown)
 	prometheus.MustRegister(siteAvailabilityAppsUnavailable)
+	prometheus.MustRegister(siteAvailabilityAppsDegraded)
+	prometheus.MustRegister(siteAvailabilityAppsMaintenance)
+	prometheus.MustRegister(siteAvailabilityAppFlapping)
+	prometheus.MustRegister(siteAvailabilityAppMaintenance)
+	prometheus.MustRegister(siteAvailabilityAppResponseTime)
+	prometheus.MustRegister(siteAvailabilityAppLastCheck)
+	prometheus.MustRegister(siteAvailabilityAppLastChange)
 	prometheus.MustRegister(siteAvailabilityTotalApps)
 	prometheus.MustRegister(siteAvailabilityTotalAppsUp)
 	prometheus.MustRegister(siteAvailabilityTotalAppsDown)
 	prometheus.MustRegister(siteAvailabilityTotalAppsUnavailable)
+	prometheus.MustRegister(siteAvailabilityTotalAppsDegraded)
+	prometheus.MustRegister(siteAvailabilityTotalAppsMaintenance)
+	prometheus.MustRegister(siteAvailabilityAppSLA)
+	prometheus.MustRegister(siteAvailabilityLocationSLA)
+	prometheus.MustRegister(siteAvailabilityLabelSLA)
+	prometheus.MustRegister(siteAvailabilityCheckAttempts)
+	prometheus.MustRegister(siteAvailabilityScrapesSkipped)
+	prometheus.MustRegister(configReloads)
+	prometheus.MustRegister(configLastReloadSuccess)
 
 	// Register site sync metrics
 	prometheus.MustRegister(siteSyncAttempts)
@@ -332,6 +553,26 @@ func Init() {
 	prometheus.MustRegister(siteSyncStatus)
 }
 
+// ObserveCheckAttempts records the number of attempts a check of a source type needed
+func ObserveCheckAttempts(sourceType string, attempts int) {
+	siteAvailabilityCheckAttempts.WithLabelValues(sourceType).Observe(float64(attempts))
+}
+
+// RecordSkippedScrape counts a scheduled scrape of a source that was skipped because the previous one was still running
+func RecordSkippedScrape(source string) {
+	siteAvailabilityScrapesSkipped.WithLabelValues(source).Inc()
+}
+
+// RecordConfigReload counts a config reload attempt
+func RecordConfigReload(success bool) {
+	if !success {
+		configReloads.WithLabelValues("failure").Inc()
+		return
+	}
+	configReloads.WithLabelValues("success").Inc()
+	configLastReloadSuccess.SetToCurrentTime()
+}
+
 // SiteSyncMetrics provides access to site sync metrics
 type SiteSyncMetrics struct {
 	SyncAttempts prometheus.Counter
@@ -2,13 +2,17 @@ package metrics_test
 
 import (
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"site-availability/config"
 	"site-availability/handlers"
+	"site-availability/history"
 	"site-availability/labels"
 	"site-availability/metrics"
 
@@ -311,6 +315,25 @@ func assertContains(t *testing.T, output, metricLine string) {
 	}
 }
 
+// assertMetricValue asserts a metric series exists with approximately the expected value.
+func assertMetricValue(t *testing.T, output, series string, expected float64) {
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, series+" ") {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimPrefix(line, series+" "), 64)
+		if err != nil {
+			t.Errorf("Invalid value for metric %q: %v", series, err)
+			return
+		}
+		if math.Abs(value-expected) > 0.1 {
+			t.Errorf("Metric %q = %v, expected %v", series, value, expected)
+		}
+		return
+	}
+	t.Errorf("Expected metric series missing: %q", series)
+}
+
 // TestMain is the entry point for setting up the test environment
 func TestMain(m *testing.M) {
 	// Initialize the metrics globally to avoid duplicate registration
@@ -607,3 +630,163 @@ func TestEmptyLabelsAreExcluded(t *testing.T) {
 		t.Errorf("Found empty version label in output, but it should be excluded")
 	}
 }
+
+func TestSLAMetrics(t *testing.T) {
+	mockData := []handlers.AppStatus{
+		{Name: "sla-app", Location: "us-east", Status: "down", Source: "test-source", OriginURL: "http://test-origin.com"},
+	}
+	setupMockAppStatusCache(mockData)
+
+	now := time.Now()
+	store := history.NewMemoryStore(history.DefaultRetention)
+	_ = store.Record(
+		history.Transition{Timestamp: now.Add(-2 * time.Hour), App: "sla-app", Source: "test-source", OriginURL: "http://test-origin.com", Status: "up"},
+		history.Transition{Timestamp: now.Add(-30 * time.Minute), App: "sla-app", Source: "test-source", OriginURL: "http://test-origin.com", Status: "down"},
+	)
+	handlers.SetHistoryStore(store)
+	defer handlers.SetHistoryStore(history.NewMemoryStore(history.DefaultRetention))
+
+	// Scrapes serve the SLA metrics of the last update without querying the history
+	metrics.UpdateSLAMetrics()
+	_ = store.Record(history.Transition{Timestamp: now.Add(-time.Minute), App: "sla-app", Source: "test-source", OriginURL: "http://test-origin.com", Status: "up"})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	// Percentages drift slightly with the time between recording and scraping
+	assertMetricValue(t, output, `site_availability_app_sla_percent{location="us-east",name="sla-app",origin_url="http://test-origin.com",source="test-source",window="30d"}`, 75)
+	assertMetricValue(t, output, `site_availability_location_sla_percent{location="us-east",window="30d"}`, 75)
+	assertMetricValue(t, output, `site_availability_label_sla_percent{label="source_env",value="test",window="30d"}`, 75)
+	assertMetricValue(t, output, `site_availability_app_sla_percent{location="us-east",name="sla-app",origin_url="http://test-origin.com",source="test-source",window="1h"}`, 50)
+}
+
+func TestDegradedMetrics(t *testing.T) {
+	setupMockAppStatusCache([]handlers.AppStatus{
+		{Name: "fast-app", Location: "us-east", Status: "up", Source: "test-source", OriginURL: "http://test-origin.com"},
+		{Name: "slow-app", Location: "us-east", Status: "degraded", Source: "test-source", OriginURL: "http://test-origin.com"},
+		{Name: "slow-app-2", Location: "us-west", Status: "degraded", Source: "test-source", OriginURL: "http://test-origin.com"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	assertContains(t, output, `site_availability_status{location="us-east",name="slow-app",origin_url="http://test-origin.com",server_env="test",server_region="us-west",source="test-source",source_env="test",source_type="mock"} 0.5`)
+	assertContains(t, output, `site_availability_status{location="us-east",name="fast-app",origin_url="http://test-origin.com",server_env="test",server_region="us-west",source="test-source",source_env="test",source_type="mock"} 1`)
+	assertContains(t, output, `site_availability_apps_degraded{location="us-east",source="test-source"} 1`)
+	assertContains(t, output, `site_availability_apps_up{location="us-east",source="test-source"} 1`)
+	assertContains(t, output, `site_availability_apps_degraded{location="us-west",source="test-source"} 1`)
+	assertContains(t, output, `site_availability_total_apps_degraded 2`)
+	assertContains(t, output, `site_availability_total_apps_unavailable 0`)
+}
+
+func TestFlappingMetric(t *testing.T) {
+	setupMockAppStatusCache([]handlers.AppStatus{
+		{Name: "stable-app", Location: "us-east", Status: "up", Source: "test-source", OriginURL: "http://test-origin.com"},
+		{Name: "flaky-app", Location: "us-east", Status: "down", Source: "test-source", OriginURL: "http://test-origin.com", Flapping: true},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	assertContains(t, output, `site_availability_app_flapping{location="us-east",name="flaky-app",origin_url="http://test-origin.com",source="test-source"} 1`)
+	assertContains(t, output, `site_availability_app_flapping{location="us-east",name="stable-app",origin_url="http://test-origin.com",source="test-source"} 0`)
+}
+
+func TestMaintenanceMetrics(t *testing.T) {
+	setupMockAppStatusCache([]handlers.AppStatus{
+		{Name: "running-app", Location: "us-east", Status: "up", Source: "test-source", OriginURL: "http://test-origin.com"},
+		{Name: "upgraded-app", Location: "us-east", Status: "maintenance", Maintenance: "upgrade", Source: "test-source", OriginURL: "http://test-origin.com"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	assertContains(t, output, `site_availability_app_maintenance{location="us-east",name="upgraded-app",origin_url="http://test-origin.com",source="test-source"} 1`)
+	assertContains(t, output, `site_availability_app_maintenance{location="us-east",name="running-app",origin_url="http://test-origin.com",source="test-source"} 0`)
+	assertContains(t, output, `site_availability_apps_maintenance{location="us-east",source="test-source"} 1`)
+	assertContains(t, output, `site_availability_total_apps_maintenance 1`)
+	assertContains(t, output, `site_availability_total_apps_unavailable 0`)
+	assertContains(t, output, `site_availability_total_apps_down 0`)
+}
+
+func TestCheckMetadataMetrics(t *testing.T) {
+	checked := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	setupMockAppStatusCache([]handlers.AppStatus{
+		{Name: "checked-app", Location: "us-east", Status: "down", Source: "test-source", OriginURL: "http://test-origin.com",
+			LastCheck: checked, LastChange: checked.Add(-time.Hour), ResponseTimeMs: 250, Reason: "status code 503 not in allowed codes"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	labels := `{location="us-east",name="checked-app",origin_url="http://test-origin.com",source="test-source"}`
+	assertContains(t, output, `site_availability_app_response_time_seconds`+labels+` 0.25`)
+	assertContains(t, output, `site_availability_app_last_check_timestamp_seconds`+labels+` 1.7357328e+09`)
+	assertContains(t, output, `site_availability_app_last_change_timestamp_seconds`+labels+` 1.7357292e+09`)
+	if strings.Contains(output, "status code 503") {
+		t.Error("failure reason must not be exported as a metric label")
+	}
+}
+
+func TestCheckAttemptsMetric(t *testing.T) {
+	metrics.ObserveCheckAttempts("http", 1)
+	metrics.ObserveCheckAttempts("http", 3)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	assertContains(t, output, `site_availability_check_attempts_bucket{type="http",le="1"} 1`)
+	assertContains(t, output, `site_availability_check_attempts_count{type="http"} 2`)
+}
+
+func TestConfigReloadMetrics(t *testing.T) {
+	metrics.RecordConfigReload(true)
+	metrics.RecordConfigReload(false)
+	metrics.RecordConfigReload(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	output := string(body)
+
+	assertContains(t, output, `site_availability_config_reloads_total{result="success"} 1`)
+	assertContains(t, output, `site_availability_config_reloads_total{result="failure"} 2`)
+	assertContains(t, output, `site_availability_config_last_reload_success_timestamp`)
+}
+
+func TestScrapesSkippedMetric(t *testing.T) {
+	metrics.RecordSkippedScrape("fleet-query")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	metrics.SetupMetricsHandler().ServeHTTP(rr, req)
+
+	body, _ := io.ReadAll(rr.Result().Body)
+	assertContains(t, string(body), `site_availability_scrapes_skipped_total{source="fleet-query"} 1`)
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"site-availability/config"
+)
+
+// alertName is the alertname label of every alert, kept stable so resolves match their firing alert
+const alertName = "SiteAvailabilityAppStatus"
+
+// AlertmanagerReceiver posts alerts to the Alertmanager v2 API
+type AlertmanagerReceiver struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// postableAlert is an alert in the format of Alertmanager's POST /api/v2/alerts
+type postableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// NewAlertmanagerReceiver creates a receiver for an Alertmanager base URL
+func NewAlertmanagerReceiver(cfg config.NotificationReceiver) (*AlertmanagerReceiver, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &AlertmanagerReceiver{
+		name:    cfg.Name,
+		url:     strings.TrimSuffix(cfg.URL, "/") + "/api/v2/alerts",
+		headers: cfg.Headers,
+		client:  client,
+	}, nil
+}
+
+// Name returns the receiver name
+func (a *AlertmanagerReceiver) Name() string {
+	return a.name
+}
+
+// Send posts all alerts of the notification. Alertmanager does its own grouping and deduplication.
+func (a *AlertmanagerReceiver) Send(ctx context.Context, notification Notification) error {
+	alerts := make([]postableAlert, 0, len(notification.Alerts))
+	for _, alert := range notification.Alerts {
+		alertLabels := map[string]string{"alertname": alertName}
+		for key, value := range alert.Labels {
+			alertLabels[key] = value
+		}
+
+		alerts = append(alerts, postableAlert{
+			Labels: alertLabels,
+			Annotations: map[string]string{
+				"summary":         fmt.Sprintf("%s in %s is %s", alert.App, alert.Location, alert.AppStatus),
+				"app_status":      alert.AppStatus,
+				"previous_status": alert.PreviousStatus,
+			},
+			StartsAt:     alert.StartsAt,
+			EndsAt:       alert.EndsAt,
+			GeneratorURL: notification.ExternalURL,
+		})
+	}
+
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("failed to encode alerts: %w", err)
+	}
+	return postJSON(ctx, a.client, a.url, a.headers, body)
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertmanagerReceiver(t *testing.T) {
+	var path string
+	var alerts []postableAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&alerts))
+	}))
+	defer server.Close()
+
+	receiver, err := NewAlertmanagerReceiver(config.NotificationReceiver{Name: "am", URL: server.URL + "/"})
+	require.NoError(t, err)
+	assert.Equal(t, "am", receiver.Name())
+
+	notification := testNotification()
+	endsAt := time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)
+	notification.Alerts = append(notification.Alerts, Alert{
+		Status:    StatusResolved,
+		App:       "search",
+		Location:  "us-east",
+		AppStatus: "up",
+		Labels:    map[string]string{"name": "search", "location": "us-east", "source": "prom"},
+		StartsAt:  time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC),
+		EndsAt:    &endsAt,
+	})
+
+	require.NoError(t, receiver.Send(context.Background(), notification))
+	assert.Equal(t, "/api/v2/alerts", path)
+	require.Len(t, alerts, 2)
+
+	assert.Equal(t, alertName, alerts[0].Labels["alertname"])
+	assert.Equal(t, "payments", alerts[0].Labels["name"])
+	assert.Equal(t, "checkout", alerts[0].Labels["team"])
+	assert.Equal(t, "payments in eu-west is down", alerts[0].Annotations["summary"])
+	assert.Equal(t, "https://status.example.com", alerts[0].GeneratorURL)
+	assert.Nil(t, alerts[0].EndsAt)
+
+	// Resolved alerts keep their labels and carry an end time
+	assert.Equal(t, alertName, alerts[1].Labels["alertname"])
+	require.NotNil(t, alerts[1].EndsAt)
+	assert.True(t, endsAt.Equal(*alerts[1].EndsAt))
+}
@@ -0,0 +1,559 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+	"site-availability/logging"
+)
+
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// appStatusMaintenance is the status of apps in a maintenance window (handlers.StatusMaintenance)
+const appStatusMaintenance = "maintenance"
+
+// Alert is the notification state of a single app on a route
+type Alert struct {
+	Status         string            `json:"status"` // "firing" or "resolved"
+	App            string            `json:"app"`
+	Source         string            `json:"source"`
+	Location       string            `json:"location"`
+	OriginURL      string            `json:"origin_url,omitempty"`
+	AppStatus      string            `json:"app_status"` // Current status of the app
+	PreviousStatus string            `json:"previous_status,omitempty"`
+	Labels         map[string]string `json:"labels"` // App labels plus name, location and source
+	StartsAt       time.Time         `json:"starts_at"`
+	EndsAt         *time.Time        `json:"ends_at,omitempty"`
+
+	notified bool // Whether the firing alert has been sent
+	silenced bool // Whether the app is in maintenance, which holds back sending the alert
+}
+
+// Notification is a batch of alerts of one group sent to a receiver
+type Notification struct {
+	Receiver    string            `json:"receiver"`
+	Status      string            `json:"status"` // "firing" when any alert fires, "resolved" otherwise
+	GroupLabels map[string]string `json:"group_labels"`
+	Alerts      []Alert           `json:"alerts"`
+	ExternalURL string            `json:"external_url"` // host_url of this server
+}
+
+// Firing returns the alerts that are firing
+func (n Notification) Firing() []Alert {
+	return n.filter(StatusFiring)
+}
+
+// Resolved returns the alerts that are resolved
+func (n Notification) Resolved() []Alert {
+	return n.filter(StatusResolved)
+}
+
+func (n Notification) filter(status string) []Alert {
+	var alerts []Alert
+	for _, alert := range n.Alerts {
+		if alert.Status == status {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// Receiver delivers notifications to an external system
+type Receiver interface {
+	Name() string
+	Send(ctx context.Context, notification Notification) error
+}
+
+// NewReceiver creates the receiver for the configured type
+func NewReceiver(cfg config.NotificationReceiver) (Receiver, error) {
+	switch cfg.Type {
+	case "webhook":
+		return NewWebhookReceiver(cfg)
+	case "alertmanager":
+		return NewAlertmanagerReceiver(cfg)
+	case "slack":
+		return NewSlackReceiver(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported receiver type %q", cfg.Type)
+	}
+}
+
+// Notifier turns status transitions into grouped notifications for the configured routes
+type Notifier struct {
+	mu          sync.Mutex
+	routes      []*route
+	externalURL string
+	stopped     bool
+}
+
+// route holds the settings and alert groups of a single configured route
+type route struct {
+	receiver       Receiver
+	matchers       []*labels.Matcher
+	statuses       map[string]bool
+	groupBy        []string
+	groupWait      time.Duration
+	repeatInterval time.Duration
+	sendResolved   bool
+
+	groups     map[string]*group // Keyed by group labels
+	alertGroup map[string]string // Alert key to group key
+}
+
+// group batches the alerts sharing the same group labels
+type group struct {
+	key    string
+	labels map[string]string
+	alerts map[string]*Alert // Keyed by app series
+
+	timer      *time.Timer
+	due        time.Time
+	generation int // Invalidates timers that were replaced
+}
+
+// New creates a notifier from the notifications config
+func New(cfg config.Notifications, externalURL string) (*Notifier, error) {
+	receivers := make(map[string]Receiver)
+	for _, receiverCfg := range cfg.Receivers {
+		receiver, err := NewReceiver(receiverCfg)
+		if err != nil {
+			return nil, fmt.Errorf("receiver %s: %w", receiverCfg.Name, err)
+		}
+		receivers[receiverCfg.Name] = receiver
+	}
+
+	notifier := &Notifier{externalURL: externalURL}
+	for i, routeCfg := range cfg.Routes {
+		receiver, ok := receivers[routeCfg.Receiver]
+		if !ok {
+			return nil, fmt.Errorf("route %d: unknown receiver %q", i, routeCfg.Receiver)
+		}
+
+		matchers, err := labels.ParseMatchers(routeCfg.Matchers)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+
+		groupWait, err := parseDuration(routeCfg.GroupWait, 30*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: invalid group_wait: %w", i, err)
+		}
+		repeatInterval, err := parseDuration(routeCfg.RepeatInterval, 4*time.Hour)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: invalid repeat_interval: %w", i, err)
+		}
+
+		statuses := make(map[string]bool)
+		for _, status := range routeCfg.Statuses {
+			statuses[status] = true
+		}
+		if len(statuses) == 0 {
+			statuses["down"] = true
+		}
+
+		notifier.routes = append(notifier.routes, &route{
+			receiver:       receiver,
+			matchers:       matchers,
+			statuses:       statuses,
+			groupBy:        routeCfg.GroupBy,
+			groupWait:      groupWait,
+			repeatInterval: repeatInterval,
+			sendResolved:   routeCfg.ShouldSendResolved(),
+			groups:         make(map[string]*group),
+			alertGroup:     make(map[string]string),
+		})
+	}
+
+	return notifier, nil
+}
+
+// Reconfigure switches the notifier to the routes and receivers of next, which must not be used afterwards.
+// Alerts are carried over to the new routes that still select them, so a config reload neither
+// forgets firing alerts nor sends them again. An alert counts as sent on a new route when an old
+// route with the same receiver sent it.
+func (n *Notifier) Reconfigure(next *Notifier) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, old := range n.routes {
+		for _, g := range old.groups {
+			if g.timer != nil {
+				g.timer.Stop()
+			}
+			g.generation++ // A flush already waiting for the lock must not send to the old receiver
+		}
+	}
+
+	for _, r := range next.routes {
+		for _, old := range n.routes {
+			sameReceiver := old.receiver.Name() == r.receiver.Name()
+			for _, g := range old.groups {
+				for alertKey, alert := range g.alerts {
+					if !r.carries(alert, sameReceiver) {
+						continue
+					}
+					carried := r.alert(alertKey)
+					if carried == nil {
+						copied := *alert
+						copied.notified = false
+						carried = &copied
+						target := r.groupFor(alert.Labels)
+						target.alerts[alertKey] = carried
+						r.alertGroup[alertKey] = target.key
+					}
+					carried.notified = carried.notified || (alert.notified && sameReceiver)
+				}
+			}
+		}
+	}
+
+	n.routes = next.routes
+	n.externalURL = next.externalURL
+	if n.stopped {
+		return
+	}
+
+	// Resolves and unsent alerts go out after the group wait, the others are repeated as before
+	for _, r := range n.routes {
+		for _, g := range r.groups {
+			delay := r.repeatInterval
+			for _, alert := range g.alerts {
+				if alert.Status == StatusResolved || (!alert.notified && !alert.silenced) {
+					delay = r.groupWait
+					break
+				}
+			}
+			n.schedule(r, g, delay)
+		}
+	}
+}
+
+// carries reports whether an alert of an old route moves to this route on reconfiguration.
+// Pending resolves only move to routes of the same receiver, as only that receiver knows the alert.
+func (r *route) carries(alert *Alert, sameReceiver bool) bool {
+	if !labels.MatchesAll(r.matchers, alert.Labels) {
+		return false
+	}
+	if alert.Status == StatusFiring {
+		return r.statuses[alert.AppStatus]
+	}
+	return sameReceiver && r.sendResolved && alert.notified
+}
+
+// alert returns the alert of an app series on the route, or nil
+func (r *route) alert(alertKey string) *Alert {
+	groupKey, ok := r.alertGroup[alertKey]
+	if !ok {
+		return nil
+	}
+	return r.groups[groupKey].alerts[alertKey]
+}
+
+// parseDuration parses an optional duration, returning the fallback when empty
+func parseDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// HandleTransitions updates the alerts of every matching route. It is registered as a handlers.TransitionListener.
+func (n *Notifier) HandleTransitions(transitions []history.Transition) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.stopped {
+		return
+	}
+
+	for _, transition := range transitions {
+		alertLabels := alertLabels(transition)
+		for _, r := range n.routes {
+			n.updateAlert(r, transition, alertLabels)
+		}
+	}
+}
+
+// updateAlert applies a transition to the alert of a route, scheduling a notification on changes
+func (n *Notifier) updateAlert(r *route, transition history.Transition, alertLabels map[string]string) {
+	alertKey := transition.Key()
+
+	var existing *Alert
+	var g *group
+	if groupKey, ok := r.alertGroup[alertKey]; ok {
+		g = r.groups[groupKey]
+		existing = g.alerts[alertKey]
+	}
+
+	// Maintenance silences a firing alert instead of resolving it, unless the route fires on maintenance
+	if transition.Status == appStatusMaintenance && !r.statuses[appStatusMaintenance] {
+		if existing != nil && existing.Status == StatusFiring {
+			existing.silenced = true
+		}
+		return
+	}
+
+	firing := r.statuses[transition.Status] && labels.MatchesAll(r.matchers, alertLabels)
+
+	switch {
+	case firing && existing != nil && existing.Status == StatusFiring:
+		silenced := existing.silenced
+		existing.silenced = false
+		existing.Labels = alertLabels
+		moved := r.regroup(g, alertKey)
+		if moved != nil {
+			g = moved
+		}
+		if existing.AppStatus == transition.Status {
+			// Still failing after maintenance: the alert continues, and is sent now if it never was
+			if moved == nil && (!silenced || existing.notified) {
+				return
+			}
+			break
+		}
+		existing.PreviousStatus = existing.AppStatus
+		existing.AppStatus = transition.Status
+
+	case firing:
+		if existing != nil {
+			// A pending resolve is replaced by the new firing alert
+			r.removeAlert(g, alertKey)
+		}
+		g = r.groupFor(alertLabels)
+		g.alerts[alertKey] = &Alert{
+			Status:         StatusFiring,
+			App:            transition.App,
+			Source:         transition.Source,
+			Location:       transition.Location,
+			OriginURL:      transition.OriginURL,
+			AppStatus:      transition.Status,
+			PreviousStatus: transition.PreviousStatus,
+			Labels:         alertLabels,
+			StartsAt:       transition.Timestamp,
+		}
+		r.alertGroup[alertKey] = g.key
+
+	case existing != nil && existing.Status == StatusFiring:
+		// Alerts that recover before they were sent, or without resolve notifications, are dropped
+		if !r.sendResolved || !existing.notified {
+			r.removeAlert(g, alertKey)
+			return
+		}
+		endsAt := transition.Timestamp
+		existing.silenced = false
+		existing.Status = StatusResolved
+		existing.PreviousStatus = existing.AppStatus
+		existing.AppStatus = transition.Status
+		existing.EndsAt = &endsAt
+
+	default:
+		return
+	}
+
+	n.schedule(r, g, r.groupWait)
+}
+
+// groupFor returns the group for the given alert labels, creating it when needed
+func (r *route) groupFor(alertLabels map[string]string) *group {
+	groupLabels := make(map[string]string, len(r.groupBy))
+	for _, name := range r.groupBy {
+		groupLabels[name] = alertLabels[name]
+	}
+	key := r.groupKey(alertLabels)
+
+	g, ok := r.groups[key]
+	if !ok {
+		g = &group{key: key, labels: groupLabels, alerts: make(map[string]*Alert)}
+		r.groups[key] = g
+	}
+	return g
+}
+
+// groupKey returns the key of the group for the given alert labels
+func (r *route) groupKey(alertLabels map[string]string) string {
+	parts := make([]string, 0, len(r.groupBy))
+	for _, name := range r.groupBy {
+		parts = append(parts, name+"="+alertLabels[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// regroup moves an alert whose group_by labels changed to the group of its current labels.
+// It returns the new group, or nil when the alert stays in its group.
+func (r *route) regroup(g *group, alertKey string) *group {
+	alert := g.alerts[alertKey]
+	if r.groupKey(alert.Labels) == g.key {
+		return nil
+	}
+	r.removeAlert(g, alertKey)
+	next := r.groupFor(alert.Labels)
+	next.alerts[alertKey] = alert
+	r.alertGroup[alertKey] = next.key
+	return next
+}
+
+// removeAlert drops an alert and deletes its group once empty
+func (r *route) removeAlert(g *group, alertKey string) {
+	delete(g.alerts, alertKey)
+	if r.alertGroup[alertKey] == g.key {
+		delete(r.alertGroup, alertKey)
+	}
+	if len(g.alerts) == 0 {
+		if g.timer != nil {
+			g.timer.Stop()
+		}
+		delete(r.groups, g.key)
+	}
+}
+
+// schedule flushes the group after the delay unless a flush is already due earlier
+func (n *Notifier) schedule(r *route, g *group, delay time.Duration) {
+	due := time.Now().Add(delay)
+	if g.timer != nil && !g.due.After(due) {
+		return
+	}
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+
+	g.generation++
+	generation := g.generation
+	g.due = due
+	g.timer = time.AfterFunc(delay, func() {
+		n.flush(r, g, generation)
+	})
+}
+
+// flush sends the current alerts of a group and schedules the repeat notification
+func (n *Notifier) flush(r *route, g *group, generation int) {
+	n.mu.Lock()
+	if n.stopped || g.generation != generation || r.groups[g.key] != g {
+		n.mu.Unlock()
+		return
+	}
+	g.timer = nil
+
+	notification := Notification{
+		Receiver:    r.receiver.Name(),
+		Status:      StatusResolved,
+		GroupLabels: g.labels,
+		ExternalURL: n.externalURL,
+	}
+	for alertKey, alert := range g.alerts {
+		if alert.silenced {
+			continue
+		}
+		alert.notified = true
+		notification.Alerts = append(notification.Alerts, *alert)
+		if alert.Status == StatusFiring {
+			notification.Status = StatusFiring
+		} else {
+			r.removeAlert(g, alertKey)
+		}
+	}
+	sort.Slice(notification.Alerts, func(i, j int) bool {
+		if notification.Alerts[i].App != notification.Alerts[j].App {
+			return notification.Alerts[i].App < notification.Alerts[j].App
+		}
+		return notification.Alerts[i].Source < notification.Alerts[j].Source
+	})
+
+	// Remind about alerts that are still firing
+	if len(g.alerts) > 0 {
+		n.schedule(r, g, r.repeatInterval)
+	}
+	n.mu.Unlock()
+
+	if len(notification.Alerts) == 0 {
+		return
+	}
+
+	if err := r.receiver.Send(context.Background(), notification); err != nil {
+		logging.Logger.WithError(err).WithFields(map[string]interface{}{
+			"receiver": notification.Receiver,
+			"group":    g.key,
+			"alerts":   len(notification.Alerts),
+		}).Error("Failed to send notification")
+		return
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"receiver": notification.Receiver,
+		"group":    g.key,
+		"status":   notification.Status,
+		"alerts":   len(notification.Alerts),
+	}).Info("Notification sent")
+}
+
+// Stop cancels pending notifications; transitions received afterwards are ignored
+func (n *Notifier) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.stopped = true
+	for _, r := range n.routes {
+		for _, g := range r.groups {
+			if g.timer != nil {
+				g.timer.Stop()
+			}
+		}
+	}
+}
+
+// alertLabels returns the app labels with the system labels, which take precedence as in the metrics
+func alertLabels(transition history.Transition) map[string]string {
+	result := labels.LabelsSliceToMap(transition.Labels)
+	if result == nil {
+		result = make(map[string]string)
+	}
+	result["name"] = transition.App
+	result["location"] = transition.Location
+	result["source"] = transition.Source
+	return result
+}
+
+// postJSON sends a request body to a receiver URL and treats non-2xx responses as errors
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// newClient returns an HTTP client using the receiver timeout (default 10s)
+func newClient(cfg config.NotificationReceiver) (*http.Client, error) {
+	timeout, err := parseDuration(cfg.Timeout, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	return &http.Client{Timeout: timeout}, nil
+}
@@ -0,0 +1,395 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"site-availability/config"
+	"site-availability/history"
+	"site-availability/labels"
+	"site-availability/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+}
+
+// newCaptureServer returns a webhook server that forwards received notifications to a channel
+func newCaptureServer(t *testing.T) (*httptest.Server, chan Notification) {
+	received := make(chan Notification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- notification
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// newTestNotifier creates a notifier with a single webhook route
+func newTestNotifier(t *testing.T, route config.NotificationRoute) (*Notifier, chan Notification) {
+	server, received := newCaptureServer(t)
+	route.Receiver = "capture"
+	if route.GroupWait == "" {
+		route.GroupWait = "20ms"
+	}
+
+	notifier, err := New(config.Notifications{
+		Receivers: []config.NotificationReceiver{{Name: "capture", Type: "webhook", URL: server.URL}},
+		Routes:    []config.NotificationRoute{route},
+	}, "https://status.example.com")
+	require.NoError(t, err)
+	t.Cleanup(notifier.Stop)
+	return notifier, received
+}
+
+func transition(app, location, status, previous string, appLabels ...labels.Label) history.Transition {
+	return history.Transition{
+		Timestamp:      time.Now(),
+		App:            app,
+		Source:         "prom",
+		OriginURL:      "https://status.example.com",
+		Location:       location,
+		Status:         status,
+		PreviousStatus: previous,
+		Labels:         appLabels,
+	}
+}
+
+// expectNotification waits for the next notification
+func expectNotification(t *testing.T, received chan Notification) Notification {
+	select {
+	case notification := <-received:
+		return notification
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a notification")
+		return Notification{}
+	}
+}
+
+// expectNoNotification fails if a notification arrives within the wait time
+func expectNoNotification(t *testing.T, received chan Notification, wait time.Duration) {
+	select {
+	case notification := <-received:
+		t.Fatalf("unexpected notification: %+v", notification)
+	case <-time.After(wait):
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("unknown receiver", func(t *testing.T) {
+		_, err := New(config.Notifications{Routes: []config.NotificationRoute{{Receiver: "missing"}}}, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown receiver")
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := New(config.Notifications{
+			Receivers: []config.NotificationReceiver{{Name: "hook", Type: "webhook", URL: "http://example.com", Template: "{{ .Status"}},
+		}, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid template")
+	})
+
+	t.Run("invalid matcher", func(t *testing.T) {
+		_, err := New(config.Notifications{
+			Receivers: []config.NotificationReceiver{{Name: "hook", Type: "webhook", URL: "http://example.com"}},
+			Routes:    []config.NotificationRoute{{Receiver: "hook", Matchers: []string{"env"}}},
+		}, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid matcher")
+	})
+}
+
+func TestNotifierGroupsAlerts(t *testing.T) {
+	notifier, received := newTestNotifier(t, config.NotificationRoute{GroupBy: []string{"location"}})
+
+	notifier.HandleTransitions([]history.Transition{
+		transition("payments", "eu-west", "down", "up"),
+		transition("checkout", "eu-west", "down", "up"),
+	})
+	notifier.HandleTransitions([]history.Transition{transition("search", "us-east", "down", "up")})
+
+	first := expectNotification(t, received)
+	second := expectNotification(t, received)
+	if first.GroupLabels["location"] != "eu-west" {
+		first, second = second, first
+	}
+
+	assert.Equal(t, "capture", first.Receiver)
+	assert.Equal(t, StatusFiring, first.Status)
+	assert.Equal(t, map[string]string{"location": "eu-west"}, first.GroupLabels)
+	assert.Equal(t, "https://status.example.com", first.ExternalURL)
+	require.Len(t, first.Alerts, 2)
+	assert.Equal(t, "checkout", first.Alerts[0].App)
+	assert.Equal(t, "payments", first.Alerts[1].App)
+	assert.Equal(t, "down", first.Alerts[1].AppStatus)
+	assert.Equal(t, "up", first.Alerts[1].PreviousStatus)
+	assert.Equal(t, "eu-west", first.Alerts[1].Labels["location"])
+
+	require.Len(t, second.Alerts, 1)
+	assert.Equal(t, "search", second.Alerts[0].App)
+}
+
+func TestNotifierResolves(t *testing.T) {
+	t.Run("sends resolve notification", func(t *testing.T) {
+		notifier, received := newTestNotifier(t, config.NotificationRoute{})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		assert.Equal(t, StatusFiring, expectNotification(t, received).Status)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "down")})
+		resolved := expectNotification(t, received)
+		assert.Equal(t, StatusResolved, resolved.Status)
+		require.Len(t, resolved.Alerts, 1)
+		assert.Equal(t, StatusResolved, resolved.Alerts[0].Status)
+		assert.Equal(t, "up", resolved.Alerts[0].AppStatus)
+		assert.Equal(t, "down", resolved.Alerts[0].PreviousStatus)
+		assert.NotNil(t, resolved.Alerts[0].EndsAt)
+
+		// Resolved alerts are forgotten once sent
+		expectNoNotification(t, received, 100*time.Millisecond)
+	})
+
+	t.Run("send_resolved disabled", func(t *testing.T) {
+		sendResolved := false
+		notifier, received := newTestNotifier(t, config.NotificationRoute{SendResolved: &sendResolved})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		expectNotification(t, received)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "down")})
+		expectNoNotification(t, received, 100*time.Millisecond)
+	})
+
+	t.Run("recovery within group wait sends nothing", func(t *testing.T) {
+		notifier, received := newTestNotifier(t, config.NotificationRoute{GroupWait: "50ms"})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "down")})
+
+		expectNoNotification(t, received, 150*time.Millisecond)
+	})
+}
+
+func TestNotifierRouting(t *testing.T) {
+	notifier, received := newTestNotifier(t, config.NotificationRoute{
+		Matchers: []string{`env="production"`, `name!~"test-.*"`},
+		Statuses: []string{"down", "unavailable"},
+	})
+
+	production := labels.Label{Key: "env", Value: "production"}
+	notifier.HandleTransitions([]history.Transition{
+		transition("dev-app", "eu-west", "down", "up", labels.Label{Key: "env", Value: "dev"}),
+		transition("test-canary", "eu-west", "down", "up", production),
+		transition("degraded-app", "eu-west", "degraded", "up", production),
+		transition("new-app", "eu-west", "up", "", production),
+		transition("payments", "eu-west", "unavailable", "up", production),
+	})
+
+	notification := expectNotification(t, received)
+	require.Len(t, notification.Alerts, 1)
+	assert.Equal(t, "payments", notification.Alerts[0].App)
+	assert.Equal(t, "unavailable", notification.Alerts[0].AppStatus)
+	expectNoNotification(t, received, 100*time.Millisecond)
+}
+
+func TestNotifierRepeatInterval(t *testing.T) {
+	notifier, received := newTestNotifier(t, config.NotificationRoute{RepeatInterval: "50ms"})
+
+	notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+	first := expectNotification(t, received)
+	repeated := expectNotification(t, received)
+
+	assert.Equal(t, StatusFiring, repeated.Status)
+	require.Len(t, repeated.Alerts, 1)
+	assert.Equal(t, first.Alerts[0].StartsAt, repeated.Alerts[0].StartsAt)
+}
+
+func TestNotifierStatusChangeWhileFiring(t *testing.T) {
+	notifier, received := newTestNotifier(t, config.NotificationRoute{Statuses: []string{"down", "unavailable"}})
+
+	notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+	expectNotification(t, received)
+
+	notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "unavailable", "down")})
+	notification := expectNotification(t, received)
+	assert.Equal(t, StatusFiring, notification.Status)
+	assert.Equal(t, "unavailable", notification.Alerts[0].AppStatus)
+	assert.Equal(t, "down", notification.Alerts[0].PreviousStatus)
+}
+
+func TestNotifierRegroupsAlerts(t *testing.T) {
+	notifier, received := newTestNotifier(t, config.NotificationRoute{
+		GroupBy:  []string{"team"},
+		Statuses: []string{"down", "unavailable"},
+	})
+
+	notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up", labels.Label{Key: "team", Value: "checkout"})})
+	assert.Equal(t, map[string]string{"team": "checkout"}, expectNotification(t, received).GroupLabels)
+
+	notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "unavailable", "down", labels.Label{Key: "team", Value: "billing"})})
+	notification := expectNotification(t, received)
+	assert.Equal(t, map[string]string{"team": "billing"}, notification.GroupLabels)
+	require.Len(t, notification.Alerts, 1)
+	assert.Equal(t, "billing", notification.Alerts[0].Labels["team"])
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	r := notifier.routes[0]
+	require.Len(t, r.groups, 1, "the empty group is removed")
+	assert.Contains(t, r.groups, "team=billing")
+}
+
+func TestNotifierMaintenance(t *testing.T) {
+	t.Run("silences a firing alert without resolving it", func(t *testing.T) {
+		notifier, received := newTestNotifier(t, config.NotificationRoute{})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		firing := expectNotification(t, received)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "maintenance", "down")})
+		expectNoNotification(t, received, 100*time.Millisecond)
+
+		// Still down when the window ends: the alert that was sent keeps firing without a new notification
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "maintenance")})
+		expectNoNotification(t, received, 100*time.Millisecond)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "down")})
+		resolved := expectNotification(t, received)
+		require.Len(t, resolved.Alerts, 1)
+		assert.Equal(t, StatusResolved, resolved.Alerts[0].Status)
+		assert.Equal(t, firing.Alerts[0].StartsAt, resolved.Alerts[0].StartsAt)
+	})
+
+	t.Run("holds back repeat notifications", func(t *testing.T) {
+		notifier, received := newTestNotifier(t, config.NotificationRoute{RepeatInterval: "100ms"})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		expectNotification(t, received)
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "maintenance", "down")})
+		expectNoNotification(t, received, 250*time.Millisecond)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "maintenance")})
+		assert.Equal(t, StatusFiring, expectNotification(t, received).Status)
+	})
+
+	t.Run("sends an unsent alert when the window ends", func(t *testing.T) {
+		notifier, received := newTestNotifier(t, config.NotificationRoute{GroupWait: "50ms"})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "maintenance", "down")})
+		expectNoNotification(t, received, 150*time.Millisecond)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "maintenance")})
+		notification := expectNotification(t, received)
+		assert.Equal(t, StatusFiring, notification.Status)
+	})
+
+	t.Run("resolves an app that recovered during maintenance", func(t *testing.T) {
+		notifier, received := newTestNotifier(t, config.NotificationRoute{})
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		expectNotification(t, received)
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "maintenance", "down")})
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "maintenance")})
+
+		resolved := expectNotification(t, received)
+		assert.Equal(t, StatusResolved, resolved.Status)
+		assert.Equal(t, "down", resolved.Alerts[0].PreviousStatus)
+	})
+}
+
+func TestNotifierReconfigure(t *testing.T) {
+	// reconfigured applies the routes to a notifier through a new notifier for the capture server
+	reconfigured := func(t *testing.T, notifier *Notifier, url string, routes ...config.NotificationRoute) {
+		next, err := New(config.Notifications{
+			Receivers: []config.NotificationReceiver{
+				{Name: "capture", Type: "webhook", URL: url},
+				{Name: "other", Type: "webhook", URL: url},
+			},
+			Routes: routes,
+		}, "https://status.example.com")
+		require.NoError(t, err)
+		notifier.Reconfigure(next)
+	}
+
+	t.Run("keeps firing alerts", func(t *testing.T) {
+		server, received := newCaptureServer(t)
+		notifier, err := New(config.Notifications{
+			Receivers: []config.NotificationReceiver{{Name: "capture", Type: "webhook", URL: server.URL}},
+			Routes:    []config.NotificationRoute{{Receiver: "capture", GroupWait: "20ms"}},
+		}, "https://status.example.com")
+		require.NoError(t, err)
+		t.Cleanup(notifier.Stop)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		firing := expectNotification(t, received)
+
+		reconfigured(t, notifier, server.URL, config.NotificationRoute{Receiver: "capture", GroupWait: "30ms", GroupBy: []string{"location"}})
+		expectNoNotification(t, received, 100*time.Millisecond)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "down")})
+		resolved := expectNotification(t, received)
+		assert.Equal(t, StatusResolved, resolved.Status)
+		assert.Equal(t, map[string]string{"location": "eu-west"}, resolved.GroupLabels)
+		assert.Equal(t, firing.Alerts[0].StartsAt, resolved.Alerts[0].StartsAt)
+	})
+
+	t.Run("drops alerts the new routes don't select", func(t *testing.T) {
+		server, received := newCaptureServer(t)
+		notifier, err := New(config.Notifications{
+			Receivers: []config.NotificationReceiver{{Name: "capture", Type: "webhook", URL: server.URL}},
+			Routes:    []config.NotificationRoute{{Receiver: "capture", GroupWait: "20ms"}},
+		}, "https://status.example.com")
+		require.NoError(t, err)
+		t.Cleanup(notifier.Stop)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		expectNotification(t, received)
+
+		reconfigured(t, notifier, server.URL, config.NotificationRoute{Receiver: "capture", GroupWait: "20ms", Matchers: []string{`location="us-east"`}})
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "up", "down")})
+		expectNoNotification(t, received, 100*time.Millisecond)
+	})
+
+	t.Run("sends firing alerts to a new receiver", func(t *testing.T) {
+		server, received := newCaptureServer(t)
+		notifier, err := New(config.Notifications{
+			Receivers: []config.NotificationReceiver{{Name: "capture", Type: "webhook", URL: server.URL}},
+			Routes:    []config.NotificationRoute{{Receiver: "capture", GroupWait: "20ms"}},
+		}, "https://status.example.com")
+		require.NoError(t, err)
+		t.Cleanup(notifier.Stop)
+
+		notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+		expectNotification(t, received)
+
+		reconfigured(t, notifier, server.URL, config.NotificationRoute{Receiver: "other", GroupWait: "20ms"})
+		notification := expectNotification(t, received)
+		assert.Equal(t, "other", notification.Receiver)
+		assert.Equal(t, StatusFiring, notification.Status)
+	})
+}
+
+func TestNotifierStop(t *testing.T) {
+	notifier, received := newTestNotifier(t, config.NotificationRoute{GroupWait: "50ms"})
+
+	notifier.HandleTransitions([]history.Transition{transition("payments", "eu-west", "down", "up")})
+	notifier.Stop()
+	notifier.HandleTransitions([]history.Transition{transition("checkout", "eu-west", "down", "up")})
+
+	expectNoNotification(t, received, 150*time.Millisecond)
+}
@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"site-availability/config"
+)
+
+// slackColors match the status colors of the map
+var slackColors = map[string]string{
+	"down":         "#EF4444",
+	"unavailable":  "#F59E0B",
+	"degraded":     "#F97316",
+	StatusResolved: "#10B981",
+}
+
+// SlackReceiver posts notifications to Slack-compatible incoming webhooks
+type SlackReceiver struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color string `json:"color"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// NewSlackReceiver creates a receiver for an incoming webhook URL
+func NewSlackReceiver(cfg config.NotificationReceiver) (*SlackReceiver, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &SlackReceiver{name: cfg.Name, url: cfg.URL, headers: cfg.Headers, client: client}, nil
+}
+
+// Name returns the receiver name
+func (s *SlackReceiver) Name() string {
+	return s.name
+}
+
+// Send posts one message with an attachment per alert
+func (s *SlackReceiver) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(slackMessageFor(notification))
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return postJSON(ctx, s.client, s.url, s.headers, body)
+}
+
+// slackMessageFor builds the message, e.g. "[FIRING:2] location=eu-west" followed by the alerts
+func slackMessageFor(notification Notification) slackMessage {
+	title := fmt.Sprintf("[%s:%d]", strings.ToUpper(notification.Status), len(notification.Firing()))
+	if notification.Status == StatusResolved {
+		title = fmt.Sprintf("[%s]", strings.ToUpper(StatusResolved))
+	}
+
+	groupKeys := make([]string, 0, len(notification.GroupLabels))
+	for key := range notification.GroupLabels {
+		groupKeys = append(groupKeys, key)
+	}
+	sort.Strings(groupKeys)
+	for _, key := range groupKeys {
+		title += fmt.Sprintf(" %s=%s", key, notification.GroupLabels[key])
+	}
+
+	message := slackMessage{Text: title}
+	for _, alert := range notification.Alerts {
+		color := slackColors[alert.AppStatus]
+		heading := fmt.Sprintf("%s is %s", alert.App, alert.AppStatus)
+		if alert.Status == StatusResolved {
+			color = slackColors[StatusResolved]
+			heading = fmt.Sprintf("%s recovered (%s)", alert.App, alert.AppStatus)
+		}
+
+		text := fmt.Sprintf("Location: %s\nSource: %s", alert.Location, alert.Source)
+		if alert.PreviousStatus != "" {
+			text += fmt.Sprintf("\nPrevious status: %s", alert.PreviousStatus)
+		}
+		if notification.ExternalURL != "" {
+			text += fmt.Sprintf("\n<%s|Open map>", notification.ExternalURL)
+		}
+
+		message.Attachments = append(message.Attachments, slackAttachment{Color: color, Title: heading, Text: text})
+	}
+	return message
+}
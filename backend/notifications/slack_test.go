@@ -0,0 +1,54 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"site-availability/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackMessageFor(t *testing.T) {
+	t.Run("firing", func(t *testing.T) {
+		message := slackMessageFor(testNotification())
+		assert.Equal(t, "[FIRING:1] location=eu-west", message.Text)
+		require.Len(t, message.Attachments, 1)
+		assert.Equal(t, "payments is down", message.Attachments[0].Title)
+		assert.Equal(t, slackColors["down"], message.Attachments[0].Color)
+		assert.Contains(t, message.Attachments[0].Text, "Previous status: up")
+		assert.Contains(t, message.Attachments[0].Text, "<https://status.example.com|Open map>")
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		notification := testNotification()
+		notification.Status = StatusResolved
+		notification.Alerts[0].Status = StatusResolved
+		notification.Alerts[0].AppStatus = "up"
+
+		message := slackMessageFor(notification)
+		assert.Equal(t, "[RESOLVED] location=eu-west", message.Text)
+		assert.Equal(t, "payments recovered (up)", message.Attachments[0].Title)
+		assert.Equal(t, slackColors[StatusResolved], message.Attachments[0].Color)
+	})
+}
+
+func TestSlackReceiver(t *testing.T) {
+	var message slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+	}))
+	defer server.Close()
+
+	receiver, err := NewSlackReceiver(config.NotificationReceiver{Name: "chat", URL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "chat", receiver.Name())
+
+	require.NoError(t, receiver.Send(context.Background(), testNotification()))
+	assert.Equal(t, "[FIRING:1] location=eu-west", message.Text)
+	assert.Len(t, message.Attachments, 1)
+}
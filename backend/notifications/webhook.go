@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"site-availability/config"
+)
+
+// WebhookReceiver posts notifications as JSON, or as the output of a configured template
+type WebhookReceiver struct {
+	name     string
+	url      string
+	headers  map[string]string
+	template *template.Template
+	client   *http.Client
+}
+
+// templateFuncs are available in webhook templates
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// NewWebhookReceiver creates a webhook receiver, parsing its body template when set
+func NewWebhookReceiver(cfg config.NotificationReceiver) (*WebhookReceiver, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver := &WebhookReceiver{name: cfg.Name, url: cfg.URL, headers: cfg.Headers, client: client}
+	if cfg.Template != "" {
+		tmpl, err := template.New(cfg.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		receiver.template = tmpl
+	}
+	return receiver, nil
+}
+
+// Name returns the receiver name
+func (w *WebhookReceiver) Name() string {
+	return w.name
+}
+
+// Send posts the notification to the webhook URL
+func (w *WebhookReceiver) Send(ctx context.Context, notification Notification) error {
+	body, err := w.render(notification)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, w.client, w.url, w.headers, body)
+}
+
+// render returns the request body for a notification
+func (w *WebhookReceiver) render(notification Notification) ([]byte, error) {
+	if w.template == nil {
+		body, err := json.Marshal(notification)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode notification: %w", err)
+		}
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, notification); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
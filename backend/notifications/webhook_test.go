@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"site-availability/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNotification returns a firing notification with a single alert
+func testNotification() Notification {
+	return Notification{
+		Receiver:    "hook",
+		Status:      StatusFiring,
+		GroupLabels: map[string]string{"location": "eu-west"},
+		ExternalURL: "https://status.example.com",
+		Alerts: []Alert{{
+			Status:         StatusFiring,
+			App:            "payments",
+			Source:         "prom",
+			Location:       "eu-west",
+			AppStatus:      "down",
+			PreviousStatus: "up",
+			Labels:         map[string]string{"name": "payments", "location": "eu-west", "source": "prom", "team": "checkout"},
+			StartsAt:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}},
+	}
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	var body string
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		headers = r.Header
+	}))
+	defer server.Close()
+
+	t.Run("default JSON body", func(t *testing.T) {
+		receiver, err := NewWebhookReceiver(config.NotificationReceiver{Name: "hook", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}})
+		require.NoError(t, err)
+		assert.Equal(t, "hook", receiver.Name())
+
+		require.NoError(t, receiver.Send(context.Background(), testNotification()))
+		assert.Contains(t, body, `"receiver":"hook"`)
+		assert.Contains(t, body, `"app_status":"down"`)
+		assert.Equal(t, "application/json", headers.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", headers.Get("Authorization"))
+	})
+
+	t.Run("templated body", func(t *testing.T) {
+		receiver, err := NewWebhookReceiver(config.NotificationReceiver{
+			Name:     "hook",
+			URL:      server.URL,
+			Headers:  map[string]string{"Content-Type": "text/plain"},
+			Template: `{{ .Status }}:{{ range .Alerts }} {{ .App }}={{ .AppStatus }} team={{ index .Labels "team" }}{{ end }} {{ json .GroupLabels }}`,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, receiver.Send(context.Background(), testNotification()))
+		assert.Equal(t, `firing: payments=down team=checkout {"location":"eu-west"}`, body)
+		assert.Equal(t, "text/plain", headers.Get("Content-Type"))
+	})
+
+	t.Run("template execution error", func(t *testing.T) {
+		receiver, err := NewWebhookReceiver(config.NotificationReceiver{Name: "hook", URL: server.URL, Template: `{{ .Missing }}`})
+		require.NoError(t, err)
+
+		err = receiver.Send(context.Background(), testNotification())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to render template")
+	})
+}
+
+func TestWebhookReceiverErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("upstream unavailable"))
+	}))
+	defer server.Close()
+
+	receiver, err := NewWebhookReceiver(config.NotificationReceiver{Name: "hook", URL: server.URL})
+	require.NoError(t, err)
+
+	err = receiver.Send(context.Background(), testNotification())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 502: upstream unavailable")
+
+	_, err = NewWebhookReceiver(config.NotificationReceiver{Name: "hook", URL: server.URL, Timeout: "soon"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timeout")
+}
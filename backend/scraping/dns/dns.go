@@ -0,0 +1,309 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/labels"
+	"site-availability/logging"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// supportedRecordTypes lists the DNS record types that can be checked
+var supportedRecordTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "MX": true, "TXT": true, "NS": true}
+
+// DNSConfig represents the configuration for DNS sources
+type DNSConfig struct {
+	Resolver string   `yaml:"resolver"` // Optional: default resolver for all apps (host or host:port)
+	Apps     []DNSApp `yaml:"apps"`
+}
+
+// DNSApp represents an app configuration for DNS monitoring
+type DNSApp struct {
+	Name       string `yaml:"name"`
+	Location   string `yaml:"location"`
+	Host       string `yaml:"host"`        // Name to resolve
+	RecordType string `yaml:"record_type"` // A (default), AAAA, CNAME, MX, TXT or NS
+	Resolver   string `yaml:"resolver"`    // Optional: overrides the source resolver
+
+	// Values that must all be present in the answer (e.g., IPs, CNAME target, MX hosts)
+	Expected []string `yaml:"expected"`
+
+	Timeout string            `yaml:"timeout"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// DNSScraper implements the Scraper interface for DNS sources
+type DNSScraper struct {
+}
+
+func NewDNSScraper() *DNSScraper {
+	return &DNSScraper{}
+}
+
+// ValidateConfig validates the DNS-specific configuration
+func (d *DNSScraper) ValidateConfig(source config.Source) error {
+	dnsCfg, err := config.DecodeConfig[DNSConfig](source.Config, source.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(dnsCfg.Apps) == 0 {
+		return fmt.Errorf("dns source %s: at least one app is required", source.Name)
+	}
+
+	if dnsCfg.Resolver != "" {
+		if _, err := resolverAddress(dnsCfg.Resolver); err != nil {
+			return fmt.Errorf("dns source %s: %w", source.Name, err)
+		}
+	}
+
+	appNames := make(map[string]bool)
+	for _, app := range dnsCfg.Apps {
+		if app.Name == "" {
+			return fmt.Errorf("dns source %s: app name is required", source.Name)
+		}
+		if _, exists := appNames[app.Name]; exists {
+			return fmt.Errorf("dns source %s: duplicate app name %q", source.Name, app.Name)
+		}
+		appNames[app.Name] = true
+
+		if app.Location == "" {
+			return fmt.Errorf("dns source %s: app %s missing 'location'", source.Name, app.Name)
+		}
+		if app.Host == "" {
+			return fmt.Errorf("dns source %s: app %s missing 'host'", source.Name, app.Name)
+		}
+		if app.RecordType != "" && !supportedRecordTypes[strings.ToUpper(app.RecordType)] {
+			return fmt.Errorf("dns source %s: app %s unsupported record_type %q", source.Name, app.Name, app.RecordType)
+		}
+		if app.Resolver != "" {
+			if _, err := resolverAddress(app.Resolver); err != nil {
+				return fmt.Errorf("dns source %s: app %s %w", source.Name, app.Name, err)
+			}
+		}
+		if app.Timeout != "" {
+			if _, err := time.ParseDuration(app.Timeout); err != nil {
+				return fmt.Errorf("dns source %s: app %s invalid timeout %q: %w", source.Name, app.Name, app.Timeout, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolverAddress normalizes a resolver to host:port, defaulting to port 53
+func resolverAddress(resolver string) (string, error) {
+	if host, port, err := net.SplitHostPort(resolver); err == nil {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil || host == "" || strings.ContainsAny(host, "/ ") {
+			return "", fmt.Errorf("invalid resolver %q", resolver)
+		}
+		return resolver, nil
+	}
+	if ip := net.ParseIP(strings.Trim(resolver, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), "53"), nil
+	}
+	if strings.ContainsAny(resolver, ":/ ") {
+		return "", fmt.Errorf("invalid resolver %q", resolver)
+	}
+	return net.JoinHostPort(resolver, "53"), nil
+}
+
+// Scrape resolves every configured name and checks the answers
+func (d *DNSScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	dnsCfg, err := config.DecodeConfig[DNSConfig](source.Config, source.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]handlers.AppStatus, len(dnsCfg.Apps))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for i, app := range dnsCfg.Apps {
+		if app.Resolver == "" {
+			app.Resolver = dnsCfg.Resolver
+		}
+
+		sem <- struct{}{} // Acquire slot
+		wg.Add(1)
+		go func(i int, app DNSApp) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			status := "up"
+			start := time.Now()
+			err := d.check(ctx, app, timeout)
+			duration := time.Since(start)
+			if err != nil {
+				logging.Logger.WithFields(map[string]interface{}{
+					"app":         app.Name,
+					"source":      source.Name,
+					"host":        app.Host,
+					"record_type": app.RecordType,
+					"error":       err.Error(),
+				}).Warn("DNS check failed - marking as down")
+				status = "down"
+			}
+
+			var appLabels []labels.Label
+			for key, value := range app.Labels {
+				appLabels = append(appLabels, labels.Label{Key: key, Value: value})
+			}
+
+			results[i] = handlers.AppStatus{
+				Name:      app.Name,
+				Location:  app.Location,
+				Status:    status,
+				Source:    source.Name,
+				OriginURL: serverSettings.HostURL,
+				Labels:    appLabels,
+			}
+			results[i].RecordCheck(start, duration, err)
+		}(i, app)
+	}
+	wg.Wait()
+
+	// DNS scraper returns nil for locations since it only provides app statuses
+	return results, nil, nil
+}
+
+// newResolver returns a resolver that queries the given server, or the system resolver when empty
+func newResolver(resolver string) (*net.Resolver, error) {
+	if resolver == "" {
+		return net.DefaultResolver, nil
+	}
+
+	address, err := resolverAddress(resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}, nil
+}
+
+// check resolves the app host and verifies the expected values are present
+func (d *DNSScraper) check(ctx context.Context, app DNSApp, timeout time.Duration) error {
+	appTimeout := timeout
+	if app.Timeout != "" {
+		if parsedTimeout, err := time.ParseDuration(app.Timeout); err == nil {
+			appTimeout = parsedTimeout
+		}
+	}
+
+	resolver, err := newResolver(app.Resolver)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, appTimeout)
+	defer cancel()
+
+	answers, err := lookup(ctx, resolver, app.Host, strings.ToUpper(app.RecordType))
+	if err != nil {
+		return fmt.Errorf("lookup failed: %w", err)
+	}
+	if len(answers) == 0 {
+		return fmt.Errorf("no records found")
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"app":     app.Name,
+		"host":    app.Host,
+		"answers": answers,
+	}).Debug("DNS lookup succeeded")
+
+	answerSet := make(map[string]bool, len(answers))
+	for _, answer := range answers {
+		answerSet[normalizeValue(answer)] = true
+	}
+
+	var missing []string
+	for _, expected := range app.Expected {
+		if !answerSet[normalizeValue(expected)] {
+			missing = append(missing, expected)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("expected values %v not found in answer %v", missing, answers)
+	}
+
+	return nil
+}
+
+// lookup resolves a host for the given record type and returns the answers as strings
+func lookup(ctx context.Context, resolver *net.Resolver, host, recordType string) ([]string, error) {
+	var answers []string
+
+	switch recordType {
+	case "", "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		records, err := resolver.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			answers = append(answers, record.Host)
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, records...)
+	case "NS":
+		records, err := resolver.LookupNS(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			answers = append(answers, record.Host)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// normalizeValue makes answers comparable regardless of case, trailing dots and IP formatting
+func normalizeValue(value string) string {
+	value = strings.TrimSpace(value)
+	if ip := net.ParseIP(value); ip != nil {
+		return ip.String()
+	}
+	return strings.TrimSuffix(strings.ToLower(value), ".")
+}
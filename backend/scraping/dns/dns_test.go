@@ -0,0 +1,281 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"site-availability/config"
+	"site-availability/logging"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+}
+
+const (
+	typeA     = 1
+	typeNS    = 2
+	typeCNAME = 5
+	typeMX    = 15
+	typeTXT   = 16
+)
+
+// encodeName encodes a domain name in DNS wire format
+func encodeName(name string) []byte {
+	var encoded []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		encoded = append(encoded, byte(len(label)))
+		encoded = append(encoded, label...)
+	}
+	return append(encoded, 0)
+}
+
+// testZone answers queries with fixed records, keyed by lowercase FQDN and record type
+var testZone = map[string]map[uint16][][]byte{
+	"app.example.test.": {
+		typeA: {{10, 0, 0, 1}, {10, 0, 0, 2}},
+		typeTXT: {
+			append([]byte{byte(len("v=spf1 -all"))}, "v=spf1 -all"...),
+		},
+	},
+	"www.example.test.": {
+		typeCNAME: {encodeName("app.example.test")},
+	},
+	"example.test.": {
+		typeMX: {append([]byte{0, 10}, encodeName("mail.example.test")...)},
+		typeNS: {encodeName("ns1.example.test")},
+	},
+}
+
+// startDNSServer runs a minimal UDP DNS server serving testZone and returns its address
+func startDNSServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if response := answerQuery(buf[:n]); response != nil {
+				_, _ = conn.WriteTo(response, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// answerQuery builds the response for a single-question query
+func answerQuery(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+
+	// Parse the question name
+	offset := 12
+	var labels []string
+	for offset < len(query) && query[offset] != 0 {
+		length := int(query[offset])
+		if offset+1+length > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[offset+1:offset+1+length]))
+		offset += 1 + length
+	}
+	offset++ // Terminating zero
+	if offset+4 > len(query) {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[offset:])
+	offset += 4
+	name := strings.ToLower(strings.Join(labels, ".")) + "."
+
+	records, found := testZone[name]
+	answers := records[qtype]
+
+	header := make([]byte, 12)
+	copy(header[0:2], query[0:2])                  // ID
+	binary.BigEndian.PutUint16(header[2:], 0x8580) // QR, AA, RD, RA
+	if !found {
+		binary.BigEndian.PutUint16(header[2:], 0x8583) // NXDOMAIN
+	}
+	binary.BigEndian.PutUint16(header[4:], 1)
+	binary.BigEndian.PutUint16(header[6:], uint16(len(answers)))
+
+	response := append(header, query[12:offset]...)
+	for _, rdata := range answers {
+		response = append(response, 0xC0, 0x0C) // Pointer to the question name
+		response = binary.BigEndian.AppendUint16(response, qtype)
+		response = binary.BigEndian.AppendUint16(response, 1) // IN
+		response = binary.BigEndian.AppendUint32(response, 60)
+		response = binary.BigEndian.AppendUint16(response, uint16(len(rdata)))
+		response = append(response, rdata...)
+	}
+	return response
+}
+
+func TestResolverAddress(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		expectErr bool
+	}{
+		{input: "1.1.1.1", expected: "1.1.1.1:53"},
+		{input: "10.0.0.53:5353", expected: "10.0.0.53:5353"},
+		{input: "2001:db8::1", expected: "[2001:db8::1]:53"},
+		{input: "[2001:db8::1]:5353", expected: "[2001:db8::1]:5353"},
+		{input: "dns.internal", expected: "dns.internal:53"},
+		{input: "udp://dns.internal", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			address, err := resolverAddress(tt.input)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, address)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	scraper := NewDNSScraper()
+
+	tests := []struct {
+		name     string
+		resolver string
+		apps     []map[string]interface{}
+		errMsg   string
+	}{
+		{
+			name:     "valid config",
+			resolver: "1.1.1.1",
+			apps: []map[string]interface{}{
+				{"name": "site", "location": "eu", "host": "example.com", "record_type": "mx", "expected": []string{"mail.example.com"}},
+			},
+		},
+		{name: "no apps", apps: []map[string]interface{}{}, errMsg: "at least one app is required"},
+		{
+			name:   "missing host",
+			apps:   []map[string]interface{}{{"name": "site", "location": "eu"}},
+			errMsg: "missing 'host'",
+		},
+		{
+			name:   "unsupported record type",
+			apps:   []map[string]interface{}{{"name": "site", "location": "eu", "host": "example.com", "record_type": "SRV"}},
+			errMsg: "unsupported record_type",
+		},
+		{
+			name:     "invalid source resolver",
+			resolver: "udp://1.1.1.1",
+			apps:     []map[string]interface{}{{"name": "site", "location": "eu", "host": "example.com"}},
+			errMsg:   "invalid resolver",
+		},
+		{
+			name:   "invalid app resolver",
+			apps:   []map[string]interface{}{{"name": "site", "location": "eu", "host": "example.com", "resolver": "bad resolver"}},
+			errMsg: "invalid resolver",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := map[string]interface{}{"apps": tt.apps}
+			if tt.resolver != "" {
+				cfg["resolver"] = tt.resolver
+			}
+			err := scraper.ValidateConfig(config.Source{Name: "test-dns", Type: "dns", Config: cfg})
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	assert.Equal(t, "mail.example.com", normalizeValue(" Mail.Example.com. "))
+	assert.Equal(t, "2001:db8::1", normalizeValue("2001:0db8:0000::0001"))
+	assert.Equal(t, "10.0.0.1", normalizeValue("10.0.0.1"))
+}
+
+func TestCheck(t *testing.T) {
+	scraper := NewDNSScraper()
+	resolver := startDNSServer(t)
+
+	tests := []struct {
+		name   string
+		app    DNSApp
+		errMsg string
+	}{
+		{name: "A record resolves", app: DNSApp{Host: "app.example.test."}},
+		{name: "A record expected", app: DNSApp{Host: "app.example.test.", RecordType: "A", Expected: []string{"10.0.0.2", "10.0.0.1"}}},
+		{name: "A record mismatch", app: DNSApp{Host: "app.example.test.", Expected: []string{"10.0.0.3"}}, errMsg: "expected values [10.0.0.3] not found"},
+		{name: "CNAME", app: DNSApp{Host: "www.example.test.", RecordType: "cname", Expected: []string{"APP.example.test"}}},
+		{name: "MX", app: DNSApp{Host: "example.test.", RecordType: "MX", Expected: []string{"mail.example.test."}}},
+		{name: "NS", app: DNSApp{Host: "example.test.", RecordType: "NS", Expected: []string{"ns1.example.test"}}},
+		{name: "TXT", app: DNSApp{Host: "app.example.test.", RecordType: "TXT", Expected: []string{"v=spf1 -all"}}},
+		{name: "NXDOMAIN", app: DNSApp{Host: "missing.example.test."}, errMsg: "lookup failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.app.Name = "site"
+			tt.app.Resolver = resolver
+			err := scraper.check(context.Background(), tt.app, 2*time.Second)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestScrape(t *testing.T) {
+	scraper := NewDNSScraper()
+	resolver := startDNSServer(t)
+
+	source := config.Source{
+		Name: "test-dns",
+		Type: "dns",
+		Config: map[string]interface{}{
+			"resolver": resolver,
+			"apps": []map[string]interface{}{
+				{"name": "resolves", "location": "eu", "host": "app.example.test.", "expected": []string{"10.0.0.1"}},
+				{"name": "wrong-ip", "location": "us", "host": "app.example.test.", "expected": []string{"192.0.2.1"}},
+			},
+		},
+	}
+	serverSettings := config.ServerSettings{HostURL: "http://localhost:8080"}
+
+	statuses, locations, err := scraper.Scrape(context.Background(), source, serverSettings, 2*time.Second, 2, nil)
+	require.NoError(t, err)
+	assert.Nil(t, locations)
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, "resolves", statuses[0].Name)
+	assert.Equal(t, "up", statuses[0].Status)
+	assert.Equal(t, "test-dns", statuses[0].Source)
+	assert.Equal(t, "wrong-ip", statuses[1].Name)
+	assert.Equal(t, "down", statuses[1].Status)
+}
@@ -0,0 +1,282 @@
+package exec_source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/labels"
+	"site-availability/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxOutputBytes limits how much of the command output is kept for label parsing and logging
+const maxOutputBytes = 64 * 1024
+
+// labelKeyPattern matches label keys accepted from command output
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ExecConfig represents the configuration for exec sources
+type ExecConfig struct {
+	Apps []ExecApp `yaml:"apps"`
+}
+
+// ExecApp represents an app configuration for command based monitoring
+type ExecApp struct {
+	Name     string `yaml:"name"`
+	Location string `yaml:"location"`
+
+	// Command to run, either an absolute path or a name looked up in PATH
+	Command    string            `yaml:"command"`
+	Args       []string          `yaml:"args"`
+	Env        map[string]string `yaml:"env"`         // Added to the server environment
+	WorkingDir string            `yaml:"working_dir"` // Optional: defaults to the server working directory
+
+	// Label keys read from key=value lines of stdout. Other keys are ignored, so a command can't set
+	// labels used by RBAC or notification routing unless they are listed.
+	ParseLabels []string `yaml:"parse_labels"`
+
+	Timeout string            `yaml:"timeout"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// ExecScraper implements the Scraper interface for exec sources
+type ExecScraper struct {
+}
+
+func NewExecScraper() *ExecScraper {
+	return &ExecScraper{}
+}
+
+// ValidateConfig validates the exec-specific configuration
+func (e *ExecScraper) ValidateConfig(source config.Source) error {
+	execCfg, err := config.DecodeConfig[ExecConfig](source.Config, source.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(execCfg.Apps) == 0 {
+		return fmt.Errorf("exec source %s: at least one app is required", source.Name)
+	}
+
+	appNames := make(map[string]bool)
+	for _, app := range execCfg.Apps {
+		if app.Name == "" {
+			return fmt.Errorf("exec source %s: app name is required", source.Name)
+		}
+		if _, exists := appNames[app.Name]; exists {
+			return fmt.Errorf("exec source %s: duplicate app name %q", source.Name, app.Name)
+		}
+		appNames[app.Name] = true
+
+		if app.Location == "" {
+			return fmt.Errorf("exec source %s: app %s missing 'location'", source.Name, app.Name)
+		}
+		if app.Command == "" {
+			return fmt.Errorf("exec source %s: app %s missing 'command'", source.Name, app.Name)
+		}
+		for _, key := range app.ParseLabels {
+			if !labelKeyPattern.MatchString(key) {
+				return fmt.Errorf("exec source %s: app %s invalid parse_labels key %q", source.Name, app.Name, key)
+			}
+		}
+		for key := range app.Env {
+			if key == "" || strings.ContainsAny(key, "=\x00") {
+				return fmt.Errorf("exec source %s: app %s invalid env name %q", source.Name, app.Name, key)
+			}
+		}
+		if app.Timeout != "" {
+			if _, err := time.ParseDuration(app.Timeout); err != nil {
+				return fmt.Errorf("exec source %s: app %s invalid timeout %q: %w", source.Name, app.Name, app.Timeout, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Scrape runs every configured command and maps the exit code to an app status
+func (e *ExecScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	execCfg, err := config.DecodeConfig[ExecConfig](source.Config, source.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]handlers.AppStatus, len(execCfg.Apps))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for i, app := range execCfg.Apps {
+		sem <- struct{}{} // Acquire slot
+		wg.Add(1)
+		go func(i int, app ExecApp) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			status, output, err := e.check(ctx, app, timeout)
+			duration := time.Since(start)
+			if err != nil {
+				logging.Logger.WithFields(map[string]interface{}{
+					"app":     app.Name,
+					"source":  source.Name,
+					"command": app.Command,
+					"status":  status,
+					"error":   err.Error(),
+				}).Warn("Exec check failed")
+			}
+
+			appLabels := make(map[string]string)
+			if len(app.ParseLabels) > 0 {
+				reported := parseLabels(output)
+				for _, key := range app.ParseLabels {
+					if value, ok := reported[key]; ok {
+						appLabels[key] = value
+					}
+				}
+			}
+			// Configured labels take precedence over labels reported by the command
+			for key, value := range app.Labels {
+				appLabels[key] = value
+			}
+
+			results[i] = handlers.AppStatus{
+				Name:      app.Name,
+				Location:  app.Location,
+				Status:    status,
+				Source:    source.Name,
+				OriginURL: serverSettings.HostURL,
+				Labels:    labels.LabelsMapToSlice(appLabels),
+			}
+			results[i].RecordCheck(start, duration, err)
+		}(i, app)
+	}
+	wg.Wait()
+
+	// Exec scraper returns nil for locations since it only provides app statuses
+	return results, nil, nil
+}
+
+// check runs the app command and returns the status derived from its exit code together with stdout.
+// Exit code 0 is up, 1 is down and anything else (including timeouts and start failures) is unavailable.
+func (e *ExecScraper) check(ctx context.Context, app ExecApp, timeout time.Duration) (string, string, error) {
+	appTimeout := timeout
+	if app.Timeout != "" {
+		if parsedTimeout, err := time.ParseDuration(app.Timeout); err == nil {
+			appTimeout = parsedTimeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, appTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, app.Command, app.Args...)
+	cmd.Dir = app.WorkingDir
+	cmd.Env = os.Environ()
+	for key, value := range app.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	// Don't wait forever for children that keep the output pipes open after a kill
+	cmd.WaitDelay = time.Second
+
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	output := stdout.String()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return "unavailable", output, fmt.Errorf("command timed out after %s", appTimeout)
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "unavailable", output, fmt.Errorf("failed to run command: %w", err)
+		}
+
+		code := exitErr.ExitCode()
+		status := "unavailable"
+		if code == 1 {
+			status = "down"
+		}
+		return status, output, fmt.Errorf("command exited with code %d: %s", code, firstLine(stderr.String(), output))
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"app":      app.Name,
+		"command":  app.Command,
+		"duration": time.Since(start).String(),
+	}).Debug("Exec check succeeded")
+
+	return "up", output, nil
+}
+
+// parseLabels extracts key=value lines from the command output.
+// Lines that are not valid label assignments are ignored.
+func parseLabels(output string) map[string]string {
+	result := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if !labelKeyPattern.MatchString(key) {
+			continue
+		}
+		result[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return result
+}
+
+// firstLine returns the first non-empty line of the given outputs, used to summarize failures
+func firstLine(outputs ...string) string {
+	for _, output := range outputs {
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				return line
+			}
+		}
+	}
+	return "no output"
+}
+
+// limitedBuffer keeps at most limit bytes and silently discards the rest
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,241 @@
+package exec_source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"site-availability/config"
+	"site-availability/labels"
+	"site-availability/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+}
+
+// shellApp returns an app that runs the given script with /bin/sh
+func shellApp(t *testing.T, script string) ExecApp {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec tests require /bin/sh")
+	}
+	return ExecApp{Name: "check", Location: "test-location", Command: "/bin/sh", Args: []string{"-c", script}}
+}
+
+func TestValidateConfig(t *testing.T) {
+	scraper := NewExecScraper()
+
+	tests := []struct {
+		name   string
+		apps   []map[string]interface{}
+		errMsg string
+	}{
+		{
+			name: "valid config",
+			apps: []map[string]interface{}{
+				{"name": "vendor", "location": "eu", "command": "check_vendor", "args": []string{"-H", "db"}, "env": map[string]string{"MODE": "strict"}, "timeout": "10s"},
+			},
+		},
+		{name: "no apps", apps: []map[string]interface{}{}, errMsg: "at least one app is required"},
+		{
+			name:   "missing name",
+			apps:   []map[string]interface{}{{"location": "eu", "command": "true"}},
+			errMsg: "app name is required",
+		},
+		{
+			name: "duplicate name",
+			apps: []map[string]interface{}{
+				{"name": "vendor", "location": "eu", "command": "true"},
+				{"name": "vendor", "location": "us", "command": "true"},
+			},
+			errMsg: "duplicate app name",
+		},
+		{
+			name:   "missing location",
+			apps:   []map[string]interface{}{{"name": "vendor", "command": "true"}},
+			errMsg: "missing 'location'",
+		},
+		{
+			name:   "missing command",
+			apps:   []map[string]interface{}{{"name": "vendor", "location": "eu"}},
+			errMsg: "missing 'command'",
+		},
+		{
+			name:   "invalid env name",
+			apps:   []map[string]interface{}{{"name": "vendor", "location": "eu", "command": "true", "env": map[string]string{"A=B": "c"}}},
+			errMsg: "invalid env name",
+		},
+		{
+			name:   "invalid timeout",
+			apps:   []map[string]interface{}{{"name": "vendor", "location": "eu", "command": "true", "timeout": "later"}},
+			errMsg: "invalid timeout",
+		},
+		{
+			name:   "invalid parse_labels key",
+			apps:   []map[string]interface{}{{"name": "vendor", "location": "eu", "command": "true", "parse_labels": []string{"bad key"}}},
+			errMsg: "invalid parse_labels key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scraper.ValidateConfig(config.Source{
+				Name:   "test-exec",
+				Type:   "exec",
+				Config: map[string]interface{}{"apps": tt.apps},
+			})
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	scraper := NewExecScraper()
+
+	tests := []struct {
+		name           string
+		script         string
+		expectedStatus string
+		expectErr      bool
+	}{
+		{name: "exit 0 is up", script: "echo OK; exit 0", expectedStatus: "up"},
+		{name: "exit 1 is down", script: "echo WARNING; exit 1", expectedStatus: "down", expectErr: true},
+		{name: "exit 2 is unavailable", script: "echo CRITICAL >&2; exit 2", expectedStatus: "unavailable", expectErr: true},
+		{name: "exit 3 is unavailable", script: "exit 3", expectedStatus: "unavailable", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, err := scraper.check(context.Background(), shellApp(t, tt.script), 5*time.Second)
+			assert.Equal(t, tt.expectedStatus, status)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("failure message includes stderr", func(t *testing.T) {
+		_, _, err := scraper.check(context.Background(), shellApp(t, "echo 'disk full' >&2; exit 1"), 5*time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "code 1: disk full")
+	})
+}
+
+func TestCheckCommandErrors(t *testing.T) {
+	scraper := NewExecScraper()
+
+	t.Run("command not found", func(t *testing.T) {
+		status, _, err := scraper.check(context.Background(), ExecApp{Name: "missing", Command: "/nonexistent/check"}, 5*time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to run command")
+		assert.Equal(t, "unavailable", status)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		app := shellApp(t, "sleep 5")
+		app.Timeout = "100ms"
+
+		start := time.Now()
+		status, _, err := scraper.check(context.Background(), app, 5*time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+		assert.Equal(t, "unavailable", status)
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+}
+
+func TestCheckEnvironmentAndWorkingDir(t *testing.T) {
+	scraper := NewExecScraper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "marker"), []byte("ready"), 0o600))
+
+	app := shellApp(t, `test "$CHECK_MODE" = strict && test -n "$PATH" && cat marker`)
+	app.Env = map[string]string{"CHECK_MODE": "strict"}
+	app.WorkingDir = dir
+
+	status, output, err := scraper.check(context.Background(), app, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "up", status)
+	assert.Equal(t, "ready", output)
+}
+
+func TestParseLabels(t *testing.T) {
+	output := "OK - all good\nversion=1.2.3\n  region = eu-west \nbad key=ignored\n=novalue\nmessage=\"quoted value\"\nperf|time=1s\n"
+
+	assert.Equal(t, map[string]string{
+		"version": "1.2.3",
+		"region":  "eu-west",
+		"message": "quoted value",
+	}, parseLabels(output))
+
+	assert.Empty(t, parseLabels(""))
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 5}
+	n, err := buf.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = buf.Write([]byte("defgh"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "abcde", buf.String())
+}
+
+func TestScrape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec tests require /bin/sh")
+	}
+
+	scraper := NewExecScraper()
+	source := config.Source{
+		Name: "test-exec",
+		Type: "exec",
+		Config: map[string]interface{}{
+			"apps": []map[string]interface{}{
+				{
+					"name":         "healthy",
+					"location":     "eu",
+					"command":      "/bin/sh",
+					"args":         []string{"-c", "echo version=2.0; echo tier=reported; echo team=admins"},
+					"parse_labels": []string{"version", "tier"},
+					"labels":       map[string]string{"tier": "configured"},
+				},
+				{"name": "degraded", "location": "us", "command": "/bin/sh", "args": []string{"-c", "echo version=1.0; exit 1"}},
+				{"name": "broken", "location": "us", "command": "/bin/sh", "args": []string{"-c", "exit 2"}},
+			},
+		},
+	}
+	serverSettings := config.ServerSettings{HostURL: "http://localhost:8080"}
+
+	statuses, locations, err := scraper.Scrape(context.Background(), source, serverSettings, 5*time.Second, 2, nil)
+	require.NoError(t, err)
+	assert.Nil(t, locations)
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, "healthy", statuses[0].Name)
+	assert.Equal(t, "up", statuses[0].Status)
+	assert.Equal(t, "test-exec", statuses[0].Source)
+	assert.Equal(t, "http://localhost:8080", statuses[0].OriginURL)
+	// Only listed labels are read, and configured labels take precedence
+	assert.Equal(t, map[string]string{"version": "2.0", "tier": "configured"}, labels.LabelsSliceToMap(statuses[0].Labels))
+
+	assert.Equal(t, "down", statuses[1].Status)
+	assert.Empty(t, statuses[1].Labels) // parse_labels is disabled
+
+	assert.Equal(t, "unavailable", statuses[2].Status)
+}
@@ -0,0 +1,269 @@
+package http_source
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// digestChallenge represents a parsed WWW-Authenticate Digest challenge (RFC 7616)
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       []string
+}
+
+// digestAlgorithms lists supported digest algorithms, strongest first
+var digestAlgorithms = []string{"SHA-256-SESS", "SHA-256", "MD5-SESS", "MD5"}
+
+// parseDigestChallenges returns the strongest supported digest challenge from WWW-Authenticate headers
+func parseDigestChallenges(headers []string) (*digestChallenge, error) {
+	var challenges []*digestChallenge
+	for _, header := range headers {
+		scheme, params, found := strings.Cut(strings.TrimSpace(header), " ")
+		if !found || !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		values := parseAuthParams(params)
+		challenge := &digestChallenge{
+			realm:     values["realm"],
+			nonce:     values["nonce"],
+			opaque:    values["opaque"],
+			algorithm: strings.ToUpper(values["algorithm"]),
+		}
+		if challenge.algorithm == "" {
+			challenge.algorithm = "MD5"
+		}
+		for _, qop := range strings.Split(values["qop"], ",") {
+			if qop = strings.TrimSpace(qop); qop != "" {
+				challenge.qop = append(challenge.qop, strings.ToLower(qop))
+			}
+		}
+		if challenge.nonce == "" {
+			continue
+		}
+		challenges = append(challenges, challenge)
+	}
+
+	for _, algorithm := range digestAlgorithms {
+		for _, challenge := range challenges {
+			if challenge.algorithm == algorithm {
+				return challenge, nil
+			}
+		}
+	}
+
+	if len(challenges) > 0 {
+		return nil, fmt.Errorf("unsupported digest algorithm %q", challenges[0].algorithm)
+	}
+	return nil, fmt.Errorf("server did not send a digest challenge")
+}
+
+// parseAuthParams parses comma separated key=value pairs where values may be quoted
+func parseAuthParams(input string) map[string]string {
+	params := make(map[string]string)
+	for i := 0; i < len(input); {
+		// Skip separators
+		for i < len(input) && (input[i] == ' ' || input[i] == ',' || input[i] == '\t') {
+			i++
+		}
+		keyStart := i
+		for i < len(input) && input[i] != '=' && input[i] != ',' {
+			i++
+		}
+		key := strings.ToLower(strings.TrimSpace(input[keyStart:i]))
+		if i >= len(input) || input[i] != '=' {
+			continue
+		}
+		i++ // skip '='
+
+		var value strings.Builder
+		if i < len(input) && input[i] == '"' {
+			i++
+			for i < len(input) && input[i] != '"' {
+				if input[i] == '\\' && i+1 < len(input) {
+					i++
+				}
+				value.WriteByte(input[i])
+				i++
+			}
+			i++ // skip closing quote
+		} else {
+			for i < len(input) && input[i] != ',' {
+				value.WriteByte(input[i])
+				i++
+			}
+		}
+		if key != "" {
+			params[key] = strings.TrimSpace(value.String())
+		}
+	}
+	return params
+}
+
+// authorize builds the Authorization header value answering the challenge
+func (c *digestChallenge) authorize(method, uri, username, password string, body []byte, cnonce string, nonceCount int) string {
+	var newHash func() hash.Hash
+	if strings.HasPrefix(c.algorithm, "SHA-256") {
+		newHash = sha256.New
+	} else {
+		newHash = md5.New
+	}
+	h := func(data string) string {
+		hasher := newHash()
+		hasher.Write([]byte(data))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	ha1 := h(username + ":" + c.realm + ":" + password)
+	if strings.HasSuffix(c.algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+
+	// Prefer qop=auth, fall back to auth-int when it is the only option
+	qop := ""
+	for _, offered := range c.qop {
+		if offered == "auth" {
+			qop = "auth"
+			break
+		}
+		if offered == "auth-int" {
+			qop = "auth-int"
+		}
+	}
+
+	ha2 := h(method + ":" + uri)
+	if qop == "auth-int" {
+		ha2 = h(method + ":" + uri + ":" + h(string(body)))
+	}
+
+	nc := fmt.Sprintf("%08x", nonceCount)
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`nonce="%s"`, c.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`algorithm=%s`, c.algorithm),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if qop != "" {
+		parts = append(parts, fmt.Sprintf("qop=%s", qop), fmt.Sprintf("nc=%s", nc), fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if c.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// newCnonce generates a random client nonce
+func newCnonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate cnonce: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// digestAuthorization answers the digest challenge of a 401 response for the given request
+func digestAuthorization(resp *http.Response, req *http.Request, auth *HTTPAuth, body string) (string, error) {
+	challenge, err := parseDigestChallenges(resp.Header.Values("WWW-Authenticate"))
+	if err != nil {
+		return "", err
+	}
+	cnonce, err := newCnonce()
+	if err != nil {
+		return "", err
+	}
+	return challenge.authorize(req.Method, req.URL.RequestURI(), auth.Username, auth.Password, []byte(body), cnonce, 1), nil
+}
+
+// usesClientCredentials reports whether the oauth2 auth config uses the client-credentials flow
+// rather than a static token
+func usesClientCredentials(auth *HTTPAuth) bool {
+	return auth.TokenURL != ""
+}
+
+// oauth2TokenSourceKey identifies the client-credentials configuration of an app in the token cache.
+// Apps don't share token sources, as a source keeps using the HTTP client of the first check.
+func oauth2TokenSourceKey(appName string, auth *HTTPAuth) string {
+	scopes := append([]string(nil), auth.Scopes...)
+	sort.Strings(scopes)
+	secret := sha256.Sum256([]byte(auth.ClientSecret))
+	return strings.Join([]string{appName, auth.TokenURL, auth.ClientID, hex.EncodeToString(secret[:]), strings.Join(scopes, " "), auth.Audience}, "|")
+}
+
+// oauth2Token returns a valid access token for the client-credentials configuration of an app.
+// Token sources are cached so tokens are reused across scrape cycles and only refreshed
+// when they expire.
+func (h *HTTPScraper) oauth2Token(appName string, auth *HTTPAuth, client *http.Client) (*oauth2.Token, error) {
+	key := oauth2TokenSourceKey(appName, auth)
+
+	h.tokenMutex.Lock()
+	source, exists := h.tokenSources[key]
+	if !exists {
+		cfg := clientcredentials.Config{
+			ClientID:     auth.ClientID,
+			ClientSecret: auth.ClientSecret,
+			TokenURL:     auth.TokenURL,
+			Scopes:       auth.Scopes,
+		}
+		if auth.Audience != "" {
+			cfg.EndpointParams = url.Values{"audience": {auth.Audience}}
+		}
+
+		// The token source outlives a single scrape, so it must not use a request-scoped context
+		ctx := context.Background()
+		if client != nil {
+			ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+		}
+		source = cfg.TokenSource(ctx)
+		h.tokenSources[key] = source
+	}
+	h.tokenMutex.Unlock()
+
+	token, err := source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain oauth2 token from %s: %w", auth.TokenURL, err)
+	}
+	return token, nil
+}
+
+// pruneTokenSources drops the token sources of apps that are no longer configured, so they don't
+// keep old secrets and clients
+func (h *HTTPScraper) pruneTokenSources(apps []HTTPApp) {
+	configured := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		if app.Auth != nil && app.Auth.Type == "oauth2" && usesClientCredentials(app.Auth) {
+			configured[oauth2TokenSourceKey(app.Name, app.Auth)] = true
+		}
+	}
+
+	h.tokenMutex.Lock()
+	defer h.tokenMutex.Unlock()
+	for key := range h.tokenSources {
+		if !configured[key] {
+			delete(h.tokenSources, key)
+		}
+	}
+}
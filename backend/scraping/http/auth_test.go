@@ -0,0 +1,269 @@
+package http_source
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthParams(t *testing.T) {
+	params := parseAuthParams(`realm="api, internal", qop="auth,auth-int", algorithm=SHA-256, nonce="abc\"def", stale=false`)
+
+	assert.Equal(t, "api, internal", params["realm"])
+	assert.Equal(t, "auth,auth-int", params["qop"])
+	assert.Equal(t, "SHA-256", params["algorithm"])
+	assert.Equal(t, `abc"def`, params["nonce"])
+	assert.Equal(t, "false", params["stale"])
+}
+
+func TestParseDigestChallenges(t *testing.T) {
+	t.Run("prefers strongest algorithm", func(t *testing.T) {
+		challenge, err := parseDigestChallenges([]string{
+			`Digest realm="test", qop="auth", algorithm=MD5, nonce="n1"`,
+			`Digest realm="test", qop="auth", algorithm=SHA-256, nonce="n2"`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "SHA-256", challenge.algorithm)
+		assert.Equal(t, "n2", challenge.nonce)
+		assert.Equal(t, []string{"auth"}, challenge.qop)
+	})
+
+	t.Run("defaults to MD5", func(t *testing.T) {
+		challenge, err := parseDigestChallenges([]string{`Digest realm="test", nonce="n1"`})
+		require.NoError(t, err)
+		assert.Equal(t, "MD5", challenge.algorithm)
+	})
+
+	t.Run("no digest challenge", func(t *testing.T) {
+		_, err := parseDigestChallenges([]string{`Basic realm="test"`})
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := parseDigestChallenges([]string{`Digest realm="test", nonce="n1", algorithm=SHA-512-256`})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported digest algorithm")
+	})
+}
+
+func TestDigestAuthorizeRFC7616Examples(t *testing.T) {
+	// Examples from RFC 7616 section 3.9.1
+	const (
+		username = "Mufasa"
+		password = "Circle of Life"
+		uri      = "/dir/index.html"
+		cnonce   = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	)
+
+	tests := []struct {
+		algorithm string
+		response  string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			challenge := &digestChallenge{
+				realm:     "http-auth@example.org",
+				nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+				algorithm: tt.algorithm,
+				qop:       []string{"auth", "auth-int"},
+			}
+
+			header := challenge.authorize("GET", uri, username, password, nil, cnonce, 1)
+
+			assert.True(t, strings.HasPrefix(header, "Digest "))
+			params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+			assert.Equal(t, tt.response, params["response"])
+			assert.Equal(t, "auth", params["qop"])
+			assert.Equal(t, "00000001", params["nc"])
+			assert.Equal(t, challenge.opaque, params["opaque"])
+			assert.Equal(t, uri, params["uri"])
+		})
+	}
+}
+
+// newDigestServer returns a server protected by MD5 digest authentication
+func newDigestServer(t *testing.T, username, password string) *httptest.Server {
+	const realm = "monitoring"
+	const nonce = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+
+	md5Hex := func(data string) string {
+		sum := md5.Sum([]byte(data))
+		return hex.EncodeToString(sum[:])
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", nonce="`+nonce+`", opaque="xyz"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+		ha1 := md5Hex(username + ":" + realm + ":" + password)
+		ha2 := md5Hex(r.Method + ":" + r.URL.RequestURI())
+		expected := md5Hex(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+		if params["username"] != username || params["response"] != expected || params["opaque"] != "xyz" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+}
+
+func TestCheckWithDigestAuth(t *testing.T) {
+	server := newDigestServer(t, "monitor", "s3cret")
+	defer server.Close()
+
+	scraper := NewHTTPScraper()
+
+	t.Run("valid credentials", func(t *testing.T) {
+		app := mergeWithDefaults(HTTPApp{
+			Name:     "digest-app",
+			Location: "test-location",
+			URL:      server.URL + "/health?verbose=1",
+			Auth:     &HTTPAuth{Type: "digest", Username: "monitor", Password: "s3cret"},
+		})
+		status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "up", status)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		app := mergeWithDefaults(HTTPApp{
+			Name:     "digest-app",
+			Location: "test-location",
+			URL:      server.URL + "/health",
+			Auth:     &HTTPAuth{Type: "digest", Username: "monitor", Password: "wrong"},
+		})
+		status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+		assert.Error(t, err)
+		assert.Equal(t, "down", status)
+	})
+}
+
+func TestCheckWithOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var expiresIn atomic.Int32
+	expiresIn.Store(3600)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		require.NoError(t, r.ParseForm())
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+		}
+		if clientID != "monitor" || clientSecret != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "health:read", r.FormValue("scope"))
+		assert.Equal(t, "internal-api", r.FormValue("audience"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", tokenRequests.Load()),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn.Load(),
+		})
+	}))
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer apiServer.Close()
+
+	newApp := func(secret string) HTTPApp {
+		return mergeWithDefaults(HTTPApp{
+			Name:     "oauth-app",
+			Location: "test-location",
+			URL:      apiServer.URL + "/health",
+			Auth: &HTTPAuth{
+				Type:         "oauth2",
+				TokenURL:     tokenServer.URL + "/token",
+				ClientID:     "monitor",
+				ClientSecret: secret,
+				Scopes:       []string{"health:read"},
+				Audience:     "internal-api",
+			},
+		})
+	}
+
+	t.Run("token is cached across checks", func(t *testing.T) {
+		scraper := NewHTTPScraper()
+		for i := 0; i < 3; i++ {
+			status, err := scraper.check(context.Background(), newApp("s3cret"), 5*time.Second, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "up", status)
+		}
+		assert.Equal(t, int32(1), tokenRequests.Load())
+	})
+
+	t.Run("expired token is refreshed", func(t *testing.T) {
+		tokenRequests.Store(0)
+		expiresIn.Store(1) // Within the refresh margin, so every check needs a new token
+
+		scraper := NewHTTPScraper()
+		for i := 0; i < 2; i++ {
+			status, err := scraper.check(context.Background(), newApp("s3cret"), 5*time.Second, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "up", status)
+		}
+		assert.Equal(t, int32(2), tokenRequests.Load())
+	})
+
+	t.Run("token sources are per app and dropped with the app", func(t *testing.T) {
+		tokenRequests.Store(0)
+		expiresIn.Store(3600)
+
+		scraper := NewHTTPScraper()
+		first, second := newApp("s3cret"), newApp("s3cret")
+		second.Name = "other-app"
+		for _, app := range []HTTPApp{first, second} {
+			_, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), tokenRequests.Load(), "apps don't share the HTTP client of a token source")
+		assert.Len(t, scraper.tokenSources, 2)
+
+		rotated := newApp("rotated")
+		scraper.pruneTokenSources([]HTTPApp{rotated})
+		assert.Empty(t, scraper.tokenSources, "sources of removed apps and old secrets are dropped")
+
+		scraper.pruneTokenSources([]HTTPApp{first})
+		_, err := scraper.check(context.Background(), first, 5*time.Second, nil)
+		require.NoError(t, err)
+		assert.Len(t, scraper.tokenSources, 1)
+	})
+
+	t.Run("token endpoint rejects credentials", func(t *testing.T) {
+		scraper := NewHTTPScraper()
+		status, err := scraper.check(context.Background(), newApp("wrong"), 5*time.Second, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "oauth2 token")
+		assert.Equal(t, "down", status)
+	})
+}
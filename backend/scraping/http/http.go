@@ -1,9 +1,9 @@
 package http_source
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,10 +13,14 @@ import (
 	"site-availability/handlers"
 	"site-availability/labels"
 	"site-availability/logging"
+	"site-availability/metrics"
+	"site-availability/scraping/retry"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/oauth2"
 )
 
 // HTTPConfig represents the configuration for HTTP sources
@@ -39,6 +43,11 @@ type HTTPApp struct {
 	FollowRedirects *bool  `yaml:"follow_redirects"`
 	MaxRedirects    int    `yaml:"max_redirects"`
 
+	// Retries within a single scrape, bounded by the check timeout
+	Retries      int      `yaml:"retries"`
+	RetryBackoff string   `yaml:"retry_backoff"`
+	RetryOn      []string `yaml:"retry_on"`
+
 	// Authentication
 	Auth *HTTPAuth `yaml:"auth"`
 
@@ -49,6 +58,9 @@ type HTTPApp struct {
 	// Content validation
 	Validation *HTTPValidation `yaml:"validation"`
 
+	// Soft threshold: a passing check slower than this is degraded instead of up (0 = disabled)
+	DegradedResponseTimeMS int `yaml:"degraded_response_time_ms"`
+
 	// Simplified SSL configuration
 	SSLVerify *bool `yaml:"ssl_verify"`
 
@@ -61,6 +73,13 @@ type HTTPAuth struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
 	Token    string `yaml:"token"`
+
+	// OAuth2 client-credentials flow (used instead of a static token when token_url is set)
+	TokenURL     string   `yaml:"token_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+	Audience     string   `yaml:"audience"`
 }
 
 // HTTPValidation represents content validation configuration
@@ -83,10 +102,15 @@ type HTTPValidationCondition struct {
 
 // HTTPScraper implements the Scraper interface for HTTP sources
 type HTTPScraper struct {
+	// OAuth2 token sources cached across scrape cycles
+	tokenSources map[string]oauth2.TokenSource
+	tokenMutex   sync.Mutex
 }
 
 func NewHTTPScraper() *HTTPScraper {
-	return &HTTPScraper{}
+	return &HTTPScraper{
+		tokenSources: make(map[string]oauth2.TokenSource),
+	}
 }
 
 // getDefaultHTTPApp returns an HTTPApp with all default values
@@ -211,6 +235,19 @@ func (h *HTTPScraper) ValidateConfig(source config.Source) error {
 				return fmt.Errorf("http source %s: app %s %w", source.Name, app.Name, err)
 			}
 		}
+
+		// Validate content validation conditions
+		if err := validateConditions(app.Validation); err != nil {
+			return fmt.Errorf("http source %s: app %s %w", source.Name, app.Name, err)
+		}
+
+		if app.DegradedResponseTimeMS < 0 {
+			return fmt.Errorf("http source %s: app %s degraded_response_time_ms must not be negative", source.Name, app.Name)
+		}
+
+		if _, err := retry.NewPolicy(app.Retries, app.RetryBackoff, app.RetryOn); err != nil {
+			return fmt.Errorf("http source %s: app %s %w", source.Name, app.Name, err)
+		}
 	}
 
 	return nil
@@ -241,6 +278,32 @@ func isValidStatusCodeRange(s string) bool {
 	return matched
 }
 
+// validateConditions validates success and failure conditions
+func validateConditions(validation *HTTPValidation) error {
+	if validation == nil {
+		return nil
+	}
+
+	conditions := map[string][]HTTPValidationCondition{
+		"success": validation.Success,
+		"failure": validation.Failure,
+	}
+	for _, kind := range []string{"success", "failure"} {
+		for i, condition := range conditions[kind] {
+			if condition.Type != "json_path" {
+				continue
+			}
+			if condition.Path == "" {
+				return fmt.Errorf("validation: %s condition %d: json_path requires 'path'", kind, i)
+			}
+			if err := validateJSONPathCondition(condition); err != nil {
+				return fmt.Errorf("validation: %s condition %d: %w", kind, i, err)
+			}
+		}
+	}
+	return nil
+}
+
 // validateAuth validates authentication configuration
 func validateAuth(auth *HTTPAuth) error {
 	validTypes := map[string]bool{"basic": true, "bearer": true, "digest": true, "oauth2": true}
@@ -262,21 +325,30 @@ func validateAuth(auth *HTTPAuth) error {
 			return fmt.Errorf("auth: digest authentication requires username and password")
 		}
 	case "oauth2":
-		if auth.Token == "" {
-			return fmt.Errorf("auth: oauth2 authentication requires token")
+		if usesClientCredentials(auth) {
+			if _, err := url.ParseRequestURI(auth.TokenURL); err != nil {
+				return fmt.Errorf("auth: oauth2 invalid token_url %q: %w", auth.TokenURL, err)
+			}
+			if auth.ClientID == "" || auth.ClientSecret == "" {
+				return fmt.Errorf("auth: oauth2 client credentials require client_id and client_secret")
+			}
+		} else if auth.Token == "" {
+			return fmt.Errorf("auth: oauth2 authentication requires token or token_url")
 		}
 	}
 
 	return nil
 }
 
-func (h *HTTPScraper) Scrape(source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+func (h *HTTPScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
 	// Decode the source-specific config
 	httpCfg, err := config.DecodeConfig[HTTPConfig](source.Config, source.Name)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	h.pruneTokenSources(httpCfg.Apps)
+
 	results := make([]handlers.AppStatus, len(httpCfg.Apps))
 	sem := make(chan struct{}, maxParallel)
 	var wg sync.WaitGroup
@@ -294,7 +366,9 @@ func (h *HTTPScraper) Scrape(source config.Source, serverSettings config.ServerS
 			// Merge with defaults
 			app = mergeWithDefaults(app)
 
-			status, err := h.check(app, timeout, tlsConfig)
+			start := time.Now()
+			status, err := h.check(ctx, app, timeout, tlsConfig)
+			duration := time.Since(start)
 			if err != nil {
 				logging.Logger.WithFields(map[string]interface{}{
 					"app":    app.Name,
@@ -319,6 +393,7 @@ func (h *HTTPScraper) Scrape(source config.Source, serverSettings config.ServerS
 				OriginURL: serverSettings.HostURL, // Use host URL as origin for deduplication
 				Labels:    appLabels,
 			}
+			results[i].RecordCheck(start, duration, err)
 			mu.Unlock()
 		}(i, app)
 	}
@@ -328,7 +403,7 @@ func (h *HTTPScraper) Scrape(source config.Source, serverSettings config.ServerS
 	return results, nil, nil
 }
 
-func (h *HTTPScraper) check(app HTTPApp, timeout time.Duration, tlsConfig *tls.Config) (string, error) {
+func (h *HTTPScraper) check(ctx context.Context, app HTTPApp, timeout time.Duration, tlsConfig *tls.Config) (string, error) {
 	// Parse app timeout or use server timeout
 	appTimeout := timeout
 	if app.Timeout != "" {
@@ -367,31 +442,62 @@ func (h *HTTPScraper) check(app HTTPApp, timeout time.Duration, tlsConfig *tls.C
 
 	client.Transport = transport
 
-	// Create request
-	req, err := http.NewRequest(strings.ToUpper(app.Method), app.URL, nil)
+	policy, err := retry.NewPolicy(app.Retries, app.RetryBackoff, app.RetryOn)
 	if err != nil {
-		return "down", fmt.Errorf("failed to create request: %w", err)
+		return "down", err
 	}
 
-	// Add headers
-	for key, value := range app.Headers {
-		req.Header.Set(key, value)
+	// Retries share the budget of a single check so they can't overlap the next scrape. A longer app
+	// timeout applies to each attempt, never to the whole check.
+	if budget := checkBudget(timeout, appTimeout); budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
 	}
 
-	// Add authentication
-	if app.Auth != nil {
-		if err := h.addAuthentication(req, app.Auth); err != nil {
-			return "down", fmt.Errorf("authentication failed: %w", err)
+	status := "down"
+	attempts, err := policy.Do(ctx, func(ctx context.Context) error {
+		var attemptErr error
+		status, attemptErr = h.attempt(ctx, app, client)
+		if attemptErr != nil {
+			status = "down"
+		}
+		return attemptErr
+	})
+	metrics.ObserveCheckAttempts("http", attempts)
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"app":      app.Name,
+		"status":   status,
+		"attempts": attempts,
+	}).Debug("HTTP check finished")
+
+	return status, err
+}
+
+// checkBudget returns the shortest of the timeouts that are set, or zero when none is
+func checkBudget(timeouts ...time.Duration) time.Duration {
+	var budget time.Duration
+	for _, timeout := range timeouts {
+		if timeout > 0 && (budget == 0 || timeout < budget) {
+			budget = timeout
 		}
 	}
+	return budget
+}
 
-	// Add body for POST/PUT requests
-	if app.Body != "" {
-		req.Body = io.NopCloser(strings.NewReader(app.Body))
-		if app.ContentType != "" {
-			req.Header.Set("Content-Type", app.ContentType)
-		} else if strings.ToUpper(app.Method) == "POST" || strings.ToUpper(app.Method) == "PUT" {
-			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+// attempt performs a single request of a check and evaluates the response
+func (h *HTTPScraper) attempt(ctx context.Context, app HTTPApp, client *http.Client) (string, error) {
+	// Create request
+	req, err := newCheckRequest(ctx, app)
+	if err != nil {
+		return "down", err
+	}
+
+	// Add authentication
+	if app.Auth != nil {
+		if err := h.addAuthentication(req, app.Name, app.Auth, client); err != nil {
+			return "down", fmt.Errorf("authentication failed: %w", err)
 		}
 	}
 
@@ -409,6 +515,29 @@ func (h *HTTPScraper) check(app HTTPApp, timeout time.Duration, tlsConfig *tls.C
 	if err != nil {
 		return "down", fmt.Errorf("HTTP request failed: %w", err)
 	}
+
+	// Digest authentication needs the server challenge, answer it and retry once
+	if app.Auth != nil && app.Auth.Type == "digest" && resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := digestAuthorization(resp, req, app.Auth, app.Body)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "down", fmt.Errorf("digest authentication failed: %w", err)
+		}
+
+		req, err = newCheckRequest(ctx, app)
+		if err != nil {
+			return "down", err
+		}
+		req.Header.Set("Authorization", authorization)
+
+		start = time.Now()
+		resp, err = client.Do(req)
+		responseTime = time.Since(start)
+		if err != nil {
+			return "down", fmt.Errorf("HTTP request failed: %w", err)
+		}
+	}
 	defer resp.Body.Close()
 
 	// Read response body for validation
@@ -420,10 +549,10 @@ func (h *HTTPScraper) check(app HTTPApp, timeout time.Duration, tlsConfig *tls.C
 
 	// Check status code
 	if !h.isStatusAllowed(resp.StatusCode, app.AllowedStatusCodes) {
-		return "down", fmt.Errorf("status code %d not in allowed codes", resp.StatusCode)
+		return "down", &retry.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("status code %d not in allowed codes", resp.StatusCode)}
 	}
 	if h.isStatusBlocked(resp.StatusCode, app.BlockedStatusCodes) {
-		return "down", fmt.Errorf("status code %d is in blocked codes", resp.StatusCode)
+		return "down", &retry.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("status code %d is in blocked codes", resp.StatusCode)}
 	}
 
 	// Validate content
@@ -444,6 +573,16 @@ func (h *HTTPScraper) check(app HTTPApp, timeout time.Duration, tlsConfig *tls.C
 		}
 	}
 
+	if app.DegradedResponseTimeMS > 0 && responseTime.Milliseconds() > int64(app.DegradedResponseTimeMS) {
+		logging.Logger.WithFields(map[string]interface{}{
+			"app":           app.Name,
+			"status_code":   resp.StatusCode,
+			"response_time": int(responseTime.Milliseconds()),
+			"threshold":     app.DegradedResponseTimeMS,
+		}).Info("HTTP check slower than degraded threshold - marking as degraded")
+		return "degraded", nil
+	}
+
 	logging.Logger.WithFields(map[string]interface{}{
 		"app":           app.Name,
 		"status_code":   resp.StatusCode,
@@ -453,8 +592,38 @@ func (h *HTTPScraper) check(app HTTPApp, timeout time.Duration, tlsConfig *tls.C
 	return "up", nil
 }
 
-// addAuthentication adds authentication to the request
-func (h *HTTPScraper) addAuthentication(req *http.Request, auth *HTTPAuth) error {
+// newCheckRequest creates the HTTP request for an app check with its headers and body
+func newCheckRequest(ctx context.Context, app HTTPApp) (*http.Request, error) {
+	var body io.Reader
+	if app.Body != "" {
+		body = strings.NewReader(app.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(app.Method), app.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add headers
+	for key, value := range app.Headers {
+		req.Header.Set(key, value)
+	}
+
+	// Add content type for POST/PUT requests
+	if app.Body != "" {
+		if app.ContentType != "" {
+			req.Header.Set("Content-Type", app.ContentType)
+		} else if strings.ToUpper(app.Method) == "POST" || strings.ToUpper(app.Method) == "PUT" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	return req, nil
+}
+
+// addAuthentication adds authentication to the request.
+// Digest authentication is answered after the server's challenge, see check.
+func (h *HTTPScraper) addAuthentication(req *http.Request, appName string, auth *HTTPAuth, client *http.Client) error {
 	switch auth.Type {
 	case "basic":
 		credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
@@ -462,11 +631,17 @@ func (h *HTTPScraper) addAuthentication(req *http.Request, auth *HTTPAuth) error
 	case "bearer":
 		req.Header.Set("Authorization", "Bearer "+auth.Token)
 	case "digest":
-		// Digest authentication would require more complex implementation
-		// For now, just set the Authorization header
-		req.Header.Set("Authorization", "Digest "+auth.Token)
+		// Sent without credentials first, the 401 challenge is answered in check
 	case "oauth2":
-		req.Header.Set("Authorization", "Bearer "+auth.Token)
+		if !usesClientCredentials(auth) {
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+			return nil
+		}
+		token, err := h.oauth2Token(appName, auth, client)
+		if err != nil {
+			return err
+		}
+		token.SetAuthHeader(req)
 	default:
 		return fmt.Errorf("unsupported authentication type: %s", auth.Type)
 	}
@@ -552,16 +727,13 @@ func (h *HTTPScraper) validateCondition(condition HTTPValidationCondition, statu
 			return !strings.Contains(bodyString, condition.Text)
 		}
 	case "json_path":
-		// Simple JSON path validation - could be enhanced with a proper JSON path library
-		if condition.Path != "" && condition.ExpectedValue != nil {
-			// For now, just check if the path exists in the JSON
-			// This is a simplified implementation
-			var jsonData map[string]interface{}
-			if err := json.Unmarshal([]byte(bodyString), &jsonData); err != nil {
+		if condition.Path != "" {
+			matched, err := evaluateJSONPathCondition(condition, bodyString)
+			if err != nil {
+				logging.Logger.WithError(err).WithField("path", condition.Path).Debug("JSON path condition could not be evaluated")
 				return false
 			}
-			// Basic path checking - could be enhanced
-			return true // Simplified for now
+			return matched
 		}
 	}
 	return false
@@ -1,6 +1,7 @@
 package http_source
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"site-availability/config"
 	"site-availability/handlers"
 	"site-availability/logging"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -313,6 +315,113 @@ func TestValidateConfig(t *testing.T) {
 			expectErr: true,
 			errMsg:    "invalid type",
 		},
+		{
+			name: "valid json path condition",
+			source: config.Source{
+				Name: "test-http",
+				Type: "http",
+				Config: map[string]interface{}{
+					"apps": []map[string]interface{}{
+						{
+							"name":     "test-app",
+							"location": "test-location",
+							"url":      "http://example.com",
+							"validation": map[string]interface{}{
+								"success": []map[string]interface{}{
+									{"type": "json_path", "path": "$.checks[*].status", "expected_value": "UP"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "malformed json path",
+			source: config.Source{
+				Name: "test-http",
+				Type: "http",
+				Config: map[string]interface{}{
+					"apps": []map[string]interface{}{
+						{
+							"name":     "test-app",
+							"location": "test-location",
+							"url":      "http://example.com",
+							"validation": map[string]interface{}{
+								"failure": []map[string]interface{}{
+									{"type": "json_path", "path": "$.checks[0", "expected_value": "DOWN"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+			errMsg:    "unterminated bracket",
+		},
+		{
+			name: "json path invalid operator",
+			source: config.Source{
+				Name: "test-http",
+				Type: "http",
+				Config: map[string]interface{}{
+					"apps": []map[string]interface{}{
+						{
+							"name":     "test-app",
+							"location": "test-location",
+							"url":      "http://example.com",
+							"validation": map[string]interface{}{
+								"success": []map[string]interface{}{
+									{"type": "json_path", "path": "$.status", "operator": "between", "expected_value": "ok"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid operator",
+		},
+		{
+			name: "negative degraded response time",
+			source: config.Source{
+				Name: "test-http",
+				Type: "http",
+				Config: map[string]interface{}{
+					"apps": []map[string]interface{}{
+						{
+							"name":                      "test-app",
+							"location":                  "test-location",
+							"url":                       "http://example.com",
+							"degraded_response_time_ms": -1,
+						},
+					},
+				},
+			},
+			expectErr: true,
+			errMsg:    "degraded_response_time_ms must not be negative",
+		},
+		{
+			name: "invalid retry_on",
+			source: config.Source{
+				Name: "test-http",
+				Type: "http",
+				Config: map[string]interface{}{
+					"apps": []map[string]interface{}{
+						{
+							"name":     "test-app",
+							"location": "test-location",
+							"url":      "http://example.com",
+							"retries":  2,
+							"retry_on": []string{"4xx"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+			errMsg:    "invalid retry_on",
+		},
 	}
 
 	for _, tt := range tests {
@@ -492,6 +601,46 @@ func TestValidateAuth(t *testing.T) {
 			expectErr: true,
 			errMsg:    "requires token",
 		},
+		{
+			name: "valid oauth2 client credentials",
+			auth: &HTTPAuth{
+				Type:         "oauth2",
+				TokenURL:     "https://auth.example.com/oauth/token",
+				ClientID:     "client",
+				ClientSecret: "secret",
+				Scopes:       []string{"read"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "oauth2 client credentials missing secret",
+			auth: &HTTPAuth{
+				Type:     "oauth2",
+				TokenURL: "https://auth.example.com/oauth/token",
+				ClientID: "client",
+			},
+			expectErr: true,
+			errMsg:    "require client_id and client_secret",
+		},
+		{
+			name: "oauth2 invalid token url",
+			auth: &HTTPAuth{
+				Type:         "oauth2",
+				TokenURL:     "not a url",
+				ClientID:     "client",
+				ClientSecret: "secret",
+			},
+			expectErr: true,
+			errMsg:    "invalid token_url",
+		},
+		{
+			name: "oauth2 missing token and token url",
+			auth: &HTTPAuth{
+				Type: "oauth2",
+			},
+			expectErr: true,
+			errMsg:    "requires token or token_url",
+		},
 	}
 
 	for _, tt := range tests {
@@ -629,6 +778,7 @@ func TestScrape(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			statuses, locations, err := scraper.Scrape(
+				context.Background(),
 				tt.source,
 				config.ServerSettings{},
 				10*time.Second,
@@ -702,7 +852,7 @@ func TestAddAuthentication(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req, _ := http.NewRequest("GET", "http://example.com", nil)
 
-			err := scraper.addAuthentication(req, tt.auth)
+			err := scraper.addAuthentication(req, "test-app", tt.auth, http.DefaultClient)
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -928,6 +1078,26 @@ func TestValidateCondition(t *testing.T) {
 			bodyString: `{"status": "ok"}`,
 			expected:   true,
 		},
+		{
+			name: "json path condition value mismatch",
+			condition: HTTPValidationCondition{
+				Type:          "json_path",
+				Path:          "$.status",
+				ExpectedValue: "UP",
+			},
+			bodyString: `{"status": "DEGRADED"}`,
+			expected:   false,
+		},
+		{
+			name: "json path condition invalid json",
+			condition: HTTPValidationCondition{
+				Type:          "json_path",
+				Path:          "$.status",
+				ExpectedValue: "ok",
+			},
+			bodyString: `status: ok`,
+			expected:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1043,7 +1213,7 @@ func TestCheck(t *testing.T) {
 			// Merge with defaults to ensure all required fields are set
 			app := mergeWithDefaults(tt.app)
 
-			status, err := scraper.check(app, 5*time.Second, nil)
+			status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
 
 			assert.Equal(t, tt.expectStatus, status)
 			if tt.expectErr {
@@ -1135,7 +1305,7 @@ func TestCheckWithValidation(t *testing.T) {
 				Validation: tt.validation,
 			})
 
-			status, err := scraper.check(app, 5*time.Second, nil)
+			status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
 
 			assert.Equal(t, tt.expectStatus, status)
 			if tt.expectErr {
@@ -1147,6 +1317,153 @@ func TestCheckWithValidation(t *testing.T) {
 	}
 }
 
+func TestCheckDegradedResponseTime(t *testing.T) {
+	scraper := NewHTTPScraper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		thresholdMS  int
+		validation   *HTTPValidation
+		expectStatus string
+		expectErr    bool
+	}{
+		{name: "fast response is up", path: "/fast", thresholdMS: 1000, expectStatus: "up"},
+		{name: "slow response is degraded", path: "/slow", thresholdMS: 10, expectStatus: "degraded"},
+		{name: "threshold disabled", path: "/slow", thresholdMS: 0, expectStatus: "up"},
+		{
+			name:        "failed check stays down",
+			path:        "/slow",
+			thresholdMS: 10,
+			validation: &HTTPValidation{
+				Success: []HTTPValidationCondition{{Type: "body_contains", Text: "MISSING"}},
+			},
+			expectStatus: "down",
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := mergeWithDefaults(HTTPApp{
+				Name:                   "test-app",
+				Location:               "test-location",
+				URL:                    server.URL + tt.path,
+				Validation:             tt.validation,
+				DegradedResponseTimeMS: tt.thresholdMS,
+			})
+
+			status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+
+			assert.Equal(t, tt.expectStatus, status)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckRetries(t *testing.T) {
+	scraper := NewHTTPScraper()
+
+	// newFlakyServer fails the first failures requests with the given status code
+	newFlakyServer := func(failures int32, statusCode int) (*httptest.Server, *int32) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) <= failures {
+				w.WriteHeader(statusCode)
+				return
+			}
+			_, _ = w.Write([]byte("OK"))
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	t.Run("recovers after server errors", func(t *testing.T) {
+		server, requests := newFlakyServer(2, http.StatusBadGateway)
+		app := mergeWithDefaults(HTTPApp{Name: "test-app", URL: server.URL, Retries: 2, RetryBackoff: "1ms"})
+
+		status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "up", status)
+		assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+
+	t.Run("gives up after the retries", func(t *testing.T) {
+		server, requests := newFlakyServer(5, http.StatusServiceUnavailable)
+		app := mergeWithDefaults(HTTPApp{Name: "test-app", URL: server.URL, Retries: 2, RetryBackoff: "1ms"})
+
+		status, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+
+		assert.Error(t, err)
+		assert.Equal(t, "down", status)
+		assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		server, requests := newFlakyServer(5, http.StatusNotFound)
+		app := mergeWithDefaults(HTTPApp{Name: "test-app", URL: server.URL, Retries: 2, RetryBackoff: "1ms"})
+
+		status, _ := scraper.check(context.Background(), app, 5*time.Second, nil)
+
+		assert.Equal(t, "down", status)
+		assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+
+	t.Run("retry_on limits the retried failures", func(t *testing.T) {
+		server, requests := newFlakyServer(5, http.StatusBadGateway)
+		app := mergeWithDefaults(HTTPApp{Name: "test-app", URL: server.URL, Retries: 2, RetryBackoff: "1ms", RetryOn: []string{"connection_error"}})
+
+		_, err := scraper.check(context.Background(), app, 5*time.Second, nil)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+
+	t.Run("retries stop at the timeout", func(t *testing.T) {
+		server, requests := newFlakyServer(5, http.StatusBadGateway)
+		app := mergeWithDefaults(HTTPApp{Name: "test-app", URL: server.URL, Retries: 3, RetryBackoff: "200ms"})
+
+		start := time.Now()
+		_, err := scraper.check(context.Background(), app, 300*time.Millisecond, nil)
+
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+
+	t.Run("a longer app timeout doesn't extend the scrape timeout", func(t *testing.T) {
+		server, requests := newFlakyServer(5, http.StatusBadGateway)
+		app := mergeWithDefaults(HTTPApp{Name: "test-app", URL: server.URL, Timeout: "5s", Retries: 3, RetryBackoff: "200ms"})
+
+		start := time.Now()
+		_, err := scraper.check(context.Background(), app, 300*time.Millisecond, nil)
+
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+}
+
+func TestCheckBudget(t *testing.T) {
+	assert.Equal(t, 10*time.Second, checkBudget(10*time.Second, 30*time.Second))
+	assert.Equal(t, 5*time.Second, checkBudget(10*time.Second, 5*time.Second))
+	assert.Equal(t, 5*time.Second, checkBudget(0, 5*time.Second))
+	assert.Equal(t, time.Duration(0), checkBudget(0, 0))
+}
+
 func TestCheckWithTLS(t *testing.T) {
 	scraper := NewHTTPScraper()
 
@@ -1190,7 +1507,7 @@ func TestCheckWithTLS(t *testing.T) {
 				SSLVerify: &tt.sslVerify,
 			})
 
-			status, err := scraper.check(app, 5*time.Second, tt.tlsConfig)
+			status, err := scraper.check(context.Background(), app, 5*time.Second, tt.tlsConfig)
 
 			assert.Equal(t, tt.expectStatus, status)
 			if tt.expectErr {
@@ -1289,6 +1606,7 @@ func TestIntegrationScrapeWithRealScenarios(t *testing.T) {
 	}
 
 	statuses, locations, err := scraper.Scrape(
+		context.Background(),
 		source,
 		config.ServerSettings{},
 		5*time.Second,
@@ -1337,6 +1655,34 @@ func TestIntegrationScrapeWithRealScenarios(t *testing.T) {
 	assert.Equal(t, "up", authStatus.Status)
 }
 
+func TestScrapeRecordsCheckMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	scraper := NewHTTPScraper()
+	source := config.Source{
+		Name: "metadata-test",
+		Type: "http",
+		Config: map[string]interface{}{
+			"apps": []map[string]interface{}{
+				{"name": "unavailable", "location": "datacenter-1", "url": server.URL},
+			},
+		},
+	}
+
+	before := time.Now()
+	statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+
+	assert.Equal(t, "down", statuses[0].Status)
+	assert.Equal(t, "status code 503 not in allowed codes", statuses[0].Reason)
+	assert.False(t, statuses[0].LastCheck.Before(before))
+	assert.Greater(t, statuses[0].ResponseTimeMs, 0.0)
+}
+
 // Helper function to find an app status by name
 func findAppStatus(statuses []handlers.AppStatus, name string) *handlers.AppStatus {
 	for _, status := range statuses {
@@ -0,0 +1,382 @@
+package http_source
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment represents a single step of a JSONPath expression
+type jsonPathSegment struct {
+	key      string // Object key (when index is not used)
+	index    int    // Array index, negative values count from the end
+	isIndex  bool
+	wildcard bool // Matches all object values or array elements
+}
+
+// jsonPath is a parsed JSONPath expression.
+// Supported syntax: $.key, $.key.nested, $['key with spaces'], $.items[0], $.items[-1], $.items[*], $.*
+type jsonPath struct {
+	segments []jsonPathSegment
+}
+
+// parseJSONPath parses a JSONPath expression. The leading "$" is optional.
+func parseJSONPath(path string) (*jsonPath, error) {
+	expr := strings.TrimSpace(path)
+	if expr == "" {
+		return nil, fmt.Errorf("json path is empty")
+	}
+
+	if strings.HasPrefix(expr, "$") {
+		expr = expr[1:]
+	} else if !strings.HasPrefix(expr, "[") {
+		// Allow "status.code" as shorthand for "$.status.code"
+		expr = "." + expr
+	}
+
+	parsed := &jsonPath{}
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			i++
+			if i < len(expr) && expr[i] == '.' {
+				return nil, fmt.Errorf("json path %q: recursive descent (..) is not supported", path)
+			}
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			name := expr[start:i]
+			if name == "" {
+				return nil, fmt.Errorf("json path %q: empty key at position %d", path, start)
+			}
+			if name == "*" {
+				parsed.segments = append(parsed.segments, jsonPathSegment{wildcard: true})
+			} else {
+				parsed.segments = append(parsed.segments, jsonPathSegment{key: name})
+			}
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q: unterminated bracket at position %d", path, i)
+			}
+			segment, err := parseJSONPathBracket(expr[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("json path %q: %w", path, err)
+			}
+			parsed.segments = append(parsed.segments, segment)
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("json path %q: unexpected character %q at position %d", path, expr[i], i)
+		}
+	}
+
+	return parsed, nil
+}
+
+// parseJSONPathBracket parses the content of a bracket segment: *, an index or a quoted key
+func parseJSONPathBracket(content string) (jsonPathSegment, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return jsonPathSegment{}, fmt.Errorf("empty brackets")
+	}
+	if content == "*" {
+		return jsonPathSegment{wildcard: true}, nil
+	}
+
+	if len(content) >= 2 {
+		quote := content[0]
+		if (quote == '\'' || quote == '"') && content[len(content)-1] == quote {
+			key := content[1 : len(content)-1]
+			if key == "" {
+				return jsonPathSegment{}, fmt.Errorf("empty quoted key")
+			}
+			return jsonPathSegment{key: key}, nil
+		}
+	}
+
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathSegment{}, fmt.Errorf("invalid index %q", content)
+	}
+	return jsonPathSegment{index: index, isIndex: true}, nil
+}
+
+// evaluate returns all values matched by the path. An empty result means the path does not exist.
+func (p *jsonPath) evaluate(data interface{}) []interface{} {
+	current := []interface{}{data}
+
+	for _, segment := range p.segments {
+		var next []interface{}
+		for _, value := range current {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if segment.wildcard {
+					// Sort keys for deterministic results
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if !segment.isIndex {
+					if child, ok := v[segment.key]; ok {
+						next = append(next, child)
+					}
+				}
+			case []interface{}:
+				if segment.wildcard {
+					next = append(next, v...)
+				} else if segment.isIndex {
+					index := segment.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		current = next
+		if len(current) == 0 {
+			break
+		}
+	}
+
+	return current
+}
+
+// jsonValueType returns the JSON type name of a decoded value
+func jsonValueType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// toFloat converts numbers and numeric strings to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// jsonValueString converts a decoded JSON value to its string representation
+func jsonValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+// jsonValuesEqual compares a decoded JSON value with an expected config value.
+// Numbers are compared numerically, everything else by string representation.
+func jsonValuesEqual(actual, expected interface{}, caseSensitive bool) bool {
+	if _, isNumber := actual.(float64); isNumber {
+		if a, ok := toFloat(actual); ok {
+			if e, ok := toFloat(expected); ok {
+				return a == e
+			}
+		}
+	}
+
+	actualString := jsonValueString(actual)
+	expectedString := fmt.Sprint(expected)
+	if expected == nil {
+		expectedString = "null"
+	}
+	if !caseSensitive {
+		return strings.EqualFold(actualString, expectedString)
+	}
+	return actualString == expectedString
+}
+
+// matchJSONValue checks a single matched value against the condition's operator
+func matchJSONValue(actual interface{}, condition HTTPValidationCondition, pattern *regexp.Regexp) bool {
+	if condition.ValueType != "" && jsonValueType(actual) != condition.ValueType {
+		return false
+	}
+
+	caseSensitive := condition.CaseSensitive == nil || *condition.CaseSensitive
+
+	switch jsonPathOperator(condition) {
+	case "exists":
+		return true
+	case "eq":
+		return jsonValuesEqual(actual, condition.ExpectedValue, caseSensitive)
+	case "ne":
+		return !jsonValuesEqual(actual, condition.ExpectedValue, caseSensitive)
+	case "gt", "lt":
+		a, ok := toFloat(actual)
+		if !ok {
+			return false
+		}
+		e, ok := toFloat(condition.ExpectedValue)
+		if !ok {
+			return false
+		}
+		if jsonPathOperator(condition) == "gt" {
+			return a > e
+		}
+		return a < e
+	case "contains":
+		switch v := actual.(type) {
+		case []interface{}:
+			for _, item := range v {
+				if jsonValuesEqual(item, condition.ExpectedValue, caseSensitive) {
+					return true
+				}
+			}
+			return false
+		case map[string]interface{}:
+			_, ok := v[fmt.Sprint(condition.ExpectedValue)]
+			return ok
+		default:
+			actualString := jsonValueString(actual)
+			expectedString := fmt.Sprint(condition.ExpectedValue)
+			if !caseSensitive {
+				return strings.Contains(strings.ToLower(actualString), strings.ToLower(expectedString))
+			}
+			return strings.Contains(actualString, expectedString)
+		}
+	case "regex":
+		if pattern == nil {
+			return false
+		}
+		return pattern.MatchString(jsonValueString(actual))
+	}
+	return false
+}
+
+// jsonPathOperator returns the operator of a json_path condition, defaulting to
+// "eq" when an expected value is set and "exists" otherwise
+func jsonPathOperator(condition HTTPValidationCondition) string {
+	if condition.Operator != "" {
+		return strings.ToLower(condition.Operator)
+	}
+	if condition.ExpectedValue == nil {
+		return "exists"
+	}
+	return "eq"
+}
+
+// validateJSONPathCondition validates the configuration of a json_path condition
+func validateJSONPathCondition(condition HTTPValidationCondition) error {
+	if _, err := parseJSONPath(condition.Path); err != nil {
+		return err
+	}
+
+	validOperators := map[string]bool{"eq": true, "ne": true, "gt": true, "lt": true, "contains": true, "regex": true, "exists": true}
+	operator := jsonPathOperator(condition)
+	if !validOperators[operator] {
+		return fmt.Errorf("json path %q: invalid operator %q", condition.Path, condition.Operator)
+	}
+
+	validValueTypes := map[string]bool{"": true, "string": true, "number": true, "boolean": true, "null": true, "array": true, "object": true}
+	if !validValueTypes[condition.ValueType] {
+		return fmt.Errorf("json path %q: invalid value_type %q", condition.Path, condition.ValueType)
+	}
+
+	switch operator {
+	case "exists":
+		return nil
+	case "gt", "lt":
+		if _, ok := toFloat(condition.ExpectedValue); !ok {
+			return fmt.Errorf("json path %q: operator %s requires a numeric expected_value", condition.Path, operator)
+		}
+	case "regex":
+		pattern, ok := condition.ExpectedValue.(string)
+		if !ok {
+			return fmt.Errorf("json path %q: operator regex requires a string expected_value", condition.Path)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("json path %q: invalid regex %q: %w", condition.Path, pattern, err)
+		}
+	case "eq", "ne", "contains":
+		if condition.ExpectedValue == nil && condition.ValueType != "null" {
+			return fmt.Errorf("json path %q: operator %s requires expected_value", condition.Path, operator)
+		}
+	}
+
+	return nil
+}
+
+// evaluateJSONPathCondition evaluates a json_path condition against a response body.
+// All values matched by the path must satisfy the operator (wildcards match every element);
+// "exists" only requires the path to match at least one value.
+func evaluateJSONPathCondition(condition HTTPValidationCondition, bodyString string) (bool, error) {
+	path, err := parseJSONPath(condition.Path)
+	if err != nil {
+		return false, err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(bodyString), &data); err != nil {
+		return false, fmt.Errorf("response body is not valid JSON: %w", err)
+	}
+
+	values := path.evaluate(data)
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	var pattern *regexp.Regexp
+	if jsonPathOperator(condition) == "regex" {
+		expr, _ := condition.ExpectedValue.(string)
+		pattern, err = regexp.Compile(expr)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+	}
+
+	for _, value := range values {
+		if !matchJSONValue(value, condition, pattern) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,198 @@
+package http_source
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected []jsonPathSegment
+		errMsg   string
+	}{
+		{
+			name:     "nested keys",
+			path:     "$.status.database",
+			expected: []jsonPathSegment{{key: "status"}, {key: "database"}},
+		},
+		{
+			name:     "without root",
+			path:     "status.database",
+			expected: []jsonPathSegment{{key: "status"}, {key: "database"}},
+		},
+		{
+			name:     "array index and wildcard",
+			path:     "$.checks[0].items[*]",
+			expected: []jsonPathSegment{{key: "checks"}, {index: 0, isIndex: true}, {key: "items"}, {wildcard: true}},
+		},
+		{
+			name:     "negative index",
+			path:     "$.checks[-1]",
+			expected: []jsonPathSegment{{key: "checks"}, {index: -1, isIndex: true}},
+		},
+		{
+			name:     "quoted keys",
+			path:     `$['service name']["version"]`,
+			expected: []jsonPathSegment{{key: "service name"}, {key: "version"}},
+		},
+		{
+			name:     "dot wildcard",
+			path:     "$.components.*.status",
+			expected: []jsonPathSegment{{key: "components"}, {wildcard: true}, {key: "status"}},
+		},
+		{
+			name:     "root only",
+			path:     "$",
+			expected: nil,
+		},
+		{name: "empty", path: "", errMsg: "empty"},
+		{name: "empty key", path: "$.status.", errMsg: "empty key"},
+		{name: "recursive descent", path: "$..status", errMsg: "recursive descent"},
+		{name: "unterminated bracket", path: "$.checks[0", errMsg: "unterminated bracket"},
+		{name: "invalid index", path: "$.checks[abc]", errMsg: "invalid index"},
+		{name: "empty brackets", path: "$.checks[]", errMsg: "empty brackets"},
+		{name: "unexpected character", path: "$status", errMsg: "unexpected character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseJSONPath(tt.path)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, parsed.segments)
+		})
+	}
+}
+
+func TestJSONPathEvaluate(t *testing.T) {
+	body := `{
+		"status": "UP",
+		"uptime": 3600,
+		"components": {"db": {"status": "UP"}, "cache": {"status": "DOWN"}},
+		"checks": [{"name": "disk", "ok": true}, {"name": "memory", "ok": false}],
+		"tags": ["prod", "eu"]
+	}`
+	var data interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &data))
+
+	tests := []struct {
+		path     string
+		expected []interface{}
+	}{
+		{"$.status", []interface{}{"UP"}},
+		{"$.uptime", []interface{}{float64(3600)}},
+		{"$.components.db.status", []interface{}{"UP"}},
+		{"$.components.*.status", []interface{}{"DOWN", "UP"}},
+		{"$.checks[1].name", []interface{}{"memory"}},
+		{"$.checks[-1].ok", []interface{}{false}},
+		{"$.checks[*].ok", []interface{}{true, false}},
+		{"$.tags[0]", []interface{}{"prod"}},
+		{"$.tags[5]", nil},
+		{"$.missing.key", nil},
+		{"$.status[0]", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parseJSONPath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, path.evaluate(data))
+		})
+	}
+}
+
+func TestEvaluateJSONPathCondition(t *testing.T) {
+	body := `{
+		"status": "DEGRADED",
+		"version": "2.4.1",
+		"connections": 42,
+		"healthy": true,
+		"components": [{"status": "UP"}, {"status": "UP"}],
+		"mixed": [{"status": "UP"}, {"status": "DOWN"}],
+		"tags": ["prod", "eu"],
+		"maintenance": null
+	}`
+
+	tests := []struct {
+		name      string
+		condition HTTPValidationCondition
+		expected  bool
+	}{
+		{"eq mismatch", HTTPValidationCondition{Path: "$.status", ExpectedValue: "UP"}, false},
+		{"eq match", HTTPValidationCondition{Path: "$.status", ExpectedValue: "DEGRADED"}, true},
+		{"eq case insensitive", HTTPValidationCondition{Path: "$.status", ExpectedValue: "degraded", CaseSensitive: boolPtr(false)}, true},
+		{"eq number", HTTPValidationCondition{Path: "$.connections", ExpectedValue: 42}, true},
+		{"eq boolean", HTTPValidationCondition{Path: "$.healthy", ExpectedValue: true}, true},
+		{"eq null", HTTPValidationCondition{Path: "$.maintenance", ValueType: "null", Operator: "eq"}, true},
+		{"ne", HTTPValidationCondition{Path: "$.status", Operator: "ne", ExpectedValue: "DOWN"}, true},
+		{"gt", HTTPValidationCondition{Path: "$.connections", Operator: "gt", ExpectedValue: 10}, true},
+		{"gt not satisfied", HTTPValidationCondition{Path: "$.connections", Operator: "gt", ExpectedValue: "100"}, false},
+		{"lt", HTTPValidationCondition{Path: "$.connections", Operator: "lt", ExpectedValue: 100.5}, true},
+		{"lt on string", HTTPValidationCondition{Path: "$.status", Operator: "lt", ExpectedValue: 1}, false},
+		{"contains string", HTTPValidationCondition{Path: "$.version", Operator: "contains", ExpectedValue: "2.4"}, true},
+		{"contains array", HTTPValidationCondition{Path: "$.tags", Operator: "contains", ExpectedValue: "eu"}, true},
+		{"contains array missing", HTTPValidationCondition{Path: "$.tags", Operator: "contains", ExpectedValue: "us"}, false},
+		{"regex", HTTPValidationCondition{Path: "$.version", Operator: "regex", ExpectedValue: `^2\.\d+\.\d+$`}, true},
+		{"regex mismatch", HTTPValidationCondition{Path: "$.version", Operator: "regex", ExpectedValue: `^3\.`}, false},
+		{"exists", HTTPValidationCondition{Path: "$.components[0].status", Operator: "exists"}, true},
+		{"exists default operator", HTTPValidationCondition{Path: "$.healthy"}, true},
+		{"exists missing", HTTPValidationCondition{Path: "$.database.status", Operator: "exists"}, false},
+		{"wildcard all match", HTTPValidationCondition{Path: "$.components[*].status", ExpectedValue: "UP"}, true},
+		{"wildcard one mismatch", HTTPValidationCondition{Path: "$.mixed[*].status", ExpectedValue: "UP"}, false},
+		{"value type match", HTTPValidationCondition{Path: "$.connections", ValueType: "number", Operator: "gt", ExpectedValue: 0}, true},
+		{"value type mismatch", HTTPValidationCondition{Path: "$.version", ValueType: "number", ExpectedValue: "2.4.1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.condition.Type = "json_path"
+			matched, err := evaluateJSONPathCondition(tt.condition, body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+
+	t.Run("invalid body", func(t *testing.T) {
+		_, err := evaluateJSONPathCondition(HTTPValidationCondition{Path: "$.status", ExpectedValue: "UP"}, "not json")
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateJSONPathCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition HTTPValidationCondition
+		errMsg    string
+	}{
+		{"valid eq", HTTPValidationCondition{Path: "$.status", ExpectedValue: "UP"}, ""},
+		{"valid exists", HTTPValidationCondition{Path: "$.status", Operator: "exists"}, ""},
+		{"valid regex", HTTPValidationCondition{Path: "$.version", Operator: "regex", ExpectedValue: "^2\\."}, ""},
+		{"malformed path", HTTPValidationCondition{Path: "$.items[", ExpectedValue: "x"}, "unterminated bracket"},
+		{"invalid operator", HTTPValidationCondition{Path: "$.status", Operator: "like", ExpectedValue: "x"}, "invalid operator"},
+		{"invalid value type", HTTPValidationCondition{Path: "$.status", ValueType: "integer", ExpectedValue: 1}, "invalid value_type"},
+		{"gt without number", HTTPValidationCondition{Path: "$.count", Operator: "gt", ExpectedValue: "many"}, "numeric expected_value"},
+		{"invalid regex", HTTPValidationCondition{Path: "$.status", Operator: "regex", ExpectedValue: "(["}, "invalid regex"},
+		{"eq without expected value", HTTPValidationCondition{Path: "$.status", Operator: "eq"}, "requires expected_value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJSONPathCondition(tt.condition)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
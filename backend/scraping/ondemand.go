@@ -0,0 +1,151 @@
+package scraping
+
+import (
+	"context"
+	"fmt"
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/logging"
+	"time"
+)
+
+// OnDemand runs checks outside the scrape schedule for the API. It implements handlers.Checker.
+type OnDemand struct{}
+
+// ScrapeSource scrapes a source immediately, like a scheduled scrape, and returns the published statuses of its apps.
+// The next scheduled scrape is not moved.
+func (OnDemand) ScrapeSource(ctx context.Context, sourceName string) ([]handlers.AppStatus, error) {
+	runnersMutex.RLock()
+	defer runnersMutex.RUnlock()
+
+	r, ok := runners[sourceName]
+	if !ok {
+		return nil, handlers.ErrSourceNotFound
+	}
+
+	r.scrapeMutex.Lock()
+	defer r.scrapeMutex.Unlock()
+
+	logging.Logger.WithField("source", sourceName).Info("Scraping source on demand")
+	if err := scrapeSource(ctx, r.spec, r.scraper, false); err != nil {
+		return nil, err
+	}
+	return handlers.GetSourceAppStatuses(sourceName), nil
+}
+
+// CheckApp checks a single app of a source and returns its published statuses.
+// When the app is configured in the source's apps list, only that app is checked and the other apps keep
+// their cached status. Apps the source discovers itself (site sources, Prometheus apps split by a label)
+// are checked by scraping the whole source. A failed check of a configured app leaves the caches unchanged.
+func (OnDemand) CheckApp(ctx context.Context, sourceName, appName string) ([]handlers.AppStatus, error) {
+	runnersMutex.RLock()
+	defer runnersMutex.RUnlock()
+
+	r, ok := runners[sourceName]
+	if !ok {
+		return nil, handlers.ErrSourceNotFound
+	}
+
+	narrowed, ok := narrowToApp(r.spec.Source, appName)
+	if !ok {
+		if len(appStatuses(handlers.GetSourceAppStatuses(sourceName), appName)) == 0 {
+			return nil, handlers.ErrAppNotFound
+		}
+
+		r.scrapeMutex.Lock()
+		defer r.scrapeMutex.Unlock()
+
+		logging.Logger.WithFields(map[string]interface{}{
+			"source": sourceName,
+			"app":    appName,
+		}).Info("Checking app on demand by scraping its source")
+		if err := scrapeSource(ctx, r.spec, r.scraper, false); err != nil {
+			return nil, err
+		}
+		return appStatuses(handlers.GetSourceAppStatuses(sourceName), appName), nil
+	}
+
+	r.scrapeMutex.Lock()
+	defer r.scrapeMutex.Unlock()
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"source": sourceName,
+		"app":    appName,
+	}).Info("Checking app on demand")
+
+	statuses, _, err := r.scraper.Scrape(ctx, narrowed, r.spec.ServerSettings, r.spec.Timeout, r.spec.MaxParallel, globalTLSConfig)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	statuses = debouncer.ApplyApps(r.spec.Source, statuses, time.Now())
+
+	// UpdateAppStatus replaces all apps of the source, so the checked apps are merged into the cached ones
+	checked := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		checked[status.Name] = true
+	}
+	merged := statuses
+	for _, cached := range handlers.GetSourceAppStatuses(sourceName) {
+		if !checked[cached.Name] {
+			merged = append(merged, cached)
+		}
+	}
+
+	updateResult := handlers.UpdateAppStatus(sourceName, merged, r.spec.Source, r.spec.ServerSettings)
+	if updateResult.Error != nil {
+		return nil, fmt.Errorf("failed to update app status cache: %w", updateResult.Error)
+	}
+
+	var result []handlers.AppStatus
+	for _, app := range handlers.GetSourceAppStatuses(sourceName) {
+		if checked[app.Name] {
+			result = append(result, app)
+		}
+	}
+	return result, nil
+}
+
+// narrowToApp returns a copy of the source whose apps list only holds the named app.
+// It reports false when the source has no apps list or the app is not in it.
+func narrowToApp(source config.Source, appName string) (config.Source, bool) {
+	apps, ok := source.Config["apps"].([]interface{})
+	if !ok {
+		return source, false
+	}
+
+	for _, app := range apps {
+		var name interface{}
+		switch fields := app.(type) {
+		case map[string]interface{}:
+			name = fields["name"]
+		case map[interface{}]interface{}:
+			name = fields["name"]
+		}
+		if name != appName {
+			continue
+		}
+
+		narrowed := source
+		narrowed.Config = make(map[string]interface{}, len(source.Config))
+		for key, value := range source.Config {
+			narrowed.Config[key] = value
+		}
+		narrowed.Config["apps"] = []interface{}{app}
+		return narrowed, true
+	}
+	return source, false
+}
+
+// appStatuses returns the statuses of the named app, one per origin URL
+func appStatuses(statuses []handlers.AppStatus, appName string) []handlers.AppStatus {
+	var result []handlers.AppStatus
+	for _, status := range statuses {
+		if status.Name == appName {
+			result = append(result, status)
+		}
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package scraping
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"site-availability/config"
+	"site-availability/handlers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appsScraper reports one status per app in the source's apps list
+type appsScraper struct {
+	mu       sync.Mutex
+	statuses map[string]string // app name -> status; "up" when not set
+	err      error
+	scraped  [][]string // app names of every scrape
+}
+
+func (a *appsScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var names []string
+	var results []handlers.AppStatus
+	for _, app := range source.Config["apps"].([]interface{}) {
+		name := app.(map[string]interface{})["name"].(string)
+		names = append(names, name)
+
+		status := a.statuses[name]
+		if status == "" {
+			status = "up"
+		}
+		results = append(results, handlers.AppStatus{Name: name, Location: "loc1", Status: status, Source: source.Name, OriginURL: serverSettings.HostURL})
+	}
+	a.scraped = append(a.scraped, names)
+	if a.err != nil {
+		return nil, nil, a.err
+	}
+	return results, nil, nil
+}
+
+func (a *appsScraper) ValidateConfig(source config.Source) error {
+	return nil
+}
+
+func (a *appsScraper) set(name, status string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.statuses[name] = status
+	a.err = err
+}
+
+func (a *appsScraper) scrapes() [][]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([][]string(nil), a.scraped...)
+}
+
+// cachedStatuses returns the cached status of every app of a source
+func cachedStatuses(sourceName string) map[string]string {
+	result := make(map[string]string)
+	for _, app := range handlers.GetSourceAppStatuses(sourceName) {
+		result[app.Name] = app.Status
+	}
+	return result
+}
+
+func TestOnDemand(t *testing.T) {
+	setupScrapingTest(t)
+
+	scraper := &appsScraper{statuses: make(map[string]string)}
+	spec := runnerSpec{
+		Source: config.Source{
+			Name: "http-checks",
+			Type: "mock",
+			Config: map[string]interface{}{
+				"apps": []interface{}{
+					map[string]interface{}{"name": "payments"},
+					map[string]interface{}{"name": "search"},
+				},
+			},
+		},
+		Interval:       time.Hour,
+		ServerSettings: config.ServerSettings{HostURL: "https://test-server.com"},
+	}
+
+	runnersMutex.Lock()
+	runners["http-checks"] = startRunner(spec, scraper)
+	runnersMutex.Unlock()
+	require.Eventually(t, func() bool { return len(cachedStatuses("http-checks")) == 2 }, time.Second, 5*time.Millisecond)
+
+	checker := OnDemand{}
+	ctx := context.Background()
+
+	t.Run("check a single app", func(t *testing.T) {
+		scraper.set("payments", "down", nil)
+		scraper.set("search", "down", nil)
+
+		result, err := checker.CheckApp(ctx, "http-checks", "payments")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "down", result[0].Status)
+
+		scrapes := scraper.scrapes()
+		assert.Equal(t, []string{"payments"}, scrapes[len(scrapes)-1], "only the app is checked")
+		assert.Equal(t, map[string]string{"payments": "down", "search": "up"}, cachedStatuses("http-checks"), "other apps keep their status")
+	})
+
+	t.Run("failed check leaves the cache unchanged", func(t *testing.T) {
+		scraper.set("payments", "up", errors.New("connection refused"))
+
+		_, err := checker.CheckApp(ctx, "http-checks", "payments")
+		assert.Error(t, err)
+		assert.Equal(t, map[string]string{"payments": "down", "search": "up"}, cachedStatuses("http-checks"))
+		scraper.set("payments", "up", nil)
+	})
+
+	t.Run("scrape a source", func(t *testing.T) {
+		result, err := checker.ScrapeSource(ctx, "http-checks")
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(result))
+		for _, app := range result {
+			names = append(names, app.Name)
+		}
+		sort.Strings(names)
+		assert.Equal(t, []string{"payments", "search"}, names)
+		assert.Equal(t, map[string]string{"payments": "up", "search": "down"}, cachedStatuses("http-checks"))
+	})
+
+	t.Run("unknown source and app", func(t *testing.T) {
+		_, err := checker.ScrapeSource(ctx, "missing")
+		assert.ErrorIs(t, err, handlers.ErrSourceNotFound)
+
+		_, err = checker.CheckApp(ctx, "missing", "payments")
+		assert.ErrorIs(t, err, handlers.ErrSourceNotFound)
+
+		_, err = checker.CheckApp(ctx, "http-checks", "checkout")
+		assert.ErrorIs(t, err, handlers.ErrAppNotFound)
+	})
+}
+
+func TestNarrowToApp(t *testing.T) {
+	source := config.Source{
+		Name: "http-checks",
+		Config: map[string]interface{}{
+			"apps": []interface{}{
+				map[string]interface{}{"name": "payments", "url": "https://payments.example.com"},
+				map[interface{}]interface{}{"name": "search", "url": "https://search.example.com"},
+			},
+		},
+	}
+
+	narrowed, ok := narrowToApp(source, "search")
+	require.True(t, ok)
+	assert.Len(t, narrowed.Config["apps"], 1)
+	assert.Len(t, source.Config["apps"], 2, "the original config is not modified")
+
+	_, ok = narrowToApp(source, "checkout")
+	assert.False(t, ok)
+
+	_, ok = narrowToApp(config.Source{Name: "remote", Config: map[string]interface{}{"url": "https://site.example.com"}}, "payments")
+	assert.False(t, ok, "sources without an apps list are scraped as a whole")
+}
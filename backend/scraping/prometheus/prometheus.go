@@ -1,25 +1,57 @@
 package prometheus
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"site-availability/config"
 	"site-availability/handlers"
 	"site-availability/labels"
 	"site-availability/logging"
+	"site-availability/metrics"
+	"site-availability/scraping/retry"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // PrometheusConfig represents the configuration for Prometheus sources
 type PrometheusConfig struct {
-	URL   string          `yaml:"url"`
-	Token string          `yaml:"token"`
-	Auth  string          `yaml:"auth"`
-	Apps  []PrometheusApp `yaml:"apps"`
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+	Auth  string `yaml:"auth"` // bearer or basic
+
+	// Basic auth credentials; a basic token is used as-is when no username is set
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// Extra headers sent with every query (e.g., X-Scope-OrgID for Mimir/Cortex tenants)
+	Headers map[string]string `yaml:"headers"`
+
+	// Optional: per-source TLS settings, overriding the global CA configuration
+	TLS *PrometheusTLSConfig `yaml:"tls"`
+
+	// Retries of a query within a single scrape, bounded by the scrape timeout
+	Retries      int      `yaml:"retries"`
+	RetryBackoff string   `yaml:"retry_backoff"`
+	RetryOn      []string `yaml:"retry_on"`
+
+	Apps []PrometheusApp `yaml:"apps"`
+}
+
+// PrometheusTLSConfig configures TLS for a single Prometheus source
+type PrometheusTLSConfig struct {
+	CAFile             string `yaml:"ca_file"`   // PEM bundle replacing the global CAs
+	CertFile           string `yaml:"cert_file"` // Client certificate for mTLS
+	KeyFile            string `yaml:"key_file"`  // Client key for mTLS
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
 }
 
 // PrometheusApp represents an app configuration for Prometheus
@@ -28,6 +60,180 @@ type PrometheusApp struct {
 	Location string            `yaml:"location"`
 	Metric   string            `yaml:"metric"`
 	Labels   map[string]string `yaml:"labels"`
+
+	// Optional: threshold expressions such as "> 0.99" or ">= 0.9, < 0.99"; up_when defaults to "== 1"
+	UpWhen       string `yaml:"up_when"`
+	DegradedWhen string `yaml:"degraded_when"`
+
+	// Deprecated: the older form of degraded_when, translated to ">= min, <= max"
+	DegradedRange *ValueRange `yaml:"degraded_range"`
+
+	// Optional: take the location from this series label, falling back to location
+	LocationLabel string `yaml:"location_label"`
+
+	// Optional: report one app per series, named from this label, instead of a single app
+	NameLabel string `yaml:"name_label"`
+	// Series labels copied to the app labels when fanning out
+	SeriesLabels []string `yaml:"series_labels"`
+}
+
+// thresholdOperators lists the supported comparison operators, longest first so ">=" wins over ">"
+var thresholdOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// Threshold compares metric values against a constant
+type Threshold struct {
+	Operator string
+	Value    float64
+}
+
+// ParseThreshold parses expressions such as "> 0.99", ">=1" or "!= 0"
+func ParseThreshold(expr string) (*Threshold, error) {
+	expr = strings.TrimSpace(expr)
+	for _, operator := range thresholdOperators {
+		if !strings.HasPrefix(expr, operator) {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(expr, operator)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: value must be a number", expr)
+		}
+		return &Threshold{Operator: operator, Value: value}, nil
+	}
+	return nil, fmt.Errorf("invalid threshold %q: must start with one of %s", expr, strings.Join(thresholdOperators, " "))
+}
+
+// parseThresholds parses comma separated thresholds that must all match, such as ">= 0.9, < 0.99"
+func parseThresholds(expr string) ([]Threshold, error) {
+	var thresholds []Threshold
+	for _, part := range strings.Split(expr, ",") {
+		threshold, err := ParseThreshold(part)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, *threshold)
+	}
+	return thresholds, nil
+}
+
+// Matches reports whether the value satisfies the threshold
+func (t Threshold) Matches(value float64) bool {
+	switch t.Operator {
+	case ">=":
+		return value >= t.Value
+	case "<=":
+		return value <= t.Value
+	case "==":
+		return value == t.Value
+	case "!=":
+		return value != t.Value
+	case ">":
+		return value > t.Value
+	case "<":
+		return value < t.Value
+	}
+	return false
+}
+
+// statusRules maps a series value to an app status. A rule matches when all of its thresholds match.
+type statusRules struct {
+	upWhen       []Threshold // empty means the value must be exactly 1
+	degradedWhen []Threshold // empty means never degraded
+}
+
+// newStatusRules builds the status rules of an app from its configuration
+func newStatusRules(app PrometheusApp) (statusRules, error) {
+	var rules statusRules
+	if app.UpWhen != "" {
+		thresholds, err := parseThresholds(app.UpWhen)
+		if err != nil {
+			return rules, fmt.Errorf("up_when: %w", err)
+		}
+		rules.upWhen = thresholds
+	}
+	if app.DegradedWhen != "" {
+		if app.DegradedRange != nil {
+			return rules, fmt.Errorf("can't use both 'degraded_when' and 'degraded_range'")
+		}
+		thresholds, err := parseThresholds(app.DegradedWhen)
+		if err != nil {
+			return rules, fmt.Errorf("degraded_when: %w", err)
+		}
+		rules.degradedWhen = thresholds
+	}
+	if app.DegradedRange != nil {
+		thresholds, err := app.DegradedRange.thresholds()
+		if err != nil {
+			return rules, fmt.Errorf("degraded_range %w", err)
+		}
+		rules.degradedWhen = thresholds
+	}
+	return rules, nil
+}
+
+// status returns up when the up rule matches, degraded when the degraded rule matches and down otherwise
+func (r statusRules) status(value float64) string {
+	up := value == 1
+	if len(r.upWhen) > 0 {
+		up = matchesAll(r.upWhen, value)
+	}
+	if up {
+		return "up"
+	}
+	if len(r.degradedWhen) > 0 && matchesAll(r.degradedWhen, value) {
+		return "degraded"
+	}
+	return "down"
+}
+
+// matchesAll reports whether the value satisfies every threshold
+func matchesAll(thresholds []Threshold, value float64) bool {
+	for _, threshold := range thresholds {
+		if !threshold.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// statusSeverity orders statuses so the worst series determines the app status
+var statusSeverity = map[string]int{"up": 0, "degraded": 1, "down": 2, "unavailable": 3}
+
+// worseStatus returns the more severe of two statuses
+func worseStatus(a, b string) string {
+	if statusSeverity[b] > statusSeverity[a] {
+		return b
+	}
+	return a
+}
+
+// Sample is a single series of an instant query result
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// ValueRange is an inclusive range of metric values; a missing bound is unbounded
+type ValueRange struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+}
+
+// thresholds expresses the range as the equivalent degraded_when thresholds
+func (r ValueRange) thresholds() ([]Threshold, error) {
+	if r.Min == nil && r.Max == nil {
+		return nil, fmt.Errorf("requires 'min' or 'max'")
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return nil, fmt.Errorf("'min' must not be greater than 'max'")
+	}
+	var thresholds []Threshold
+	if r.Min != nil {
+		thresholds = append(thresholds, Threshold{Operator: ">=", Value: *r.Min})
+	}
+	if r.Max != nil {
+		thresholds = append(thresholds, Threshold{Operator: "<=", Value: *r.Max})
+	}
+	return thresholds, nil
 }
 
 // PrometheusResponse represents the structure of the Prometheus API response.
@@ -62,6 +268,29 @@ func (p *PrometheusScraper) ValidateConfig(source config.Source) error {
 		return fmt.Errorf("prometheus source %s: missing 'url'", source.Name)
 	}
 
+	switch promCfg.Auth {
+	case "", "bearer", "basic":
+	default:
+		return fmt.Errorf("prometheus source %s: unsupported auth %q", source.Name, promCfg.Auth)
+	}
+	if promCfg.Username != "" && promCfg.Auth == "bearer" {
+		return fmt.Errorf("prometheus source %s: 'username' requires basic auth", source.Name)
+	}
+	if promCfg.Password != "" && promCfg.Username == "" {
+		return fmt.Errorf("prometheus source %s: 'password' requires 'username'", source.Name)
+	}
+	for name := range promCfg.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("prometheus source %s: invalid header name %q", source.Name, name)
+		}
+	}
+	if _, err := buildTLSConfig(promCfg.TLS, nil); err != nil {
+		return fmt.Errorf("prometheus source %s: %w", source.Name, err)
+	}
+	if _, err := retry.NewPolicy(promCfg.Retries, promCfg.RetryBackoff, promCfg.RetryOn); err != nil {
+		return fmt.Errorf("prometheus source %s: %w", source.Name, err)
+	}
+
 	// Validate apps
 	if len(promCfg.Apps) == 0 {
 		return fmt.Errorf("prometheus source %s: at least one app is required", source.Name)
@@ -80,31 +309,46 @@ func (p *PrometheusScraper) ValidateConfig(source config.Source) error {
 		if app.Metric == "" {
 			return fmt.Errorf("prometheus source %s: app %s missing 'metric'", source.Name, app.Name)
 		}
-		if app.Location == "" {
-			return fmt.Errorf("prometheus source %s: app %s missing 'location'", source.Name, app.Name)
+		if app.Location == "" && app.LocationLabel == "" {
+			return fmt.Errorf("prometheus source %s: app %s missing 'location' or 'location_label'", source.Name, app.Name)
+		}
+		if _, err := newStatusRules(app); err != nil {
+			return fmt.Errorf("prometheus source %s: app %s %w", source.Name, app.Name, err)
+		}
+		if app.DegradedRange != nil {
+			logging.Logger.WithFields(map[string]interface{}{
+				"source": source.Name,
+				"app":    app.Name,
+			}).Warn("'degraded_range' is deprecated, use 'degraded_when' instead")
+		}
+		if len(app.SeriesLabels) > 0 && app.NameLabel == "" {
+			return fmt.Errorf("prometheus source %s: app %s 'series_labels' requires 'name_label'", source.Name, app.Name)
 		}
 	}
 
 	return nil
 }
 
-func (p *PrometheusScraper) Scrape(source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+func (p *PrometheusScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
 	// Decode the source-specific config
 	promCfg, err := config.DecodeConfig[PrometheusConfig](source.Config, source.Name)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	results := make([]handlers.AppStatus, len(promCfg.Apps))
+	appResults := make([][]handlers.AppStatus, len(promCfg.Apps))
 	sem := make(chan struct{}, maxParallel)
 	var wg sync.WaitGroup
-	var mu sync.Mutex
 
-	// Shared HTTP client with optional TLS config
+	// Shared HTTP client with the source TLS config, falling back to the global one
+	sourceTLSConfig, err := buildTLSConfig(promCfg.TLS, tlsConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("prometheus source %s: %w", source.Name, err)
+	}
 	client := &http.Client{Timeout: timeout}
-	if tlsConfig != nil {
+	if sourceTLSConfig != nil {
 		client.Transport = &http.Transport{
-			TLSClientConfig: tlsConfig,
+			TLSClientConfig: sourceTLSConfig,
 		}
 	}
 
@@ -116,9 +360,10 @@ func (p *PrometheusScraper) Scrape(source config.Source, serverSettings config.S
 				<-sem
 				wg.Done()
 			}()
-			statusCode, err := p.check(client, promCfg.URL, app.Metric, promCfg.Auth, promCfg.Token)
-			status := "unavailable"
 
+			start := time.Now()
+			samples, err := p.check(ctx, client, promCfg, app.Metric)
+			duration := time.Since(start)
 			if err != nil {
 				// If check function failed, log as warning and mark app as unavailable
 				logging.Logger.WithFields(map[string]interface{}{
@@ -126,44 +371,259 @@ func (p *PrometheusScraper) Scrape(source config.Source, serverSettings config.S
 					"source": source.Name,
 					"error":  err.Error(),
 				}).Warn("Application status check failed - marking as unavailable")
-			} else {
-				if statusCode == 1 {
-					status = "up"
-					logging.Logger.WithField("app", app.Name).Debug("Application is UP")
-				} else {
-					status = "down"
-					logging.Logger.WithField("app", app.Name).Debug("Application is DOWN")
-				}
 			}
 
-			// Convert map[string]string labels to []Label format
-			var appLabels []labels.Label
-			for key, value := range app.Labels {
-				appLabels = append(appLabels, labels.Label{Key: key, Value: value})
+			rules, rulesErr := newStatusRules(app)
+			if rulesErr != nil {
+				// Rejected by ValidateConfig, so only reachable with an unvalidated config
+				err = rulesErr
 			}
 
-			mu.Lock()
-			results[i] = handlers.AppStatus{
-				Name:      app.Name,
-				Location:  app.Location,
-				Status:    status,
-				Source:    source.Name,
-				OriginURL: serverSettings.HostURL, // Use host URL as origin for deduplication
-				Labels:    appLabels,              // App labels only - source/server labels added in UpdateAppStatus
+			var statuses []handlers.AppStatus
+			if app.NameLabel != "" && err == nil {
+				statuses = fanOutStatuses(app, samples, rules)
+			} else {
+				statuses = aggregateStatus(app, samples, rules, err)
+			}
+
+			for j := range statuses {
+				statuses[j].Source = source.Name
+				statuses[j].OriginURL = serverSettings.HostURL // Use host URL as origin for deduplication
+				statuses[j].RecordCheck(start, duration, err)
 			}
-			mu.Unlock()
+			appResults[i] = statuses
 		}(i, app)
 	}
 	wg.Wait()
 
+	var results []handlers.AppStatus
+	for _, statuses := range appResults {
+		results = append(results, statuses...)
+	}
+
 	// Always return success - check errors are handled by marking apps as unavailable
 	// Prometheus scraper returns nil for locations since it only provides app statuses
 	return results, nil, nil
 }
 
-func (p *PrometheusScraper) check(client *http.Client, prometheusURL, promQLQuery, auth, token string) (int, error) {
+// aggregateStatus reports a single app whose status is the worst status across all series.
+// A failed check marks the app unavailable.
+func aggregateStatus(app PrometheusApp, samples []Sample, rules statusRules, checkErr error) []handlers.AppStatus {
+	status := "unavailable"
+	reason := ""
+	location := app.Location
+	if checkErr == nil {
+		status = "up"
+		for _, sample := range samples {
+			if sampleStatus := rules.status(sample.Value); worseStatus(status, sampleStatus) != status {
+				status = sampleStatus
+				reason = valueReason(sample.Value, sampleStatus)
+			}
+		}
+		if app.LocationLabel != "" {
+			for _, sample := range samples {
+				if value := sample.Labels[app.LocationLabel]; value != "" {
+					location = value
+					break
+				}
+			}
+		}
+
+		logging.Logger.WithFields(map[string]interface{}{
+			"app":    app.Name,
+			"series": len(samples),
+			"status": status,
+		}).Debug("Application status determined from metric value")
+	}
+
+	if location == "" {
+		logging.Logger.WithFields(map[string]interface{}{
+			"app":            app.Name,
+			"location_label": app.LocationLabel,
+		}).Warn("No location found for app - skipping")
+		return nil
+	}
+
+	return []handlers.AppStatus{{
+		Name:     app.Name,
+		Location: location,
+		Status:   status,
+		Labels:   appLabels(app, nil), // App labels only - source/server labels added in UpdateAppStatus
+		Reason:   reason,
+	}}
+}
+
+// valueReason explains why a metric value did not evaluate as up
+func valueReason(value float64, status string) string {
+	if status == "up" {
+		return ""
+	}
+	return fmt.Sprintf("metric value %g evaluated as %s", value, status)
+}
+
+// fanOutStatuses reports one app per series, named from the name label.
+// Series sharing a name are merged into one app with the worst status.
+func fanOutStatuses(app PrometheusApp, samples []Sample, rules statusRules) []handlers.AppStatus {
+	var statuses []handlers.AppStatus
+	index := make(map[string]int)
+
+	for _, sample := range samples {
+		name := sample.Labels[app.NameLabel]
+		location := app.Location
+		if app.LocationLabel != "" && sample.Labels[app.LocationLabel] != "" {
+			location = sample.Labels[app.LocationLabel]
+		}
+		if name == "" || location == "" {
+			logging.Logger.WithFields(map[string]interface{}{
+				"app":    app.Name,
+				"series": sample.Labels,
+			}).Warn("Series is missing the name or location label - skipping")
+			continue
+		}
+
+		status := rules.status(sample.Value)
+		if i, exists := index[name]; exists {
+			if worseStatus(statuses[i].Status, status) != statuses[i].Status {
+				statuses[i].Status = status
+				statuses[i].Reason = valueReason(sample.Value, status)
+			}
+			continue
+		}
+
+		index[name] = len(statuses)
+		statuses = append(statuses, handlers.AppStatus{
+			Name:     name,
+			Location: location,
+			Status:   status,
+			Labels:   appLabels(app, sample.Labels),
+			Reason:   valueReason(sample.Value, status),
+		})
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"app":    app.Name,
+		"series": len(samples),
+		"apps":   len(statuses),
+	}).Debug("Fanned out Prometheus series into apps")
+
+	return statuses
+}
+
+// appLabels combines the configured series labels with the app labels; app labels take precedence
+func appLabels(app PrometheusApp, series map[string]string) []labels.Label {
+	merged := make(map[string]string)
+	for _, key := range app.SeriesLabels {
+		if value, ok := series[key]; ok {
+			merged[key] = value
+		}
+	}
+	for key, value := range app.Labels {
+		merged[key] = value
+	}
+
+	var appLabels []labels.Label
+	for key, value := range merged {
+		appLabels = append(appLabels, labels.Label{Key: key, Value: value})
+	}
+	return appLabels
+}
+
+// buildTLSConfig returns the TLS config for a source. Without source settings the global config is used as-is.
+func buildTLSConfig(tlsCfg *PrometheusTLSConfig, globalTLSConfig *tls.Config) (*tls.Config, error) {
+	if tlsCfg == nil {
+		return globalTLSConfig, nil
+	}
+
+	clientConfig := &tls.Config{}
+	if globalTLSConfig != nil {
+		clientConfig = globalTLSConfig.Clone()
+	}
+
+	if tlsCfg.CAFile != "" {
+		caData, err := os.ReadFile(tlsCfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if ok := caCertPool.AppendCertsFromPEM(caData); !ok {
+			return nil, fmt.Errorf("ca_file %s contains no valid certificates", tlsCfg.CAFile)
+		}
+		clientConfig.RootCAs = caCertPool
+	}
+
+	if (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return nil, fmt.Errorf("'cert_file' and 'key_file' must be set together")
+	}
+	if tlsCfg.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		clientConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if tlsCfg.ServerName != "" {
+		clientConfig.ServerName = tlsCfg.ServerName
+	}
+	clientConfig.InsecureSkipVerify = tlsCfg.InsecureSkipVerify
+
+	return clientConfig, nil
+}
+
+// addRequestHeaders sets the configured headers and authentication on a query request
+func addRequestHeaders(req *http.Request, promCfg PrometheusConfig) {
+	for name, value := range promCfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Authentication takes precedence over a configured Authorization header
+	switch {
+	case promCfg.Username != "":
+		req.SetBasicAuth(promCfg.Username, promCfg.Password)
+	case promCfg.Auth == "bearer" && promCfg.Token != "":
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", promCfg.Token))
+	case promCfg.Auth == "basic" && promCfg.Token != "":
+		// Legacy: the token is the already encoded "username:password"
+		req.Header.Set("Authorization", fmt.Sprintf("Basic %s", promCfg.Token))
+	}
+}
+
+// check runs the PromQL query and returns every series of the result.
+// Failed queries are retried according to the source retry settings within the client timeout.
+func (p *PrometheusScraper) check(ctx context.Context, client *http.Client, promCfg PrometheusConfig, promQLQuery string) ([]Sample, error) {
+	policy, err := retry.NewPolicy(promCfg.Retries, promCfg.RetryBackoff, promCfg.RetryOn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retries share the budget of a single query so they can't overlap the next scrape
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+
+	var samples []Sample
+	attempts, err := policy.Do(ctx, func(ctx context.Context) error {
+		var queryErr error
+		samples, queryErr = p.query(ctx, client, promCfg, promQLQuery)
+		return queryErr
+	})
+	metrics.ObserveCheckAttempts("prometheus", attempts)
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"metric":   promQLQuery,
+		"attempts": attempts,
+		"failed":   err != nil,
+	}).Debug("Prometheus check finished")
+
+	return samples, err
+}
+
+// query performs a single PromQL query
+func (p *PrometheusScraper) query(ctx context.Context, client *http.Client, promCfg PrometheusConfig, promQLQuery string) ([]Sample, error) {
 	encodedQuery := url.QueryEscape(promQLQuery)
-	fullURL := fmt.Sprintf("%s/api/v1/query?query=%s", prometheusURL, encodedQuery)
+	fullURL := fmt.Sprintf("%s/api/v1/query?query=%s", promCfg.URL, encodedQuery)
 
 	logging.Logger.WithFields(map[string]interface{}{
 		"url":    fullURL,
@@ -171,71 +631,77 @@ func (p *PrometheusScraper) check(client *http.Client, prometheusURL, promQLQuer
 		"source": "prometheusScraper.check",
 	}).Debug("Querying Prometheus")
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Add authentication if credentials are available
-	if auth != "" && token != "" {
-		switch auth {
-		case "bearer":
-			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-		case "basic":
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", token))
-		}
-	}
+	addRequestHeaders(req, promCfg)
 
 	resp, err := client.Do(req)
 	if err != nil {
 		logging.Logger.WithError(err).WithField("url", fullURL).Error("Failed to query Prometheus")
-		return 0, fmt.Errorf("failed to query Prometheus: %v", err)
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, &retry.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("prometheus server %s returned status code %d", promCfg.URL, resp.StatusCode)}
+	}
+
 	if resp.StatusCode == http.StatusUnauthorized {
+		auth := promCfg.Auth
+		if promCfg.Username != "" {
+			auth = "basic"
+		}
 		logging.Logger.WithFields(map[string]interface{}{
 			"url":         fullURL,
 			"auth_method": auth,
 			"status_code": resp.StatusCode,
 		}).Error("Authentication failed for Prometheus server")
-		return 0, fmt.Errorf("authentication failed for Prometheus server %s using %s auth", fullURL, auth)
+		return nil, fmt.Errorf("authentication failed for Prometheus server %s using %s auth", fullURL, auth)
 	}
 
 	var promResp PrometheusResponse
 	if err := json.NewDecoder(resp.Body).Decode(&promResp); err != nil {
 		logging.Logger.WithError(err).Error("Failed to decode Prometheus response")
-		return 0, fmt.Errorf("failed to decode Prometheus response: %v", err)
+		return nil, fmt.Errorf("failed to decode Prometheus response: %v", err)
 	}
 
 	if promResp.Status != "success" {
 		logging.Logger.WithField("status", promResp.Status).Error("Prometheus query did not succeed")
-		return 0, fmt.Errorf("prometheus query %s failed: %s", promQLQuery, promResp.Status)
+		return nil, fmt.Errorf("prometheus query %s failed: %s", promQLQuery, promResp.Status)
 	}
 
 	if len(promResp.Data.Result) == 0 {
 		logging.Logger.WithField("metric", promQLQuery).Warn("Prometheus query returned no results")
-		return 0, fmt.Errorf("prometheus query %s did not return any result", promQLQuery)
+		return nil, fmt.Errorf("prometheus query %s did not return any result", promQLQuery)
 	}
 
-	if len(promResp.Data.Result[0].Value) < 2 {
-		logging.Logger.Error("Unexpected response format: value array too short")
-		return 0, fmt.Errorf("unexpected response format: value array too short")
-	}
+	samples := make([]Sample, 0, len(promResp.Data.Result))
+	for _, result := range promResp.Data.Result {
+		if len(result.Value) < 2 {
+			logging.Logger.Error("Unexpected response format: value array too short")
+			return nil, fmt.Errorf("unexpected response format: value array too short")
+		}
 
-	value, ok := promResp.Data.Result[0].Value[1].(string)
-	if !ok {
-		logging.Logger.Error("Unexpected response format: metric value is not a string")
-		return 0, fmt.Errorf("unexpected response format: value is not a string")
+		value, ok := result.Value[1].(string)
+		if !ok {
+			logging.Logger.Error("Unexpected response format: metric value is not a string")
+			return nil, fmt.Errorf("unexpected response format: value is not a string")
+		}
+
+		parsedValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected response format: value %q is not a number", value)
+		}
+		samples = append(samples, Sample{Labels: result.Metric, Value: parsedValue})
 	}
 
 	logging.Logger.WithFields(map[string]interface{}{
-		"value":  value,
+		"series": len(samples),
 		"metric": promQLQuery,
-	}).Debug("Prometheus metric value retrieved")
+	}).Debug("Prometheus metric values retrieved")
 
-	if value == "1" {
-		return 1, nil
-	}
-	return 0, nil
+	return samples, nil
 }
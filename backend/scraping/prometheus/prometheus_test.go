@@ -1,13 +1,25 @@
 package prometheus
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"math"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"site-availability/config"
+	"site-availability/labels"
 	"site-availability/logging"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -89,7 +101,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, statuses, 1)
 
@@ -145,7 +157,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, statuses, 1)
 		assert.Equal(t, "down", statuses[0].Status)
@@ -173,7 +185,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err) // Scrape method always returns success
 		require.Len(t, statuses, 1)
 		assert.Equal(t, "unavailable", statuses[0].Status)
@@ -236,7 +248,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 2, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 2, nil)
 		require.NoError(t, err)
 		require.Len(t, statuses, 2)
 
@@ -293,7 +305,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 		// Use insecure TLS config for testing
 		tlsConfig := &tls.Config{InsecureSkipVerify: true}
 
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, tlsConfig)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, tlsConfig)
 		require.NoError(t, err)
 		require.Len(t, statuses, 1)
 		assert.Equal(t, "up", statuses[0].Status)
@@ -309,7 +321,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		assert.Empty(t, statuses)
 	})
@@ -374,7 +386,7 @@ func TestPrometheusScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		statuses, _, err := scraper.Scrape(source, serverSettings, 5*time.Second, 1, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, serverSettings, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, statuses, 1)
 
@@ -443,9 +455,10 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		result, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		result, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		require.NoError(t, err)
-		assert.Equal(t, 1, result)
+		require.Len(t, result, 1)
+		assert.Equal(t, 1.0, result[0].Value)
 	})
 
 	t.Run("successful query with down status", func(t *testing.T) {
@@ -480,9 +493,10 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		result, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		result, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		require.NoError(t, err)
-		assert.Equal(t, 0, result)
+		require.Len(t, result, 1)
+		assert.Equal(t, 0.0, result[0].Value)
 	})
 
 	t.Run("authentication with bearer token", func(t *testing.T) {
@@ -521,9 +535,10 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		result, err := scraper.check(client, server.URL, `up{instance="test"}`, "bearer", "test-token")
+		result, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL, Auth: "bearer", Token: "test-token"}, `up{instance="test"}`)
 		require.NoError(t, err)
-		assert.Equal(t, 1, result)
+		require.Len(t, result, 1)
+		assert.Equal(t, 1.0, result[0].Value)
 	})
 
 	t.Run("authentication with basic token", func(t *testing.T) {
@@ -562,9 +577,10 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		result, err := scraper.check(client, server.URL, `up{instance="test"}`, "basic", "test-token")
+		result, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL, Auth: "basic", Token: "test-token"}, `up{instance="test"}`)
 		require.NoError(t, err)
-		assert.Equal(t, 1, result)
+		require.Len(t, result, 1)
+		assert.Equal(t, 1.0, result[0].Value)
 	})
 
 	t.Run("authentication with empty auth but token provided", func(t *testing.T) {
@@ -603,9 +619,10 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		result, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "test-token")
+		result, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL, Token: "test-token"}, `up{instance="test"}`)
 		require.NoError(t, err)
-		assert.Equal(t, 1, result)
+		require.Len(t, result, 1)
+		assert.Equal(t, 1.0, result[0].Value)
 	})
 
 	t.Run("invalid request URL", func(t *testing.T) {
@@ -613,7 +630,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		client := &http.Client{Timeout: 5 * time.Second}
 
 		// Use invalid URL with spaces to trigger request creation error
-		_, err := scraper.check(client, "http://invalid url with spaces", `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: "http://invalid url with spaces"}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to create request")
 	})
@@ -623,7 +640,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		client := &http.Client{Timeout: 5 * time.Second}
 
 		// Use non-existent server
-		_, err := scraper.check(client, "http://localhost:99999", `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: "http://localhost:99999"}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to query Prometheus")
 	})
@@ -637,7 +654,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		_, err := scraper.check(client, server.URL, `up{instance="test"}`, "bearer", "invalid-token")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL, Auth: "bearer", Token: "invalid-token"}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "authentication failed")
 		assert.Contains(t, err.Error(), "bearer")
@@ -653,7 +670,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		_, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to decode Prometheus response")
 	})
@@ -672,7 +689,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		_, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "prometheus query")
 		assert.Contains(t, err.Error(), "failed")
@@ -705,7 +722,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		_, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "did not return any result")
 	})
@@ -742,7 +759,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		_, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "value array too short")
 	})
@@ -779,7 +796,7 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		_, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "value is not a string")
 	})
@@ -816,9 +833,10 @@ func TestPrometheusScraper_Check(t *testing.T) {
 		scraper := NewPrometheusScraper()
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		result, err := scraper.check(client, server.URL, `up{instance="test"}`, "", "")
+		result, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, `up{instance="test"}`)
 		require.NoError(t, err)
-		assert.Equal(t, 0, result) // Any value != "1" should return 0
+		require.Len(t, result, 1)
+		assert.Equal(t, 0.5, result[0].Value) // Non 0/1 values are returned as-is
 	})
 }
 
@@ -916,7 +934,7 @@ func TestPrometheusScraper_EdgeCases(t *testing.T) {
 		}
 
 		start := time.Now()
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 2, nil) // max 2 concurrent
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 2, nil) // max 2 concurrent
 		duration := time.Since(start)
 
 		require.NoError(t, err)
@@ -952,9 +970,537 @@ func TestPrometheusScraper_EdgeCases(t *testing.T) {
 		}
 
 		// Use very short timeout
-		statuses, _, err := scraper.Scrape(source, config.ServerSettings{}, 10*time.Millisecond, 1, nil)
+		statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 10*time.Millisecond, 1, nil)
 		require.NoError(t, err) // Scrape method always returns success
 		require.Len(t, statuses, 1)
 		assert.Equal(t, "unavailable", statuses[0].Status) // Should be unavailable due to timeout
 	})
 }
+
+func TestPrometheusScraper_ValidateConfig(t *testing.T) {
+	scraper := NewPrometheusScraper()
+
+	tests := []struct {
+		name   string
+		app    map[string]interface{}
+		errMsg string
+	}{
+		{
+			name: "valid degraded range",
+			app:  map[string]interface{}{"name": "api", "location": "eu", "metric": "success_ratio", "degraded_range": map[string]interface{}{"min": 0.9, "max": 0.99}},
+		},
+		{
+			name: "open ended degraded range",
+			app:  map[string]interface{}{"name": "api", "location": "eu", "metric": "success_ratio", "degraded_range": map[string]interface{}{"min": 0.9}},
+		},
+		{
+			name:   "empty degraded range",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "success_ratio", "degraded_range": map[string]interface{}{}},
+			errMsg: "degraded_range requires 'min' or 'max'",
+		},
+		{
+			name:   "inverted degraded range",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "success_ratio", "degraded_range": map[string]interface{}{"min": 1, "max": 0.5}},
+			errMsg: "'min' must not be greater than 'max'",
+		},
+		{
+			name:   "missing metric",
+			app:    map[string]interface{}{"name": "api", "location": "eu"},
+			errMsg: "missing 'metric'",
+		},
+		{
+			name: "thresholds with location label",
+			app:  map[string]interface{}{"name": "api", "location_label": "region", "metric": "success_ratio", "up_when": "> 0.99", "degraded_when": ">= 0.95"},
+		},
+		{
+			name: "fan out",
+			app:  map[string]interface{}{"name": "fleet", "location": "eu", "metric": "up", "name_label": "instance", "series_labels": []string{"job"}},
+		},
+		{
+			name:   "missing location and location label",
+			app:    map[string]interface{}{"name": "api", "metric": "up"},
+			errMsg: "missing 'location' or 'location_label'",
+		},
+		{
+			name:   "invalid up_when",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "up", "up_when": "about 1"},
+			errMsg: "up_when: invalid threshold",
+		},
+		{
+			name:   "invalid degraded_when value",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "up", "degraded_when": "> high"},
+			errMsg: "degraded_when: invalid threshold",
+		},
+		{
+			name:   "invalid degraded_when list",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "up", "degraded_when": ">= 0.9,"},
+			errMsg: "degraded_when: invalid threshold",
+		},
+		{
+			name:   "degraded_when with degraded_range",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "up", "degraded_when": "> 0.5", "degraded_range": map[string]interface{}{"min": 0.5}},
+			errMsg: "can't use both",
+		},
+		{
+			name:   "series labels without name label",
+			app:    map[string]interface{}{"name": "api", "location": "eu", "metric": "up", "series_labels": []string{"job"}},
+			errMsg: "'series_labels' requires 'name_label'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scraper.ValidateConfig(config.Source{
+				Name: "test-prometheus",
+				Type: "prometheus",
+				Config: map[string]interface{}{
+					"url":  "http://prometheus:9090",
+					"apps": []map[string]interface{}{tt.app},
+				},
+			})
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestStatusRules(t *testing.T) {
+	floatPtr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name     string
+		app      PrometheusApp
+		value    float64
+		expected string
+	}{
+		{name: "one is up", value: 1, expected: "up"},
+		{name: "zero is down", value: 0, expected: "down"},
+		{name: "other values are down without degraded_when", value: 0.95, expected: "down"},
+		{name: "all degraded thresholds match", app: PrometheusApp{DegradedWhen: ">= 0.9, <= 0.99"}, value: 0.95, expected: "degraded"},
+		{name: "one degraded threshold fails", app: PrometheusApp{DegradedWhen: ">= 0.9, <= 0.99"}, value: 0.995, expected: "down"},
+		{name: "all up thresholds match", app: PrometheusApp{UpWhen: "> 0, < 100"}, value: 50, expected: "up"},
+		{name: "range is degraded", app: PrometheusApp{DegradedRange: &ValueRange{Min: floatPtr(0.9), Max: floatPtr(0.99)}}, value: 0.95, expected: "degraded"},
+		{name: "range bounds are inclusive", app: PrometheusApp{DegradedRange: &ValueRange{Min: floatPtr(0.9), Max: floatPtr(0.99)}}, value: 0.9, expected: "degraded"},
+		{name: "value below range is down", app: PrometheusApp{DegradedRange: &ValueRange{Min: floatPtr(0.9), Max: floatPtr(0.99)}}, value: 0.5, expected: "down"},
+		{name: "one outside range is up", app: PrometheusApp{DegradedRange: &ValueRange{Min: floatPtr(0.9), Max: floatPtr(0.99)}}, value: 1, expected: "up"},
+		{name: "open ended range", app: PrometheusApp{DegradedRange: &ValueRange{Min: floatPtr(200)}}, value: 250, expected: "degraded"},
+		{name: "NaN is never in a range", app: PrometheusApp{DegradedRange: &ValueRange{Max: floatPtr(1)}}, value: math.NaN(), expected: "down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := newStatusRules(tt.app)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rules.status(tt.value))
+		})
+	}
+}
+
+func TestPrometheusScraper_ScrapeDegraded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1234567890,"0.95"]}]}}`))
+	}))
+	defer server.Close()
+
+	scraper := NewPrometheusScraper()
+	source := config.Source{
+		Name: "test-prometheus",
+		Config: map[string]interface{}{
+			"url": server.URL,
+			"apps": []map[string]interface{}{
+				{"name": "with-range", "location": "eu", "metric": "success_ratio", "degraded_range": map[string]interface{}{"min": 0.9, "max": 0.99}},
+				{"name": "without-range", "location": "eu", "metric": "success_ratio"},
+			},
+		},
+	}
+
+	statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "degraded", statuses[0].Status)
+	assert.Equal(t, "down", statuses[1].Status)
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected *Threshold
+		errMsg   string
+	}{
+		{expr: "> 0.99", expected: &Threshold{Operator: ">", Value: 0.99}},
+		{expr: ">=1", expected: &Threshold{Operator: ">=", Value: 1}},
+		{expr: " < 500 ", expected: &Threshold{Operator: "<", Value: 500}},
+		{expr: "<= -1", expected: &Threshold{Operator: "<=", Value: -1}},
+		{expr: "== 1", expected: &Threshold{Operator: "==", Value: 1}},
+		{expr: "!= 0", expected: &Threshold{Operator: "!=", Value: 0}},
+		{expr: "0.99", errMsg: "must start with one of"},
+		{expr: "=> 1", errMsg: "must start with one of"},
+		{expr: "> ", errMsg: "value must be a number"},
+		{expr: ">> 1", errMsg: "value must be a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			threshold, err := ParseThreshold(tt.expr)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, threshold)
+		})
+	}
+}
+
+func TestStatusRulesThresholds(t *testing.T) {
+	rules, err := newStatusRules(PrometheusApp{UpWhen: "> 0.99", DegradedWhen: "> 0.95"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "up", rules.status(0.999))
+	assert.Equal(t, "up", rules.status(1))
+	assert.Equal(t, "degraded", rules.status(0.97))
+	assert.Equal(t, "down", rules.status(0.95))
+	assert.Equal(t, "down", rules.status(math.NaN()))
+
+	// Latency style rule where lower values are better
+	rules, err = newStatusRules(PrometheusApp{UpWhen: "< 0.5", DegradedWhen: "< 2"})
+	require.NoError(t, err)
+	assert.Equal(t, "up", rules.status(0.2))
+	assert.Equal(t, "degraded", rules.status(1.5))
+	assert.Equal(t, "down", rules.status(3))
+}
+
+// newSeriesServer serves a fixed instant query result
+func newSeriesServer(t *testing.T, result string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":` + result + `}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPrometheusScraper_ScrapeMultiSeries(t *testing.T) {
+	server := newSeriesServer(t, `[
+		{"metric":{"instance":"a","region":"eu-west"},"value":[1234567890,"1"]},
+		{"metric":{"instance":"b","region":"eu-west"},"value":[1234567890,"0.97"]},
+		{"metric":{"instance":"c","region":"eu-west"},"value":[1234567890,"1"]}
+	]`)
+
+	scraper := NewPrometheusScraper()
+	source := config.Source{
+		Name: "test-prometheus",
+		Config: map[string]interface{}{
+			"url": server.URL,
+			"apps": []map[string]interface{}{
+				{"name": "default-rule", "location": "eu", "metric": "up"},
+				{"name": "thresholds", "location_label": "region", "metric": "up", "up_when": "> 0.99", "degraded_when": "> 0.95"},
+				{"name": "missing-label", "location": "fallback", "location_label": "zone", "metric": "up", "up_when": ">= 0.9"},
+			},
+		},
+	}
+
+	statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{HostURL: "http://localhost:8080"}, 5*time.Second, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+
+	// The worst series determines the status
+	assert.Equal(t, "down", statuses[0].Status)
+	assert.Equal(t, "eu", statuses[0].Location)
+
+	assert.Equal(t, "degraded", statuses[1].Status)
+	assert.Equal(t, "eu-west", statuses[1].Location)
+	assert.Equal(t, "test-prometheus", statuses[1].Source)
+	assert.Equal(t, "http://localhost:8080", statuses[1].OriginURL)
+
+	assert.Equal(t, "up", statuses[2].Status)
+	assert.Equal(t, "fallback", statuses[2].Location)
+
+	// Check metadata is recorded for every app
+	assert.Equal(t, "metric value 0.97 evaluated as down", statuses[0].Reason)
+	assert.Equal(t, "metric value 0.97 evaluated as degraded", statuses[1].Reason)
+	assert.Empty(t, statuses[2].Reason)
+	for _, status := range statuses {
+		assert.False(t, status.LastCheck.IsZero())
+		assert.Greater(t, status.ResponseTimeMs, 0.0)
+	}
+}
+
+func TestPrometheusScraper_ScrapeFanOut(t *testing.T) {
+	server := newSeriesServer(t, `[
+		{"metric":{"instance":"web-1","region":"eu-west","job":"web"},"value":[1234567890,"1"]},
+		{"metric":{"instance":"web-2","region":"us-east","job":"web"},"value":[1234567890,"0"]},
+		{"metric":{"instance":"web-2","region":"us-east","job":"web","path":"/health"},"value":[1234567890,"1"]},
+		{"metric":{"region":"eu-west","job":"web"},"value":[1234567890,"1"]},
+		{"metric":{"instance":"web-3","job":"web"},"value":[1234567890,"1"]}
+	]`)
+
+	scraper := NewPrometheusScraper()
+	source := config.Source{
+		Name: "test-prometheus",
+		Config: map[string]interface{}{
+			"url": server.URL,
+			"apps": []map[string]interface{}{
+				{
+					"name":           "fleet",
+					"location_label": "region",
+					"metric":         "up{job=\"web\"}",
+					"name_label":     "instance",
+					"series_labels":  []string{"job", "missing"},
+					"labels":         map[string]string{"team": "web"},
+				},
+			},
+		},
+	}
+
+	statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 2, nil)
+	require.NoError(t, err)
+
+	// Series without an instance or a location are skipped; duplicate names are merged
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, "web-1", statuses[0].Name)
+	assert.Equal(t, "eu-west", statuses[0].Location)
+	assert.Equal(t, "up", statuses[0].Status)
+	assert.Equal(t, "test-prometheus", statuses[0].Source)
+	assert.ElementsMatch(t, []labels.Label{{Key: "job", Value: "web"}, {Key: "team", Value: "web"}}, statuses[0].Labels)
+
+	assert.Equal(t, "web-2", statuses[1].Name)
+	assert.Equal(t, "us-east", statuses[1].Location)
+	assert.Equal(t, "down", statuses[1].Status)
+}
+
+func TestPrometheusScraper_ScrapeFanOutFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	scraper := NewPrometheusScraper()
+	source := config.Source{
+		Name: "test-prometheus",
+		Config: map[string]interface{}{
+			"url": server.URL,
+			"apps": []map[string]interface{}{
+				{"name": "fleet", "location": "eu", "metric": "up", "name_label": "instance"},
+				{"name": "fleet-by-region", "location_label": "region", "metric": "up", "name_label": "instance"},
+			},
+		},
+	}
+
+	statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 2, nil)
+	require.NoError(t, err)
+
+	// A failed query reports the fan-out app itself as unavailable when its location is known
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "fleet", statuses[0].Name)
+	assert.Equal(t, "unavailable", statuses[0].Status)
+}
+
+func TestPrometheusScraper_ValidateSourceOptions(t *testing.T) {
+	scraper := NewPrometheusScraper()
+	app := map[string]interface{}{"name": "api", "location": "eu", "metric": "up"}
+
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		errMsg  string
+	}{
+		{name: "username and password", options: map[string]interface{}{"auth": "basic", "username": "user", "password": "pass"}},
+		{name: "username without auth", options: map[string]interface{}{"username": "user", "password": "pass"}},
+		{name: "tenant header", options: map[string]interface{}{"headers": map[string]string{"X-Scope-OrgID": "team-a"}}},
+		{name: "insecure tls", options: map[string]interface{}{"tls": map[string]interface{}{"insecure_skip_verify": true}}},
+		{name: "unsupported auth", options: map[string]interface{}{"auth": "digest"}, errMsg: "unsupported auth"},
+		{name: "username with bearer", options: map[string]interface{}{"auth": "bearer", "username": "user"}, errMsg: "'username' requires basic auth"},
+		{name: "password without username", options: map[string]interface{}{"password": "pass"}, errMsg: "'password' requires 'username'"},
+		{name: "invalid header name", options: map[string]interface{}{"headers": map[string]string{"X Tenant": "a"}}, errMsg: "invalid header name"},
+		{name: "missing ca file", options: map[string]interface{}{"tls": map[string]interface{}{"ca_file": "/nonexistent/ca.pem"}}, errMsg: "failed to read ca_file"},
+		{name: "cert without key", options: map[string]interface{}{"tls": map[string]interface{}{"cert_file": "/tmp/client.pem"}}, errMsg: "must be set together"},
+		{name: "retries", options: map[string]interface{}{"retries": 2, "retry_backoff": "200ms", "retry_on": []string{"5xx", "timeout"}}},
+		{name: "negative retries", options: map[string]interface{}{"retries": -1}, errMsg: "retries must not be negative"},
+		{name: "invalid retry backoff", options: map[string]interface{}{"retries": 1, "retry_backoff": "soon"}, errMsg: "invalid retry_backoff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := map[string]interface{}{"url": "http://prometheus:9090", "apps": []map[string]interface{}{app}}
+			for key, value := range tt.options {
+				cfg[key] = value
+			}
+			err := scraper.ValidateConfig(config.Source{Name: "test-prometheus", Type: "prometheus", Config: cfg})
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestAddRequestHeaders(t *testing.T) {
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest("GET", "http://prometheus:9090/api/v1/query", nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("basic auth from username and password", func(t *testing.T) {
+		req := newRequest()
+		addRequestHeaders(req, PrometheusConfig{Auth: "basic", Username: "user", Password: "p@ss:word"})
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "p@ss:word", password)
+	})
+
+	t.Run("custom headers", func(t *testing.T) {
+		req := newRequest()
+		addRequestHeaders(req, PrometheusConfig{Headers: map[string]string{"X-Scope-OrgID": "team-a"}, Auth: "bearer", Token: "secret"})
+		assert.Equal(t, "team-a", req.Header.Get("X-Scope-OrgID"))
+		assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+	})
+
+	t.Run("authentication overrides authorization header", func(t *testing.T) {
+		req := newRequest()
+		addRequestHeaders(req, PrometheusConfig{Headers: map[string]string{"Authorization": "Bearer stale"}, Username: "user"})
+		username, _, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+	})
+}
+
+// writeCertificate writes a self-signed certificate and key to dir and returns their paths
+func writeCertificate(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, name+".pem")
+	keyPath := filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	global := &tls.Config{ServerName: "global.example.com"}
+
+	t.Run("no source settings uses global config", func(t *testing.T) {
+		tlsConfig, err := buildTLSConfig(nil, global)
+		require.NoError(t, err)
+		assert.Same(t, global, tlsConfig)
+	})
+
+	t.Run("source settings extend a copy of the global config", func(t *testing.T) {
+		tlsConfig, err := buildTLSConfig(&PrometheusTLSConfig{InsecureSkipVerify: true}, global)
+		require.NoError(t, err)
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+		assert.Equal(t, "global.example.com", tlsConfig.ServerName)
+		assert.False(t, global.InsecureSkipVerify)
+	})
+
+	t.Run("invalid ca file", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+		_, err := buildTLSConfig(&PrometheusTLSConfig{CAFile: caFile}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "contains no valid certificates")
+	})
+}
+
+func TestPrometheusScraper_ScrapeWithSourceTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientKey := writeCertificate(t, dir, "client")
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "client" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Scope-OrgID") != "team-a" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1234567890,"1"]}]}}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	scraper := NewPrometheusScraper()
+	source := config.Source{
+		Name: "test-prometheus",
+		Config: map[string]interface{}{
+			"url":     server.URL,
+			"headers": map[string]string{"X-Scope-OrgID": "team-a"},
+			"tls": map[string]interface{}{
+				"ca_file":     caFile,
+				"cert_file":   clientCert,
+				"key_file":    clientKey,
+				"server_name": "example.com",
+			},
+			"apps": []map[string]interface{}{{"name": "api", "location": "eu", "metric": "up"}},
+		},
+	}
+	require.NoError(t, scraper.ValidateConfig(source))
+
+	// The global config trusts nothing, so success means the source CA replaced it
+	globalTLSConfig := &tls.Config{RootCAs: x509.NewCertPool()}
+	statuses, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, globalTLSConfig)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "up", statuses[0].Status)
+}
+
+func TestPrometheusScraper_CheckRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1234567890,"1"]}]}}`))
+	}))
+	defer server.Close()
+
+	scraper := NewPrometheusScraper()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	t.Run("without retries", func(t *testing.T) {
+		_, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL}, "up")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status code 503")
+	})
+
+	t.Run("retries a server error", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		samples, err := scraper.check(context.Background(), client, PrometheusConfig{URL: server.URL, Retries: 1, RetryBackoff: "1ms"}, "up")
+		require.NoError(t, err)
+		require.Len(t, samples, 1)
+		assert.Equal(t, 1.0, samples[0].Value)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+}
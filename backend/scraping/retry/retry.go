@@ -0,0 +1,128 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Failure kinds that can be retried, as used in retry_on
+const (
+	ConnectionError = "connection_error"
+	Timeout         = "timeout"
+	ServerError     = "5xx"
+)
+
+// DefaultBackoff is the delay before the first retry when retry_backoff is not set
+const DefaultBackoff = 500 * time.Millisecond
+
+// DefaultRetryOn lists the failure kinds retried when retry_on is not set
+var DefaultRetryOn = []string{ConnectionError, Timeout, ServerError}
+
+// Policy decides whether and when a failed check is attempted again
+type Policy struct {
+	Retries int             // Additional attempts after the first one
+	Backoff time.Duration   // Delay before the first retry, doubled for every further retry
+	RetryOn map[string]bool // Failure kinds that are retried
+}
+
+// StatusError reports an HTTP response whose status code failed the check
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// NewPolicy builds a policy from the retries, retry_backoff and retry_on settings
+func NewPolicy(retries int, backoff string, retryOn []string) (Policy, error) {
+	policy := Policy{Retries: retries, Backoff: DefaultBackoff, RetryOn: make(map[string]bool)}
+	if retries < 0 {
+		return policy, fmt.Errorf("retries must not be negative")
+	}
+
+	if backoff != "" {
+		parsed, err := time.ParseDuration(backoff)
+		if err != nil {
+			return policy, fmt.Errorf("invalid retry_backoff %q: %w", backoff, err)
+		}
+		if parsed <= 0 {
+			return policy, fmt.Errorf("retry_backoff must be positive")
+		}
+		policy.Backoff = parsed
+	}
+
+	if len(retryOn) == 0 {
+		retryOn = DefaultRetryOn
+	}
+	for _, kind := range retryOn {
+		switch kind {
+		case ConnectionError, Timeout, ServerError:
+			policy.RetryOn[kind] = true
+		default:
+			return policy, fmt.Errorf("invalid retry_on %q (must be %s, %s or %s)", kind, ConnectionError, Timeout, ServerError)
+		}
+	}
+	return policy, nil
+}
+
+// Do calls attempt until it succeeds, fails with an error that is not retried, or the retries are used up.
+// No retry is started when the backoff would not end before the context deadline, so the attempts
+// together never take longer than the deadline. Returns the number of attempts and the last error.
+func (p Policy) Do(ctx context.Context, attempt func(ctx context.Context) error) (int, error) {
+	for attempts := 1; ; attempts++ {
+		err := attempt(ctx)
+		if err == nil || attempts > p.Retries || !p.RetryOn[Kind(err)] {
+			return attempts, err
+		}
+
+		wait := p.Backoff << (attempts - 1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return attempts, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, err
+		case <-timer.C:
+		}
+	}
+}
+
+// Kind classifies a check error as one of the retryable failure kinds, or "" when it is none of them
+func Kind(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= 500 && statusErr.StatusCode <= 599 {
+			return ServerError
+		}
+		return ""
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Timeout
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return ConnectionError
+	}
+	return ""
+}
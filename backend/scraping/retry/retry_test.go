@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPolicy(t *testing.T) {
+	policy, err := NewPolicy(0, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBackoff, policy.Backoff)
+	assert.Equal(t, map[string]bool{ConnectionError: true, Timeout: true, ServerError: true}, policy.RetryOn)
+
+	policy, err = NewPolicy(3, "2s", []string{Timeout})
+	require.NoError(t, err)
+	assert.Equal(t, 3, policy.Retries)
+	assert.Equal(t, 2*time.Second, policy.Backoff)
+	assert.Equal(t, map[string]bool{Timeout: true}, policy.RetryOn)
+
+	invalid := []struct {
+		name    string
+		retries int
+		backoff string
+		retryOn []string
+		errMsg  string
+	}{
+		{name: "negative retries", retries: -1, errMsg: "retries must not be negative"},
+		{name: "invalid backoff", retries: 1, backoff: "soon", errMsg: "invalid retry_backoff"},
+		{name: "zero backoff", retries: 1, backoff: "0s", errMsg: "retry_backoff must be positive"},
+		{name: "unknown kind", retries: 1, retryOn: []string{"4xx"}, errMsg: "invalid retry_on"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPolicy(tt.retries, tt.backoff, tt.retryOn)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind string
+	}{
+		{name: "no error", err: nil, kind: ""},
+		{name: "server error", err: &StatusError{StatusCode: 503}, kind: ServerError},
+		{name: "wrapped server error", err: fmt.Errorf("check failed: %w", &StatusError{StatusCode: 500}), kind: ServerError},
+		{name: "client error", err: &StatusError{StatusCode: 404}, kind: ""},
+		{name: "deadline", err: fmt.Errorf("request failed: %w", context.DeadlineExceeded), kind: Timeout},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, kind: ConnectionError},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), kind: ConnectionError},
+		{name: "other error", err: errors.New("success condition failed"), kind: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, Kind(tt.err))
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	serverError := &StatusError{StatusCode: 502, Message: "bad gateway"}
+
+	t.Run("success on first attempt", func(t *testing.T) {
+		policy, _ := NewPolicy(3, "1ms", nil)
+		attempts, err := policy.Do(context.Background(), func(ctx context.Context) error { return nil })
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("retries until success", func(t *testing.T) {
+		policy, _ := NewPolicy(3, "1ms", nil)
+		calls := 0
+		attempts, err := policy.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return serverError
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("returns the last error when retries are used up", func(t *testing.T) {
+		policy, _ := NewPolicy(2, "1ms", nil)
+		attempts, err := policy.Do(context.Background(), func(ctx context.Context) error { return serverError })
+		assert.Equal(t, serverError, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("errors that are not retried", func(t *testing.T) {
+		policy, _ := NewPolicy(2, "1ms", []string{Timeout})
+		attempts, err := policy.Do(context.Background(), func(ctx context.Context) error { return serverError })
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("backoff beyond the deadline stops retries", func(t *testing.T) {
+		policy, _ := NewPolicy(5, "50ms", nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+		defer cancel()
+
+		// Waits 50ms and 100ms; the second backoff would end after the deadline
+		attempts, err := policy.Do(ctx, func(ctx context.Context) error { return serverError })
+		assert.Error(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+}
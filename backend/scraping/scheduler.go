@@ -0,0 +1,189 @@
+package scraping
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"site-availability/config"
+	"site-availability/logging"
+	"site-availability/metrics"
+	"sync"
+	"time"
+)
+
+// runner is the scrape loop of a single source
+type runner struct {
+	spec    runnerSpec
+	scraper Source
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	// scrapeMutex serializes scheduled and on-demand scrapes of the source
+	scrapeMutex sync.Mutex
+}
+
+// runnerSpec holds everything a scrape loop depends on. On reload, a loop is only restarted when its spec changed.
+type runnerSpec struct {
+	Source         config.Source
+	Interval       time.Duration
+	Timeout        time.Duration
+	Jitter         float64
+	MaxParallel    int
+	ServerSettings config.ServerSettings // Only the fields used by scrapers: host_url and labels
+	Locations      []config.Location
+	SiteURLs       []string // Site sources only: directly scraped sites for circular prevention
+}
+
+var (
+	// runners are the running scrape loops by source name. On-demand scrapes hold a read lock,
+	// so a source is not stopped or replaced while one is running.
+	runners      = make(map[string]*runner)
+	runnersMutex sync.RWMutex
+)
+
+// newRunnerSpec builds the spec of a source's scrape loop. The source interval and timeout override the global ones.
+func newRunnerSpec(cfg *config.Config, source config.Source, interval, timeout time.Duration, siteURLs []string) (runnerSpec, error) {
+	spec := runnerSpec{
+		Source:      source,
+		Interval:    interval,
+		Timeout:     timeout,
+		Jitter:      cfg.Scraping.JitterFraction(),
+		MaxParallel: cfg.Scraping.MaxParallel,
+		ServerSettings: config.ServerSettings{
+			HostURL: cfg.ServerSettings.HostURL,
+			Labels:  cfg.ServerSettings.Labels,
+		},
+		Locations: cfg.Locations,
+	}
+	if source.Type == "site" {
+		spec.SiteURLs = siteURLs
+	}
+
+	if source.Interval != "" {
+		parsed, err := time.ParseDuration(source.Interval)
+		if err != nil || parsed <= 0 {
+			return spec, fmt.Errorf("invalid interval %q", source.Interval)
+		}
+		spec.Interval = parsed
+	}
+	if source.Timeout != "" {
+		parsed, err := time.ParseDuration(source.Timeout)
+		if err != nil || parsed <= 0 {
+			return spec, fmt.Errorf("invalid timeout %q", source.Timeout)
+		}
+		spec.Timeout = parsed
+	}
+	return spec, nil
+}
+
+// parseScrapingDurations parses the scraping interval and timeout
+func parseScrapingDurations(cfg *config.Config) (time.Duration, time.Duration, error) {
+	interval, err := time.ParseDuration(cfg.Scraping.Interval)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid scraping interval: %w", err)
+	}
+	timeout, err := time.ParseDuration(cfg.Scraping.Timeout)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid scraping timeout: %w", err)
+	}
+	return interval, timeout, nil
+}
+
+// startRunner starts the scrape loop of a source
+func startRunner(spec runnerSpec, scraper Source) *runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &runner{spec: spec, scraper: scraper, cancel: cancel, done: make(chan struct{})}
+	go r.run(ctx)
+	return r
+}
+
+// run scrapes the source immediately and then every interval. The schedule is shifted by a random
+// offset of up to jitter × interval, so sources with the same interval don't all scrape at the same
+// instant. A run that is due while the previous scrape is still in progress is skipped.
+func (r *runner) run(ctx context.Context) {
+	defer close(r.done)
+
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+	busy := make(chan struct{}, 1)
+
+	trigger := func(initial bool) {
+		if ctx.Err() != nil {
+			// The timer and the cancellation can be ready at once; a stopped loop starts no new scrapes
+			return
+		}
+		select {
+		case busy <- struct{}{}:
+		default:
+			logging.Logger.WithFields(map[string]interface{}{
+				"source":   r.spec.Source.Name,
+				"interval": r.spec.Interval,
+			}).Warn("Previous scrape still running, skipping this run")
+			metrics.RecordSkippedScrape(r.spec.Source.Name)
+			return
+		}
+
+		inFlight.Add(1)
+		go func() {
+			defer func() {
+				<-busy
+				inFlight.Done()
+			}()
+			r.scrapeMutex.Lock()
+			defer r.scrapeMutex.Unlock()
+			_ = scrapeSource(ctx, r.spec, r.scraper, initial)
+		}()
+	}
+
+	// Perform initial scrape immediately
+	trigger(true)
+
+	next := time.Now().Add(r.spec.Interval + jitterOffset(r.spec.Interval, r.spec.Jitter))
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			trigger(false)
+
+			// Keep the cadence; runs missed while the process was paused are not caught up
+			now := time.Now()
+			for !next.After(now) {
+				next = next.Add(r.spec.Interval)
+			}
+			timer.Reset(time.Until(next))
+		}
+	}
+}
+
+// jitterOffset returns a random offset of up to jitter × interval
+func jitterOffset(interval time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Float64() * jitter * float64(interval))
+}
+
+// halt stops the scrape loop and waits for a running scrape to finish
+func (r *runner) halt() {
+	r.cancel()
+	<-r.done
+}
+
+// Stop cancels all scrape loops and waits for running scrapes to finish. Called on server shutdown.
+func Stop() {
+	runnersMutex.Lock()
+	defer runnersMutex.Unlock()
+
+	for _, r := range runners {
+		r.cancel()
+	}
+	for name, r := range runners {
+		<-r.done
+		delete(runners, name)
+	}
+	logging.Logger.Info("All scrapers stopped")
+}
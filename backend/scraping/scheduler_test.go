@@ -0,0 +1,132 @@
+package scraping
+
+import (
+	"context"
+	"crypto/tls"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"site-availability/config"
+	"site-availability/handlers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingScraper holds every scrape until it is released or cancelled
+type blockingScraper struct {
+	calls     atomic.Int32
+	cancelled atomic.Int32
+	release   chan struct{}
+}
+
+func (b *blockingScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	b.calls.Add(1)
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		b.cancelled.Add(1)
+	}
+	return []handlers.AppStatus{{Name: "blocked-app", Location: "loc1", Status: "down", Source: source.Name, OriginURL: serverSettings.HostURL}}, nil, nil
+}
+
+func (b *blockingScraper) ValidateConfig(source config.Source) error {
+	return nil
+}
+
+func TestNewRunnerSpec(t *testing.T) {
+	jitter := 0.5
+	cfg := &config.Config{
+		Scraping:       config.ScrapingSettings{Interval: "60s", Timeout: "10s", MaxParallel: 3, Jitter: &jitter},
+		ServerSettings: config.ServerSettings{HostURL: "https://test-server.com", Port: "8080"},
+	}
+
+	t.Run("global settings", func(t *testing.T) {
+		spec, err := newRunnerSpec(cfg, config.Source{Name: "ping", Type: "http"}, time.Minute, 10*time.Second, nil)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, spec.Interval)
+		assert.Equal(t, 10*time.Second, spec.Timeout)
+		assert.Equal(t, 0.5, spec.Jitter)
+		assert.Empty(t, spec.ServerSettings.Port, "only settings used by scrapers are part of the spec")
+	})
+
+	t.Run("source overrides", func(t *testing.T) {
+		source := config.Source{Name: "fleet", Type: "prometheus", Interval: "5m", Timeout: "45s"}
+		spec, err := newRunnerSpec(cfg, source, time.Minute, 10*time.Second, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, spec.Interval)
+		assert.Equal(t, 45*time.Second, spec.Timeout)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		_, err := newRunnerSpec(cfg, config.Source{Name: "bad", Interval: "often"}, time.Minute, 10*time.Second, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestJitterOffset(t *testing.T) {
+	assert.Zero(t, jitterOffset(time.Minute, 0))
+	for i := 0; i < 100; i++ {
+		offset := jitterOffset(time.Minute, 0.1)
+		assert.GreaterOrEqual(t, offset, time.Duration(0))
+		assert.Less(t, offset, 6*time.Second)
+	}
+}
+
+func TestRunnerSkipsOverlappingRuns(t *testing.T) {
+	setupScrapingTest(t)
+	scraper := &blockingScraper{release: make(chan struct{})}
+	spec := runnerSpec{
+		Source:         config.Source{Name: "slow-source", Type: "mock"},
+		Interval:       10 * time.Millisecond,
+		Timeout:        time.Second,
+		ServerSettings: config.ServerSettings{HostURL: "https://test-server.com"},
+	}
+
+	r := startRunner(spec, scraper)
+	t.Cleanup(r.halt)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), scraper.calls.Load(), "runs due during a scrape are skipped")
+
+	r.halt()
+	assert.Equal(t, int32(1), scraper.cancelled.Load(), "halt cancels the running scrape")
+	assert.True(t, handlers.IsAppStatusCacheEmpty(), "results of a cancelled scrape are discarded")
+}
+
+func TestRunnerScrapesOnSchedule(t *testing.T) {
+	setupScrapingTest(t)
+	scraper := &blockingScraper{release: make(chan struct{})}
+	close(scraper.release)
+	spec := runnerSpec{
+		Source:         config.Source{Name: "fast-source", Type: "mock"},
+		Interval:       20 * time.Millisecond,
+		Jitter:         0.5,
+		ServerSettings: config.ServerSettings{HostURL: "https://test-server.com"},
+	}
+
+	r := startRunner(spec, scraper)
+	t.Cleanup(r.halt)
+	require.Eventually(t, func() bool { return scraper.calls.Load() >= 3 }, 2*time.Second, 5*time.Millisecond)
+	r.halt()
+
+	calls := scraper.calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, scraper.calls.Load(), "no scrapes after halt")
+	assert.False(t, handlers.IsAppStatusCacheEmpty())
+}
+
+func TestStop(t *testing.T) {
+	setupScrapingTest(t)
+	scraper := &blockingScraper{release: make(chan struct{})}
+	spec := runnerSpec{Source: config.Source{Name: "stop-source", Type: "mock"}, Interval: time.Hour}
+
+	runnersMutex.Lock()
+	runners["stop-source"] = startRunner(spec, scraper)
+	runnersMutex.Unlock()
+	require.Eventually(t, func() bool { return scraper.calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	Stop()
+	assert.Equal(t, int32(1), scraper.cancelled.Load())
+	assert.Empty(t, runners)
+}
@@ -1,16 +1,24 @@
 package scraping
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"net/http"
 	"os"
+	"reflect"
 	"site-availability/config"
+	"site-availability/debounce"
 	"site-availability/handlers"
 	"site-availability/logging"
+	"site-availability/scraping/dns"
+	exec_source "site-availability/scraping/exec"
 	http_source "site-availability/scraping/http"
 	"site-availability/scraping/prometheus"
 	"site-availability/scraping/site"
+	"site-availability/scraping/tcp"
+	tls_source "site-availability/scraping/tls"
 	"strings"
 	"time"
 )
@@ -22,12 +30,18 @@ type Source interface {
 	// Scrape performs a single scrape operation for a source with the given timeout and max parallel settings.
 	// It returns the app statuses, locations, and an error if scraping fails.
 	// The serverSettings parameter is passed for label merging purposes.
-	Scrape(source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error)
+	// Checks must stop when ctx is cancelled, which happens when the source is stopped or reloaded.
+	Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error)
 }
 
+// supportedSourceTypes lists the source types handled by newScraper
+var supportedSourceTypes = []string{"prometheus", "site", "http", "tcp", "tls", "dns", "exec"}
+
 var (
 	Scrapers        = make(map[string]Source)
 	globalTLSConfig *tls.Config
+	// debouncer holds raw results back until enough consecutive results confirm a status change
+	debouncer = debounce.New()
 )
 
 // createUnavailableStatuses creates empty app statuses when a scraper fails completely
@@ -97,29 +111,8 @@ func InitScrapers(cfg *config.Config) {
 	directScrapedSites := extractSiteURLs(cfg)
 
 	for _, src := range cfg.Sources {
-		var scraper Source
-		switch src.Type {
-		case "prometheus":
-			scraper = prometheus.NewPrometheusScraper()
-		case "site":
-			siteScraper := site.NewSiteScraper()
-			// Configure site scraper with direct scraped sites for circular prevention
-			siteScraper.SetDirectScrapedSites(directScrapedSites)
-			scraper = siteScraper
-		case "http":
-			scraper = http_source.NewHTTPScraper()
-		default:
-			// Log error and skip this source instead of failing the entire application
-			logging.Logger.WithFields(map[string]interface{}{
-				"source_name":     src.Name,
-				"source_type":     src.Type,
-				"supported_types": []string{"prometheus", "site", "http"},
-			}).Error("Unsupported source type encountered. Skipping this source.")
-			continue
-		}
-
-		// Validate the source configuration
-		if err := scraper.ValidateConfig(src); err != nil {
+		scraper, err := newScraper(src, directScrapedSites)
+		if err != nil {
 			// Log error and skip this source instead of failing the entire application
 			logging.Logger.WithError(err).WithFields(map[string]interface{}{
 				"source_name": src.Name,
@@ -144,6 +137,37 @@ func InitScrapers(cfg *config.Config) {
 	}).Info("Source scraper initialization completed")
 }
 
+// newScraper creates the scraper for a source and validates the source configuration
+func newScraper(src config.Source, directScrapedSites []string) (Source, error) {
+	var scraper Source
+	switch src.Type {
+	case "prometheus":
+		scraper = prometheus.NewPrometheusScraper()
+	case "site":
+		siteScraper := site.NewSiteScraper()
+		// Configure site scraper with direct scraped sites for circular prevention
+		siteScraper.SetDirectScrapedSites(directScrapedSites)
+		scraper = siteScraper
+	case "http":
+		scraper = http_source.NewHTTPScraper()
+	case "tcp":
+		scraper = tcp.NewTCPScraper()
+	case "tls":
+		scraper = tls_source.NewTLSScraper()
+	case "dns":
+		scraper = dns.NewDNSScraper()
+	case "exec":
+		scraper = exec_source.NewExecScraper()
+	default:
+		return nil, fmt.Errorf("unsupported source type %q (supported: %s)", src.Type, strings.Join(supportedSourceTypes, ", "))
+	}
+
+	if err := scraper.ValidateConfig(src); err != nil {
+		return nil, err
+	}
+	return scraper, nil
+}
+
 // extractSiteURLs extracts URLs of all site sources for circular prevention
 func extractSiteURLs(cfg *config.Config) []string {
 	var siteURLs []string
@@ -157,16 +181,17 @@ func extractSiteURLs(cfg *config.Config) []string {
 	return siteURLs
 }
 
+// Start starts the scrape loops of all initialized sources
 func Start(cfg *config.Config) {
-	interval, err := time.ParseDuration(cfg.Scraping.Interval)
+	interval, timeout, err := parseScrapingDurations(cfg)
 	if err != nil {
-		logging.Logger.WithError(err).Fatal("Invalid scraping interval")
+		logging.Logger.WithError(err).Fatal("Failed to start scrapers")
 	}
 
-	timeout, err := time.ParseDuration(cfg.Scraping.Timeout)
-	if err != nil {
-		logging.Logger.WithError(err).Fatal("Invalid scraping timeout")
-	}
+	siteURLs := extractSiteURLs(cfg)
+
+	runnersMutex.Lock()
+	defer runnersMutex.Unlock()
 
 	// Only start scrapers for sources that were successfully initialized
 	for _, source := range cfg.Sources {
@@ -176,67 +201,133 @@ func Start(cfg *config.Config) {
 			continue
 		}
 
-		go func(source config.Source, scraper Source) {
-			ticker := time.NewTicker(interval)
-			defer ticker.Stop()
-
-			// Perform initial scrape immediately
-			var statuses []handlers.AppStatus
-			var locations []handlers.Location
-			var err error
-
-			// Generic scrape call - all source-specific logic is handled internally
-			statuses, locations, err = scraper.Scrape(source, cfg.ServerSettings, timeout, cfg.Scraping.MaxParallel, globalTLSConfig)
-			if err != nil {
-				logging.Logger.WithError(err).WithField("source", source.Name).Error("Initial scraper failed")
-				// Create unavailable statuses for all configured apps when scraper fails completely
-				statuses = createUnavailableStatuses(source)
-				locations = []handlers.Location{} // No locations when scraper fails
-			}
+		spec, err := newRunnerSpec(cfg, source, interval, timeout, siteURLs)
+		if err != nil {
+			logging.Logger.WithError(err).WithField("source_name", source.Name).Error("Skipping scraping for source with invalid schedule")
+			continue
+		}
 
-			// Always update caches, even on scraper failure
-			updateResult := handlers.UpdateAppStatus(source.Name, statuses, source, cfg.ServerSettings)
-			if updateResult.Error != nil {
-				logging.Logger.WithError(updateResult.Error).WithField("source", source.Name).Error("Failed to update app status cache")
-			}
-			handlers.UpdateLocationCache(source.Name, locations, cfg.Locations)
-			logging.Logger.WithFields(map[string]interface{}{
-				"source":         source.Name,
-				"app_count":      len(statuses),
-				"location_count": len(locations),
-				"scraper_error":  err != nil,
-			}).Info("Updated app status and location caches after initial scrape")
-
-			// Continue scraping at intervals
-			for range ticker.C {
-				var statuses []handlers.AppStatus
-				var locations []handlers.Location
-				var err error
-
-				// Generic scrape call - all source-specific logic is handled internally
-				statuses, locations, err = scraper.Scrape(source, cfg.ServerSettings, timeout, cfg.Scraping.MaxParallel, globalTLSConfig)
-				if err != nil {
-					logging.Logger.WithError(err).WithField("source", source.Name).Error("Scraper failed")
-					// Create unavailable statuses for all configured apps when scraper fails completely
-					statuses = createUnavailableStatuses(source)
-					locations = []handlers.Location{} // No locations when scraper fails
-				}
-
-				// Always update caches, even on scraper failure
-				updateResult := handlers.UpdateAppStatus(source.Name, statuses, source, cfg.ServerSettings)
-				if updateResult.Error != nil {
-					logging.Logger.WithError(updateResult.Error).WithField("source", source.Name).Error("Failed to update app status cache")
-				}
-				handlers.UpdateLocationCache(source.Name, locations, cfg.Locations)
-				logging.Logger.WithFields(map[string]interface{}{
-					"source":         source.Name,
-					"app_count":      len(statuses),
-					"location_count": len(locations),
-					"scraper_error":  err != nil,
-				}).Debug("Updated app status and location caches after scrape")
-			}
-		}(source, scraper)
+		if existing, ok := runners[source.Name]; ok {
+			existing.halt()
+		}
+		runners[source.Name] = startRunner(spec, scraper)
 	}
 
 	logging.Logger.WithField("active_scrapers", len(Scrapers)).Info("All scrapers started successfully")
 }
+
+// Reload applies a new configuration to the running scrapers. Scrape loops of removed sources are stopped
+// and their apps dropped, new sources are started and changed sources restarted; unchanged sources keep running.
+// Every source is validated first, so an invalid configuration is rejected without changing anything.
+func Reload(cfg *config.Config) error {
+	interval, timeout, err := parseScrapingDurations(cfg)
+	if err != nil {
+		return err
+	}
+
+	siteURLs := extractSiteURLs(cfg)
+	scrapers := make(map[string]Source, len(cfg.Sources))
+	specs := make(map[string]runnerSpec, len(cfg.Sources))
+	for _, source := range cfg.Sources {
+		scraper, err := newScraper(source, siteURLs)
+		if err != nil {
+			return fmt.Errorf("source %s: %w", source.Name, err)
+		}
+		spec, err := newRunnerSpec(cfg, source, interval, timeout, siteURLs)
+		if err != nil {
+			return fmt.Errorf("source %s: %w", source.Name, err)
+		}
+		scrapers[source.Name] = scraper
+		specs[source.Name] = spec
+	}
+
+	runnersMutex.Lock()
+	defer runnersMutex.Unlock()
+
+	// Stop all loops first so slow scrapes finish in parallel
+	var stopped []*runner
+	var removed []string
+	for name, r := range runners {
+		spec, ok := specs[name]
+		if ok && reflect.DeepEqual(spec, r.spec) {
+			continue
+		}
+		r.cancel()
+		stopped = append(stopped, r)
+		delete(runners, name)
+		if !ok {
+			removed = append(removed, name)
+		}
+	}
+	for _, r := range stopped {
+		<-r.done
+	}
+
+	for _, name := range removed {
+		delete(Scrapers, name)
+		debouncer.Remove(name)
+		handlers.RemoveSource(name)
+	}
+
+	started := 0
+	for _, source := range cfg.Sources {
+		if _, ok := runners[source.Name]; ok {
+			continue
+		}
+		Scrapers[source.Name] = scrapers[source.Name]
+		runners[source.Name] = startRunner(specs[source.Name], scrapers[source.Name])
+		started++
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"started":   started,
+		"stopped":   len(stopped),
+		"removed":   len(removed),
+		"unchanged": len(runners) - started,
+	}).Info("Scrapers reloaded")
+	return nil
+}
+
+// scrapeSource performs one scrape of a source and updates the caches. It returns the scraper error, if any.
+// Results of a scrape cancelled by stopping the source are discarded, since its checks were aborted.
+func scrapeSource(ctx context.Context, spec runnerSpec, scraper Source, initial bool) error {
+	source := spec.Source
+
+	// Generic scrape call - all source-specific logic is handled internally
+	statuses, locations, err := scraper.Scrape(ctx, source, spec.ServerSettings, spec.Timeout, spec.MaxParallel, globalTLSConfig)
+	if ctx.Err() != nil {
+		logging.Logger.WithField("source", source.Name).Debug("Scrape cancelled, discarding results")
+		return ctx.Err()
+	}
+	if err != nil {
+		message := "Scraper failed"
+		if initial {
+			message = "Initial scraper failed"
+		}
+		logging.Logger.WithError(err).WithField("source", source.Name).Error(message)
+		// Create unavailable statuses for all configured apps when scraper fails completely
+		statuses = createUnavailableStatuses(source)
+		locations = []handlers.Location{} // No locations when scraper fails
+	}
+	statuses = debouncer.Apply(source, statuses, time.Now())
+
+	// Always update caches, even on scraper failure
+	updateResult := handlers.UpdateAppStatus(source.Name, statuses, source, spec.ServerSettings)
+	if updateResult.Error != nil {
+		logging.Logger.WithError(updateResult.Error).WithField("source", source.Name).Error("Failed to update app status cache")
+	}
+	handlers.UpdateLocationCache(source.Name, locations, spec.Locations)
+
+	entry := logging.Logger.WithFields(map[string]interface{}{
+		"source":         source.Name,
+		"app_count":      len(statuses),
+		"location_count": len(locations),
+		"scraper_error":  err != nil,
+	})
+	if initial {
+		entry.Info("Updated app status and location caches after initial scrape")
+	} else {
+		entry.Debug("Updated app status and location caches after scrape")
+	}
+	return err
+}
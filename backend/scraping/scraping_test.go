@@ -1,12 +1,14 @@
 package scraping
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"site-availability/config"
+	"site-availability/debounce"
 	"site-availability/handlers"
 	"site-availability/logging"
 	"sync"
@@ -17,19 +19,27 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// setupScrapingTest initializes logging and cleans up global state
-func setupScrapingTest() {
-	// Set log level to panic to suppress error logs during tests
-	os.Setenv("LOG_LEVEL", "panic")
-	_ = logging.Init()
+// setupScrapingTest resets the global state for a test. Scrape loops the test starts are stopped when
+// it ends, so they can't race with the next test.
+func setupScrapingTest(t *testing.T) {
+	t.Helper()
+	resetScrapingState()
+	t.Cleanup(resetScrapingState)
+}
 
-	// Reset global state
+// resetScrapingState stops all scrape loops and resets the scrapers, TLS config, debouncer and caches
+func resetScrapingState() {
+	Stop()
 	Scrapers = make(map[string]Source)
 	globalTLSConfig = nil
+	debouncer = debounce.New()
+	handlers.ResetCacheForTesting()
 }
 
 func TestMain(m *testing.M) {
-	setupScrapingTest()
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
 	m.Run()
 }
 
@@ -43,7 +53,7 @@ type MockScraper struct {
 	calls     int
 }
 
-func (m *MockScraper) Scrape(source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+func (m *MockScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
 	m.mutex.Lock()
 	m.calls++
 	m.mutex.Unlock()
@@ -97,7 +107,7 @@ func (m *MockScraper) GetCallCount() int {
 }
 
 func TestInitCertificateFromPath(t *testing.T) {
-	setupScrapingTest()
+	setupScrapingTest(t)
 
 	t.Run("empty path", func(t *testing.T) {
 		InitCertificateFromPath("")
@@ -170,7 +180,7 @@ m3v3m3v3m3v3m3v3m3v3m3v3m3v3m3v3m3v3m3v3m3v3mw==
 }
 
 func TestGetHTTPClient(t *testing.T) {
-	setupScrapingTest()
+	setupScrapingTest(t)
 
 	t.Run("client without custom TLS config", func(t *testing.T) {
 		globalTLSConfig = nil
@@ -214,10 +224,10 @@ func TestGetHTTPClient(t *testing.T) {
 }
 
 func TestInitScrapers(t *testing.T) {
-	setupScrapingTest()
+	setupScrapingTest(t)
 
 	t.Run("initialize prometheus and site scrapers", func(t *testing.T) {
-		setupScrapingTest() // Reset scrapers for this test
+		setupScrapingTest(t) // Reset scrapers for this test
 		cfg := &config.Config{
 			Sources: []config.Source{
 				{
@@ -255,8 +265,72 @@ func TestInitScrapers(t *testing.T) {
 		assert.NotNil(t, Scrapers["test-site"])
 	})
 
+	t.Run("initialize probe scrapers", func(t *testing.T) {
+		setupScrapingTest(t) // Reset scrapers for this test
+		cfg := &config.Config{
+			Sources: []config.Source{
+				{
+					Name: "test-tcp",
+					Type: "tcp",
+					Config: map[string]interface{}{
+						"apps": []map[string]interface{}{
+							{"name": "postgres", "location": "test-location", "address": "db.internal:5432"},
+						},
+					},
+				},
+				{
+					Name: "test-tls",
+					Type: "tls",
+					Config: map[string]interface{}{
+						"apps": []map[string]interface{}{
+							{"name": "ldaps", "location": "test-location", "address": "ldap.internal:636", "expiry_down_days": 7},
+						},
+					},
+				},
+				{
+					Name: "test-dns",
+					Type: "dns",
+					Config: map[string]interface{}{
+						"apps": []map[string]interface{}{
+							{"name": "api-record", "location": "test-location", "host": "api.internal", "record_type": "A"},
+						},
+					},
+				},
+			},
+		}
+
+		InitScrapers(cfg)
+
+		assert.Len(t, Scrapers, 3)
+		assert.Contains(t, Scrapers, "test-tcp")
+		assert.Contains(t, Scrapers, "test-tls")
+		assert.Contains(t, Scrapers, "test-dns")
+	})
+
+	t.Run("initialize exec scraper", func(t *testing.T) {
+		setupScrapingTest(t) // Reset scrapers for this test
+		cfg := &config.Config{
+			Sources: []config.Source{
+				{
+					Name: "test-exec",
+					Type: "exec",
+					Config: map[string]interface{}{
+						"apps": []map[string]interface{}{
+							{"name": "vendor-check", "location": "test-location", "command": "/usr/local/bin/check_vendor", "args": []string{"--quiet"}},
+						},
+					},
+				},
+			},
+		}
+
+		InitScrapers(cfg)
+
+		assert.Len(t, Scrapers, 1)
+		assert.Contains(t, Scrapers, "test-exec")
+	})
+
 	t.Run("initialize with empty sources", func(t *testing.T) {
-		setupScrapingTest() // Reset scrapers for this test
+		setupScrapingTest(t) // Reset scrapers for this test
 		cfg := &config.Config{
 			Sources: []config.Source{},
 		}
@@ -267,7 +341,7 @@ func TestInitScrapers(t *testing.T) {
 	})
 
 	t.Run("initialize with unknown source type", func(t *testing.T) {
-		setupScrapingTest() // Reset scrapers for this test
+		setupScrapingTest(t) // Reset scrapers for this test
 
 		// This should cause a fatal error due to unsupported source type
 		// We can't easily test fatal in unit tests, so we'll skip this test
@@ -276,7 +350,7 @@ func TestInitScrapers(t *testing.T) {
 	})
 
 	t.Run("initialize multiple sources of same type", func(t *testing.T) {
-		setupScrapingTest() // Reset scrapers for this test
+		setupScrapingTest(t) // Reset scrapers for this test
 		cfg := &config.Config{
 			Sources: []config.Source{
 				{
@@ -319,7 +393,7 @@ func TestInitScrapers(t *testing.T) {
 }
 
 func TestStart(t *testing.T) {
-	setupScrapingTest()
+	setupScrapingTest(t)
 
 	t.Run("invalid scraping interval", func(t *testing.T) {
 		cfg := &config.Config{
@@ -405,7 +479,8 @@ func TestStart(t *testing.T) {
 		}
 
 		// Start scraping in background
-		go Start(cfg)
+		Start(cfg)
+		t.Cleanup(Stop)
 
 		// Wait for a few scrapes to happen
 		time.Sleep(300 * time.Millisecond)
@@ -456,7 +531,8 @@ func TestStart(t *testing.T) {
 		}
 
 		// Start scraping in background
-		go Start(cfg)
+		Start(cfg)
+		t.Cleanup(Stop)
 
 		// Wait for a few scrapes to happen
 		time.Sleep(300 * time.Millisecond)
@@ -530,7 +606,8 @@ func TestStart(t *testing.T) {
 		}
 
 		// Start scraping in background
-		go Start(cfg)
+		Start(cfg)
+		t.Cleanup(Stop)
 
 		// Wait for scrapes to happen
 		time.Sleep(500 * time.Millisecond)
@@ -578,7 +655,8 @@ func TestStart(t *testing.T) {
 			},
 		}
 
-		go Start(cfg)
+		Start(cfg)
+		t.Cleanup(Stop)
 		time.Sleep(200 * time.Millisecond)
 
 		assert.True(t, mockScraper.GetCallCount() >= 1)
@@ -586,7 +664,7 @@ func TestStart(t *testing.T) {
 }
 
 func TestIntegration(t *testing.T) {
-	setupScrapingTest()
+	setupScrapingTest(t)
 
 	t.Run("complete workflow", func(t *testing.T) {
 		// Test the complete workflow: InitScrapers -> Start
@@ -633,7 +711,7 @@ func TestIntegration(t *testing.T) {
 		Scrapers["integration-test"] = mockScraper
 
 		// 4. Start scraping
-		go Start(cfg)
+		Start(cfg)
 
 		// 5. Wait and verify
 		time.Sleep(500 * time.Millisecond)
@@ -651,3 +729,72 @@ func TestIntegration(t *testing.T) {
 		assert.True(t, found)
 	})
 }
+
+func TestReload(t *testing.T) {
+	setupScrapingTest(t)
+
+	execSource := func(name string) config.Source {
+		return config.Source{
+			Name: name,
+			Type: "exec",
+			Config: map[string]interface{}{
+				"apps": []interface{}{
+					map[string]interface{}{"name": name + "-app", "location": "loc1", "command": "true"},
+				},
+			},
+		}
+	}
+	newConfig := func(interval string, sources ...config.Source) *config.Config {
+		return &config.Config{
+			Scraping:       config.ScrapingSettings{Interval: interval, Timeout: "5s", MaxParallel: 2},
+			ServerSettings: config.ServerSettings{HostURL: "https://test-server.com"},
+			Sources:        sources,
+		}
+	}
+	cachedSources := func() map[string]bool {
+		sources := make(map[string]bool)
+		for _, app := range handlers.GetAppStatusCache() {
+			sources[app.Source] = true
+		}
+		return sources
+	}
+	currentRunner := func(name string) *runner {
+		runnersMutex.Lock()
+		defer runnersMutex.Unlock()
+		return runners[name]
+	}
+
+	require.NoError(t, Reload(newConfig("1s", execSource("kept"), execSource("dropped"))))
+	require.Eventually(t, func() bool {
+		sources := cachedSources()
+		return sources["kept"] && sources["dropped"]
+	}, 2*time.Second, 20*time.Millisecond)
+	kept := currentRunner("kept")
+	require.NotNil(t, kept)
+
+	t.Run("adds and removes sources", func(t *testing.T) {
+		require.NoError(t, Reload(newConfig("1s", execSource("kept"), execSource("added"))))
+		require.Eventually(t, func() bool { return cachedSources()["added"] }, 2*time.Second, 20*time.Millisecond)
+
+		assert.False(t, cachedSources()["dropped"])
+		assert.NotContains(t, Scrapers, "dropped")
+		assert.Nil(t, currentRunner("dropped"))
+		assert.Same(t, kept, currentRunner("kept"), "unchanged sources keep running")
+	})
+
+	t.Run("rejects invalid sources", func(t *testing.T) {
+		invalid := config.Source{Name: "broken", Type: "unknown", Config: map[string]interface{}{}}
+		err := Reload(newConfig("1s", execSource("kept"), invalid))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported source type")
+
+		assert.Same(t, kept, currentRunner("kept"))
+		assert.NotNil(t, currentRunner("added"))
+	})
+
+	t.Run("restarts sources when scraping settings change", func(t *testing.T) {
+		require.NoError(t, Reload(newConfig("2s", execSource("kept"))))
+		assert.NotSame(t, kept, currentRunner("kept"))
+		assert.Nil(t, currentRunner("added"))
+	})
+}
@@ -1,6 +1,7 @@
 package site
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"site-availability/config"
 	"site-availability/handlers"
 	"site-availability/logging"
+	"site-availability/maintenance"
 	"time"
 )
 
@@ -53,12 +55,12 @@ func (s *SiteScraper) ValidateConfig(source config.Source) error {
 // Scrape fetches the status of all apps and locations from a remote site using the /sync endpoint.
 // Since site scraping involves a single request, the maxParallel parameter is not used.
 // Circular prevention is handled automatically using the configured directScrapedSites.
-func (s *SiteScraper) Scrape(source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
-	return s.ScrapeWithCircularPrevention(source, serverSettings, timeout, maxParallel, tlsConfig, s.directScrapedSites)
+func (s *SiteScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	return s.ScrapeWithCircularPrevention(ctx, source, serverSettings, timeout, maxParallel, tlsConfig, s.directScrapedSites)
 }
 
 // ScrapeWithCircularPrevention is like Scrape but includes circular scraping prevention logic
-func (s *SiteScraper) ScrapeWithCircularPrevention(source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config, directScrapedSites []string) ([]handlers.AppStatus, []handlers.Location, error) {
+func (s *SiteScraper) ScrapeWithCircularPrevention(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config, directScrapedSites []string) ([]handlers.AppStatus, []handlers.Location, error) {
 	// Decode the source-specific config
 	siteCfg, err := config.DecodeConfig[SiteConfig](source.Config, source.Name)
 	if err != nil {
@@ -74,7 +76,7 @@ func (s *SiteScraper) ScrapeWithCircularPrevention(source config.Source, serverS
 		}
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		// Request creation errors are code issues, return them
 		return nil, nil, fmt.Errorf("failed to create request for site %s: %w", source.Name, err)
@@ -190,6 +192,16 @@ func (s *SiteScraper) ScrapeWithCircularPrevention(source config.Source, serverS
 	// Replace the original apps with filtered apps
 	response.Apps = filteredApps
 
+	// Maintenance windows of the remote site apply to its apps here too. Windows that came from
+	// this server are stale copies, like its apps.
+	windows := make([]maintenance.Window, 0, len(response.Maintenance))
+	for _, window := range response.Maintenance {
+		if window.OriginURL != serverSettings.HostURL {
+			windows = append(windows, window)
+		}
+	}
+	handlers.GetMaintenanceStore().SetSynced(source.Name, windows)
+
 	logging.Logger.WithFields(map[string]interface{}{
 		"source":       source.Name,
 		"total_apps":   len(response.Apps) + appsSkipped,
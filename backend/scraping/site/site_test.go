@@ -1,6 +1,7 @@
 package site
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"site-availability/handlers"
 	"site-availability/labels"
 	"site-availability/logging"
+	"site-availability/maintenance"
 	"testing"
 	"time"
 
@@ -44,11 +46,14 @@ func TestSiteScraper_Scrape(t *testing.T) {
 	t.Run("successful scrape without authentication", func(t *testing.T) {
 		expectedStatuses := []handlers.AppStatus{
 			{
-				Name:      "app1",
-				Location:  "location1",
-				Status:    "up",
-				Source:    "",
-				OriginURL: "http://test-origin.com", // Should be preserved by scraper
+				Name:           "app1",
+				Location:       "location1",
+				Status:         "up",
+				Source:         "",
+				OriginURL:      "http://test-origin.com", // Should be preserved by scraper
+				LastCheck:      time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+				LastChange:     time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC),
+				ResponseTimeMs: 42,
 			},
 			{
 				Name:      "app2",
@@ -88,7 +93,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, results, 2)
 
@@ -96,6 +101,9 @@ func TestSiteScraper_Scrape(t *testing.T) {
 		assert.Equal(t, "app1", results[0].Name)
 		assert.Equal(t, "test-site", results[0].Source)
 		assert.Equal(t, "http://test-origin.com", results[0].OriginURL) // Should preserve original origin URL
+		assert.True(t, expectedStatuses[0].LastCheck.Equal(results[0].LastCheck), "check metadata of the remote site is preserved")
+		assert.True(t, expectedStatuses[0].LastChange.Equal(results[0].LastChange))
+		assert.Equal(t, 42.0, results[0].ResponseTimeMs)
 		assert.Equal(t, "app2", results[1].Name)
 		assert.Equal(t, "test-site", results[1].Source)
 		assert.Equal(t, "http://test-origin.com", results[1].OriginURL) // Should preserve original origin URL
@@ -147,7 +155,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 
@@ -186,7 +194,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 		// Use insecure TLS config for testing
 		tlsConfig := &tls.Config{InsecureSkipVerify: true}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, tlsConfig)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, tlsConfig)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 
@@ -194,6 +202,72 @@ func TestSiteScraper_Scrape(t *testing.T) {
 		assert.Equal(t, "tls-site", results[0].Source)
 	})
 
+	t.Run("degraded status is synced", func(t *testing.T) {
+		degraded := "degraded"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			response := handlers.StatusResponse{
+				Apps: []handlers.AppStatus{
+					{Name: "slow-app", Location: "location1", Status: "degraded", OriginURL: "http://test-origin.com"},
+				},
+				Locations: []handlers.Location{
+					{Name: "location1", Status: &degraded, Up: 2, Degraded: 1},
+				},
+			}
+			_ = json.NewEncoder(w).Encode(response)
+		}))
+		defer server.Close()
+
+		scraper := NewSiteScraper()
+		source := config.Source{Name: "test-site", Type: "site", Config: map[string]interface{}{"url": server.URL}}
+
+		apps, locations, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+		assert.Equal(t, "degraded", apps[0].Status)
+		require.Len(t, locations, 1)
+		assert.Equal(t, "degraded", *locations[0].Status)
+		assert.Equal(t, 1, locations[0].Degraded)
+	})
+
+	t.Run("maintenance windows are synced", func(t *testing.T) {
+		handlers.ResetCacheForTesting()
+		defer handlers.ResetCacheForTesting()
+
+		window := func(name, originURL string) maintenance.Window {
+			return maintenance.Window{
+				MaintenanceWindow: config.MaintenanceWindow{Name: name, Matchers: []string{`name="app1"`}, Schedule: "0 2 * * *", Duration: "1h"},
+				Kind:              maintenance.KindConfig,
+				OriginURL:         originURL,
+			}
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			response := handlers.StatusResponse{
+				Apps: []handlers.AppStatus{
+					{Name: "app1", Location: "location1", Status: "maintenance", Maintenance: "nightly", OriginURL: "http://test-origin.com"},
+				},
+				Maintenance: []maintenance.Window{window("nightly", "http://test-origin.com"), window("own", "https://own-server.com")},
+			}
+			_ = json.NewEncoder(w).Encode(response)
+		}))
+		defer server.Close()
+
+		scraper := NewSiteScraper()
+		source := config.Source{Name: "test-site", Type: "site", Config: map[string]interface{}{"url": server.URL}}
+
+		apps, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{HostURL: "https://own-server.com"}, 5*time.Second, 1, nil)
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+		assert.Equal(t, "maintenance", apps[0].Status)
+		assert.Equal(t, "nightly", apps[0].Maintenance)
+
+		windows := handlers.GetMaintenanceStore().All()
+		require.Len(t, windows, 1, "windows of this server are not synced back")
+		assert.Equal(t, "nightly", windows[0].Name)
+		assert.Equal(t, maintenance.KindSync, windows[0].Kind)
+	})
+
 	t.Run("scrape with empty response", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
@@ -212,7 +286,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		assert.Empty(t, results)
 	})
@@ -227,7 +301,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		assert.Error(t, err)
 		assert.Nil(t, results)
 		assert.Contains(t, err.Error(), "failed to create request")
@@ -244,7 +318,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err) // Network errors are handled gracefully
 		assert.Empty(t, results)
 	})
@@ -277,7 +351,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 					},
 				}
 
-				results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+				results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 				require.NoError(t, err) // HTTP errors are handled gracefully
 				assert.Empty(t, results)
 			})
@@ -301,7 +375,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err) // JSON parsing errors are handled gracefully
 		assert.Empty(t, results)
 	})
@@ -324,7 +398,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err) // JSON structure errors are handled gracefully
 		assert.Empty(t, results)
 	})
@@ -347,7 +421,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 		}
 
 		// Use very short timeout
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 10*time.Millisecond, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 10*time.Millisecond, 1, nil)
 		require.NoError(t, err) // Timeout errors are handled gracefully
 		assert.Empty(t, results)
 	})
@@ -375,7 +449,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 		}
 
 		// Try with different maxParallel values - should always make only 1 request
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 100, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 100, nil)
 		require.NoError(t, err)
 		assert.Empty(t, results)
 		assert.Equal(t, 1, requestCount)
@@ -400,7 +474,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		assert.Empty(t, results)
 	})
@@ -430,7 +504,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		assert.Empty(t, results)
 	})
@@ -470,7 +544,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, results, 2)
 
@@ -527,7 +601,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, config.ServerSettings{}, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, config.ServerSettings{}, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 		assert.Equal(t, "special-token-site", results[0].Source)
@@ -582,7 +656,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 			},
 		}
 
-		results, _, err := scraper.Scrape(source, serverSettings, 5*time.Second, 1, nil)
+		results, _, err := scraper.Scrape(context.Background(), source, serverSettings, 5*time.Second, 1, nil)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 
@@ -679,6 +753,7 @@ func TestSiteScraper_Scrape(t *testing.T) {
 		}
 
 		results, _, err := scraper.ScrapeWithCircularPrevention(
+			context.Background(),
 			source,
 			serverSettings,
 			5*time.Second,
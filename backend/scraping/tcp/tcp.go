@@ -0,0 +1,205 @@
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/labels"
+	"site-availability/logging"
+	"sync"
+	"time"
+)
+
+// maxBannerBytes limits how much of the response is read while waiting for the expected banner
+const maxBannerBytes = 64 * 1024
+
+// TCPConfig represents the configuration for TCP sources
+type TCPConfig struct {
+	Apps []TCPApp `yaml:"apps"`
+}
+
+// TCPApp represents an app configuration for TCP monitoring
+type TCPApp struct {
+	Name     string `yaml:"name"`
+	Location string `yaml:"location"`
+	Address  string `yaml:"address"` // host:port
+
+	// Optional request/response check after connecting
+	Send   string `yaml:"send"`   // Payload written after the connection is established
+	Expect string `yaml:"expect"` // Text the response (or banner) must contain
+
+	Timeout string            `yaml:"timeout"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// TCPScraper implements the Scraper interface for TCP sources
+type TCPScraper struct {
+}
+
+func NewTCPScraper() *TCPScraper {
+	return &TCPScraper{}
+}
+
+// ValidateConfig validates the TCP-specific configuration
+func (t *TCPScraper) ValidateConfig(source config.Source) error {
+	tcpCfg, err := config.DecodeConfig[TCPConfig](source.Config, source.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(tcpCfg.Apps) == 0 {
+		return fmt.Errorf("tcp source %s: at least one app is required", source.Name)
+	}
+
+	appNames := make(map[string]bool)
+	for _, app := range tcpCfg.Apps {
+		if app.Name == "" {
+			return fmt.Errorf("tcp source %s: app name is required", source.Name)
+		}
+		if _, exists := appNames[app.Name]; exists {
+			return fmt.Errorf("tcp source %s: duplicate app name %q", source.Name, app.Name)
+		}
+		appNames[app.Name] = true
+
+		if app.Location == "" {
+			return fmt.Errorf("tcp source %s: app %s missing 'location'", source.Name, app.Name)
+		}
+		if app.Address == "" {
+			return fmt.Errorf("tcp source %s: app %s missing 'address'", source.Name, app.Name)
+		}
+		if _, _, err := net.SplitHostPort(app.Address); err != nil {
+			return fmt.Errorf("tcp source %s: app %s invalid address %q: %w", source.Name, app.Name, app.Address, err)
+		}
+		if app.Timeout != "" {
+			if _, err := time.ParseDuration(app.Timeout); err != nil {
+				return fmt.Errorf("tcp source %s: app %s invalid timeout %q: %w", source.Name, app.Name, app.Timeout, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Scrape checks every configured TCP endpoint
+func (t *TCPScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	tcpCfg, err := config.DecodeConfig[TCPConfig](source.Config, source.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]handlers.AppStatus, len(tcpCfg.Apps))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for i, app := range tcpCfg.Apps {
+		sem <- struct{}{} // Acquire slot
+		wg.Add(1)
+		go func(i int, app TCPApp) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			status := "up"
+			start := time.Now()
+			err := t.check(ctx, app, timeout)
+			duration := time.Since(start)
+			if err != nil {
+				logging.Logger.WithFields(map[string]interface{}{
+					"app":     app.Name,
+					"source":  source.Name,
+					"address": app.Address,
+					"error":   err.Error(),
+				}).Warn("TCP check failed - marking as down")
+				status = "down"
+			}
+
+			var appLabels []labels.Label
+			for key, value := range app.Labels {
+				appLabels = append(appLabels, labels.Label{Key: key, Value: value})
+			}
+
+			results[i] = handlers.AppStatus{
+				Name:      app.Name,
+				Location:  app.Location,
+				Status:    status,
+				Source:    source.Name,
+				OriginURL: serverSettings.HostURL,
+				Labels:    appLabels,
+			}
+			results[i].RecordCheck(start, duration, err)
+		}(i, app)
+	}
+	wg.Wait()
+
+	// TCP scraper returns nil for locations since it only provides app statuses
+	return results, nil, nil
+}
+
+// check connects to the app address and validates the optional banner
+func (t *TCPScraper) check(ctx context.Context, app TCPApp, timeout time.Duration) error {
+	appTimeout := timeout
+	if app.Timeout != "" {
+		if parsedTimeout, err := time.ParseDuration(app.Timeout); err == nil {
+			appTimeout = parsedTimeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, appTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", app.Address)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer conn.Close()
+
+	// Abort reads and writes when the scrape is cancelled
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := conn.SetDeadline(time.Now().Add(appTimeout)); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	if app.Send != "" {
+		if _, err := conn.Write([]byte(app.Send)); err != nil {
+			return fmt.Errorf("failed to send payload: %w", err)
+		}
+	}
+
+	if app.Expect == "" {
+		return nil
+	}
+
+	// Read until the expected text shows up, the server closes the connection or the deadline hits
+	expected := []byte(app.Expect)
+	var received []byte
+	buf := make([]byte, 4096)
+	for len(received) < maxBannerBytes {
+		n, err := conn.Read(buf)
+		received = append(received, buf[:n]...)
+		if bytes.Contains(received, expected) {
+			logging.Logger.WithFields(map[string]interface{}{
+				"app":     app.Name,
+				"address": app.Address,
+			}).Debug("TCP check received expected response")
+			return nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("expected response %q not received: %w", app.Expect, err)
+		}
+	}
+
+	return fmt.Errorf("expected response %q not received", app.Expect)
+}
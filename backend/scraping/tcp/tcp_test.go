@@ -0,0 +1,185 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"site-availability/config"
+	"site-availability/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+}
+
+// startTCPServer starts a line based server that greets with a banner and answers PING with PONG
+func startTCPServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_, _ = conn.Write([]byte("220 test-server ready\r\n"))
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == "PING\r\n" {
+						_, _ = conn.Write([]byte("+PONG\r\n"))
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// closedAddress returns an address nothing is listening on
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func TestValidateConfig(t *testing.T) {
+	scraper := NewTCPScraper()
+
+	tests := []struct {
+		name   string
+		apps   []map[string]interface{}
+		errMsg string
+	}{
+		{
+			name: "valid config",
+			apps: []map[string]interface{}{
+				{"name": "redis", "location": "eu", "address": "redis:6379", "send": "PING\r\n", "expect": "PONG"},
+			},
+		},
+		{name: "no apps", apps: []map[string]interface{}{}, errMsg: "at least one app is required"},
+		{
+			name:   "missing name",
+			apps:   []map[string]interface{}{{"location": "eu", "address": "redis:6379"}},
+			errMsg: "app name is required",
+		},
+		{
+			name: "duplicate name",
+			apps: []map[string]interface{}{
+				{"name": "redis", "location": "eu", "address": "redis:6379"},
+				{"name": "redis", "location": "us", "address": "redis:6379"},
+			},
+			errMsg: "duplicate app name",
+		},
+		{
+			name:   "missing location",
+			apps:   []map[string]interface{}{{"name": "redis", "address": "redis:6379"}},
+			errMsg: "missing 'location'",
+		},
+		{
+			name:   "missing port",
+			apps:   []map[string]interface{}{{"name": "redis", "location": "eu", "address": "redis"}},
+			errMsg: "invalid address",
+		},
+		{
+			name:   "invalid timeout",
+			apps:   []map[string]interface{}{{"name": "redis", "location": "eu", "address": "redis:6379", "timeout": "soon"}},
+			errMsg: "invalid timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scraper.ValidateConfig(config.Source{
+				Name:   "test-tcp",
+				Type:   "tcp",
+				Config: map[string]interface{}{"apps": tt.apps},
+			})
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scraper := NewTCPScraper()
+	address := startTCPServer(t)
+
+	tests := []struct {
+		name      string
+		app       TCPApp
+		expectErr bool
+	}{
+		{name: "connect only", app: TCPApp{Address: address}},
+		{name: "banner", app: TCPApp{Address: address, Expect: "220 test-server"}},
+		{name: "send and expect", app: TCPApp{Address: address, Send: "PING\r\n", Expect: "+PONG"}},
+		{name: "unexpected banner", app: TCPApp{Address: address, Expect: "SSH-2.0", Timeout: "200ms"}, expectErr: true},
+		{name: "connection refused", app: TCPApp{Address: closedAddress(t)}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scraper.check(context.Background(), tt.app, 2*time.Second)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestScrape(t *testing.T) {
+	scraper := NewTCPScraper()
+	address := startTCPServer(t)
+
+	source := config.Source{
+		Name: "test-tcp",
+		Type: "tcp",
+		Config: map[string]interface{}{
+			"apps": []map[string]interface{}{
+				{"name": "healthy", "location": "eu", "address": address, "expect": "ready", "labels": map[string]string{"tier": "db"}},
+				{"name": "offline", "location": "us", "address": closedAddress(t)},
+			},
+		},
+	}
+	serverSettings := config.ServerSettings{HostURL: "http://localhost:8080"}
+
+	statuses, locations, err := scraper.Scrape(context.Background(), source, serverSettings, 2*time.Second, 2, nil)
+	require.NoError(t, err)
+	assert.Nil(t, locations)
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, "healthy", statuses[0].Name)
+	assert.Equal(t, "up", statuses[0].Status)
+	assert.Equal(t, "eu", statuses[0].Location)
+	assert.Equal(t, "test-tcp", statuses[0].Source)
+	assert.Equal(t, "http://localhost:8080", statuses[0].OriginURL)
+	require.Len(t, statuses[0].Labels, 1)
+	assert.Equal(t, "tier", statuses[0].Labels[0].Key)
+
+	assert.Equal(t, "offline", statuses[1].Name)
+	assert.Equal(t, "down", statuses[1].Status)
+}
@@ -0,0 +1,236 @@
+package tls_source
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"site-availability/config"
+	"site-availability/handlers"
+	"site-availability/labels"
+	"site-availability/logging"
+	"sync"
+	"time"
+)
+
+// TLSConfig represents the configuration for TLS certificate sources
+type TLSConfig struct {
+	Apps []TLSApp `yaml:"apps"`
+}
+
+// TLSApp represents an app configuration for TLS certificate monitoring
+type TLSApp struct {
+	Name       string `yaml:"name"`
+	Location   string `yaml:"location"`
+	Address    string `yaml:"address"`     // host:port
+	ServerName string `yaml:"server_name"` // SNI and verification name, defaults to the address host
+
+	// The app is down when the certificate expires within this many days (0 = only when expired)
+	ExpiryDownDays int `yaml:"expiry_down_days"`
+	// The app is degraded when the certificate expires within this many days (0 = disabled)
+	ExpiryDegradedDays int `yaml:"expiry_degraded_days"`
+
+	Timeout string            `yaml:"timeout"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// CertificateInfo describes the leaf certificate presented by a server
+type CertificateInfo struct {
+	Subject  string
+	NotAfter time.Time
+	DaysLeft float64
+}
+
+// TLSScraper implements the Scraper interface for TLS certificate sources
+type TLSScraper struct {
+}
+
+func NewTLSScraper() *TLSScraper {
+	return &TLSScraper{}
+}
+
+// ValidateConfig validates the TLS-specific configuration
+func (t *TLSScraper) ValidateConfig(source config.Source) error {
+	tlsCfg, err := config.DecodeConfig[TLSConfig](source.Config, source.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(tlsCfg.Apps) == 0 {
+		return fmt.Errorf("tls source %s: at least one app is required", source.Name)
+	}
+
+	appNames := make(map[string]bool)
+	for _, app := range tlsCfg.Apps {
+		if app.Name == "" {
+			return fmt.Errorf("tls source %s: app name is required", source.Name)
+		}
+		if _, exists := appNames[app.Name]; exists {
+			return fmt.Errorf("tls source %s: duplicate app name %q", source.Name, app.Name)
+		}
+		appNames[app.Name] = true
+
+		if app.Location == "" {
+			return fmt.Errorf("tls source %s: app %s missing 'location'", source.Name, app.Name)
+		}
+		if app.Address == "" {
+			return fmt.Errorf("tls source %s: app %s missing 'address'", source.Name, app.Name)
+		}
+		if _, _, err := net.SplitHostPort(app.Address); err != nil {
+			return fmt.Errorf("tls source %s: app %s invalid address %q: %w", source.Name, app.Name, app.Address, err)
+		}
+		if app.ExpiryDownDays < 0 {
+			return fmt.Errorf("tls source %s: app %s expiry_down_days must not be negative", source.Name, app.Name)
+		}
+		if app.ExpiryDegradedDays < 0 {
+			return fmt.Errorf("tls source %s: app %s expiry_degraded_days must not be negative", source.Name, app.Name)
+		}
+		if app.ExpiryDegradedDays > 0 && app.ExpiryDegradedDays <= app.ExpiryDownDays {
+			return fmt.Errorf("tls source %s: app %s expiry_degraded_days must be greater than expiry_down_days", source.Name, app.Name)
+		}
+		if app.Timeout != "" {
+			if _, err := time.ParseDuration(app.Timeout); err != nil {
+				return fmt.Errorf("tls source %s: app %s invalid timeout %q: %w", source.Name, app.Name, app.Timeout, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Scrape performs a TLS handshake with every configured endpoint and checks the certificate
+func (t *TLSScraper) Scrape(ctx context.Context, source config.Source, serverSettings config.ServerSettings, timeout time.Duration, maxParallel int, tlsConfig *tls.Config) ([]handlers.AppStatus, []handlers.Location, error) {
+	tlsCfg, err := config.DecodeConfig[TLSConfig](source.Config, source.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]handlers.AppStatus, len(tlsCfg.Apps))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for i, app := range tlsCfg.Apps {
+		sem <- struct{}{} // Acquire slot
+		wg.Add(1)
+		go func(i int, app TLSApp) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			status, err := t.check(ctx, app, timeout, tlsConfig)
+			duration := time.Since(start)
+			if err != nil {
+				logging.Logger.WithFields(map[string]interface{}{
+					"app":     app.Name,
+					"source":  source.Name,
+					"address": app.Address,
+					"error":   err.Error(),
+				}).Warn("TLS check failed - marking as down")
+			}
+
+			var appLabels []labels.Label
+			for key, value := range app.Labels {
+				appLabels = append(appLabels, labels.Label{Key: key, Value: value})
+			}
+
+			results[i] = handlers.AppStatus{
+				Name:      app.Name,
+				Location:  app.Location,
+				Status:    status,
+				Source:    source.Name,
+				OriginURL: serverSettings.HostURL,
+				Labels:    appLabels,
+			}
+			results[i].RecordCheck(start, duration, err)
+		}(i, app)
+	}
+	wg.Wait()
+
+	// TLS scraper returns nil for locations since it only provides app statuses
+	return results, nil, nil
+}
+
+// check performs the handshake and evaluates the certificate expiry.
+// Returns down with an error when the handshake fails or the certificate expires within expiry_down_days,
+// and degraded when it expires within expiry_degraded_days.
+func (t *TLSScraper) check(ctx context.Context, app TLSApp, timeout time.Duration, tlsConfig *tls.Config) (string, error) {
+	cert, err := t.fetchCertificate(ctx, app, timeout, tlsConfig)
+	if err != nil {
+		return "down", err
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"app":       app.Name,
+		"subject":   cert.Subject,
+		"not_after": cert.NotAfter,
+		"days_left": int(cert.DaysLeft),
+	}).Debug("TLS certificate retrieved")
+
+	if cert.DaysLeft < float64(app.ExpiryDownDays) || cert.DaysLeft <= 0 {
+		return "down", fmt.Errorf("certificate %s expires on %s (%.1f days left)", cert.Subject, cert.NotAfter.Format(time.RFC3339), cert.DaysLeft)
+	}
+
+	if cert.DaysLeft < float64(app.ExpiryDegradedDays) {
+		logging.Logger.WithFields(map[string]interface{}{
+			"app":       app.Name,
+			"subject":   cert.Subject,
+			"not_after": cert.NotAfter,
+			"days_left": int(cert.DaysLeft),
+		}).Info("TLS certificate expires soon - marking as degraded")
+		return "degraded", nil
+	}
+
+	return "up", nil
+}
+
+// fetchCertificate connects to the app and returns its verified leaf certificate.
+// Handshake fails when the chain is not trusted or does not match the server name.
+func (t *TLSScraper) fetchCertificate(ctx context.Context, app TLSApp, timeout time.Duration, tlsConfig *tls.Config) (*CertificateInfo, error) {
+	appTimeout := timeout
+	if app.Timeout != "" {
+		if parsedTimeout, err := time.ParseDuration(app.Timeout); err == nil {
+			appTimeout = parsedTimeout
+		}
+	}
+
+	serverName := app.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(app.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", app.Address, err)
+		}
+		serverName = host
+	}
+
+	// Use the global TLS config (custom CAs) as base
+	clientConfig := &tls.Config{}
+	if tlsConfig != nil {
+		clientConfig = tlsConfig.Clone()
+	}
+	clientConfig.ServerName = serverName
+	clientConfig.InsecureSkipVerify = false
+
+	ctx, cancel := context.WithTimeout(ctx, appTimeout)
+	defer cancel()
+
+	dialer := &tls.Dialer{Config: clientConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", app.Address)
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	defer conn.Close()
+
+	certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("server presented no certificate")
+	}
+
+	leaf := certificates[0]
+	return &CertificateInfo{
+		Subject:  leaf.Subject.CommonName,
+		NotAfter: leaf.NotAfter,
+		DaysLeft: time.Until(leaf.NotAfter).Hours() / 24,
+	}, nil
+}
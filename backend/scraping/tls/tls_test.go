@@ -0,0 +1,180 @@
+package tls_source
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"site-availability/config"
+	"site-availability/logging"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Set log level to panic to suppress error logs during tests
+	os.Setenv("LOG_LEVEL", "panic")
+	_ = logging.Init()
+}
+
+// newTLSServer starts a TLS server and returns its address and a client config trusting its certificate
+func newTLSServer(t *testing.T) (string, *tls.Config) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	t.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	return strings.TrimPrefix(server.URL, "https://"), &tls.Config{RootCAs: pool}
+}
+
+func TestValidateConfig(t *testing.T) {
+	scraper := NewTLSScraper()
+
+	tests := []struct {
+		name   string
+		apps   []map[string]interface{}
+		errMsg string
+	}{
+		{
+			name: "valid config",
+			apps: []map[string]interface{}{
+				{"name": "api", "location": "eu", "address": "api.example.com:443", "expiry_down_days": 7},
+			},
+		},
+		{name: "no apps", apps: []map[string]interface{}{}, errMsg: "at least one app is required"},
+		{
+			name:   "missing address",
+			apps:   []map[string]interface{}{{"name": "api", "location": "eu"}},
+			errMsg: "missing 'address'",
+		},
+		{
+			name:   "missing port",
+			apps:   []map[string]interface{}{{"name": "api", "location": "eu", "address": "api.example.com"}},
+			errMsg: "invalid address",
+		},
+		{
+			name:   "negative expiry days",
+			apps:   []map[string]interface{}{{"name": "api", "location": "eu", "address": "api.example.com:443", "expiry_down_days": -1}},
+			errMsg: "expiry_down_days must not be negative",
+		},
+		{
+			name:   "degraded days not above down days",
+			apps:   []map[string]interface{}{{"name": "api", "location": "eu", "address": "api.example.com:443", "expiry_down_days": 7, "expiry_degraded_days": 7}},
+			errMsg: "expiry_degraded_days must be greater than expiry_down_days",
+		},
+		{
+			name: "duplicate name",
+			apps: []map[string]interface{}{
+				{"name": "api", "location": "eu", "address": "api.example.com:443"},
+				{"name": "api", "location": "us", "address": "api.example.com:443"},
+			},
+			errMsg: "duplicate app name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scraper.ValidateConfig(config.Source{
+				Name:   "test-tls",
+				Type:   "tls",
+				Config: map[string]interface{}{"apps": tt.apps},
+			})
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scraper := NewTLSScraper()
+	address, trusted := newTLSServer(t)
+
+	t.Run("trusted certificate", func(t *testing.T) {
+		status, err := scraper.check(context.Background(), TLSApp{Name: "api", Address: address, ExpiryDownDays: 7}, 5*time.Second, trusted)
+		require.NoError(t, err)
+		assert.Equal(t, "up", status)
+	})
+
+	t.Run("server name from config", func(t *testing.T) {
+		status, err := scraper.check(context.Background(), TLSApp{Name: "api", Address: address, ServerName: "example.com"}, 5*time.Second, trusted)
+		require.NoError(t, err)
+		assert.Equal(t, "up", status)
+	})
+
+	t.Run("server name mismatch", func(t *testing.T) {
+		status, err := scraper.check(context.Background(), TLSApp{Name: "api", Address: address, ServerName: "other.test"}, 5*time.Second, trusted)
+		assert.Error(t, err)
+		assert.Equal(t, "down", status)
+	})
+
+	t.Run("expires within threshold", func(t *testing.T) {
+		// The test certificate is valid for decades, so use a threshold beyond its lifetime
+		status, err := scraper.check(context.Background(), TLSApp{Name: "api", Address: address, ExpiryDownDays: 100000}, 5*time.Second, trusted)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expires on")
+		assert.Equal(t, "down", status)
+	})
+
+	t.Run("expires within degraded threshold", func(t *testing.T) {
+		status, err := scraper.check(context.Background(), TLSApp{Name: "api", Address: address, ExpiryDownDays: 1, ExpiryDegradedDays: 100000}, 5*time.Second, trusted)
+		require.NoError(t, err)
+		assert.Equal(t, "degraded", status)
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		status, err := scraper.check(context.Background(), TLSApp{Name: "api", Address: address}, 5*time.Second, &tls.Config{RootCAs: x509.NewCertPool()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TLS handshake failed")
+		assert.Equal(t, "down", status)
+	})
+}
+
+func TestFetchCertificate(t *testing.T) {
+	scraper := NewTLSScraper()
+	address, trusted := newTLSServer(t)
+
+	cert, err := scraper.fetchCertificate(context.Background(), TLSApp{Name: "api", Address: address}, 5*time.Second, trusted)
+	require.NoError(t, err)
+	assert.True(t, cert.NotAfter.After(time.Now()))
+	assert.Greater(t, cert.DaysLeft, 0.0)
+}
+
+func TestScrape(t *testing.T) {
+	scraper := NewTLSScraper()
+	address, trusted := newTLSServer(t)
+
+	source := config.Source{
+		Name: "test-tls",
+		Type: "tls",
+		Config: map[string]interface{}{
+			"apps": []map[string]interface{}{
+				{"name": "valid", "location": "eu", "address": address, "labels": map[string]string{"team": "edge"}},
+				{"name": "expiring", "location": "us", "address": address, "expiry_down_days": 100000},
+			},
+		},
+	}
+	serverSettings := config.ServerSettings{HostURL: "http://localhost:8080"}
+
+	statuses, locations, err := scraper.Scrape(context.Background(), source, serverSettings, 5*time.Second, 2, trusted)
+	require.NoError(t, err)
+	assert.Nil(t, locations)
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, "up", statuses[0].Status)
+	assert.Equal(t, "test-tls", statuses[0].Source)
+	require.Len(t, statuses[0].Labels, 1)
+	assert.Equal(t, "down", statuses[1].Status)
+}
@@ -6,23 +6,47 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"site-availability/authentication/apitoken"
 	authHandlers "site-availability/authentication/handlers"
+	"site-availability/authentication/lockout"
 	"site-availability/authentication/middleware"
 	"site-availability/authentication/session"
 	"site-availability/config"
 	appHandlers "site-availability/handlers"
+	"site-availability/history"
 	"site-availability/logging"
+	"site-availability/maintenance"
 	"site-availability/metrics"
+	"site-availability/notifications"
 	"site-availability/scraping"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
 
+// maintenanceRefreshInterval is how often maintenance windows are applied to the cached apps
+const maintenanceRefreshInterval = 30 * time.Second
+
+// slaRefreshInterval is how often the SLA metrics are recalculated from the status history
+const slaRefreshInterval = time.Minute
+
 // Server represents the web server instance
 type Server struct {
+	mux            *http.ServeMux
+	sessionManager *session.Manager
+	tokens         *apitoken.Store
+	loginLockout   *lockout.Tracker
+	runtime        atomic.Pointer[runtime]
+	notifier       atomic.Pointer[notifications.Notifier]
+	reloadMutex    sync.Mutex
+}
+
+// runtime holds the configuration and the components built from it.
+// A reload replaces it as a whole, so every request sees a single consistent configuration.
+type runtime struct {
 	config                *config.Config
-	mux                   *http.ServeMux
-	sessionManager        *session.Manager
 	authHandlers          *authHandlers.AuthHandlers
 	authMiddleware        *middleware.AuthMiddleware
 	authzMiddleware       *middleware.AuthzMiddleware
@@ -31,91 +55,365 @@ type Server struct {
 
 // NewServer creates a new server instance
 func NewServer(cfg *config.Config) *Server {
-	return &Server{
-		config: cfg,
-		mux:    http.NewServeMux(),
-	}
+	s := &Server{mux: http.NewServeMux()}
+	s.runtime.Store(&runtime{config: cfg})
+	return s
+}
+
+// current returns the runtime of the active configuration
+func (s *Server) current() *runtime {
+	return s.runtime.Load()
 }
 
 // Start initializes and starts the server
 func (s *Server) Start() error {
+	cfg := s.current().config
+
 	// Initialize custom CA certificates if configured
-	scraping.InitCertificateFromPath(s.config.ServerSettings.CustomCAPath)
-	scraping.InitScrapers(s.config)
+	scraping.InitCertificateFromPath(cfg.ServerSettings.CustomCAPath)
+	scraping.InitScrapers(cfg)
 	metrics.Init()
-	scraping.Start(s.config)
+	s.initHistory()
+	s.initNotifications()
+	s.initMaintenance()
+	scraping.Start(cfg)
+	appHandlers.SetChecker(scraping.OnDemand{})
 
 	// Initialize authentication components
 	s.initAuthentication()
 
 	s.setupRoutes()
-	return s.startServer(s.config.ServerSettings.Port)
+	return s.startServer(cfg.ServerSettings.Port)
+}
+
+// initHistory initializes the status history store used by the app status cache
+func (s *Server) initHistory() {
+	historySettings := s.current().config.Scraping.History
+	if !historySettings.IsEnabled() {
+		logging.Logger.Info("Status history is disabled")
+		appHandlers.SetHistoryStore(nil)
+		return
+	}
+
+	retention := history.DefaultRetention
+	if historySettings.Retention != "" {
+		parsed, err := time.ParseDuration(historySettings.Retention)
+		if err != nil {
+			logging.Logger.WithError(err).Warn("Invalid history retention, using default")
+		} else {
+			retention = parsed
+		}
+	}
+
+	if historySettings.Path == "" {
+		logging.Logger.WithField("retention", retention).Info("Status history kept in memory only (no history path configured)")
+		appHandlers.SetHistoryStore(history.NewMemoryStore(retention))
+		return
+	}
+
+	store, err := history.NewFileStore(historySettings.Path, retention)
+	if err != nil {
+		logging.Logger.WithError(err).Fatal("Failed to initialize status history store")
+	}
+	appHandlers.SetHistoryStore(store)
+}
+
+// initMaintenance loads the maintenance windows defined in the config
+func (s *Server) initMaintenance() {
+	cfg := s.current().config
+	if err := appHandlers.GetMaintenanceStore().Configure(cfg.Maintenance.Windows, cfg.ServerSettings.HostURL); err != nil {
+		logging.Logger.WithError(err).Fatal("Failed to initialize maintenance windows")
+	}
+	logging.Logger.WithField("windows", len(cfg.Maintenance.Windows)).Info("Maintenance windows initialized")
+}
+
+// refreshMaintenance applies the maintenance windows periodically, so they start and end between scrapes
+func (s *Server) refreshMaintenance(stop <-chan struct{}) {
+	ticker := time.NewTicker(maintenanceRefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				appHandlers.RefreshMaintenance()
+			}
+		}
+	}()
+}
+
+// refreshSLAMetrics recalculates the SLA metrics periodically, keeping history queries out of /metrics scrapes
+func (s *Server) refreshSLAMetrics(stop <-chan struct{}) {
+	metrics.UpdateSLAMetrics()
+	ticker := time.NewTicker(slaRefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				metrics.UpdateSLAMetrics()
+			}
+		}
+	}()
+}
+
+// initNotifications sends app status changes to the configured receivers.
+// The transition listener forwards to the current notifier, so a reload can replace it.
+func (s *Server) initNotifications() {
+	notifier, err := newNotifier(s.current().config)
+	if err != nil {
+		logging.Logger.WithError(err).Fatal("Failed to initialize notifications")
+	}
+	s.notifier.Store(notifier)
+	appHandlers.AddTransitionListener(s.notifyTransitions)
+}
+
+// newNotifier creates the notifier for a configuration, or nil when no routes are configured
+func newNotifier(cfg *config.Config) (*notifications.Notifier, error) {
+	if len(cfg.Notifications.Routes) == 0 {
+		logging.Logger.Info("No notification routes configured")
+		return nil, nil
+	}
+
+	notifier, err := notifications.New(cfg.Notifications, cfg.ServerSettings.HostURL)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"receivers": len(cfg.Notifications.Receivers),
+		"routes":    len(cfg.Notifications.Routes),
+	}).Info("Notifications initialized")
+	return notifier, nil
+}
+
+// reconfigureNotifications applies the notifier of a reloaded configuration. A running notifier is
+// reconfigured in place, so its firing alerts are kept.
+func (s *Server) reconfigureNotifications(next *notifications.Notifier) {
+	current := s.notifier.Load()
+	switch {
+	case current == nil:
+		s.notifier.Store(next)
+	case next == nil:
+		s.notifier.Store(nil)
+		current.Stop()
+	default:
+		current.Reconfigure(next)
+	}
+}
+
+// notifyTransitions passes status changes to the current notifier
+func (s *Server) notifyTransitions(transitions []history.Transition) {
+	if notifier := s.notifier.Load(); notifier != nil {
+		notifier.HandleTransitions(transitions)
+	}
 }
 
 // initAuthentication initializes authentication components
 func (s *Server) initAuthentication() {
+	cfg := s.current().config
+
 	// Parse session timeout
-	sessionTimeout, err := session.ParseTimeout(s.config.ServerSettings.SessionTimeout)
+	sessionTimeout, err := session.ParseTimeout(cfg.ServerSettings.SessionTimeout)
 	if err != nil {
 		logging.Logger.WithError(err).Warn("Invalid session timeout, using default")
 		sessionTimeout = 12 * time.Hour
 	}
 
 	// Initialize session manager
-	s.sessionManager = session.NewManager(sessionTimeout)
+	s.sessionManager, err = session.NewManagerFromConfig(sessionTimeout, cfg.ServerSettings.SessionStore)
+	if err != nil {
+		logging.Logger.WithError(err).Fatal("Failed to initialize session store")
+	}
+	logging.Logger.WithField("type", cfg.ServerSettings.SessionStore.Type).Info("Session store initialized")
+
+	s.loginLockout = lockout.NewTracker()
 
-	// Initialize auth handlers
-	s.authHandlers, err = authHandlers.NewAuthHandlers(s.config, s.sessionManager)
+	// Initialize API token store
+	s.tokens, err = apitoken.NewStore(cfg.ServerSettings.APITokens.Path)
+	if err != nil {
+		logging.Logger.WithError(err).Fatal("Failed to initialize API token store")
+	}
+
+	rt, err := s.newRuntime(cfg)
 	if err != nil {
 		logging.Logger.WithError(err).Fatal("Failed to initialize authentication handlers")
 	}
+	s.runtime.Store(rt)
 
-	// Initialize auth middleware
-	s.authMiddleware = middleware.NewAuthMiddleware(s.config, s.sessionManager)
+	logging.Logger.Info("Authentication and authorization components initialized")
+}
 
-	// Initialize authorization middleware
-	s.authzMiddleware = middleware.NewAuthzMiddleware(s.config)
+// newRuntime builds the authentication and authorization components for a configuration.
+// They share the session manager, API tokens and failed logins of the server, so they survive a reload.
+func (s *Server) newRuntime(cfg *config.Config) (*runtime, error) {
+	handlers, err := authHandlers.NewAuthHandlers(cfg, s.sessionManager, s.tokens, s.loginLockout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &runtime{
+		config:                cfg,
+		authHandlers:          handlers,
+		authMiddleware:        middleware.NewAuthMiddleware(cfg, s.sessionManager, s.tokens),
+		authzMiddleware:       middleware.NewAuthzMiddleware(cfg),
+		metricsAuthMiddleware: middleware.NewMetricsAuthMiddleware(cfg),
+	}, nil
+}
 
-	// Initialize metrics auth middleware
-	s.metricsAuthMiddleware = middleware.NewMetricsAuthMiddleware(s.config)
+// Reload loads config.yaml and credentials.yaml again and applies them without a restart:
+// scrapers of removed or changed sources are stopped, new ones are started, and the
+// configuration used by the handlers and RBAC is swapped. An invalid configuration is
+// rejected and the running one is kept.
+func (s *Server) Reload() error {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
 
-	logging.Logger.Info("Authentication and authorization components initialized")
+	err := s.reload()
+	metrics.RecordConfigReload(err == nil)
+	if err != nil {
+		logging.Logger.WithError(err).Error("Configuration reload failed, keeping the running configuration")
+	}
+	return err
+}
+
+func (s *Server) reload() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Everything that can fail is built before anything changes, so a failed reload keeps the previous runtime
+	windows, err := maintenance.ConfigWindows(cfg.Maintenance.Windows, cfg.ServerSettings.HostURL)
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance windows: %w", err)
+	}
+
+	previous := s.current()
+	rt, err := s.newRuntime(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize authentication handlers: %w", err)
+	}
+
+	notificationsChanged := !reflect.DeepEqual(previous.config.Notifications, cfg.Notifications) ||
+		previous.config.ServerSettings.HostURL != cfg.ServerSettings.HostURL
+	var notifier *notifications.Notifier
+	if notificationsChanged {
+		if notifier, err = newNotifier(cfg); err != nil {
+			return fmt.Errorf("failed to initialize notifications: %w", err)
+		}
+	}
+
+	if err := scraping.Reload(cfg); err != nil {
+		if notifier != nil {
+			notifier.Stop()
+		}
+		return err
+	}
+
+	appHandlers.GetMaintenanceStore().SetConfigured(windows)
+	appHandlers.RefreshMaintenance()
+
+	s.runtime.Store(rt)
+	if notificationsChanged {
+		s.reconfigureNotifications(notifier)
+	}
+
+	warnRestartRequired(previous.config, cfg)
+	logging.Logger.Info("Configuration reloaded")
+	return nil
 }
 
-// requireAuthAndAuthz chains authentication and authorization middleware
-func (s *Server) requireAuthAndAuthz(handler http.HandlerFunc) http.HandlerFunc {
-	return s.authMiddleware.RequireAuth(s.authzMiddleware.RequireAuthz(handler))
+// warnRestartRequired logs the changed settings that only take effect after a restart
+func warnRestartRequired(previous, next *config.Config) {
+	restartOnly := map[string]bool{
+		"port":             previous.ServerSettings.Port != next.ServerSettings.Port,
+		"sync_enable":      previous.ServerSettings.SyncEnable != next.ServerSettings.SyncEnable,
+		"session_timeout":  previous.ServerSettings.SessionTimeout != next.ServerSettings.SessionTimeout,
+		"session_store":    !reflect.DeepEqual(previous.ServerSettings.SessionStore, next.ServerSettings.SessionStore),
+		"api_tokens.path":  previous.ServerSettings.APITokens.Path != next.ServerSettings.APITokens.Path,
+		"custom_ca_path":   previous.ServerSettings.CustomCAPath != next.ServerSettings.CustomCAPath,
+		"config_reload":    !reflect.DeepEqual(previous.ServerSettings.ConfigReload, next.ServerSettings.ConfigReload),
+		"scraping.history": !reflect.DeepEqual(previous.Scraping.History, next.Scraping.History),
+	}
+	for setting, changed := range restartOnly {
+		if changed {
+			logging.Logger.WithField("setting", setting).Warn("Setting changed, restart the server to apply it")
+		}
+	}
+}
+
+// requireAuthAndAuthz chains authentication and authorization middleware of the current configuration
+// and passes that configuration to the handler
+func (s *Server) requireAuthAndAuthz(handler func(http.ResponseWriter, *http.Request, *config.Config)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rt := s.current()
+		rt.authMiddleware.RequireAuth(rt.authzMiddleware.RequireAuthz(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r, rt.config)
+		}))(w, r)
+	}
+}
+
+// requireAuthHandler chains the authentication middleware and an authentication handler of the current configuration
+func (s *Server) requireAuthHandler(handler func(*authHandlers.AuthHandlers, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rt := s.current()
+		rt.authMiddleware.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+			handler(rt.authHandlers, w, r)
+		})(w, r)
+	}
 }
 
 // Setup HTTP routes and handlers
 func (s *Server) setupRoutes() {
 	// Authentication endpoints
-	s.mux.HandleFunc("/auth/config", s.authHandlers.HandleAuthConfig)
-	s.mux.HandleFunc("/auth/login", s.authHandlers.HandleLogin)
-	s.mux.HandleFunc("/auth/user", s.authMiddleware.RequireAuth(s.authHandlers.HandleUser))
-	s.mux.HandleFunc("/auth/logout", s.authHandlers.HandleLogout)
+	s.mux.HandleFunc("/auth/config", func(w http.ResponseWriter, r *http.Request) {
+		s.current().authHandlers.HandleAuthConfig(w, r)
+	})
+	s.mux.HandleFunc("/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		s.current().authHandlers.HandleLogin(w, r)
+	})
+	s.mux.HandleFunc("/auth/user", func(w http.ResponseWriter, r *http.Request) {
+		rt := s.current()
+		rt.authMiddleware.RequireAuth(rt.authHandlers.HandleUser)(w, r)
+	})
+	s.mux.HandleFunc("/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		s.current().authHandlers.HandleLogout(w, r)
+	})
 
 	// OIDC endpoints
-	s.mux.HandleFunc("/auth/oidc/login", s.authHandlers.HandleOIDCLogin)
-	s.mux.HandleFunc("/auth/oidc/callback", s.authHandlers.HandleOIDCCallback)
+	s.mux.HandleFunc("/auth/oidc/login", func(w http.ResponseWriter, r *http.Request) {
+		s.current().authHandlers.HandleOIDCLogin(w, r)
+	})
+	s.mux.HandleFunc("/auth/oidc/callback", func(w http.ResponseWriter, r *http.Request) {
+		s.current().authHandlers.HandleOIDCCallback(w, r)
+	})
 
 	// Protected API endpoints
-	s.mux.HandleFunc("/api/locations", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request) {
-		appHandlers.GetLocationsWithAuthz(w, r, s.config)
-	}))
-	s.mux.HandleFunc("/api/apps", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request) {
-		appHandlers.GetAppsWithAuthz(w, r, s.config)
-	}))
-	s.mux.HandleFunc("/api/labels", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request) {
-		appHandlers.GetLabelsWithAuthz(w, r, s.config)
-	}))
-	s.mux.HandleFunc("/api/scrape-interval", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request) {
+	s.mux.HandleFunc("/api/locations", s.requireAuthAndAuthz(appHandlers.GetLocationsWithAuthz))
+	s.mux.HandleFunc("/api/apps", s.requireAuthAndAuthz(appHandlers.GetAppsWithAuthz))
+	s.mux.HandleFunc("GET /api/apps/{name}/history", s.requireAuthAndAuthz(appHandlers.GetAppHistoryWithAuthz))
+	s.mux.HandleFunc("POST /api/apps/{source}/{name}/check", s.requireAuthAndAuthz(appHandlers.CheckAppWithAuthz))
+	s.mux.HandleFunc("POST /api/sources/{name}/scrape", s.requireAuthAndAuthz(appHandlers.ScrapeSourceWithAuthz))
+	s.mux.HandleFunc("/api/sla", s.requireAuthAndAuthz(appHandlers.GetSLAWithAuthz))
+	s.mux.HandleFunc("GET /api/maintenance", s.requireAuthAndAuthz(appHandlers.GetMaintenanceWithAuthz))
+	s.mux.HandleFunc("POST /api/maintenance", s.requireAuthAndAuthz(appHandlers.CreateMaintenanceWithAuthz))
+	s.mux.HandleFunc("DELETE /api/maintenance/{id}", s.requireAuthAndAuthz(appHandlers.DeleteMaintenanceWithAuthz))
+	s.mux.HandleFunc("GET /api/tokens", s.requireAuthHandler((*authHandlers.AuthHandlers).HandleListTokens))
+	s.mux.HandleFunc("POST /api/tokens", s.requireAuthHandler((*authHandlers.AuthHandlers).HandleCreateToken))
+	s.mux.HandleFunc("DELETE /api/tokens/{id}", s.requireAuthHandler((*authHandlers.AuthHandlers).HandleRevokeToken))
+	s.mux.HandleFunc("/api/labels", s.requireAuthAndAuthz(appHandlers.GetLabelsWithAuthz))
+	s.mux.HandleFunc("GET /api/events", s.requireAuthAndAuthz(appHandlers.GetEventsWithAuthz))
+	s.mux.HandleFunc("/api/scrape-interval", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		logging.Logger.Debug("Handling /api/scrape-interval request")
-		appHandlers.GetScrapeInterval(w, r, s.config)
+		appHandlers.GetScrapeInterval(w, r, cfg)
 	}))
-	s.mux.HandleFunc("/api/docs", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request) {
+	s.mux.HandleFunc("/api/docs", s.requireAuthAndAuthz(func(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		logging.Logger.Debug("Handling /api/docs request")
-		appHandlers.GetDocs(w, r, s.config)
+		appHandlers.GetDocs(w, r, cfg)
 	}))
 	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		logging.Logger.Debug("Handling /healthz probe")
@@ -125,15 +423,17 @@ func (s *Server) setupRoutes() {
 		logging.Logger.Debug("Handling /readyz probe")
 		s.readinessProbe(w, r)
 	})
-	s.mux.HandleFunc("/metrics", s.metricsAuthMiddleware.RequireMetricsAuth(func(w http.ResponseWriter, r *http.Request) {
-		metrics.SetupMetricsHandler().ServeHTTP(w, r)
-	}))
+	s.mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		s.current().metricsAuthMiddleware.RequireMetricsAuth(func(w http.ResponseWriter, r *http.Request) {
+			metrics.SetupMetricsHandler().ServeHTTP(w, r)
+		})(w, r)
+	})
 
 	// Add sync endpoint if sync is enabled
-	if s.config.ServerSettings.SyncEnable {
+	if s.current().config.ServerSettings.SyncEnable {
 		s.mux.HandleFunc("/sync", func(w http.ResponseWriter, r *http.Request) {
 			logging.Logger.Debug("Handling /sync request")
-			appHandlers.HandleSyncRequest(w, r, s.config)
+			appHandlers.HandleSyncRequest(w, r, s.current().config)
 		})
 	}
 
@@ -158,9 +458,16 @@ func (s *Server) startServer(port string) error {
 		Addr:    ":" + port,
 		Handler: s.mux,
 	}
+	srv.RegisterOnShutdown(appHandlers.CloseEventStreams)
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	stop := make(chan struct{})
+	defer close(stop)
+	s.watchConfigReload(stop)
+	s.refreshMaintenance(stop)
+	s.refreshSLAMetrics(stop)
+
 	go func() {
 		logging.Logger.Infof("Server starting on %s", port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -172,6 +479,50 @@ func (s *Server) startServer(port string) error {
 	return s.gracefulShutdown(srv)
 }
 
+// watchConfigReload reloads the configuration on SIGHUP and, when enabled, on changes of the config files
+func (s *Server) watchConfigReload(stop <-chan struct{}) {
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hupChan)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-hupChan:
+				logging.Logger.Info("Received SIGHUP, reloading configuration")
+				_ = s.Reload()
+			}
+		}
+	}()
+
+	reloadSettings := s.current().config.ServerSettings.ConfigReload
+	if !reloadSettings.IsWatchEnabled() {
+		logging.Logger.Info("Config file watch is disabled, reload with SIGHUP")
+		return
+	}
+
+	interval, err := time.ParseDuration(reloadSettings.Interval)
+	if err != nil || interval <= 0 {
+		logging.Logger.WithField("interval", reloadSettings.Interval).Warn("Invalid config_reload interval, config file watch is disabled")
+		return
+	}
+
+	configFile, credentialsFile := config.Files()
+	watcher := config.NewWatcher(interval, configFile, credentialsFile)
+	go watcher.Run(stop, func() {
+		logging.Logger.Info("Config files changed, reloading configuration")
+		_ = s.Reload()
+	})
+
+	logging.Logger.WithFields(map[string]interface{}{
+		"config_file":      configFile,
+		"credentials_file": credentialsFile,
+		"interval":         interval,
+	}).Info("Watching config files for changes")
+}
+
 // Gracefully shut down the server
 func (s *Server) gracefulShutdown(srv *http.Server) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -188,12 +539,48 @@ func (s *Server) gracefulShutdown(srv *http.Server) error {
 	// Wait for either shutdown to complete or timeout
 	select {
 	case err := <-shutdownErr:
+		scraping.Stop()
+		s.stopNotifications()
+		s.closeHistory()
+		s.closeSessions()
 		return err
 	case <-ctx.Done():
+		scraping.Stop()
+		s.stopNotifications()
+		s.closeHistory()
+		s.closeSessions()
 		return fmt.Errorf("Server forced to shutdown")
 	}
 }
 
+// stopNotifications cancels pending notifications
+func (s *Server) stopNotifications() {
+	if notifier := s.notifier.Load(); notifier != nil {
+		notifier.Stop()
+	}
+}
+
+// closeHistory flushes and closes the status history store
+func (s *Server) closeHistory() {
+	store := appHandlers.GetHistoryStore()
+	if store == nil {
+		return
+	}
+	if err := store.Close(); err != nil {
+		logging.Logger.WithError(err).Error("Failed to close status history store")
+	}
+}
+
+// closeSessions stops the session cleanup and closes the session store
+func (s *Server) closeSessions() {
+	if s.sessionManager == nil {
+		return
+	}
+	if err := s.sessionManager.Close(); err != nil {
+		logging.Logger.WithError(err).Error("Failed to close session store")
+	}
+}
+
 // Liveness probe handler
 func (s *Server) livenessProbe(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -67,7 +67,7 @@ func TestNewServer(t *testing.T) {
 
 		server := NewServer(cfg)
 		assert.NotNil(t, server)
-		assert.Equal(t, cfg, server.config)
+		assert.Equal(t, cfg, server.current().config)
 		assert.NotNil(t, server.mux)
 	})
 
@@ -75,7 +75,7 @@ func TestNewServer(t *testing.T) {
 		cfg := &config.Config{}
 		server := NewServer(cfg)
 		assert.NotNil(t, server)
-		assert.Equal(t, cfg, server.config)
+		assert.Equal(t, cfg, server.current().config)
 	})
 }
 
@@ -559,7 +559,130 @@ func TestServerInitializationWithMetricsAuth(t *testing.T) {
 	server.initAuthentication()
 
 	// Check that metrics auth middleware is initialized
-	if server.metricsAuthMiddleware == nil {
+	if server.current().metricsAuthMiddleware == nil {
 		t.Fatal("Metrics auth middleware should be initialized")
 	}
 }
+
+func TestInitNotifications(t *testing.T) {
+	defer handlers.ClearTransitionListeners()
+
+	t.Run("no routes", func(t *testing.T) {
+		server := NewServer(&config.Config{})
+		server.initNotifications()
+		assert.Nil(t, server.notifier.Load())
+		server.stopNotifications()
+	})
+
+	t.Run("routes configured", func(t *testing.T) {
+		server := NewServer(&config.Config{
+			ServerSettings: config.ServerSettings{HostURL: "https://status.example.com"},
+			Notifications: config.Notifications{
+				Receivers: []config.NotificationReceiver{{Name: "ops", Type: "webhook", URL: "https://hooks.example.com/ops"}},
+				Routes:    []config.NotificationRoute{{Receiver: "ops"}},
+			},
+		})
+		server.initNotifications()
+		require.NotNil(t, server.notifier.Load())
+		server.stopNotifications()
+	})
+}
+
+func TestInitMaintenance(t *testing.T) {
+	handlers.ResetCacheForTesting()
+	defer handlers.ResetCacheForTesting()
+
+	server := NewServer(&config.Config{
+		ServerSettings: config.ServerSettings{HostURL: "https://status.example.com"},
+		Maintenance: config.Maintenance{Windows: []config.MaintenanceWindow{
+			{Name: "weekly", Matchers: []string{`location="loc1"`}, Schedule: "0 2 * * sun", Duration: "2h"},
+		}},
+	})
+	server.initMaintenance()
+	server.initAuthentication()
+	server.setupRoutes()
+
+	req := httptest.NewRequest("GET", "/api/maintenance", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"weekly"`)
+	assert.Contains(t, w.Body.String(), `"origin_url":"https://status.example.com"`)
+}
+
+func TestReload(t *testing.T) {
+	defer handlers.ClearTransitionListeners()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials.yaml"))
+
+	writeConfig := func(title, interval, groupWait string) {
+		content := `
+server_settings:
+  port: "8080"
+  host_url: "https://status.example.com"
+scraping:
+  interval: "` + interval + `"
+  timeout: "5s"
+  max_parallel: 2
+documentation:
+  title: "` + title + `"
+  url: "https://example.com/docs"
+locations:
+  - name: "loc1"
+    latitude: 40.7128
+    longitude: -74.0060
+sources: []
+notifications:
+  receivers:
+    - name: "ops"
+      type: "webhook"
+      url: "https://hooks.example.com/ops"
+  routes:
+    - receiver: "ops"
+      group_wait: "` + groupWait + `"
+`
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0644))
+	}
+
+	writeConfig("Initial Docs", "60s", "30s")
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+
+	server := NewServer(cfg)
+	server.initAuthentication()
+	server.initNotifications()
+	server.setupRoutes()
+	t.Cleanup(server.stopNotifications)
+	sessionManager := server.sessionManager
+	notifier := server.notifier.Load()
+	require.NotNil(t, notifier)
+
+	getDocs := func() string {
+		req := httptest.NewRequest("GET", "/api/docs", nil)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	assert.Contains(t, getDocs(), "Initial Docs")
+
+	t.Run("valid config is applied", func(t *testing.T) {
+		writeConfig("Reloaded Docs", "30s", "10s")
+		require.NoError(t, server.Reload())
+
+		assert.Equal(t, "Reloaded Docs", server.current().config.Documentation.Title)
+		assert.Contains(t, getDocs(), "Reloaded Docs")
+		assert.Same(t, sessionManager, server.sessionManager, "sessions survive a reload")
+		assert.Same(t, notifier, server.notifier.Load(), "the notifier is reconfigured in place, keeping its alerts")
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		writeConfig("Broken Docs", "soon", "10s")
+		assert.Error(t, server.Reload())
+
+		assert.Equal(t, "Reloaded Docs", server.current().config.Documentation.Title)
+		assert.Contains(t, getDocs(), "Reloaded Docs")
+	})
+}
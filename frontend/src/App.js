@@ -6,7 +6,10 @@ import { AuthProvider, useAuth } from "./contexts/AuthContext";
 import SimpleLogin from "./components/SimpleLogin";
 import SimpleUserModal from "./components/SimpleUserModal";
 import Spinner from "./components/Spinner";
-import { fetchLocations } from "./api/appStatusAPI";
+import {
+  fetchLocations,
+  subscribeToStatusEvents,
+} from "./api/appStatusAPI";
 import { fetchScrapeInterval } from "./api/scrapeIntervalAPI";
 import { fetchDocs } from "./api/docsAPI";
 import { userPreferences } from "./utils/storage";
@@ -72,6 +75,8 @@ function OriginalApp({ user, onUserInfoClick }) {
   const [labelFilters, setLabelFilters] = useState(
     userPreferences.loadLabelFilters(),
   );
+  const [liveUpdates, setLiveUpdates] = useState(false);
+  const [statusVersion, setStatusVersion] = useState(0);
 
   // Fetch locations with their calculated status from the server
   const refreshLocations = useCallback(async () => {
@@ -102,8 +107,8 @@ function OriginalApp({ user, onUserInfoClick }) {
   }, []);
 
   useEffect(() => {
-    if (scrapeInterval && !isPanelOpen) {
-      // Only set up periodic refresh of locations when panel is closed
+    if (scrapeInterval && !isPanelOpen && !liveUpdates) {
+      // Only set up periodic refresh of locations when panel is closed and no events are received
       // When panel is open, it will coordinate the refresh
       const intervalId = setInterval(() => {
         refreshLocations();
@@ -112,7 +117,24 @@ function OriginalApp({ user, onUserInfoClick }) {
       // Clean up interval on component unmount or when scrapeInterval changes
       return () => clearInterval(intervalId);
     }
-  }, [scrapeInterval, statusFilters, labelFilters, isPanelOpen]);
+  }, [scrapeInterval, statusFilters, labelFilters, isPanelOpen, liveUpdates]);
+
+  // Receive status changes as they happen while the event stream is connected
+  useEffect(() => {
+    return subscribeToStatusEvents(
+      statusFilters,
+      labelFilters,
+      () => setStatusVersion((version) => version + 1),
+      setLiveUpdates,
+    );
+  }, [statusFilters, labelFilters]);
+
+  // Refresh locations on status changes; when panel is open, it will coordinate the refresh
+  useEffect(() => {
+    if (statusVersion > 0 && !isPanelOpen) {
+      refreshLocations();
+    }
+  }, [statusVersion]);
 
   // Refresh locations when filters change
   useEffect(() => {
@@ -205,6 +227,8 @@ function OriginalApp({ user, onUserInfoClick }) {
           statusFilters={statusFilters}
           labelFilters={labelFilters}
           refreshLocations={refreshLocations}
+          liveUpdates={liveUpdates}
+          statusVersion={statusVersion}
         />
       )}
     </div>
@@ -16,6 +16,8 @@ export const AppStatusPanel = ({
   statusFilters,
   labelFilters,
   refreshLocations,
+  liveUpdates,
+  statusVersion,
 }) => {
   const panelRef = useRef(null);
   const groupDropdownRef = useRef(null);
@@ -81,16 +83,24 @@ export const AppStatusPanel = ({
     }
   };
 
-  // Set up periodic refresh while panel is open
+  // Set up periodic refresh while panel is open and no events are received
   useEffect(() => {
-    if (scrapeInterval) {
+    if (scrapeInterval && !liveUpdates) {
       const intervalId = setInterval(() => {
         refreshApps();
       }, scrapeInterval);
 
       return () => clearInterval(intervalId);
     }
-  }, [scrapeInterval, refreshApps]);
+  }, [scrapeInterval, liveUpdates, refreshApps]);
+
+  // Refresh on status changes received after the panel opened
+  const initialStatusVersion = useRef(statusVersion);
+  useEffect(() => {
+    if (statusVersion !== initialStatusVersion.current) {
+      refreshApps();
+    }
+  }, [statusVersion]);
 
   // Close dropdowns on outside click
   useEffect(() => {
@@ -170,6 +180,9 @@ export const AppStatusPanel = ({
   const getGroupStatus = (apps) => {
     if (apps.some((app) => app.status === "down")) return "down";
     if (apps.some((app) => app.status === "unavailable")) return "unavailable";
+    if (apps.some((app) => app.status === "degraded")) return "degraded";
+    if (apps.length > 0 && apps.every((app) => app.status === "maintenance"))
+      return "maintenance";
     return "up";
   };
 
@@ -179,10 +192,28 @@ export const AppStatusPanel = ({
         acc[app.status]++;
         return acc;
       },
-      { up: 0, down: 0, unavailable: 0 },
+      { up: 0, degraded: 0, down: 0, unavailable: 0, maintenance: 0 },
     );
   };
 
+  // Tooltip with the metadata of the latest check
+  const getCheckDetails = (app) => {
+    const details = [];
+    if (app.reason) details.push(app.reason);
+    if (app.response_time_ms !== undefined) {
+      details.push(`Response time: ${Math.round(app.response_time_ms)} ms`);
+    }
+    if (app.last_check) {
+      details.push(`Last check: ${new Date(app.last_check).toLocaleString()}`);
+    }
+    if (app.last_change) {
+      details.push(
+        `Status since: ${new Date(app.last_change).toLocaleString()}`,
+      );
+    }
+    return details.length > 0 ? details.join("\n") : undefined;
+  };
+
   const renderSortOption = (label, value) => (
     <li
       className={sortOrder === value ? "selected" : ""}
@@ -706,12 +737,20 @@ export const AppStatusPanel = ({
                   <div className="group-stats">
                     <div className="status-dots">
                       <span className="status-dot up">{statusCounts.up}</span>
+                      <span className="status-dot degraded">
+                        {statusCounts.degraded}
+                      </span>
                       <span className="status-dot unavailable">
                         {statusCounts.unavailable}
                       </span>
                       <span className="status-dot down">
                         {statusCounts.down}
                       </span>
+                      {statusCounts.maintenance > 0 && (
+                        <span className="status-dot maintenance">
+                          {statusCounts.maintenance}
+                        </span>
+                      )}
                     </div>
                     {isExpanded ? <FaChevronUp /> : <FaChevronDown />}
                   </div>
@@ -724,22 +763,43 @@ export const AppStatusPanel = ({
                           ? "status-up"
                           : app.status === "down"
                             ? "status-down"
-                            : "status-unavailable";
+                            : app.status === "degraded"
+                              ? "status-degraded"
+                              : app.status === "maintenance"
+                                ? "status-maintenance"
+                                : "status-unavailable";
 
                       const label =
                         app.status === "up"
                           ? "Up"
                           : app.status === "down"
                             ? "Down"
-                            : "Unavailable";
+                            : app.status === "degraded"
+                              ? "Degraded"
+                              : app.status === "maintenance"
+                                ? "Maintenance"
+                                : "Unavailable";
 
                       return (
                         <li key={app.name}>
                           <div className="app-info">
-                            <div className="app-name">{app.name}</div>
+                            <div className="app-name">
+                              {app.name}
+                              {app.flapping && (
+                                <span
+                                  className="app-flapping"
+                                  title="Status changed often recently"
+                                >
+                                  Flapping
+                                </span>
+                              )}
+                            </div>
                             {renderAppLabels(app, panelWidth)}
                           </div>
-                          <div className={`status-indicator ${statusClass}`}>
+                          <div
+                            className={`status-indicator ${statusClass}`}
+                            title={getCheckDetails(app)}
+                          >
                             {label}
                           </div>
                         </li>
@@ -759,22 +819,45 @@ export const AppStatusPanel = ({
                 ? "status-up"
                 : app.status === "down"
                   ? "status-down"
-                  : "status-unavailable";
+                  : app.status === "degraded"
+                    ? "status-degraded"
+                    : app.status === "maintenance"
+                      ? "status-maintenance"
+                      : "status-unavailable";
 
             const label =
               app.status === "up"
                 ? "Up"
                 : app.status === "down"
                   ? "Down"
-                  : "Unavailable";
+                  : app.status === "degraded"
+                    ? "Degraded"
+                    : app.status === "maintenance"
+                      ? "Maintenance"
+                      : "Unavailable";
 
             return (
               <li key={app.name}>
                 <div className="app-info">
-                  <div className="app-name">{app.name}</div>
+                  <div className="app-name">
+                    {app.name}
+                    {app.flapping && (
+                      <span
+                        className="app-flapping"
+                        title="Status changed often recently"
+                      >
+                        Flapping
+                      </span>
+                    )}
+                  </div>
                   {renderAppLabels(app, panelWidth)}
                 </div>
-                <div className={`status-indicator ${statusClass}`}>{label}</div>
+                <div
+                  className={`status-indicator ${statusClass}`}
+                  title={getCheckDetails(app)}
+                >
+                  {label}
+                </div>
               </li>
             );
           })}
@@ -52,16 +52,18 @@ const Sidebar = ({
   // Calculate total counts from all locations
   const calculateTotalCounts = () => {
     if (!locations || locations.length === 0) {
-      return { up: 0, down: 0, unavailable: 0 };
+      return { up: 0, degraded: 0, down: 0, unavailable: 0, maintenance: 0 };
     }
 
     return locations.reduce(
       (totals, location) => ({
         up: totals.up + (location.up || 0),
+        degraded: totals.degraded + (location.degraded || 0),
         down: totals.down + (location.down || 0),
         unavailable: totals.unavailable + (location.unavailable || 0),
+        maintenance: totals.maintenance + (location.maintenance || 0),
       }),
-      { up: 0, down: 0, unavailable: 0 },
+      { up: 0, degraded: 0, down: 0, unavailable: 0, maintenance: 0 },
     );
   };
 
@@ -353,6 +355,20 @@ const Sidebar = ({
                     {totalCounts.up}
                   </span>
                 </label>
+                <label className="sidebar__filter-option">
+                  <input
+                    type="checkbox"
+                    value="degraded"
+                    checked={selectedStatusFilters.includes("degraded")}
+                    onChange={() => onStatusFilterChange("degraded")}
+                  />
+                  <span className="sidebar__checkbox"></span>
+                  <span className="sidebar__status-circle sidebar__status-circle--degraded"></span>
+                  Degraded
+                  <span className="sidebar__filter-count">
+                    {totalCounts.degraded}
+                  </span>
+                </label>
                 <label className="sidebar__filter-option">
                   <input
                     type="checkbox"
@@ -381,6 +397,20 @@ const Sidebar = ({
                     {totalCounts.unavailable}
                   </span>
                 </label>
+                <label className="sidebar__filter-option">
+                  <input
+                    type="checkbox"
+                    value="maintenance"
+                    checked={selectedStatusFilters.includes("maintenance")}
+                    onChange={() => onStatusFilterChange("maintenance")}
+                  />
+                  <span className="sidebar__checkbox"></span>
+                  <span className="sidebar__status-circle sidebar__status-circle--maintenance"></span>
+                  Maintenance
+                  <span className="sidebar__filter-count">
+                    {totalCounts.maintenance}
+                  </span>
+                </label>
               </div>
             </div>
 
@@ -98,3 +98,54 @@ export const fetchLabels = async (key = null) => {
     return [];
   }
 };
+
+// Subscribes to status changes pushed by the server. onChange is called once per burst of
+// events; onConnectionChange reports whether the stream is connected, so polling can stop
+// while it is. The browser reconnects on its own and resumes from the last event.
+export const subscribeToStatusEvents = (
+  statusFilters = [],
+  labelFilters = [],
+  onChange,
+  onConnectionChange,
+) => {
+  if (typeof EventSource === "undefined") {
+    return () => {};
+  }
+
+  const params = new URLSearchParams();
+  if (Array.isArray(statusFilters) && statusFilters.length > 0) {
+    statusFilters.forEach((status) => {
+      params.append("status", status);
+    });
+  }
+  labelFilters.forEach((label) => {
+    params.append(`labels.${label.key}`, label.value);
+  });
+
+  const queryString = params.toString();
+  const url = queryString ? `/api/events?${queryString}` : "/api/events";
+  const source = new EventSource(url, { withCredentials: true });
+
+  let timeoutId = null;
+  const handleEvent = () => {
+    // Apps of a scrape change together, so refresh once per burst
+    if (timeoutId === null) {
+      timeoutId = setTimeout(() => {
+        timeoutId = null;
+        onChange();
+      }, 500);
+    }
+  };
+
+  source.onopen = () => onConnectionChange(true);
+  source.onerror = () => onConnectionChange(false);
+  ["app", "location", "reset"].forEach((type) => {
+    source.addEventListener(type, handleEvent);
+  });
+
+  return () => {
+    clearTimeout(timeoutId);
+    source.close();
+    onConnectionChange(false);
+  };
+};
@@ -19,7 +19,10 @@ export default function SimpleLogin() {
   // Check what authentication methods are available
   const hasLocal = authConfig?.auth_methods?.includes("local");
   const hasOIDC = authConfig?.auth_methods?.includes("oidc");
+  const hasLDAP = authConfig?.auth_methods?.includes("ldap");
+  const hasPassword = hasLocal || hasLDAP;
   const oidcProviderName = authConfig?.oidc_provider_name || "SSO";
+  const ldapProviderName = authConfig?.ldap_provider_name || "LDAP";
 
   return (
     <div className="auth-login-container">
@@ -43,14 +46,14 @@ export default function SimpleLogin() {
         )}
 
         {/* Divider if both methods are available */}
-        {hasLocal && hasOIDC && (
+        {hasPassword && hasOIDC && (
           <div className="auth-divider">
             <span>OR</span>
           </div>
         )}
 
-        {/* Local Login Form */}
-        {hasLocal && (
+        {/* Password Login Form, for local accounts and LDAP */}
+        {hasPassword && (
           <div className="auth-local-section">
             <form onSubmit={handleSubmit} className="auth-local-form">
               <input
@@ -76,7 +79,11 @@ export default function SimpleLogin() {
                 disabled={isLoading}
                 className="auth-submit-btn"
               >
-                {isLoading ? "Logging in..." : "Login"}
+                {isLoading
+                  ? "Logging in..."
+                  : hasLDAP && !hasLocal
+                    ? `Login with ${ldapProviderName}`
+                    : "Login"}
               </button>
             </form>
           </div>
@@ -196,7 +196,11 @@ export const MapComponent = ({ locations, onSiteClick }) => {
                   ? "#EF4444"
                   : site.status === "unavailable"
                     ? "#F59E0B"
-                    : "#D6D6DA";
+                    : site.status === "degraded"
+                      ? "#F97316"
+                      : site.status === "maintenance"
+                        ? "#60A5FA"
+                        : "#D6D6DA";
 
             const isHovered = hoveredMarker === site.name;
             const markerScale =